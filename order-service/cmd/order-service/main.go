@@ -6,6 +6,8 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -13,12 +15,18 @@ import (
 	"github.com/user/go-microservices/pkg/config"
 	"github.com/user/go-microservices/pkg/logger"
 	"github.com/user/go-microservices/pkg/otel"
+	"github.com/user/go-microservices/pkg/valueobject"
 
 	httpSwagger "github.com/swaggo/http-swagger"
 	_ "github.com/user/go-microservices/order-service/docs" // Generated docs
 	delivery "github.com/user/go-microservices/order-service/internal/delivery/http"
+	"github.com/user/go-microservices/order-service/internal/delivery/worker"
+	"github.com/user/go-microservices/order-service/internal/domain"
 	client "github.com/user/go-microservices/order-service/internal/infrastructure/client"
 	repo "github.com/user/go-microservices/order-service/internal/infrastructure/db"
+	"github.com/user/go-microservices/order-service/internal/infrastructure/events"
+	"github.com/user/go-microservices/order-service/internal/infrastructure/payment"
+	"github.com/user/go-microservices/order-service/internal/infrastructure/ratefile"
 	"github.com/user/go-microservices/order-service/internal/usecase"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.uber.org/zap"
@@ -39,6 +47,27 @@ func main() {
 	// Config
 	serverPort := config.GetEnv("SERVER_PORT", "8082")
 	productServiceURL := config.GetEnv("PRODUCT_SERVICE_URL", "http://localhost:8081")
+	taxRatesFile := config.GetEnv("TAX_RATES_FILE", "")
+	shippingRatesFile := config.GetEnv("SHIPPING_RATES_FILE", "")
+	blockedUsers, err := parseUserIDs(config.GetEnv("ORDER_BLOCKED_USERS", ""))
+	if err != nil {
+		log.Fatal("Invalid ORDER_BLOCKED_USERS", zap.Error(err))
+	}
+	// Order limits; 0 disables a limit
+	orderLimits := domain.OrderLimits{
+		BlockedUsers:     blockedUsers,
+		MaxQuantity:      config.GetEnvInt("ORDER_MAX_QUANTITY", 0),
+		MaxOrderValue:    valueobject.NewMoney(float64(config.GetEnvInt("ORDER_MAX_VALUE", 0))),
+		MaxOrdersPerHour: config.GetEnvInt("ORDER_MAX_PER_USER_HOUR", 0),
+	}
+	// Payments go through the local fake provider, which can be told to add
+	// latency and to decline or time out a percentage of authorizations
+	paymentConfig := payment.FakeConfig{
+		Latency:      time.Duration(config.GetEnvInt("PAYMENT_FAKE_LATENCY_MS", 0)) * time.Millisecond,
+		DeclineRate:  float64(config.GetEnvInt("PAYMENT_FAKE_DECLINE_PCT", 0)) / 100,
+		TimeoutRate:  float64(config.GetEnvInt("PAYMENT_FAKE_TIMEOUT_PCT", 0)) / 100,
+		TimeoutAfter: time.Duration(config.GetEnvInt("PAYMENT_FAKE_TIMEOUT_MS", 3000)) * time.Millisecond,
+	}
 
 	// OTEL
 	otlpEndpoint := config.GetEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "localhost:4317")
@@ -71,14 +100,139 @@ func main() {
 		}
 	}
 
+	// Tax and shipping rates come from a local file when one is configured,
+	// otherwise from the database
+	var taxRates []domain.TaxRate
+	if taxRatesFile != "" {
+		taxRates, err = ratefile.LoadTaxRates(taxRatesFile)
+	} else {
+		taxRates, err = repo.LoadTaxRates(context.Background(), dbConn)
+	}
+	if err != nil {
+		log.Fatal("Could not load tax rates", zap.Error(err))
+	}
+	taxTable, err := domain.NewTaxTable(taxRates)
+	if err != nil {
+		log.Fatal("Invalid tax rates", zap.Error(err))
+	}
+
+	var shippingRates []domain.ShippingRate
+	if shippingRatesFile != "" {
+		shippingRates, err = ratefile.LoadShippingRates(shippingRatesFile)
+	} else {
+		shippingRates, err = repo.LoadShippingRates(context.Background(), dbConn)
+	}
+	if err != nil {
+		log.Fatal("Could not load shipping rates", zap.Error(err))
+	}
+	shippingTable, err := domain.NewShippingRateTable(shippingRates)
+	if err != nil {
+		log.Fatal("Invalid shipping rates", zap.Error(err))
+	}
+
 	// Layers
 	orderRepo := repo.NewOrderRepository(dbConn)
+	taskRepo := repo.NewTaskRepository(dbConn)
 	prodClient := client.NewProductClient(productServiceURL)
-	orderUsecase := usecase.NewOrderUsecase(orderRepo, prodClient, 5*time.Second)
+	couponRepo := repo.NewCouponRepository(dbConn)
+	quoteRepo := repo.NewQuoteRepository(dbConn)
+	payments := payment.NewFakeGateway(paymentConfig)
+	orderUsecase := usecase.NewOrderUsecase(orderRepo, repo.NewSagaRepository(dbConn), taskRepo, prodClient, couponRepo, quoteRepo, taxTable, shippingTable, orderLimits.Rules(orderRepo), payments, 5*time.Second)
 	orderUsecase = usecase.NewTracingOrderUsecase(orderUsecase)
+	returnUsecase := usecase.NewReturnUsecase(repo.NewReturnRepository(dbConn), orderRepo, taskRepo, prodClient, payments, 5*time.Second)
+	returnUsecase = usecase.NewTracingReturnUsecase(returnUsecase)
+	couponUsecase := usecase.NewTracingCouponUsecase(usecase.NewCouponUsecase(couponRepo, 5*time.Second))
+	quoteUsecase := usecase.NewTracingQuoteUsecase(usecase.NewQuoteUsecase(quoteRepo, prodClient, taxTable, shippingTable,
+		time.Duration(config.GetEnvInt("QUOTE_TTL_MIN", 15))*time.Minute, 5*time.Second))
+	invoiceUsecase := usecase.NewTracingInvoiceUsecase(usecase.NewInvoiceUsecase(repo.NewInvoiceRepository(dbConn), orderRepo, 5*time.Second))
+	cartUsecase := usecase.NewTracingCartUsecase(usecase.NewCartUsecase(repo.NewCartRepository(dbConn), orderUsecase, prodClient, taxTable, shippingTable,
+		time.Duration(config.GetEnvInt("CART_TTL_HOURS", 72))*time.Hour, 5*time.Second))
+	subscriptionUsecase := usecase.NewTracingSubscriptionUsecase(usecase.NewSubscriptionUsecase(repo.NewSubscriptionRepository(dbConn), orderUsecase, prodClient,
+		domain.RetryPolicy{
+			MaxAttempts: config.GetEnvInt("SUBSCRIPTION_RETRY_ATTEMPTS", 3),
+			Backoff:     time.Duration(config.GetEnvInt("SUBSCRIPTION_RETRY_BACKOFF_MIN", 60)) * time.Minute,
+		}, 5*time.Second))
+	idempotencyUsecase := usecase.NewTracingIdempotencyUsecase(usecase.NewIdempotencyUsecase(repo.NewIdempotencyRepository(dbConn),
+		time.Duration(config.GetEnvInt("IDEMPOTENCY_RETENTION_HOURS", 24))*time.Hour, 5*time.Second))
+
+	// Background workers
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	locker := repo.NewAdvisoryLocker(dbConn)
+	recoverer := worker.NewSagaRecoverer(orderUsecase, locker,
+		time.Duration(config.GetEnvInt("SAGA_STALE_SEC", 60))*time.Second,
+		time.Duration(config.GetEnvInt("SAGA_RECOVERY_INTERVAL_SEC", 30))*time.Second,
+		config.GetEnvInt("SAGA_RECOVERY_BATCH_SIZE", 100),
+	)
+	go recoverer.Run(workerCtx)
+	taskRunner := worker.NewTaskRunner(usecase.NewTracingTaskUsecase(usecase.NewTaskUsecase(taskRepo, prodClient, payments, 5*time.Second)), locker,
+		time.Duration(config.GetEnvInt("ORDER_TASK_INTERVAL_SEC", 10))*time.Second,
+		config.GetEnvInt("ORDER_TASK_BATCH_SIZE", 100),
+	)
+	go taskRunner.Run(workerCtx)
+	reaper := worker.NewExpiryReaper(orderUsecase, locker,
+		time.Duration(config.GetEnvInt("ORDER_PENDING_TTL_MIN", 30))*time.Minute,
+		time.Duration(config.GetEnvInt("ORDER_REAPER_INTERVAL_SEC", 60))*time.Second,
+		config.GetEnvInt("ORDER_REAPER_BATCH_SIZE", 100),
+	)
+	go reaper.Run(workerCtx)
+	allocator := worker.NewBackorderAllocator(orderUsecase, locker,
+		time.Duration(config.GetEnvInt("BACKORDER_INTERVAL_SEC", 60))*time.Second,
+		config.GetEnvInt("BACKORDER_BATCH_SIZE", 100),
+	)
+	go allocator.Run(workerCtx)
+	releaser := worker.NewPreorderReleaser(orderUsecase, locker,
+		time.Duration(config.GetEnvInt("PREORDER_RELEASE_INTERVAL_SEC", 60))*time.Second,
+		config.GetEnvInt("PREORDER_RELEASE_BATCH_SIZE", 100),
+	)
+	go releaser.Run(workerCtx)
+	scheduler := worker.NewSubscriptionScheduler(subscriptionUsecase, locker,
+		time.Duration(config.GetEnvInt("SUBSCRIPTION_INTERVAL_SEC", 60))*time.Second,
+		config.GetEnvInt("SUBSCRIPTION_BATCH_SIZE", 100),
+	)
+	go scheduler.Run(workerCtx)
+	purger := worker.NewIdempotencyPurger(idempotencyUsecase, locker,
+		time.Duration(config.GetEnvInt("IDEMPOTENCY_PURGE_INTERVAL_SEC", 3600))*time.Second,
+		config.GetEnvInt("IDEMPOTENCY_PURGE_BATCH_SIZE", 1000),
+	)
+	go purger.Run(workerCtx)
+	var publisher domain.EventPublisher
+	if addr := config.GetEnv("EVENT_BROKER_ADDR", ""); addr != "" {
+		redisPublisher := events.NewRedisStreamPublisher(addr, config.GetEnv("EVENT_STREAM", "order-events"))
+		defer redisPublisher.Close()
+		publisher = redisPublisher
+	} else {
+		log.Warn("EVENT_BROKER_ADDR not set; order events are published in memory only")
+		publisher = events.NewMemoryPublisher(config.GetEnvInt("EVENT_MEMORY_LIMIT", 1000))
+	}
+	outboxUsecase := usecase.NewTracingOutboxUsecase(usecase.NewOutboxUsecase(repo.NewOutboxRepository(dbConn), publisher,
+		time.Duration(config.GetEnvInt("OUTBOX_RETENTION_HOURS", 168))*time.Hour, 5*time.Second))
+	relay := worker.NewOutboxRelay(outboxUsecase, locker,
+		time.Duration(config.GetEnvInt("OUTBOX_RELAY_INTERVAL_SEC", 1))*time.Second,
+		config.GetEnvInt("OUTBOX_RELAY_BATCH_SIZE", 200),
+	)
+	go relay.Run(workerCtx)
+	outboxPurger := worker.NewOutboxPurger(outboxUsecase, locker,
+		time.Duration(config.GetEnvInt("OUTBOX_PURGE_INTERVAL_SEC", 3600))*time.Second,
+		config.GetEnvInt("OUTBOX_PURGE_BATCH_SIZE", 1000),
+	)
+	go outboxPurger.Run(workerCtx)
 
 	router := mux.NewRouter()
-	delivery.NewOrderHandler(router, orderUsecase)
+	delivery.NewOrderHandler(router, orderUsecase, idempotencyUsecase)
+	delivery.NewReturnHandler(router, returnUsecase)
+	delivery.NewCouponHandler(router, couponUsecase)
+	delivery.NewQuoteHandler(router, quoteUsecase)
+	delivery.NewCartHandler(router, cartUsecase)
+	delivery.NewInvoiceHandler(router, invoiceUsecase)
+	delivery.NewSubscriptionHandler(router, subscriptionUsecase)
+	if secret := config.GetEnv("PAYMENT_WEBHOOK_SECRET", ""); secret != "" {
+		delivery.NewWebhookHandler(router, orderUsecase, secret,
+			time.Duration(config.GetEnvInt("PAYMENT_WEBHOOK_TOLERANCE_SEC", 300))*time.Second)
+	} else {
+		log.Warn("PAYMENT_WEBHOOK_SECRET not set; payment webhooks are disabled")
+	}
 
 	// Swagger UI
 	router.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
@@ -104,6 +258,7 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Info("Shutting down server...")
+	stopWorkers()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -118,3 +273,20 @@ func main() {
 
 	log.Info("Server exiting")
 }
+
+// parseUserIDs reads a comma separated list of user IDs
+func parseUserIDs(list string) ([]int64, error) {
+	var ids []int64
+	for _, field := range strings.Split(list, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
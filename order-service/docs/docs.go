@@ -15,30 +15,99 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/orders": {
+        "/carts": {
+            "post": {
+                "description": "Create an empty cart for a user. Carts expire after a period without changes.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "carts"
+                ],
+                "summary": "Create a cart",
+                "parameters": [
+                    {
+                        "description": "Cart owner and destination",
+                        "name": "cart",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http.CreateCartRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.Cart"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/carts/{id}": {
             "get": {
-                "description": "Get a list of all orders",
+                "description": "Get a cart with its items and totals priced at current catalog prices",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "orders"
+                    "carts"
+                ],
+                "summary": "Get a cart",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Cart ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "List all orders",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.Order"
+                            "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.Cart"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
                             }
                         }
                     }
                 }
-            },
+            }
+        },
+        "/carts/{id}/checkout": {
             "post": {
-                "description": "Create a new order for a product and user",
+                "description": "Place an order for the cart's items at current prices, reserving stock, and close the cart. The cart is left open if the order cannot be placed.",
                 "consumes": [
                     "application/json"
                 ],
@@ -46,17 +115,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "orders"
+                    "carts"
                 ],
-                "summary": "Create a new order",
+                "summary": "Check out a cart",
                 "parameters": [
                     {
-                        "description": "Order request",
-                        "name": "order",
+                        "type": "integer",
+                        "description": "Cart ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Optional coupon",
+                        "name": "request",
                         "in": "body",
-                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/internal_delivery_http.CreateOrderRequest"
+                            "$ref": "#/definitions/internal_delivery_http.CheckoutRequest"
                         }
                     }
                 ],
@@ -76,6 +151,24 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "422": {
                         "description": "Unprocessable Entity",
                         "schema": {
@@ -88,30 +181,42 @@ const docTemplate = `{
                 }
             }
         },
-        "/orders/{id}": {
-            "get": {
-                "description": "Get detailed information about an order by its ID",
+        "/carts/{id}/items": {
+            "post": {
+                "description": "Add units of a product to a cart, merging with the product's existing line",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "orders"
+                    "carts"
                 ],
-                "summary": "Get an order by ID",
+                "summary": "Add an item to a cart",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Order ID",
+                        "description": "Cart ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Product and quantity",
+                        "name": "item",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http.CreateOrderItemRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.Order"
+                            "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.Cart"
                         }
                     },
                     "400": {
@@ -131,89 +236,3080 @@ const docTemplate = `{
                                 "type": "string"
                             }
                         }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "github_com_user_go-microservices_order-service_internal_domain.Order": {
-            "type": "object",
-            "properties": {
-                "created_at": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "integer"
-                },
-                "order_status": {
-                    "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.OrderStatus"
-                },
-                "payment_status": {
-                    "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.PaymentStatus"
-                },
-                "product_id": {
-                    "type": "integer"
-                },
-                "product_name": {
-                    "description": "Snapshot",
-                    "type": "string"
-                },
-                "quantity": {
-                    "type": "integer"
-                },
-                "total_price": {
-                    "$ref": "#/definitions/valueobject.Money"
-                },
-                "unit_price": {
-                    "description": "Snapshot",
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/valueobject.Money"
+        },
+        "/carts/{id}/items/{productId}": {
+            "put": {
+                "description": "Replace the quantity of a product already in the cart",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "carts"
+                ],
+                "summary": "Change an item quantity",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Cart ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Product ID",
+                        "name": "productId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New quantity",
+                        "name": "item",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http.UpdateCartItemRequest"
                         }
-                    ]
-                },
-                "user_id": {
-                    "type": "integer"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.Cart"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
                 }
-            }
-        },
-        "github_com_user_go-microservices_order-service_internal_domain.OrderStatus": {
-            "type": "string",
-            "enum": [
-                "PENDING",
-                "COMPLETED",
-                "CANCELLED"
-            ],
-            "x-enum-varnames": [
-                "OrderPending",
-                "OrderCompleted",
-                "OrderCancelled"
-            ]
-        },
-        "github_com_user_go-microservices_order-service_internal_domain.PaymentStatus": {
-            "type": "string",
-            "enum": [
-                "PENDING",
-                "PAID",
-                "FAILED"
-            ],
-            "x-enum-varnames": [
-                "PaymentPending",
-                "PaymentPaid",
-                "PaymentFailed"
-            ]
-        },
-        "internal_delivery_http.CreateOrderRequest": {
-            "type": "object",
-            "properties": {
-                "product_id": {
-                    "type": "integer"
-                },
-                "quantity": {
-                    "type": "integer"
-                },
-                "user_id": {
+            },
+            "delete": {
+                "description": "Remove a product line from the cart",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "carts"
+                ],
+                "summary": "Remove an item from a cart",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Cart ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Product ID",
+                        "name": "productId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.Cart"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/coupons": {
+            "get": {
+                "description": "List every coupon, active or not, with its redemption count",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "coupons"
+                ],
+                "summary": "List coupons",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.Coupon"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Start a discount campaign. Supported types are PERCENTAGE, FIXED_AMOUNT and BUY_X_GET_Y; zero usage limits mean unlimited.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "coupons"
+                ],
+                "summary": "Create a coupon",
+                "parameters": [
+                    {
+                        "description": "Coupon definition",
+                        "name": "coupon",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.Coupon"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.Coupon"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/coupons/{code}": {
+            "get": {
+                "description": "Get a coupon by its code",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "coupons"
+                ],
+                "summary": "Get a coupon",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Coupon code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.Coupon"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/coupons/{code}/deactivate": {
+            "post": {
+                "description": "End a campaign; orders that already redeemed the coupon keep their discount",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "coupons"
+                ],
+                "summary": "Deactivate a coupon",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Coupon code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.Coupon"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/orders": {
+            "get": {
+                "description": "Get a list of all orders",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "List all orders",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.Order"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a new order with one or more product lines for a user, optionally redeeming a coupon. With a quote_id the order is placed at the quote's locked prices, or rejected with 409 once the quote has expired. Orders refused by a fraud or limit rule are rejected with 422 and the rule's reason code in \"code\". A request repeated with the same Idempotency-Key within the retention window gets the first response, marked with Idempotent-Replayed; reusing a key for a different request is rejected with 422, and a repeat sent while the first is still running waits for it or is rejected with 409.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Create a new order",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Key making retries of this request safe",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Order request",
+                        "name": "order",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http.CreateOrderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.Order"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}": {
+            "get": {
+                "description": "Get detailed information about an order by its ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Get an order by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.Order"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Change line quantities of a pending, unpaid order. Only the difference is reserved or released and prices come from the original snapshot.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Amend an unpaid order",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New line quantities",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http.AmendOrderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.Order"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}/cancel": {
+            "post": {
+                "description": "Cancel an order and release its reserved stock. Cancelling an already cancelled order is a no-op.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Cancel an order",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Cancellation reason",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http.CancelOrderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.Order"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}/complete": {
+            "post": {
+                "description": "Complete a delivered order. Recording a delivery completes the order automatically; this is for orders left DELIVERED.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Complete an order",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.Order"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}/history": {
+            "get": {
+                "description": "List every status change of an order, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Get an order's status history",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.StatusChange"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}/invoice": {
+            "get": {
+                "description": "Get the invoice issued when the order was paid. Responds with a rendered HTML document when the Accept header prefers text/html or format=html is given.",
+                "produces": [
+                    "application/json",
+                    "text/html"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Get an order's invoice",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "json or html",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.Invoice"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}/pay": {
+            "post": {
+                "description": "Charge the order total through the payment provider and mark the order paid. A declined or failed charge marks the payment FAILED; the order can be paid again.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Pay for an order",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Payment method token",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http.PayOrderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.Order"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}/refunds": {
+            "get": {
+                "description": "List every refund issued against an order, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "refunds"
+                ],
+                "summary": "List refunds of an order",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.Refund"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Refund part or all of a paid order. The refund may not exceed the amount still refundable. An order that has not shipped is refunded in full by cancelling it. The refund is recorded first and then paid back through the payment provider, with retries until that succeeds.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "refunds"
+                ],
+                "summary": "Refund a paid order",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Refund request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http.RefundRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.Refund"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}/returns": {
+            "get": {
+                "description": "List every return requested against an order, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "returns"
+                ],
+                "summary": "List returns of an order",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.Return"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Request a return of some or all units of a completed order. The refund due is derived from the order's unit prices.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "returns"
+                ],
+                "summary": "Request a return",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Return request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http.ReturnRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.Return"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}/returns/{returnId}/approve": {
+            "post": {
+                "description": "Authorize the customer to send the goods back",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "returns"
+                ],
+                "summary": "Approve a return",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Return ID",
+                        "name": "returnId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.Return"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}/returns/{returnId}/receive": {
+            "post": {
+                "description": "Record that the returned goods arrived, put them back into stock and refund their value",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "returns"
+                ],
+                "summary": "Receive a return",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Return ID",
+                        "name": "returnId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.Return"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}/returns/{returnId}/reject": {
+            "post": {
+                "description": "Decline a requested return",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "returns"
+                ],
+                "summary": "Reject a return",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Return ID",
+                        "name": "returnId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Rejection note",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http.RejectReturnRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.Return"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}/shipments": {
+            "get": {
+                "description": "List every shipment of an order with its delivery status",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shipments"
+                ],
+                "summary": "List shipments of an order",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.Shipment"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Hand a paid order to a carrier and confirm its reserved stock. shipped_at defaults to now.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shipments"
+                ],
+                "summary": "Ship an order",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Shipment details",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http.ShipOrderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.Shipment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}/shipments/{shipmentId}/delivered": {
+            "post": {
+                "description": "Record that a shipment reached the customer, which completes the order. delivered_at defaults to now.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shipments"
+                ],
+                "summary": "Record a delivery",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Shipment ID",
+                        "name": "shipmentId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Delivery details",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http.DeliverShipmentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.Order"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}/transitions": {
+            "get": {
+                "description": "Get the actions that can be applied to an order from its current state",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "List legal next actions for an order",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http.TransitionsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/quotes": {
+            "post": {
+                "description": "Price items at current catalog prices, with tax and shipping, and lock those prices until the quote expires. Pass the quote ID as quote_id when creating the order.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quotes"
+                ],
+                "summary": "Create a price quote",
+                "parameters": [
+                    {
+                        "description": "Quote request",
+                        "name": "quote",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http.CreateQuoteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.Quote"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/quotes/{id}": {
+            "get": {
+                "description": "Get a quote with its locked prices and expiry",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quotes"
+                ],
+                "summary": "Get a quote",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Quote ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.Quote"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions": {
+            "post": {
+                "description": "Place an order for the product every interval_days days, starting at first_run_at. Orders that cannot be placed, for example because the product is out of stock, are retried with backoff and the delivery is skipped once retries run out.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Subscribe to a product",
+                "parameters": [
+                    {
+                        "description": "Product, quantity and schedule",
+                        "name": "subscription",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http.CreateSubscriptionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}": {
+            "get": {
+                "description": "Get a subscription with its schedule and any pending retry",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Get a subscription",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/cancel": {
+            "post": {
+                "description": "Stop the subscription for good. Orders already placed are not affected.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Cancel a subscription",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/orders": {
+            "get": {
+                "description": "List the orders placed by a subscription, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "List a subscription's orders",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.SubscriptionOrder"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/pause": {
+            "post": {
+                "description": "Stop placing orders until the subscription is resumed",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Pause a subscription",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/resume": {
+            "post": {
+                "description": "Reactivate a paused subscription. Deliveries missed while paused are not ordered.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Resume a subscription",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/skip": {
+            "post": {
+                "description": "Drop the next delivery without placing an order: a pending retry if there is one, otherwise the next scheduled run",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Skip the next delivery",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/payments": {
+            "post": {
+                "description": "Apply a signed payment provider event to its order. The X-Payment-Signature header must hold \"t=\u003cunix seconds\u003e,v1=\u003chex HMAC-SHA256 of t.body\u003e\". Redelivered events are acknowledged without changing the order.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Receive a payment notification",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Event signature",
+                        "name": "X-Payment-Signature",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Payment event",
+                        "name": "event",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.PaymentEvent"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.Order"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "github_com_user_go-microservices_order-service_internal_domain.Cart": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.CartItem"
+                    }
+                },
+                "order_id": {
+                    "type": "integer"
+                },
+                "region": {
+                    "type": "string"
+                },
+                "shipping": {
+                    "$ref": "#/definitions/valueobject.Money"
+                },
+                "status": {
+                    "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.CartStatus"
+                },
+                "subtotal": {
+                    "$ref": "#/definitions/valueobject.Money"
+                },
+                "tax": {
+                    "$ref": "#/definitions/valueobject.Money"
+                },
+                "total_price": {
+                    "$ref": "#/definitions/valueobject.Money"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_user_go-microservices_order-service_internal_domain.CartItem": {
+            "type": "object",
+            "properties": {
+                "line_total": {
+                    "$ref": "#/definitions/valueobject.Money"
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "product_name": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "unit_price": {
+                    "$ref": "#/definitions/valueobject.Money"
+                }
+            }
+        },
+        "github_com_user_go-microservices_order-service_internal_domain.CartStatus": {
+            "type": "string",
+            "enum": [
+                "OPEN",
+                "CHECKED_OUT"
+            ],
+            "x-enum-varnames": [
+                "CartOpen",
+                "CartCheckedOut"
+            ]
+        },
+        "github_com_user_go-microservices_order-service_internal_domain.Coupon": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "amount_off": {
+                    "description": "AmountOff is used by FIXED_AMOUNT coupons",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/valueobject.Money"
+                        }
+                    ]
+                },
+                "buy_qty": {
+                    "type": "integer"
+                },
+                "code": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "get_qty": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "max_uses": {
+                    "description": "MaxUses and MaxUsesPerUser limit redemptions; zero means unlimited",
+                    "type": "integer"
+                },
+                "max_uses_per_user": {
+                    "type": "integer"
+                },
+                "min_spend": {
+                    "description": "MinSpend is the subtotal an order must reach; zero means no minimum",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/valueobject.Money"
+                        }
+                    ]
+                },
+                "percentage": {
+                    "description": "Percentage is used by PERCENTAGE coupons, e.g. 15 for 15% off",
+                    "type": "number"
+                },
+                "product_id": {
+                    "description": "ProductID, BuyQty and GetQty are used by BUY_X_GET_Y coupons: for\nevery BuyQty units of the product bought, GetQty more are free",
+                    "type": "integer"
+                },
+                "type": {
+                    "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.DiscountType"
+                },
+                "uses_count": {
+                    "type": "integer"
+                },
+                "valid_from": {
+                    "type": "string"
+                },
+                "valid_until": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_user_go-microservices_order-service_internal_domain.DiscountTerms": {
+            "type": "object",
+            "properties": {
+                "amount_off": {
+                    "description": "AmountOff is used by FIXED_AMOUNT coupons",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/valueobject.Money"
+                        }
+                    ]
+                },
+                "buy_qty": {
+                    "type": "integer"
+                },
+                "get_qty": {
+                    "type": "integer"
+                },
+                "min_spend": {
+                    "description": "MinSpend is the subtotal an order must reach; zero means no minimum",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/valueobject.Money"
+                        }
+                    ]
+                },
+                "percentage": {
+                    "description": "Percentage is used by PERCENTAGE coupons, e.g. 15 for 15% off",
+                    "type": "number"
+                },
+                "product_id": {
+                    "description": "ProductID, BuyQty and GetQty are used by BUY_X_GET_Y coupons: for\nevery BuyQty units of the product bought, GetQty more are free",
+                    "type": "integer"
+                },
+                "type": {
+                    "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.DiscountType"
+                }
+            }
+        },
+        "github_com_user_go-microservices_order-service_internal_domain.DiscountType": {
+            "type": "string",
+            "enum": [
+                "PERCENTAGE",
+                "FIXED_AMOUNT",
+                "BUY_X_GET_Y"
+            ],
+            "x-enum-varnames": [
+                "DiscountPercentage",
+                "DiscountFixed",
+                "DiscountBuyXGetY"
+            ]
+        },
+        "github_com_user_go-microservices_order-service_internal_domain.Invoice": {
+            "type": "object",
+            "properties": {
+                "discount": {
+                    "$ref": "#/definitions/valueobject.Money"
+                },
+                "discount_code": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "issued_at": {
+                    "type": "string"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.InvoiceLine"
+                    }
+                },
+                "number": {
+                    "description": "Number is gap-free within a calendar year, e.g. INV-2026-000123",
+                    "type": "string"
+                },
+                "order_id": {
+                    "type": "integer"
+                },
+                "payment_reference": {
+                    "type": "string"
+                },
+                "region": {
+                    "type": "string"
+                },
+                "shipping": {
+                    "$ref": "#/definitions/valueobject.Money"
+                },
+                "subtotal": {
+                    "$ref": "#/definitions/valueobject.Money"
+                },
+                "tax": {
+                    "$ref": "#/definitions/valueobject.Money"
+                },
+                "total": {
+                    "$ref": "#/definitions/valueobject.Money"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_user_go-microservices_order-service_internal_domain.InvoiceLine": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "line_total": {
+                    "$ref": "#/definitions/valueobject.Money"
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "tax_rate": {
+                    "type": "number"
+                },
+                "unit_price": {
+                    "$ref": "#/definitions/valueobject.Money"
+                }
+            }
+        },
+        "github_com_user_go-microservices_order-service_internal_domain.Order": {
+            "type": "object",
+            "properties": {
+                "allocated_at": {
+                    "description": "AllocatedAt is when a backordered or pre-ordered order received its\nstock; the payment window starts then rather than at creation",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "discount": {
+                    "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.OrderDiscount"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.OrderItem"
+                    }
+                },
+                "order_status": {
+                    "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.OrderStatus"
+                },
+                "payment_reference": {
+                    "description": "PaymentReference identifies the captured payment at the payment provider",
+                    "type": "string"
+                },
+                "payment_status": {
+                    "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.PaymentStatus"
+                },
+                "refunded_amount": {
+                    "$ref": "#/definitions/valueobject.Money"
+                },
+                "release_at": {
+                    "description": "ReleaseAt is when the last product of a pre-order is released",
+                    "type": "string"
+                },
+                "shipping": {
+                    "$ref": "#/definitions/valueobject.Money"
+                },
+                "shipping_rate": {
+                    "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.ShippingRate"
+                },
+                "subscription_id": {
+                    "description": "SubscriptionID links an order placed by a subscription's scheduler",
+                    "type": "integer"
+                },
+                "subtotal": {
+                    "$ref": "#/definitions/valueobject.Money"
+                },
+                "tax": {
+                    "$ref": "#/definitions/valueobject.Money"
+                },
+                "tax_region": {
+                    "description": "Destination region, also selects the shipping rate",
+                    "type": "string"
+                },
+                "total_price": {
+                    "description": "Subtotal - discount + Tax + Shipping",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/valueobject.Money"
+                        }
+                    ]
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "version": {
+                    "description": "Version counts amendments of the order's lines, so an amendment based\non lines that have changed since they were read can be refused",
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_user_go-microservices_order-service_internal_domain.OrderAction": {
+            "type": "string",
+            "enum": [
+                "pay",
+                "amend",
+                "ship",
+                "deliver",
+                "complete",
+                "cancel",
+                "partial_refund",
+                "refund",
+                "fail_payment",
+                "allocate"
+            ],
+            "x-enum-varnames": [
+                "ActionPay",
+                "ActionAmend",
+                "ActionShip",
+                "ActionDeliver",
+                "ActionComplete",
+                "ActionCancel",
+                "ActionPartialRefund",
+                "ActionRefund",
+                "ActionFailPayment",
+                "ActionAllocate"
+            ]
+        },
+        "github_com_user_go-microservices_order-service_internal_domain.OrderDiscount": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "$ref": "#/definitions/valueobject.Money"
+                },
+                "code": {
+                    "type": "string"
+                },
+                "coupon_id": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "order_id": {
+                    "type": "integer"
+                },
+                "terms": {
+                    "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.DiscountTerms"
+                }
+            }
+        },
+        "github_com_user_go-microservices_order-service_internal_domain.OrderItem": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "line_total": {
+                    "$ref": "#/definitions/valueobject.Money"
+                },
+                "order_id": {
+                    "type": "integer"
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "product_name": {
+                    "description": "Snapshot",
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "tax_category": {
+                    "description": "Snapshot",
+                    "type": "string"
+                },
+                "tax_rate": {
+                    "description": "Snapshot, set by NewOrder",
+                    "type": "number"
+                },
+                "unit_price": {
+                    "description": "Snapshot",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/valueobject.Money"
+                        }
+                    ]
+                },
+                "weight_grams": {
+                    "description": "Snapshot, per unit",
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_user_go-microservices_order-service_internal_domain.OrderStatus": {
+            "type": "string",
+            "enum": [
+                "PENDING",
+                "SHIPPED",
+                "DELIVERED",
+                "COMPLETED",
+                "CANCELLED",
+                "BACKORDERED",
+                "PREORDER"
+            ],
+            "x-enum-varnames": [
+                "OrderPending",
+                "OrderShipped",
+                "OrderDelivered",
+                "OrderCompleted",
+                "OrderCancelled",
+                "OrderBackordered",
+                "OrderPreorder"
+            ]
+        },
+        "github_com_user_go-microservices_order-service_internal_domain.PaymentEvent": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "$ref": "#/definitions/valueobject.Money"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "order_id": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "description": "Reason explains a failed payment",
+                    "type": "string"
+                },
+                "reference": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.PaymentEventType"
+                }
+            }
+        },
+        "github_com_user_go-microservices_order-service_internal_domain.PaymentEventType": {
+            "type": "string",
+            "enum": [
+                "payment.succeeded",
+                "payment.failed"
+            ],
+            "x-enum-varnames": [
+                "PaymentEventSucceeded",
+                "PaymentEventFailed"
+            ]
+        },
+        "github_com_user_go-microservices_order-service_internal_domain.PaymentStatus": {
+            "type": "string",
+            "enum": [
+                "PENDING",
+                "PAID",
+                "FAILED",
+                "PARTIALLY_REFUNDED",
+                "REFUNDED"
+            ],
+            "x-enum-varnames": [
+                "PaymentPending",
+                "PaymentPaid",
+                "PaymentFailed",
+                "PaymentPartiallyRefunded",
+                "PaymentRefunded"
+            ]
+        },
+        "github_com_user_go-microservices_order-service_internal_domain.Quote": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.OrderItem"
+                    }
+                },
+                "region": {
+                    "type": "string"
+                },
+                "shipping": {
+                    "$ref": "#/definitions/valueobject.Money"
+                },
+                "shipping_rate": {
+                    "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.ShippingRate"
+                },
+                "subtotal": {
+                    "$ref": "#/definitions/valueobject.Money"
+                },
+                "tax": {
+                    "$ref": "#/definitions/valueobject.Money"
+                },
+                "total_price": {
+                    "$ref": "#/definitions/valueobject.Money"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_user_go-microservices_order-service_internal_domain.Refund": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "$ref": "#/definitions/valueobject.Money"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "order_id": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_user_go-microservices_order-service_internal_domain.Return": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "$ref": "#/definitions/valueobject.Money"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.ReturnItem"
+                    }
+                },
+                "note": {
+                    "type": "string"
+                },
+                "order_id": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "refund_id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.ReturnStatus"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_user_go-microservices_order-service_internal_domain.ReturnItem": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "$ref": "#/definitions/valueobject.Money"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "return_id": {
+                    "type": "integer"
+                },
+                "unit_price": {
+                    "$ref": "#/definitions/valueobject.Money"
+                }
+            }
+        },
+        "github_com_user_go-microservices_order-service_internal_domain.ReturnStatus": {
+            "type": "string",
+            "enum": [
+                "REQUESTED",
+                "APPROVED",
+                "REJECTED",
+                "RECEIVED"
+            ],
+            "x-enum-varnames": [
+                "ReturnRequested",
+                "ReturnApproved",
+                "ReturnRejected",
+                "ReturnReceived"
+            ]
+        },
+        "github_com_user_go-microservices_order-service_internal_domain.Shipment": {
+            "type": "object",
+            "properties": {
+                "carrier": {
+                    "type": "string"
+                },
+                "delivered_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "order_id": {
+                    "type": "integer"
+                },
+                "shipped_at": {
+                    "type": "string"
+                },
+                "tracking_number": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_user_go-microservices_order-service_internal_domain.ShippingRate": {
+            "type": "object",
+            "properties": {
+                "base_fee": {
+                    "$ref": "#/definitions/valueobject.Money"
+                },
+                "free_over": {
+                    "description": "FreeOver waives the charge when the discounted subtotal reaches it;\nzero means shipping is never free",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/valueobject.Money"
+                        }
+                    ]
+                },
+                "per_kg_fee": {
+                    "$ref": "#/definitions/valueobject.Money"
+                },
+                "zone": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_user_go-microservices_order-service_internal_domain.StatusChange": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "from_order_status": {
+                    "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.OrderStatus"
+                },
+                "from_payment_status": {
+                    "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.PaymentStatus"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "order_id": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "to_order_status": {
+                    "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.OrderStatus"
+                },
+                "to_payment_status": {
+                    "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.PaymentStatus"
+                }
+            }
+        },
+        "github_com_user_go-microservices_order-service_internal_domain.Subscription": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "failed_attempts": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "interval_days": {
+                    "type": "integer"
+                },
+                "last_order_id": {
+                    "type": "integer"
+                },
+                "next_run_at": {
+                    "description": "NextRunAt is the date of the next scheduled delivery",
+                    "type": "string"
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "region": {
+                    "type": "string"
+                },
+                "retry_at": {
+                    "description": "RetryAt is set while a failed run is waiting to be retried, and\nFailedAttempts counts the failures of that run",
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.SubscriptionStatus"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_user_go-microservices_order-service_internal_domain.SubscriptionOrder": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "order_id": {
+                    "type": "integer"
+                },
+                "order_status": {
+                    "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.OrderStatus"
+                },
+                "payment_status": {
+                    "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.PaymentStatus"
+                },
+                "total_price": {
+                    "$ref": "#/definitions/valueobject.Money"
+                }
+            }
+        },
+        "github_com_user_go-microservices_order-service_internal_domain.SubscriptionStatus": {
+            "type": "string",
+            "enum": [
+                "ACTIVE",
+                "PAUSED",
+                "CANCELLED"
+            ],
+            "x-enum-varnames": [
+                "SubscriptionActive",
+                "SubscriptionPaused",
+                "SubscriptionCancelled"
+            ]
+        },
+        "internal_delivery_http.AmendOrderRequest": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_delivery_http.CreateOrderItemRequest"
+                    }
+                }
+            }
+        },
+        "internal_delivery_http.CancelOrderRequest": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_delivery_http.CheckoutRequest": {
+            "type": "object",
+            "properties": {
+                "coupon_code": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_delivery_http.CreateCartRequest": {
+            "type": "object",
+            "properties": {
+                "region": {
+                    "description": "Region is the destination, e.g. \"US-CA\"; it selects tax and shipping rates",
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "internal_delivery_http.CreateOrderItemRequest": {
+            "type": "object",
+            "properties": {
+                "product_id": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                }
+            }
+        },
+        "internal_delivery_http.CreateOrderRequest": {
+            "type": "object",
+            "properties": {
+                "coupon_code": {
+                    "type": "string"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_delivery_http.CreateOrderItemRequest"
+                    }
+                },
+                "quote_id": {
+                    "description": "QuoteID orders the items of a quote at its locked prices; items and\nregion are then taken from the quote and must be omitted",
+                    "type": "integer"
+                },
+                "region": {
+                    "description": "Region is the destination, e.g. \"US-CA\"; it selects tax and shipping rates",
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "internal_delivery_http.CreateQuoteRequest": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_delivery_http.CreateOrderItemRequest"
+                    }
+                },
+                "region": {
+                    "description": "Region is the destination, e.g. \"US-CA\"; it selects tax and shipping rates",
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "internal_delivery_http.CreateSubscriptionRequest": {
+            "type": "object",
+            "properties": {
+                "first_run_at": {
+                    "description": "FirstRunAt is when the first order is placed; omit to order right away",
+                    "type": "string"
+                },
+                "interval_days": {
+                    "type": "integer"
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "region": {
+                    "description": "Region is the destination, e.g. \"US-CA\"; it selects tax and shipping rates",
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "internal_delivery_http.DeliverShipmentRequest": {
+            "type": "object",
+            "properties": {
+                "delivered_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_delivery_http.PayOrderRequest": {
+            "type": "object",
+            "properties": {
+                "payment_token": {
+                    "description": "PaymentToken identifies the customer's payment method at the provider",
+                    "type": "string"
+                }
+            }
+        },
+        "internal_delivery_http.RefundRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "$ref": "#/definitions/valueobject.Money"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_delivery_http.RejectReturnRequest": {
+            "type": "object",
+            "properties": {
+                "note": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_delivery_http.ReturnItemRequest": {
+            "type": "object",
+            "properties": {
+                "product_id": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                }
+            }
+        },
+        "internal_delivery_http.ReturnRequest": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_delivery_http.ReturnItemRequest"
+                    }
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_delivery_http.ShipOrderRequest": {
+            "type": "object",
+            "properties": {
+                "carrier": {
+                    "type": "string"
+                },
+                "shipped_at": {
+                    "type": "string"
+                },
+                "tracking_number": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_delivery_http.TransitionsResponse": {
+            "type": "object",
+            "properties": {
+                "actions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.OrderAction"
+                    }
+                },
+                "order_id": {
+                    "type": "integer"
+                },
+                "order_status": {
+                    "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.OrderStatus"
+                },
+                "payment_status": {
+                    "$ref": "#/definitions/github_com_user_go-microservices_order-service_internal_domain.PaymentStatus"
+                }
+            }
+        },
+        "internal_delivery_http.UpdateCartItemRequest": {
+            "type": "object",
+            "properties": {
+                "quantity": {
                     "type": "integer"
                 }
             }
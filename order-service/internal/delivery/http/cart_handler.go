@@ -0,0 +1,267 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/order-service/internal/usecase"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+	"go.uber.org/zap"
+)
+
+type CartHandler struct {
+	CartUsecase usecase.CartUsecase
+}
+
+func NewCartHandler(r *mux.Router, us usecase.CartUsecase) {
+	handler := &CartHandler{
+		CartUsecase: us,
+	}
+
+	r.HandleFunc("/carts", handler.CreateCart).Methods("POST")
+	r.HandleFunc("/carts/{id}", handler.GetCart).Methods("GET")
+	r.HandleFunc("/carts/{id}/items", handler.AddItem).Methods("POST")
+	r.HandleFunc("/carts/{id}/items/{productId}", handler.UpdateItem).Methods("PUT")
+	r.HandleFunc("/carts/{id}/items/{productId}", handler.RemoveItem).Methods("DELETE")
+	r.HandleFunc("/carts/{id}/checkout", handler.Checkout).Methods("POST")
+}
+
+type CreateCartRequest struct {
+	UserID int64 `json:"user_id"`
+	// Region is the destination, e.g. "US-CA"; it selects tax and shipping rates
+	Region string `json:"region"`
+}
+
+type UpdateCartItemRequest struct {
+	Quantity int `json:"quantity"`
+}
+
+type CheckoutRequest struct {
+	CouponCode string `json:"coupon_code,omitempty"`
+}
+
+// CreateCart godoc
+// @Summary Create a cart
+// @Description Create an empty cart for a user. Carts expire after a period without changes.
+// @Tags carts
+// @Accept  json
+// @Produce  json
+// @Param cart body CreateCartRequest true "Cart owner and destination"
+// @Success 201 {object} domain.Cart
+// @Failure 400 {object} map[string]string
+// @Router /carts [post]
+func (h *CartHandler) CreateCart(w http.ResponseWriter, r *http.Request) {
+	var req CreateCartRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	cart, err := h.CartUsecase.CreateCart(r.Context(), req.UserID, req.Region)
+	if err != nil {
+		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
+		return
+	}
+	h.respondWithJSON(w, http.StatusCreated, cart)
+}
+
+// GetCart godoc
+// @Summary Get a cart
+// @Description Get a cart with its items and totals priced at current catalog prices
+// @Tags carts
+// @Produce  json
+// @Param id path int true "Cart ID"
+// @Success 200 {object} domain.Cart
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /carts/{id} [get]
+func (h *CartHandler) GetCart(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	cart, err := h.CartUsecase.GetCart(r.Context(), id)
+	if err != nil {
+		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, cart)
+}
+
+// AddItem godoc
+// @Summary Add an item to a cart
+// @Description Add units of a product to a cart, merging with the product's existing line
+// @Tags carts
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Cart ID"
+// @Param item body CreateOrderItemRequest true "Product and quantity"
+// @Success 200 {object} domain.Cart
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /carts/{id}/items [post]
+func (h *CartHandler) AddItem(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	var req CreateOrderItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	cart, err := h.CartUsecase.AddItem(r.Context(), id, req.ProductID, req.Quantity)
+	if err != nil {
+		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, cart)
+}
+
+// UpdateItem godoc
+// @Summary Change an item quantity
+// @Description Replace the quantity of a product already in the cart
+// @Tags carts
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Cart ID"
+// @Param productId path int true "Product ID"
+// @Param item body UpdateCartItemRequest true "New quantity"
+// @Success 200 {object} domain.Cart
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /carts/{id}/items/{productId} [put]
+func (h *CartHandler) UpdateItem(w http.ResponseWriter, r *http.Request) {
+	id, productID, ok := h.parseItemIDs(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateCartItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	cart, err := h.CartUsecase.UpdateItem(r.Context(), id, productID, req.Quantity)
+	if err != nil {
+		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, cart)
+}
+
+// RemoveItem godoc
+// @Summary Remove an item from a cart
+// @Description Remove a product line from the cart
+// @Tags carts
+// @Produce  json
+// @Param id path int true "Cart ID"
+// @Param productId path int true "Product ID"
+// @Success 200 {object} domain.Cart
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /carts/{id}/items/{productId} [delete]
+func (h *CartHandler) RemoveItem(w http.ResponseWriter, r *http.Request) {
+	id, productID, ok := h.parseItemIDs(w, r)
+	if !ok {
+		return
+	}
+
+	cart, err := h.CartUsecase.RemoveItem(r.Context(), id, productID)
+	if err != nil {
+		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, cart)
+}
+
+// Checkout godoc
+// @Summary Check out a cart
+// @Description Place an order for the cart's items at current prices, reserving stock, and close the cart. The cart is left open if the order cannot be placed.
+// @Tags carts
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Cart ID"
+// @Param request body CheckoutRequest false "Optional coupon"
+// @Success 201 {object} domain.Order
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /carts/{id}/checkout [post]
+func (h *CartHandler) Checkout(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	var req CheckoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	order, err := h.CartUsecase.Checkout(r.Context(), id, req.CouponCode)
+	if err != nil {
+		if rule := domain.ViolatedRule(err); rule != "" {
+			h.respondWithJSON(w, pkgerrors.GetStatusCode(err), map[string]string{"error": err.Error(), "code": rule})
+			return
+		}
+		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
+		return
+	}
+	h.respondWithJSON(w, http.StatusCreated, order)
+}
+
+func (h *CartHandler) parseID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid cart ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// parseItemIDs reads the cart and product IDs from the path, responding with a
+// 400 when either is malformed
+func (h *CartHandler) parseItemIDs(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	id, ok := h.parseID(w, r)
+	if !ok {
+		return 0, 0, false
+	}
+	productID, err := strconv.ParseInt(mux.Vars(r)["productId"], 10, 64)
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return 0, 0, false
+	}
+	return id, productID, true
+}
+
+func (h *CartHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	h.respondWithJSON(w, code, map[string]string{"error": message})
+}
+
+func (h *CartHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	response, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(response)
+
+	logger.Info("request handled",
+		zap.Int("status", code),
+		zap.String("response", string(response)),
+	)
+}
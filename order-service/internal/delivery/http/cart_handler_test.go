@@ -0,0 +1,94 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/order-service/internal/usecase/mocks"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+)
+
+func TestCartHandler(t *testing.T) {
+	logger.Init()
+	mockUC := mocks.NewCartUsecase(t)
+	router := mux.NewRouter()
+	NewCartHandler(router, mockUC)
+
+	t.Run("CreateCart_Success", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/carts", bytes.NewBufferString(`{"user_id":1,"region":"US-CA"}`))
+		rr := httptest.NewRecorder()
+
+		mockUC.On("CreateCart", mock.Anything, int64(1), "US-CA").Return(&domain.Cart{ID: 5, UserID: 1}, nil)
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		var res domain.Cart
+		json.Unmarshal(rr.Body.Bytes(), &res)
+		assert.Equal(t, int64(5), res.ID)
+	})
+
+	t.Run("AddItem_Success", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/carts/5/items", bytes.NewBufferString(`{"product_id":1,"quantity":2}`))
+		rr := httptest.NewRecorder()
+
+		mockUC.On("AddItem", mock.Anything, int64(5), int64(1), 2).Return(&domain.Cart{ID: 5}, nil)
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("UpdateItem_CartClosed", func(t *testing.T) {
+		req, _ := http.NewRequest("PUT", "/carts/5/items/1", bytes.NewBufferString(`{"quantity":4}`))
+		rr := httptest.NewRecorder()
+
+		mockUC.On("UpdateItem", mock.Anything, int64(5), int64(1), 4).Return(nil, domain.ErrCartClosed)
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+
+	t.Run("RemoveItem_InvalidProductID", func(t *testing.T) {
+		req, _ := http.NewRequest("DELETE", "/carts/5/items/abc", nil)
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Checkout_Success", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/carts/5/checkout", bytes.NewBuffer(nil))
+		rr := httptest.NewRecorder()
+
+		mockUC.On("Checkout", mock.Anything, int64(5), "").Return(&domain.Order{ID: 42}, nil)
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		var res domain.Order
+		json.Unmarshal(rr.Body.Bytes(), &res)
+		assert.Equal(t, int64(42), res.ID)
+	})
+
+	t.Run("Checkout_InsufficientStock", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/carts/6/checkout", bytes.NewBufferString(`{"coupon_code":"SPRING10"}`))
+		rr := httptest.NewRecorder()
+
+		mockUC.On("Checkout", mock.Anything, int64(6), "SPRING10").Return(nil, pkgerrors.ErrInsufficientStock)
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	})
+}
@@ -0,0 +1,123 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/order-service/internal/usecase"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+	"go.uber.org/zap"
+)
+
+type CouponHandler struct {
+	CouponUsecase usecase.CouponUsecase
+}
+
+func NewCouponHandler(r *mux.Router, us usecase.CouponUsecase) {
+	handler := &CouponHandler{
+		CouponUsecase: us,
+	}
+
+	r.HandleFunc("/coupons", handler.CreateCoupon).Methods("POST")
+	r.HandleFunc("/coupons", handler.GetAllCoupons).Methods("GET")
+	r.HandleFunc("/coupons/{code}", handler.GetCoupon).Methods("GET")
+	r.HandleFunc("/coupons/{code}/deactivate", handler.DeactivateCoupon).Methods("POST")
+}
+
+// CreateCoupon godoc
+// @Summary Create a coupon
+// @Description Start a discount campaign. Supported types are PERCENTAGE, FIXED_AMOUNT and BUY_X_GET_Y; zero usage limits mean unlimited.
+// @Tags coupons
+// @Accept  json
+// @Produce  json
+// @Param coupon body domain.Coupon true "Coupon definition"
+// @Success 201 {object} domain.Coupon
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /coupons [post]
+func (h *CouponHandler) CreateCoupon(w http.ResponseWriter, r *http.Request) {
+	var c domain.Coupon
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	coupon, err := h.CouponUsecase.CreateCoupon(r.Context(), &c)
+	if err != nil {
+		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
+		return
+	}
+	h.respondWithJSON(w, http.StatusCreated, coupon)
+}
+
+// GetAllCoupons godoc
+// @Summary List coupons
+// @Description List every coupon, active or not, with its redemption count
+// @Tags coupons
+// @Produce  json
+// @Success 200 {array} domain.Coupon
+// @Router /coupons [get]
+func (h *CouponHandler) GetAllCoupons(w http.ResponseWriter, r *http.Request) {
+	coupons, err := h.CouponUsecase.GetAllCoupons(r.Context())
+	if err != nil {
+		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, coupons)
+}
+
+// GetCoupon godoc
+// @Summary Get a coupon
+// @Description Get a coupon by its code
+// @Tags coupons
+// @Produce  json
+// @Param code path string true "Coupon code"
+// @Success 200 {object} domain.Coupon
+// @Failure 404 {object} map[string]string
+// @Router /coupons/{code} [get]
+func (h *CouponHandler) GetCoupon(w http.ResponseWriter, r *http.Request) {
+	coupon, err := h.CouponUsecase.GetCoupon(r.Context(), mux.Vars(r)["code"])
+	if err != nil {
+		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, coupon)
+}
+
+// DeactivateCoupon godoc
+// @Summary Deactivate a coupon
+// @Description End a campaign; orders that already redeemed the coupon keep their discount
+// @Tags coupons
+// @Produce  json
+// @Param code path string true "Coupon code"
+// @Success 200 {object} domain.Coupon
+// @Failure 404 {object} map[string]string
+// @Router /coupons/{code}/deactivate [post]
+func (h *CouponHandler) DeactivateCoupon(w http.ResponseWriter, r *http.Request) {
+	coupon, err := h.CouponUsecase.DeactivateCoupon(r.Context(), mux.Vars(r)["code"])
+	if err != nil {
+		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, coupon)
+}
+
+func (h *CouponHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	h.respondWithJSON(w, code, map[string]string{"error": message})
+}
+
+func (h *CouponHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	response, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(response)
+
+	logger.Info("request handled",
+		zap.Int("status", code),
+		zap.String("response", string(response)),
+	)
+}
@@ -0,0 +1,72 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/order-service/internal/usecase/mocks"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+)
+
+func TestCouponHandler(t *testing.T) {
+	logger.Init()
+	mockUC := mocks.NewCouponUsecase(t)
+	router := mux.NewRouter()
+	NewCouponHandler(router, mockUC)
+
+	t.Run("CreateCoupon_Success", func(t *testing.T) {
+		body := []byte(`{"code":"spring10","type":"PERCENTAGE","percentage":10,"max_uses":100}`)
+		req, _ := http.NewRequest("POST", "/coupons", bytes.NewBuffer(body))
+		rr := httptest.NewRecorder()
+
+		mockUC.On("CreateCoupon", mock.Anything, mock.MatchedBy(func(c *domain.Coupon) bool {
+			return c.Code == "spring10" && c.Type == domain.DiscountPercentage && c.Percentage == 10 && c.MaxUses == 100
+		})).Return(&domain.Coupon{ID: 1, Code: "SPRING10"}, nil)
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		var res domain.Coupon
+		json.Unmarshal(rr.Body.Bytes(), &res)
+		assert.Equal(t, "SPRING10", res.Code)
+	})
+
+	t.Run("CreateCoupon_InvalidPayload", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/coupons", bytes.NewBufferString("{"))
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("GetCoupon_NotFound", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/coupons/NOPE", nil)
+		rr := httptest.NewRecorder()
+
+		mockUC.On("GetCoupon", mock.Anything, "NOPE").Return(nil, pkgerrors.ErrNotFound)
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("DeactivateCoupon_Success", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/coupons/SPRING10/deactivate", nil)
+		rr := httptest.NewRecorder()
+
+		mockUC.On("DeactivateCoupon", mock.Anything, "SPRING10").Return(&domain.Coupon{ID: 1, Code: "SPRING10", Active: false}, nil)
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+}
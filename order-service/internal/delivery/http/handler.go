@@ -1,9 +1,14 @@
 package http
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 
@@ -11,41 +16,102 @@ import (
 	"github.com/user/go-microservices/order-service/internal/usecase"
 	pkgerrors "github.com/user/go-microservices/pkg/errors"
 	"github.com/user/go-microservices/pkg/logger"
+	"github.com/user/go-microservices/pkg/valueobject"
 	"go.uber.org/zap"
 )
 
-var _ = domain.Order{}
-
 type OrderHandler struct {
-	OrderUsecase usecase.OrderUsecase
+	OrderUsecase       usecase.OrderUsecase
+	IdempotencyUsecase usecase.IdempotencyUsecase
 }
 
-func NewOrderHandler(r *mux.Router, us usecase.OrderUsecase) {
+func NewOrderHandler(r *mux.Router, us usecase.OrderUsecase, idempotency usecase.IdempotencyUsecase) {
 	handler := &OrderHandler{
-		OrderUsecase: us,
+		OrderUsecase:       us,
+		IdempotencyUsecase: idempotency,
 	}
 
-	r.HandleFunc("/orders", handler.CreateOrder).Methods("POST")
+	r.HandleFunc("/orders", withActor(handler.CreateOrder)).Methods("POST")
 	r.HandleFunc("/orders", handler.GetAllOrders).Methods("GET")
 	r.HandleFunc("/orders/{id}", handler.GetOrder).Methods("GET")
+	r.HandleFunc("/orders/{id}", withActor(handler.AmendOrder)).Methods("PATCH")
+	r.HandleFunc("/orders/{id}/transitions", handler.GetOrderTransitions).Methods("GET")
+	r.HandleFunc("/orders/{id}/history", handler.GetOrderHistory).Methods("GET")
+	r.HandleFunc("/orders/{id}/refunds", withActor(handler.RefundOrder)).Methods("POST")
+	r.HandleFunc("/orders/{id}/refunds", handler.GetRefunds).Methods("GET")
+	r.HandleFunc("/orders/{id}/shipments", withActor(handler.ShipOrder)).Methods("POST")
+	r.HandleFunc("/orders/{id}/shipments", handler.GetShipments).Methods("GET")
+	r.HandleFunc("/orders/{id}/shipments/{shipmentId}/delivered", withActor(handler.DeliverShipment)).Methods("POST")
+	r.HandleFunc("/orders/{id}/pay", withActor(handler.PayOrder)).Methods("POST")
+	r.HandleFunc("/orders/{id}/complete", withActor(handler.CompleteOrder)).Methods("POST")
+	r.HandleFunc("/orders/{id}/cancel", withActor(handler.CancelOrder)).Methods("POST")
 	r.HandleFunc("/health", handler.HealthCheck).Methods("GET")
 }
 
-type CreateOrderRequest struct {
-	UserID    int64 `json:"user_id"`
+// ActorHeader names who is making a change, for the order status history.
+// The service has no authentication to check it against, so it is recorded
+// as unverified.
+const ActorHeader = "X-Actor"
+
+// withActor attaches the actor named in ActorHeader to the request. It wraps
+// only the routes that change orders, so other handlers never see it.
+func withActor(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if actor := r.Header.Get(ActorHeader); actor != "" {
+			r = r.WithContext(domain.WithActor(r.Context(), domain.UnverifiedActor(actor)))
+		}
+		next(w, r)
+	}
+}
+
+// IdempotencyKeyHeader makes POST /orders safe to retry: a repeat of a
+// request with the same key gets the first response instead of a second order
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentReplayedHeader is set on a response replayed for a repeated key
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+const maxIdempotencyKeyLength = 255
+
+type CreateOrderItemRequest struct {
 	ProductID int64 `json:"product_id"`
 	Quantity  int   `json:"quantity"`
 }
 
+// toItemInputs converts requested lines, reporting false if any quantity is not positive
+func toItemInputs(lines []CreateOrderItemRequest) ([]usecase.OrderItemInput, bool) {
+	items := make([]usecase.OrderItemInput, 0, len(lines))
+	for _, item := range lines {
+		if item.Quantity <= 0 {
+			return nil, false
+		}
+		items = append(items, usecase.OrderItemInput{ProductID: item.ProductID, Quantity: item.Quantity})
+	}
+	return items, true
+}
+
+type CreateOrderRequest struct {
+	UserID int64                    `json:"user_id"`
+	Items  []CreateOrderItemRequest `json:"items"`
+	// Region is the destination, e.g. "US-CA"; it selects tax and shipping rates
+	Region     string `json:"region"`
+	CouponCode string `json:"coupon_code,omitempty"`
+	// QuoteID orders the items of a quote at its locked prices; items and
+	// region are then taken from the quote and must be omitted
+	QuoteID int64 `json:"quote_id,omitempty"`
+}
+
 // CreateOrder godoc
 // @Summary Create a new order
-// @Description Create a new order for a product and user
+// @Description Create a new order with one or more product lines for a user, optionally redeeming a coupon. With a quote_id the order is placed at the quote's locked prices, or rejected with 409 once the quote has expired. Orders refused by a fraud or limit rule are rejected with 422 and the rule's reason code in "code". A request repeated with the same Idempotency-Key within the retention window gets the first response, marked with Idempotent-Replayed; reusing a key for a different request is rejected with 422, and a repeat sent while the first is still running waits for it or is rejected with 409.
 // @Tags orders
 // @Accept  json
 // @Produce  json
+// @Param Idempotency-Key header string false "Key making retries of this request safe"
 // @Param order body CreateOrderRequest true "Order request"
 // @Success 201 {object} domain.Order
 // @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Failure 422 {object} map[string]string
 // @Router /orders [post]
 func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
@@ -55,18 +121,78 @@ func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Quantity <= 0 {
-		h.respondWithError(w, http.StatusBadRequest, "Quantity must be greater than 0")
+	key := r.Header.Get(IdempotencyKeyHeader)
+	if key == "" {
+		code, payload := h.createOrder(r.Context(), req, "")
+		h.respondWithJSON(w, code, payload)
+		return
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		h.respondWithError(w, http.StatusBadRequest, "Idempotency-Key must be at most 255 characters")
 		return
 	}
 
-	ctx := r.Context()
-	order, err := h.OrderUsecase.CreateOrder(ctx, req.UserID, req.ProductID, req.Quantity)
+	hash := hashCreateOrder(r.Context(), req)
+	rec, replayed, err := h.IdempotencyUsecase.Execute(r.Context(), key, hash,
+		func(ctx context.Context) (int, []byte) {
+			code, payload := h.createOrder(ctx, req, orderRequestKey(key, hash))
+			body, _ := json.Marshal(payload)
+			return code, body
+		})
 	if err != nil {
 		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
 		return
 	}
-	h.respondWithJSON(w, http.StatusCreated, order)
+	if replayed {
+		w.Header().Set(IdempotentReplayedHeader, "true")
+	}
+	h.respondWithBody(w, rec.StatusCode, rec.Body)
+}
+
+// createOrder validates and places the order under requestKey, returning the
+// response status and payload
+func (h *OrderHandler) createOrder(ctx context.Context, req CreateOrderRequest, requestKey string) (int, interface{}) {
+	if len(req.Items) == 0 && req.QuoteID == 0 {
+		return http.StatusBadRequest, map[string]string{"error": "Order must contain at least one item"}
+	}
+
+	items, ok := toItemInputs(req.Items)
+	if !ok {
+		return http.StatusBadRequest, map[string]string{"error": "Quantity must be greater than 0"}
+	}
+
+	order, err := h.OrderUsecase.CreateOrder(ctx, usecase.CreateOrderInput{
+		UserID:     req.UserID,
+		Items:      items,
+		Region:     req.Region,
+		CouponCode: req.CouponCode,
+		QuoteID:    req.QuoteID,
+		// Should the response be lost to a server error after the order
+		// was stored, the retry finds the order by this key
+		IdempotencyKey: requestKey,
+	})
+	if err != nil {
+		return orderError(err)
+	}
+	return http.StatusCreated, order
+}
+
+// orderRequestKey identifies a request by its idempotency key and hash, so
+// only a retry of the same request finds the order it placed
+func orderRequestKey(key, requestHash string) string {
+	sum := sha256.Sum256([]byte(key + "\x00" + requestHash))
+	return hex.EncodeToString(sum[:])
+}
+
+// hashCreateOrder identifies an order request by its decoded fields and
+// actor, so a retry matches whatever the JSON's spacing or field order
+func hashCreateOrder(ctx context.Context, req CreateOrderRequest) string {
+	b, _ := json.Marshal(struct {
+		Request CreateOrderRequest `json:"request"`
+		Actor   string             `json:"actor"`
+	}{req, domain.ActorFromContext(ctx)})
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
 }
 
 // GetAllOrders godoc
@@ -111,16 +237,436 @@ func (h *OrderHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
 	h.respondWithJSON(w, http.StatusOK, o)
 }
 
+type AmendOrderRequest struct {
+	Items []CreateOrderItemRequest `json:"items"`
+}
+
+// AmendOrder godoc
+// @Summary Amend an unpaid order
+// @Description Change line quantities of a pending, unpaid order. Only the difference is reserved or released and prices come from the original snapshot.
+// @Tags orders
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Order ID"
+// @Param request body AmendOrderRequest true "New line quantities"
+// @Success 200 {object} domain.Order
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /orders/{id} [patch]
+func (h *OrderHandler) AmendOrder(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	var req AmendOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	if len(req.Items) == 0 {
+		h.respondWithError(w, http.StatusBadRequest, "Amendment must contain at least one item")
+		return
+	}
+
+	items, ok := toItemInputs(req.Items)
+	if !ok {
+		h.respondWithError(w, http.StatusBadRequest, "Quantity must be greater than 0")
+		return
+	}
+
+	o, err := h.OrderUsecase.AmendOrder(r.Context(), id, items)
+	if err != nil {
+		h.respondWithOrderError(w, err)
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, o)
+}
+
+type TransitionsResponse struct {
+	OrderID       int64                `json:"order_id"`
+	OrderStatus   domain.OrderStatus   `json:"order_status"`
+	PaymentStatus domain.PaymentStatus `json:"payment_status"`
+	Actions       []domain.OrderAction `json:"actions"`
+}
+
+// GetOrderTransitions godoc
+// @Summary List legal next actions for an order
+// @Description Get the actions that can be applied to an order from its current state
+// @Tags orders
+// @Produce  json
+// @Param id path int true "Order ID"
+// @Success 200 {object} TransitionsResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /orders/{id}/transitions [get]
+func (h *OrderHandler) GetOrderTransitions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	o, err := h.OrderUsecase.GetOrder(r.Context(), id)
+	if err != nil {
+		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, TransitionsResponse{
+		OrderID:       o.ID,
+		OrderStatus:   o.OrderStatus,
+		PaymentStatus: o.PaymentStatus,
+		Actions:       o.AvailableActions(),
+	})
+}
+
+type PayOrderRequest struct {
+	// PaymentToken identifies the customer's payment method at the provider
+	PaymentToken string `json:"payment_token"`
+}
+
+// PayOrder godoc
+// @Summary Pay for an order
+// @Description Charge the order total through the payment provider and mark the order paid. A declined or failed charge marks the payment FAILED; the order can be paid again.
+// @Tags orders
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Order ID"
+// @Param request body PayOrderRequest false "Payment method token"
+// @Success 200 {object} domain.Order
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /orders/{id}/pay [post]
+func (h *OrderHandler) PayOrder(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	var req PayOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	o, err := h.OrderUsecase.PayOrder(r.Context(), id, req.PaymentToken)
+	if err != nil {
+		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, o)
+}
+
+// CompleteOrder godoc
+// @Summary Complete an order
+// @Description Complete a delivered order. Recording a delivery completes the order automatically; this is for orders left DELIVERED.
+// @Tags orders
+// @Produce  json
+// @Param id path int true "Order ID"
+// @Success 200 {object} domain.Order
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /orders/{id}/complete [post]
+func (h *OrderHandler) CompleteOrder(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	o, err := h.OrderUsecase.CompleteOrder(r.Context(), id)
+	if err != nil {
+		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, o)
+}
+
+type ShipOrderRequest struct {
+	Carrier        string    `json:"carrier"`
+	TrackingNumber string    `json:"tracking_number"`
+	ShippedAt      time.Time `json:"shipped_at"`
+}
+
+// ShipOrder godoc
+// @Summary Ship an order
+// @Description Hand a paid order to a carrier and confirm its reserved stock. shipped_at defaults to now.
+// @Tags shipments
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Order ID"
+// @Param request body ShipOrderRequest true "Shipment details"
+// @Success 201 {object} domain.Shipment
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /orders/{id}/shipments [post]
+func (h *OrderHandler) ShipOrder(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	var req ShipOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	shipment, err := h.OrderUsecase.ShipOrder(r.Context(), id, req.Carrier, req.TrackingNumber, req.ShippedAt)
+	if err != nil {
+		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
+		return
+	}
+	h.respondWithJSON(w, http.StatusCreated, shipment)
+}
+
+// GetShipments godoc
+// @Summary List shipments of an order
+// @Description List every shipment of an order with its delivery status
+// @Tags shipments
+// @Produce  json
+// @Param id path int true "Order ID"
+// @Success 200 {array} domain.Shipment
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /orders/{id}/shipments [get]
+func (h *OrderHandler) GetShipments(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	shipments, err := h.OrderUsecase.GetShipments(r.Context(), id)
+	if err != nil {
+		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, shipments)
+}
+
+type DeliverShipmentRequest struct {
+	DeliveredAt time.Time `json:"delivered_at"`
+}
+
+// DeliverShipment godoc
+// @Summary Record a delivery
+// @Description Record that a shipment reached the customer, which completes the order. delivered_at defaults to now.
+// @Tags shipments
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Order ID"
+// @Param shipmentId path int true "Shipment ID"
+// @Param request body DeliverShipmentRequest false "Delivery details"
+// @Success 200 {object} domain.Order
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /orders/{id}/shipments/{shipmentId}/delivered [post]
+func (h *OrderHandler) DeliverShipment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+	shipmentID, err := strconv.ParseInt(vars["shipmentId"], 10, 64)
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid shipment ID")
+		return
+	}
+
+	var req DeliverShipmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	o, err := h.OrderUsecase.DeliverShipment(r.Context(), id, shipmentID, req.DeliveredAt)
+	if err != nil {
+		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, o)
+}
+
+type CancelOrderRequest struct {
+	Reason string `json:"reason"`
+}
+
+// CancelOrder godoc
+// @Summary Cancel an order
+// @Description Cancel an order and release its reserved stock. Cancelling an already cancelled order is a no-op.
+// @Tags orders
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Order ID"
+// @Param request body CancelOrderRequest false "Cancellation reason"
+// @Success 200 {object} domain.Order
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /orders/{id}/cancel [post]
+func (h *OrderHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	var req CancelOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	o, err := h.OrderUsecase.CancelOrder(r.Context(), id, req.Reason)
+	if err != nil {
+		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, o)
+}
+
+// GetOrderHistory godoc
+// @Summary Get an order's status history
+// @Description List every status change of an order, oldest first
+// @Tags orders
+// @Produce  json
+// @Param id path int true "Order ID"
+// @Success 200 {array} domain.StatusChange
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /orders/{id}/history [get]
+func (h *OrderHandler) GetOrderHistory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	history, err := h.OrderUsecase.GetOrderHistory(r.Context(), id)
+	if err != nil {
+		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, history)
+}
+
+type RefundRequest struct {
+	Amount valueobject.Money `json:"amount"`
+	Reason string            `json:"reason"`
+}
+
+// RefundOrder godoc
+// @Summary Refund a paid order
+// @Description Refund part or all of a paid order. The refund may not exceed the amount still refundable. An order that has not shipped is refunded in full by cancelling it. The refund is recorded first and then paid back through the payment provider, with retries until that succeeds.
+// @Tags refunds
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Order ID"
+// @Param request body RefundRequest true "Refund request"
+// @Success 201 {object} domain.Refund
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /orders/{id}/refunds [post]
+func (h *OrderHandler) RefundOrder(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	var req RefundRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	refund, err := h.OrderUsecase.RefundOrder(r.Context(), id, req.Amount, req.Reason)
+	if err != nil {
+		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
+		return
+	}
+	h.respondWithJSON(w, http.StatusCreated, refund)
+}
+
+// GetRefunds godoc
+// @Summary List refunds of an order
+// @Description List every refund issued against an order, oldest first
+// @Tags refunds
+// @Produce  json
+// @Param id path int true "Order ID"
+// @Success 200 {array} domain.Refund
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /orders/{id}/refunds [get]
+func (h *OrderHandler) GetRefunds(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	refunds, err := h.OrderUsecase.GetRefunds(r.Context(), id)
+	if err != nil {
+		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, refunds)
+}
+
 func (h *OrderHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	h.respondWithJSON(w, http.StatusOK, map[string]string{"status": "UP"})
 }
 
+// respondWithOrderError reports err like respondWithError, adding the reason
+// code when a rule rejected the order
+func (h *OrderHandler) respondWithOrderError(w http.ResponseWriter, err error) {
+	code, body := orderError(err)
+	h.respondWithJSON(w, code, body)
+}
+
+func orderError(err error) (int, interface{}) {
+	body := map[string]string{"error": err.Error()}
+	if rule := domain.ViolatedRule(err); rule != "" {
+		body["code"] = rule
+	}
+	return pkgerrors.GetStatusCode(err), body
+}
+
 func (h *OrderHandler) respondWithError(w http.ResponseWriter, code int, message string) {
 	h.respondWithJSON(w, code, map[string]string{"error": message})
 }
 
 func (h *OrderHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	response, _ := json.Marshal(payload)
+	h.respondWithBody(w, code, response)
+}
+
+func (h *OrderHandler) respondWithBody(w http.ResponseWriter, code int, response []byte) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
 	w.Write(response)
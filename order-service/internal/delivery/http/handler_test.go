@@ -2,32 +2,38 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/order-service/internal/usecase"
 	"github.com/user/go-microservices/order-service/internal/usecase/mocks"
 	"github.com/user/go-microservices/pkg/logger"
+	"github.com/user/go-microservices/pkg/valueobject"
 )
 
 func TestOrderHandler(t *testing.T) {
 	logger.Init()
 	mockUC := mocks.NewOrderUsecase(t)
+	mockIdempotency := mocks.NewIdempotencyUsecase(t)
 	router := mux.NewRouter()
-	NewOrderHandler(router, mockUC)
+	NewOrderHandler(router, mockUC, mockIdempotency)
 
 	t.Run("CreateOrder_Success", func(t *testing.T) {
-		reqBody := CreateOrderRequest{UserID: 1, ProductID: 1, Quantity: 2}
+		reqBody := CreateOrderRequest{UserID: 1, Items: []CreateOrderItemRequest{{ProductID: 1, Quantity: 2}}}
 		body, _ := json.Marshal(reqBody)
 		req, _ := http.NewRequest("POST", "/orders", bytes.NewBuffer(body))
 		rr := httptest.NewRecorder()
 
-		mockUC.On("CreateOrder", mock.Anything, int64(1), int64(1), 2).Return(&domain.Order{ID: 1}, nil)
+		mockUC.On("CreateOrder", mock.Anything, usecase.CreateOrderInput{UserID: 1, Items: []usecase.OrderItemInput{{ProductID: 1, Quantity: 2}}}).Return(&domain.Order{ID: 1}, nil)
 
 		router.ServeHTTP(rr, req)
 
@@ -37,6 +43,123 @@ func TestOrderHandler(t *testing.T) {
 		assert.Equal(t, int64(1), res.ID)
 	})
 
+	t.Run("CreateOrder_CouponLimitReached", func(t *testing.T) {
+		reqBody := CreateOrderRequest{UserID: 2, Items: []CreateOrderItemRequest{{ProductID: 1, Quantity: 1}}, CouponCode: "SPRING10"}
+		body, _ := json.Marshal(reqBody)
+		req, _ := http.NewRequest("POST", "/orders", bytes.NewBuffer(body))
+		rr := httptest.NewRecorder()
+
+		mockUC.On("CreateOrder", mock.Anything, usecase.CreateOrderInput{UserID: 2, Items: []usecase.OrderItemInput{{ProductID: 1, Quantity: 1}}, CouponCode: "SPRING10"}).Return(nil, domain.ErrCouponLimitReached)
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+
+	t.Run("CreateOrder_QuoteExpired", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/orders", bytes.NewBufferString(`{"user_id":3,"quote_id":4}`))
+		rr := httptest.NewRecorder()
+
+		mockUC.On("CreateOrder", mock.Anything, mock.MatchedBy(func(in usecase.CreateOrderInput) bool {
+			return in.UserID == 3 && in.QuoteID == 4 && len(in.Items) == 0
+		})).Return(nil, domain.ErrQuoteExpired)
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+
+	t.Run("CreateOrder_RejectedByRule", func(t *testing.T) {
+		reqBody := CreateOrderRequest{UserID: 4, Items: []CreateOrderItemRequest{{ProductID: 1, Quantity: 50}}}
+		body, _ := json.Marshal(reqBody)
+		req, _ := http.NewRequest("POST", "/orders", bytes.NewBuffer(body))
+		rr := httptest.NewRecorder()
+
+		mockUC.On("CreateOrder", mock.Anything, usecase.CreateOrderInput{UserID: 4, Items: []usecase.OrderItemInput{{ProductID: 1, Quantity: 50}}}).
+			Return(nil, &domain.RuleViolation{Rule: domain.RuleMaxQuantity, Detail: "50 units exceed the limit of 10"})
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		var res map[string]string
+		json.Unmarshal(rr.Body.Bytes(), &res)
+		assert.Equal(t, domain.RuleMaxQuantity, res["code"])
+	})
+
+	t.Run("CreateOrder_NoItems", func(t *testing.T) {
+		body, _ := json.Marshal(CreateOrderRequest{UserID: 1})
+		req, _ := http.NewRequest("POST", "/orders", bytes.NewBuffer(body))
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("CreateOrder_IdempotencyKey_FirstRequest", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/orders", bytes.NewBufferString(`{"user_id":5,"items":[{"product_id":1,"quantity":1}]}`))
+		req.Header.Set(IdempotencyKeyHeader, "key-1")
+		rr := httptest.NewRecorder()
+
+		// The order records the key and request, so a retry after a lost
+		// response finds it
+		mockUC.On("CreateOrder", mock.Anything, mock.MatchedBy(func(in usecase.CreateOrderInput) bool {
+			return in.UserID == 5 && len(in.IdempotencyKey) == 64
+		})).Return(&domain.Order{ID: 5}, nil)
+		mockIdempotency.On("Execute", mock.Anything, "key-1", mock.Anything, mock.Anything).Return(
+			func(ctx context.Context, key, hash string, fn usecase.IdempotentFunc) (*domain.IdempotencyRecord, bool, error) {
+				code, body := fn(ctx)
+				return domain.NewIdempotencyRecord(key, hash, code, body, time.Now(), time.Hour), false, nil
+			}).Once()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Empty(t, rr.Header().Get(IdempotentReplayedHeader))
+		var res domain.Order
+		json.Unmarshal(rr.Body.Bytes(), &res)
+		assert.Equal(t, int64(5), res.ID)
+	})
+
+	t.Run("CreateOrder_IdempotencyKey_Replayed", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/orders", bytes.NewBufferString(`{"items":[{"quantity":1,"product_id":1}],"user_id":5}`))
+		req.Header.Set(IdempotencyKeyHeader, "key-1")
+		rr := httptest.NewRecorder()
+
+		// The same request hashes the same whatever its field order
+		mockIdempotency.On("Execute", mock.Anything, "key-1", hashCreateOrder(context.Background(), CreateOrderRequest{
+			UserID: 5, Items: []CreateOrderItemRequest{{ProductID: 1, Quantity: 1}},
+		}), mock.Anything).Return(&domain.IdempotencyRecord{StatusCode: http.StatusCreated, Body: []byte(`{"id":5}`)}, true, nil).Once()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Equal(t, "true", rr.Header().Get(IdempotentReplayedHeader))
+		assert.JSONEq(t, `{"id":5}`, rr.Body.String())
+	})
+
+	t.Run("CreateOrder_IdempotencyKey_Reused", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/orders", bytes.NewBufferString(`{"user_id":6,"items":[{"product_id":1,"quantity":1}]}`))
+		req.Header.Set(IdempotencyKeyHeader, "key-1")
+		rr := httptest.NewRecorder()
+
+		mockIdempotency.On("Execute", mock.Anything, "key-1", mock.Anything, mock.Anything).Return(nil, false, domain.ErrIdempotencyKeyReused).Once()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	})
+
+	t.Run("CreateOrder_IdempotencyKey_TooLong", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/orders", bytes.NewBufferString(`{"user_id":6,"items":[{"product_id":1,"quantity":1}]}`))
+		req.Header.Set(IdempotencyKeyHeader, strings.Repeat("k", 256))
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
 	t.Run("GetOrder_Success", func(t *testing.T) {
 		req, _ := http.NewRequest("GET", "/orders/1", nil)
 		rr := httptest.NewRecorder()
@@ -50,4 +173,214 @@ func TestOrderHandler(t *testing.T) {
 		json.Unmarshal(rr.Body.Bytes(), &res)
 		assert.Equal(t, int64(1), res.ID)
 	})
+
+	t.Run("GetOrder_IgnoresActor", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/orders/3", nil)
+		req.Header.Set(ActorHeader, "user-1")
+		rr := httptest.NewRecorder()
+
+		mockUC.On("GetOrder", mock.MatchedBy(func(ctx context.Context) bool {
+			return domain.ActorFromContext(ctx) == domain.SystemActor
+		}), int64(3)).Return(&domain.Order{ID: 3}, nil)
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("AmendOrder_Success", func(t *testing.T) {
+		body, _ := json.Marshal(AmendOrderRequest{Items: []CreateOrderItemRequest{{ProductID: 1, Quantity: 4}}})
+		req, _ := http.NewRequest("PATCH", "/orders/1", bytes.NewBuffer(body))
+		rr := httptest.NewRecorder()
+
+		mockUC.On("AmendOrder", mock.Anything, int64(1), []usecase.OrderItemInput{{ProductID: 1, Quantity: 4}}).
+			Return(&domain.Order{ID: 1, TotalPrice: valueobject.NewMoney(400)}, nil)
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("AmendOrder_Paid", func(t *testing.T) {
+		body, _ := json.Marshal(AmendOrderRequest{Items: []CreateOrderItemRequest{{ProductID: 1, Quantity: 4}}})
+		req, _ := http.NewRequest("PATCH", "/orders/2", bytes.NewBuffer(body))
+		rr := httptest.NewRecorder()
+
+		mockUC.On("AmendOrder", mock.Anything, int64(2), []usecase.OrderItemInput{{ProductID: 1, Quantity: 4}}).
+			Return(nil, domain.ErrInvalidTransition)
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+
+	t.Run("PayOrder_Success", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/orders/1/pay", bytes.NewBufferString(`{"payment_token":"tok_visa"}`))
+		rr := httptest.NewRecorder()
+
+		mockUC.On("PayOrder", mock.Anything, int64(1), "tok_visa").Return(&domain.Order{ID: 1, PaymentStatus: domain.PaymentPaid}, nil)
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var res domain.Order
+		json.Unmarshal(rr.Body.Bytes(), &res)
+		assert.Equal(t, domain.PaymentPaid, res.PaymentStatus)
+	})
+
+	t.Run("CompleteOrder_Success", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/orders/1/complete", nil)
+		rr := httptest.NewRecorder()
+
+		mockUC.On("CompleteOrder", mock.Anything, int64(1)).Return(&domain.Order{ID: 1, OrderStatus: domain.OrderCompleted}, nil)
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var res domain.Order
+		json.Unmarshal(rr.Body.Bytes(), &res)
+		assert.Equal(t, domain.OrderCompleted, res.OrderStatus)
+	})
+
+	t.Run("CancelOrder_Success", func(t *testing.T) {
+		body, _ := json.Marshal(CancelOrderRequest{Reason: "out of budget"})
+		req, _ := http.NewRequest("POST", "/orders/1/cancel", bytes.NewBuffer(body))
+		req.Header.Set(ActorHeader, "user-1")
+		rr := httptest.NewRecorder()
+
+		mockUC.On("CancelOrder", mock.MatchedBy(func(ctx context.Context) bool {
+			return domain.ActorFromContext(ctx) == "unverified:user-1"
+		}), int64(1), "out of budget").Return(&domain.Order{ID: 1, OrderStatus: domain.OrderCancelled}, nil)
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var res domain.Order
+		json.Unmarshal(rr.Body.Bytes(), &res)
+		assert.Equal(t, domain.OrderCancelled, res.OrderStatus)
+	})
+
+	t.Run("GetOrderTransitions_Success", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/orders/2/transitions", nil)
+		rr := httptest.NewRecorder()
+
+		mockUC.On("GetOrder", mock.Anything, int64(2)).Return(&domain.Order{
+			ID: 2, OrderStatus: domain.OrderPending, PaymentStatus: domain.PaymentPaid,
+		}, nil)
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var res TransitionsResponse
+		json.Unmarshal(rr.Body.Bytes(), &res)
+		assert.Equal(t, []domain.OrderAction{domain.ActionShip, domain.ActionCancel, domain.ActionPartialRefund}, res.Actions)
+	})
+
+	t.Run("PayOrder_InvalidTransition", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/orders/3/pay", bytes.NewBuffer(nil))
+		rr := httptest.NewRecorder()
+
+		mockUC.On("PayOrder", mock.Anything, int64(3), "").Return(nil, domain.ErrInvalidTransition)
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+
+	t.Run("PayOrder_Declined", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/orders/4/pay", bytes.NewBufferString(`{"payment_token":"tok_decline"}`))
+		rr := httptest.NewRecorder()
+
+		mockUC.On("PayOrder", mock.Anything, int64(4), "tok_decline").Return(nil, domain.ErrPaymentDeclined)
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	})
+
+	t.Run("GetOrderHistory_Success", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/orders/1/history", nil)
+		rr := httptest.NewRecorder()
+
+		mockUC.On("GetOrderHistory", mock.Anything, int64(1)).Return([]*domain.StatusChange{
+			{ID: 1, OrderID: 1, ToOrderStatus: domain.OrderPending, Actor: domain.SystemActor},
+			{ID: 2, OrderID: 1, FromOrderStatus: domain.OrderPending, ToOrderStatus: domain.OrderCancelled, Actor: "user-1"},
+		}, nil)
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var res []domain.StatusChange
+		json.Unmarshal(rr.Body.Bytes(), &res)
+		assert.Len(t, res, 2)
+		assert.Equal(t, "user-1", res[1].Actor)
+	})
+
+	t.Run("RefundOrder_Success", func(t *testing.T) {
+		body, _ := json.Marshal(RefundRequest{Amount: valueobject.NewMoney(25), Reason: "late delivery"})
+		req, _ := http.NewRequest("POST", "/orders/1/refunds", bytes.NewBuffer(body))
+		rr := httptest.NewRecorder()
+
+		mockUC.On("RefundOrder", mock.Anything, int64(1), valueobject.NewMoney(25), "late delivery").
+			Return(&domain.Refund{ID: 5, OrderID: 1, Amount: valueobject.NewMoney(25)}, nil)
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		var res domain.Refund
+		json.Unmarshal(rr.Body.Bytes(), &res)
+		assert.Equal(t, int64(5), res.ID)
+	})
+
+	t.Run("RefundOrder_ExceedsTotal", func(t *testing.T) {
+		body, _ := json.Marshal(RefundRequest{Amount: valueobject.NewMoney(9999)})
+		req, _ := http.NewRequest("POST", "/orders/2/refunds", bytes.NewBuffer(body))
+		rr := httptest.NewRecorder()
+
+		mockUC.On("RefundOrder", mock.Anything, int64(2), valueobject.NewMoney(9999), "").Return(nil, domain.ErrRefundExceedsTotal)
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("ShipOrder_Success", func(t *testing.T) {
+		body, _ := json.Marshal(ShipOrderRequest{Carrier: "UPS", TrackingNumber: "1Z999"})
+		req, _ := http.NewRequest("POST", "/orders/1/shipments", bytes.NewBuffer(body))
+		rr := httptest.NewRecorder()
+
+		mockUC.On("ShipOrder", mock.Anything, int64(1), "UPS", "1Z999", time.Time{}).
+			Return(&domain.Shipment{ID: 7, OrderID: 1, Carrier: "UPS", TrackingNumber: "1Z999"}, nil)
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		var res domain.Shipment
+		json.Unmarshal(rr.Body.Bytes(), &res)
+		assert.Equal(t, int64(7), res.ID)
+	})
+
+	t.Run("DeliverShipment_Success", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/orders/1/shipments/7/delivered", bytes.NewBuffer(nil))
+		rr := httptest.NewRecorder()
+
+		mockUC.On("DeliverShipment", mock.Anything, int64(1), int64(7), time.Time{}).
+			Return(&domain.Order{ID: 1, OrderStatus: domain.OrderCompleted}, nil)
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var res domain.Order
+		json.Unmarshal(rr.Body.Bytes(), &res)
+		assert.Equal(t, domain.OrderCompleted, res.OrderStatus)
+	})
+
+	t.Run("DeliverShipment_InvalidShipmentID", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/orders/1/shipments/abc/delivered", nil)
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
 }
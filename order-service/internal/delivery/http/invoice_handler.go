@@ -0,0 +1,123 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/order-service/internal/usecase"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+	"github.com/user/go-microservices/pkg/valueobject"
+	"go.uber.org/zap"
+)
+
+type InvoiceHandler struct {
+	InvoiceUsecase usecase.InvoiceUsecase
+}
+
+func NewInvoiceHandler(r *mux.Router, us usecase.InvoiceUsecase) {
+	handler := &InvoiceHandler{
+		InvoiceUsecase: us,
+	}
+
+	r.HandleFunc("/orders/{id}/invoice", handler.GetInvoice).Methods("GET")
+}
+
+var invoiceTemplate = template.Must(template.New("invoice").Funcs(template.FuncMap{
+	"money": func(m valueobject.Money) string { return fmt.Sprintf("%.2f", m.Amount()) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Invoice {{.Number}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { padding: 4px 8px; border-bottom: 1px solid #ddd; text-align: left; }
+td.num, th.num { text-align: right; }
+</style>
+</head>
+<body>
+<h1>Invoice {{.Number}}</h1>
+<p>Issued {{.IssuedAt.Format "2006-01-02"}}<br>
+Order {{.OrderID}} &middot; Customer {{.UserID}}{{if .Region}} &middot; {{.Region}}{{end}}</p>
+<table>
+<tr><th>Item</th><th class="num">Qty</th><th class="num">Unit price</th><th class="num">Tax %</th><th class="num">Amount</th></tr>
+{{range .Lines}}<tr><td>{{.Description}}</td><td class="num">{{.Quantity}}</td><td class="num">{{money .UnitPrice}}</td><td class="num">{{.TaxRate}}</td><td class="num">{{money .LineTotal}}</td></tr>
+{{end}}<tr><td colspan="4" class="num">Subtotal</td><td class="num">{{money .Subtotal}}</td></tr>
+{{if .DiscountCode}}<tr><td colspan="4" class="num">Discount ({{.DiscountCode}})</td><td class="num">-{{money .Discount}}</td></tr>
+{{end}}<tr><td colspan="4" class="num">Tax</td><td class="num">{{money .Tax}}</td></tr>
+<tr><td colspan="4" class="num">Shipping</td><td class="num">{{money .Shipping}}</td></tr>
+<tr><th colspan="4" class="num">Total</th><th class="num">{{money .Total}}</th></tr>
+</table>
+{{if .PaymentReference}}<p>Paid, reference {{.PaymentReference}}</p>{{end}}
+</body>
+</html>
+`))
+
+// GetInvoice godoc
+// @Summary Get an order's invoice
+// @Description Get the invoice issued when the order was paid. Responds with a rendered HTML document when the Accept header prefers text/html or format=html is given.
+// @Tags orders
+// @Produce  json
+// @Produce  html
+// @Param id path int true "Order ID"
+// @Param format query string false "json or html"
+// @Success 200 {object} domain.Invoice
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /orders/{id}/invoice [get]
+func (h *InvoiceHandler) GetInvoice(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	var invoice *domain.Invoice
+	invoice, err = h.InvoiceUsecase.GetInvoice(r.Context(), id)
+	if err != nil {
+		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
+		return
+	}
+
+	if wantsHTML(r) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := invoiceTemplate.Execute(w, invoice); err != nil {
+			logger.FromContext(r.Context()).Error("failed to render invoice", zap.Error(err))
+		}
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, invoice)
+}
+
+// wantsHTML reports whether the caller asked for a rendered document
+func wantsHTML(r *http.Request) bool {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format == "html"
+	}
+	return strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+func (h *InvoiceHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	h.respondWithJSON(w, code, map[string]string{"error": message})
+}
+
+func (h *InvoiceHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	response, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(response)
+
+	logger.Info("request handled",
+		zap.Int("status", code),
+		zap.String("response", string(response)),
+	)
+}
@@ -0,0 +1,70 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/order-service/internal/usecase/mocks"
+	"github.com/user/go-microservices/pkg/logger"
+	"github.com/user/go-microservices/pkg/valueobject"
+)
+
+func TestInvoiceHandler(t *testing.T) {
+	logger.Init()
+	mockUC := mocks.NewInvoiceUsecase(t)
+	router := mux.NewRouter()
+	NewInvoiceHandler(router, mockUC)
+
+	invoice := &domain.Invoice{
+		Number:  "INV-2026-000123",
+		OrderID: 1,
+		Lines: []domain.InvoiceLine{
+			{ProductID: 1, Description: "Widget <Pro>", Quantity: 2, UnitPrice: valueobject.NewMoney(100), LineTotal: valueobject.NewMoney(200)},
+		},
+		Total:    valueobject.NewMoney(200),
+		IssuedAt: time.Date(2026, 5, 4, 0, 0, 0, 0, time.UTC),
+	}
+	mockUC.On("GetInvoice", mock.Anything, int64(1)).Return(invoice, nil)
+
+	t.Run("GetInvoice_JSON", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/orders/1/invoice", nil)
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+		assert.Contains(t, rr.Body.String(), `"number":"INV-2026-000123"`)
+	})
+
+	t.Run("GetInvoice_HTML", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/orders/1/invoice", nil)
+		req.Header.Set("Accept", "text/html,application/xhtml+xml")
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Header().Get("Content-Type"), "text/html")
+		assert.Contains(t, rr.Body.String(), "Invoice INV-2026-000123")
+		assert.Contains(t, rr.Body.String(), "Widget &lt;Pro&gt;")
+		assert.Contains(t, rr.Body.String(), "200.00")
+	})
+
+	t.Run("GetInvoice_NotPaid", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/orders/2/invoice?format=html", nil)
+		rr := httptest.NewRecorder()
+
+		mockUC.On("GetInvoice", mock.Anything, int64(2)).Return(nil, domain.ErrOrderNotInvoiced)
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
@@ -0,0 +1,113 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/order-service/internal/usecase"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+	"go.uber.org/zap"
+)
+
+type QuoteHandler struct {
+	QuoteUsecase usecase.QuoteUsecase
+}
+
+func NewQuoteHandler(r *mux.Router, us usecase.QuoteUsecase) {
+	handler := &QuoteHandler{
+		QuoteUsecase: us,
+	}
+
+	r.HandleFunc("/quotes", handler.CreateQuote).Methods("POST")
+	r.HandleFunc("/quotes/{id}", handler.GetQuote).Methods("GET")
+}
+
+type CreateQuoteRequest struct {
+	UserID int64                    `json:"user_id"`
+	Items  []CreateOrderItemRequest `json:"items"`
+	// Region is the destination, e.g. "US-CA"; it selects tax and shipping rates
+	Region string `json:"region"`
+}
+
+// CreateQuote godoc
+// @Summary Create a price quote
+// @Description Price items at current catalog prices, with tax and shipping, and lock those prices until the quote expires. Pass the quote ID as quote_id when creating the order.
+// @Tags quotes
+// @Accept  json
+// @Produce  json
+// @Param quote body CreateQuoteRequest true "Quote request"
+// @Success 201 {object} domain.Quote
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /quotes [post]
+func (h *QuoteHandler) CreateQuote(w http.ResponseWriter, r *http.Request) {
+	var req CreateQuoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	if len(req.Items) == 0 {
+		h.respondWithError(w, http.StatusBadRequest, "Quote must contain at least one item")
+		return
+	}
+	items, ok := toItemInputs(req.Items)
+	if !ok {
+		h.respondWithError(w, http.StatusBadRequest, "Quantity must be greater than 0")
+		return
+	}
+
+	quote, err := h.QuoteUsecase.CreateQuote(r.Context(), req.UserID, items, req.Region)
+	if err != nil {
+		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
+		return
+	}
+	h.respondWithJSON(w, http.StatusCreated, quote)
+}
+
+// GetQuote godoc
+// @Summary Get a quote
+// @Description Get a quote with its locked prices and expiry
+// @Tags quotes
+// @Produce  json
+// @Param id path int true "Quote ID"
+// @Success 200 {object} domain.Quote
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /quotes/{id} [get]
+func (h *QuoteHandler) GetQuote(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid quote ID")
+		return
+	}
+
+	var quote *domain.Quote
+	quote, err = h.QuoteUsecase.GetQuote(r.Context(), id)
+	if err != nil {
+		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, quote)
+}
+
+func (h *QuoteHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	h.respondWithJSON(w, code, map[string]string{"error": message})
+}
+
+func (h *QuoteHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	response, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(response)
+
+	logger.Info("request handled",
+		zap.Int("status", code),
+		zap.String("response", string(response)),
+	)
+}
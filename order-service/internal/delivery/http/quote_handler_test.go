@@ -0,0 +1,70 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/order-service/internal/usecase"
+	"github.com/user/go-microservices/order-service/internal/usecase/mocks"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+)
+
+func TestQuoteHandler(t *testing.T) {
+	logger.Init()
+	mockUC := mocks.NewQuoteUsecase(t)
+	router := mux.NewRouter()
+	NewQuoteHandler(router, mockUC)
+
+	t.Run("CreateQuote_Success", func(t *testing.T) {
+		body := []byte(`{"user_id":1,"items":[{"product_id":1,"quantity":2}],"region":"US-CA"}`)
+		req, _ := http.NewRequest("POST", "/quotes", bytes.NewBuffer(body))
+		rr := httptest.NewRecorder()
+
+		mockUC.On("CreateQuote", mock.Anything, int64(1), []usecase.OrderItemInput{{ProductID: 1, Quantity: 2}}, "US-CA").
+			Return(&domain.Quote{ID: 3, UserID: 1}, nil)
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		var res domain.Quote
+		json.Unmarshal(rr.Body.Bytes(), &res)
+		assert.Equal(t, int64(3), res.ID)
+	})
+
+	t.Run("CreateQuote_NoItems", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/quotes", bytes.NewBufferString(`{"user_id":1,"items":[]}`))
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("CreateQuote_InvalidQuantity", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/quotes", bytes.NewBufferString(`{"user_id":1,"items":[{"product_id":1,"quantity":0}]}`))
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("GetQuote_NotFound", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/quotes/99", nil)
+		rr := httptest.NewRecorder()
+
+		mockUC.On("GetQuote", mock.Anything, int64(99)).Return(nil, pkgerrors.ErrNotFound)
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
@@ -0,0 +1,231 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/order-service/internal/usecase"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+	"go.uber.org/zap"
+)
+
+type ReturnHandler struct {
+	ReturnUsecase usecase.ReturnUsecase
+}
+
+func NewReturnHandler(r *mux.Router, us usecase.ReturnUsecase) {
+	handler := &ReturnHandler{
+		ReturnUsecase: us,
+	}
+
+	r.HandleFunc("/orders/{id}/returns", withActor(handler.RequestReturn)).Methods("POST")
+	r.HandleFunc("/orders/{id}/returns", handler.GetReturns).Methods("GET")
+	r.HandleFunc("/orders/{id}/returns/{returnId}/approve", withActor(handler.ApproveReturn)).Methods("POST")
+	r.HandleFunc("/orders/{id}/returns/{returnId}/reject", withActor(handler.RejectReturn)).Methods("POST")
+	r.HandleFunc("/orders/{id}/returns/{returnId}/receive", withActor(handler.ReceiveReturn)).Methods("POST")
+}
+
+type ReturnItemRequest struct {
+	ProductID int64 `json:"product_id"`
+	Quantity  int   `json:"quantity"`
+}
+
+type ReturnRequest struct {
+	Items  []ReturnItemRequest `json:"items"`
+	Reason string              `json:"reason"`
+}
+
+// RequestReturn godoc
+// @Summary Request a return
+// @Description Request a return of some or all units of a completed order. The refund due is derived from the order's unit prices.
+// @Tags returns
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Order ID"
+// @Param request body ReturnRequest true "Return request"
+// @Success 201 {object} domain.Return
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /orders/{id}/returns [post]
+func (h *ReturnHandler) RequestReturn(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	var req ReturnRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	items := make([]domain.ReturnItem, 0, len(req.Items))
+	for _, item := range req.Items {
+		items = append(items, domain.ReturnItem{ProductID: item.ProductID, Quantity: item.Quantity})
+	}
+
+	ret, err := h.ReturnUsecase.RequestReturn(r.Context(), id, items, req.Reason)
+	if err != nil {
+		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
+		return
+	}
+	h.respondWithJSON(w, http.StatusCreated, ret)
+}
+
+// GetReturns godoc
+// @Summary List returns of an order
+// @Description List every return requested against an order, oldest first
+// @Tags returns
+// @Produce  json
+// @Param id path int true "Order ID"
+// @Success 200 {array} domain.Return
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /orders/{id}/returns [get]
+func (h *ReturnHandler) GetReturns(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	returns, err := h.ReturnUsecase.GetReturns(r.Context(), id)
+	if err != nil {
+		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, returns)
+}
+
+// ApproveReturn godoc
+// @Summary Approve a return
+// @Description Authorize the customer to send the goods back
+// @Tags returns
+// @Produce  json
+// @Param id path int true "Order ID"
+// @Param returnId path int true "Return ID"
+// @Success 200 {object} domain.Return
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /orders/{id}/returns/{returnId}/approve [post]
+func (h *ReturnHandler) ApproveReturn(w http.ResponseWriter, r *http.Request) {
+	id, returnID, ok := h.parseIDs(w, r)
+	if !ok {
+		return
+	}
+
+	ret, err := h.ReturnUsecase.ApproveReturn(r.Context(), id, returnID)
+	if err != nil {
+		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, ret)
+}
+
+type RejectReturnRequest struct {
+	Note string `json:"note"`
+}
+
+// RejectReturn godoc
+// @Summary Reject a return
+// @Description Decline a requested return
+// @Tags returns
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Order ID"
+// @Param returnId path int true "Return ID"
+// @Param request body RejectReturnRequest false "Rejection note"
+// @Success 200 {object} domain.Return
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /orders/{id}/returns/{returnId}/reject [post]
+func (h *ReturnHandler) RejectReturn(w http.ResponseWriter, r *http.Request) {
+	id, returnID, ok := h.parseIDs(w, r)
+	if !ok {
+		return
+	}
+
+	var req RejectReturnRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	ret, err := h.ReturnUsecase.RejectReturn(r.Context(), id, returnID, req.Note)
+	if err != nil {
+		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, ret)
+}
+
+// ReceiveReturn godoc
+// @Summary Receive a return
+// @Description Record that the returned goods arrived, put them back into stock and refund their value
+// @Tags returns
+// @Produce  json
+// @Param id path int true "Order ID"
+// @Param returnId path int true "Return ID"
+// @Success 200 {object} domain.Return
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /orders/{id}/returns/{returnId}/receive [post]
+func (h *ReturnHandler) ReceiveReturn(w http.ResponseWriter, r *http.Request) {
+	id, returnID, ok := h.parseIDs(w, r)
+	if !ok {
+		return
+	}
+
+	ret, err := h.ReturnUsecase.ReceiveReturn(r.Context(), id, returnID)
+	if err != nil {
+		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, ret)
+}
+
+// parseIDs reads the order and return IDs from the path, responding with a
+// 400 when either is malformed
+func (h *ReturnHandler) parseIDs(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid order ID")
+		return 0, 0, false
+	}
+	returnID, err := strconv.ParseInt(vars["returnId"], 10, 64)
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid return ID")
+		return 0, 0, false
+	}
+	return id, returnID, true
+}
+
+func (h *ReturnHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	h.respondWithJSON(w, code, map[string]string{"error": message})
+}
+
+func (h *ReturnHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	response, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(response)
+
+	logger.Info("request handled",
+		zap.Int("status", code),
+		zap.String("response", string(response)),
+	)
+}
@@ -0,0 +1,72 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/order-service/internal/usecase/mocks"
+	"github.com/user/go-microservices/pkg/logger"
+)
+
+func TestReturnHandler(t *testing.T) {
+	logger.Init()
+	mockUC := mocks.NewReturnUsecase(t)
+	router := mux.NewRouter()
+	NewReturnHandler(router, mockUC)
+
+	t.Run("RequestReturn_Success", func(t *testing.T) {
+		body, _ := json.Marshal(ReturnRequest{Items: []ReturnItemRequest{{ProductID: 1, Quantity: 1}}, Reason: "damaged"})
+		req, _ := http.NewRequest("POST", "/orders/1/returns", bytes.NewBuffer(body))
+		rr := httptest.NewRecorder()
+
+		mockUC.On("RequestReturn", mock.Anything, int64(1), []domain.ReturnItem{{ProductID: 1, Quantity: 1}}, "damaged").
+			Return(&domain.Return{ID: 3, OrderID: 1, Status: domain.ReturnRequested}, nil)
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		var res domain.Return
+		json.Unmarshal(rr.Body.Bytes(), &res)
+		assert.Equal(t, int64(3), res.ID)
+	})
+
+	t.Run("RejectReturn_Success", func(t *testing.T) {
+		body, _ := json.Marshal(RejectReturnRequest{Note: "outside return window"})
+		req, _ := http.NewRequest("POST", "/orders/1/returns/3/reject", bytes.NewBuffer(body))
+		rr := httptest.NewRecorder()
+
+		mockUC.On("RejectReturn", mock.Anything, int64(1), int64(3), "outside return window").
+			Return(&domain.Return{ID: 3, OrderID: 1, Status: domain.ReturnRejected}, nil)
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("ReceiveReturn_NotApproved", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/orders/1/returns/4/receive", nil)
+		rr := httptest.NewRecorder()
+
+		mockUC.On("ReceiveReturn", mock.Anything, int64(1), int64(4)).Return(nil, domain.ErrInvalidReturnTransition)
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+
+	t.Run("ApproveReturn_InvalidReturnID", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/orders/1/returns/abc/approve", nil)
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
@@ -0,0 +1,218 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/order-service/internal/usecase"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+	"go.uber.org/zap"
+)
+
+type SubscriptionHandler struct {
+	SubscriptionUsecase usecase.SubscriptionUsecase
+}
+
+func NewSubscriptionHandler(r *mux.Router, us usecase.SubscriptionUsecase) {
+	handler := &SubscriptionHandler{
+		SubscriptionUsecase: us,
+	}
+
+	r.HandleFunc("/subscriptions", handler.CreateSubscription).Methods("POST")
+	r.HandleFunc("/subscriptions/{id}", handler.GetSubscription).Methods("GET")
+	r.HandleFunc("/subscriptions/{id}/pause", handler.PauseSubscription).Methods("POST")
+	r.HandleFunc("/subscriptions/{id}/resume", handler.ResumeSubscription).Methods("POST")
+	r.HandleFunc("/subscriptions/{id}/skip", handler.SkipSubscription).Methods("POST")
+	r.HandleFunc("/subscriptions/{id}/cancel", handler.CancelSubscription).Methods("POST")
+	r.HandleFunc("/subscriptions/{id}/orders", handler.GetSubscriptionOrders).Methods("GET")
+}
+
+type CreateSubscriptionRequest struct {
+	UserID    int64 `json:"user_id"`
+	ProductID int64 `json:"product_id"`
+	Quantity  int   `json:"quantity"`
+	// Region is the destination, e.g. "US-CA"; it selects tax and shipping rates
+	Region       string `json:"region"`
+	IntervalDays int    `json:"interval_days"`
+	// FirstRunAt is when the first order is placed; omit to order right away
+	FirstRunAt time.Time `json:"first_run_at,omitempty"`
+}
+
+// CreateSubscription godoc
+// @Summary Subscribe to a product
+// @Description Place an order for the product every interval_days days, starting at first_run_at. Orders that cannot be placed, for example because the product is out of stock, are retried with backoff and the delivery is skipped once retries run out.
+// @Tags subscriptions
+// @Accept  json
+// @Produce  json
+// @Param subscription body CreateSubscriptionRequest true "Product, quantity and schedule"
+// @Success 201 {object} domain.Subscription
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /subscriptions [post]
+func (h *SubscriptionHandler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
+	var req CreateSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	sub, err := h.SubscriptionUsecase.CreateSubscription(r.Context(), usecase.CreateSubscriptionInput{
+		UserID:       req.UserID,
+		ProductID:    req.ProductID,
+		Quantity:     req.Quantity,
+		Region:       req.Region,
+		IntervalDays: req.IntervalDays,
+		FirstRunAt:   req.FirstRunAt,
+	})
+	if err != nil {
+		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
+		return
+	}
+	h.respondWithJSON(w, http.StatusCreated, sub)
+}
+
+// GetSubscription godoc
+// @Summary Get a subscription
+// @Description Get a subscription with its schedule and any pending retry
+// @Tags subscriptions
+// @Produce  json
+// @Param id path int true "Subscription ID"
+// @Success 200 {object} domain.Subscription
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /subscriptions/{id} [get]
+func (h *SubscriptionHandler) GetSubscription(w http.ResponseWriter, r *http.Request) {
+	h.handle(w, r, h.SubscriptionUsecase.GetSubscription)
+}
+
+// PauseSubscription godoc
+// @Summary Pause a subscription
+// @Description Stop placing orders until the subscription is resumed
+// @Tags subscriptions
+// @Produce  json
+// @Param id path int true "Subscription ID"
+// @Success 200 {object} domain.Subscription
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /subscriptions/{id}/pause [post]
+func (h *SubscriptionHandler) PauseSubscription(w http.ResponseWriter, r *http.Request) {
+	h.handle(w, r, h.SubscriptionUsecase.PauseSubscription)
+}
+
+// ResumeSubscription godoc
+// @Summary Resume a subscription
+// @Description Reactivate a paused subscription. Deliveries missed while paused are not ordered.
+// @Tags subscriptions
+// @Produce  json
+// @Param id path int true "Subscription ID"
+// @Success 200 {object} domain.Subscription
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /subscriptions/{id}/resume [post]
+func (h *SubscriptionHandler) ResumeSubscription(w http.ResponseWriter, r *http.Request) {
+	h.handle(w, r, h.SubscriptionUsecase.ResumeSubscription)
+}
+
+// SkipSubscription godoc
+// @Summary Skip the next delivery
+// @Description Drop the next delivery without placing an order: a pending retry if there is one, otherwise the next scheduled run
+// @Tags subscriptions
+// @Produce  json
+// @Param id path int true "Subscription ID"
+// @Success 200 {object} domain.Subscription
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /subscriptions/{id}/skip [post]
+func (h *SubscriptionHandler) SkipSubscription(w http.ResponseWriter, r *http.Request) {
+	h.handle(w, r, h.SubscriptionUsecase.SkipSubscription)
+}
+
+// CancelSubscription godoc
+// @Summary Cancel a subscription
+// @Description Stop the subscription for good. Orders already placed are not affected.
+// @Tags subscriptions
+// @Produce  json
+// @Param id path int true "Subscription ID"
+// @Success 200 {object} domain.Subscription
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /subscriptions/{id}/cancel [post]
+func (h *SubscriptionHandler) CancelSubscription(w http.ResponseWriter, r *http.Request) {
+	h.handle(w, r, h.SubscriptionUsecase.CancelSubscription)
+}
+
+// GetSubscriptionOrders godoc
+// @Summary List a subscription's orders
+// @Description List the orders placed by a subscription, oldest first
+// @Tags subscriptions
+// @Produce  json
+// @Param id path int true "Subscription ID"
+// @Success 200 {array} domain.SubscriptionOrder
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /subscriptions/{id}/orders [get]
+func (h *SubscriptionHandler) GetSubscriptionOrders(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	orders, err := h.SubscriptionUsecase.GetSubscriptionOrders(r.Context(), id)
+	if err != nil {
+		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, orders)
+}
+
+// handle runs op on the subscription named in the path and responds with the
+// resulting subscription
+func (h *SubscriptionHandler) handle(w http.ResponseWriter, r *http.Request, op func(ctx context.Context, id int64) (*domain.Subscription, error)) {
+	id, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	sub, err := op(r.Context(), id)
+	if err != nil {
+		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, sub)
+}
+
+func (h *SubscriptionHandler) parseID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid subscription ID")
+		return 0, false
+	}
+	return id, true
+}
+
+func (h *SubscriptionHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	h.respondWithJSON(w, code, map[string]string{"error": message})
+}
+
+func (h *SubscriptionHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	response, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(response)
+
+	logger.Info("request handled",
+		zap.Int("status", code),
+		zap.String("response", string(response)),
+	)
+}
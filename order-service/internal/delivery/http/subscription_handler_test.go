@@ -0,0 +1,78 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/order-service/internal/usecase"
+	"github.com/user/go-microservices/order-service/internal/usecase/mocks"
+	"github.com/user/go-microservices/pkg/logger"
+)
+
+func TestSubscriptionHandler(t *testing.T) {
+	logger.Init()
+	mockUC := mocks.NewSubscriptionUsecase(t)
+	router := mux.NewRouter()
+	NewSubscriptionHandler(router, mockUC)
+
+	t.Run("CreateSubscription_Success", func(t *testing.T) {
+		body := `{"user_id":1,"product_id":2,"quantity":3,"region":"US-CA","interval_days":30,"first_run_at":"2024-04-01T09:00:00Z"}`
+		req, _ := http.NewRequest("POST", "/subscriptions", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+
+		mockUC.On("CreateSubscription", mock.Anything, usecase.CreateSubscriptionInput{
+			UserID: 1, ProductID: 2, Quantity: 3, Region: "US-CA", IntervalDays: 30,
+			FirstRunAt: time.Date(2024, 4, 1, 9, 0, 0, 0, time.UTC),
+		}).Return(&domain.Subscription{ID: 7}, nil)
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		var res domain.Subscription
+		json.Unmarshal(rr.Body.Bytes(), &res)
+		assert.Equal(t, int64(7), res.ID)
+	})
+
+	t.Run("PauseSubscription_AlreadyPaused", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/subscriptions/7/pause", bytes.NewBuffer(nil))
+		rr := httptest.NewRecorder()
+
+		mockUC.On("PauseSubscription", mock.Anything, int64(7)).Return(nil, domain.ErrSubscriptionState)
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+
+	t.Run("SkipSubscription_InvalidID", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/subscriptions/abc/skip", bytes.NewBuffer(nil))
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("GetSubscriptionOrders_Success", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/subscriptions/7/orders", nil)
+		rr := httptest.NewRecorder()
+
+		mockUC.On("GetSubscriptionOrders", mock.Anything, int64(7)).
+			Return([]*domain.SubscriptionOrder{{OrderID: 42, OrderStatus: domain.OrderPending}}, nil)
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var res []domain.SubscriptionOrder
+		json.Unmarshal(rr.Body.Bytes(), &res)
+		assert.Equal(t, int64(42), res[0].OrderID)
+	})
+}
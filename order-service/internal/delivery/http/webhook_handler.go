@@ -0,0 +1,152 @@
+package http
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/order-service/internal/usecase"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// SignatureHeader carries the webhook signature as "t=<unix seconds>,v1=<hex>",
+// where v1 is the HMAC-SHA256 of "<t>.<body>" keyed with the shared secret
+const SignatureHeader = "X-Payment-Signature"
+
+// maxWebhookBody bounds how much of a webhook request is read
+const maxWebhookBody = 64 << 10
+
+var errBadSignature = errors.New("invalid webhook signature")
+
+type WebhookHandler struct {
+	OrderUsecase usecase.OrderUsecase
+	secret       []byte
+	tolerance    time.Duration
+	now          func() time.Time
+}
+
+// NewWebhookHandler registers the payment webhook. Events signed more than
+// tolerance ago are rejected; within that window redeliveries are recognised
+// by their stored event ID.
+func NewWebhookHandler(r *mux.Router, us usecase.OrderUsecase, secret string, tolerance time.Duration) {
+	handler := &WebhookHandler{
+		OrderUsecase: us,
+		secret:       []byte(secret),
+		tolerance:    tolerance,
+		now:          time.Now,
+	}
+
+	r.HandleFunc("/webhooks/payments", handler.PaymentEvent).Methods("POST")
+}
+
+// PaymentEvent godoc
+// @Summary Receive a payment notification
+// @Description Apply a signed payment provider event to its order. The X-Payment-Signature header must hold "t=<unix seconds>,v1=<hex HMAC-SHA256 of t.body>". Redelivered events are acknowledged without changing the order.
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Param X-Payment-Signature header string true "Event signature"
+// @Param event body domain.PaymentEvent true "Payment event"
+// @Success 200 {object} domain.Order
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /webhooks/payments [post]
+func (h *WebhookHandler) PaymentEvent(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody))
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if err := h.verify(r.Header.Get(SignatureHeader), body); err != nil {
+		logger.FromContext(r.Context()).Warn("rejected payment webhook", zap.Error(err))
+		h.respondWithError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	var event domain.PaymentEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	order, err := h.OrderUsecase.HandlePaymentEvent(r.Context(), &event)
+	if err != nil {
+		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, order)
+}
+
+// verify checks the signature header against body and rejects signatures
+// older or newer than the tolerance, so a captured request cannot be
+// replayed once its event IDs may have been forgotten
+func (h *WebhookHandler) verify(header string, body []byte) error {
+	var timestamp, signature string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signature = value
+		}
+	}
+	if timestamp == "" || signature == "" {
+		return errBadSignature
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errBadSignature
+	}
+	if age := h.now().Sub(time.Unix(unix, 0)); math.Abs(float64(age)) > float64(h.tolerance) {
+		return fmt.Errorf("webhook signed %s ago is outside the tolerance: %w", age.Round(time.Second), errBadSignature)
+	}
+
+	given, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(given, signPayload(h.secret, timestamp, body)) {
+		return errBadSignature
+	}
+	return nil
+}
+
+func signPayload(secret []byte, timestamp string, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+func (h *WebhookHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	h.respondWithJSON(w, code, map[string]string{"error": message})
+}
+
+func (h *WebhookHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	response, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(response)
+
+	logger.Info("request handled",
+		zap.Int("status", code),
+		zap.String("response", string(response)),
+	)
+}
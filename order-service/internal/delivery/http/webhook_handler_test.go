@@ -0,0 +1,85 @@
+package http
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/order-service/internal/usecase/mocks"
+	"github.com/user/go-microservices/pkg/logger"
+)
+
+func TestWebhookHandler(t *testing.T) {
+	logger.Init()
+	mockUC := mocks.NewOrderUsecase(t)
+	router := mux.NewRouter()
+	NewWebhookHandler(router, mockUC, "whsec_test", 5*time.Minute)
+
+	body := []byte(`{"id":"evt_1","type":"payment.succeeded","order_id":1,"reference":"auth_1","amount":200}`)
+	sign := func(secret string, at time.Time) string {
+		ts := strconv.FormatInt(at.Unix(), 10)
+		return fmt.Sprintf("t=%s,v1=%s", ts, hex.EncodeToString(signPayload([]byte(secret), ts, body)))
+	}
+
+	t.Run("PaymentEvent_Success", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/webhooks/payments", bytes.NewBuffer(body))
+		req.Header.Set(SignatureHeader, sign("whsec_test", time.Now()))
+		rr := httptest.NewRecorder()
+
+		mockUC.On("HandlePaymentEvent", mock.Anything, mock.MatchedBy(func(e *domain.PaymentEvent) bool {
+			return e.ID == "evt_1" && e.Type == domain.PaymentEventSucceeded && e.Reference == "auth_1"
+		})).Return(&domain.Order{ID: 1, PaymentStatus: domain.PaymentPaid}, nil).Once()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("PaymentEvent_WrongSecret", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/webhooks/payments", bytes.NewBuffer(body))
+		req.Header.Set(SignatureHeader, sign("whsec_other", time.Now()))
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("PaymentEvent_Stale", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/webhooks/payments", bytes.NewBuffer(body))
+		req.Header.Set(SignatureHeader, sign("whsec_test", time.Now().Add(-time.Hour)))
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("PaymentEvent_TamperedBody", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/webhooks/payments", bytes.NewBufferString(`{"id":"evt_1","type":"payment.succeeded","order_id":2,"reference":"auth_1","amount":200}`))
+		req.Header.Set(SignatureHeader, sign("whsec_test", time.Now()))
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("PaymentEvent_MissingSignature", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/webhooks/payments", bytes.NewBuffer(body))
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+}
@@ -0,0 +1,56 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/order-service/internal/usecase"
+	"github.com/user/go-microservices/pkg/logger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+)
+
+const backorderLockName = "order-service:backorder-allocator"
+
+// BackorderAllocator periodically reserves restocked inventory for
+// backordered orders, oldest first. Only the replica holding the shared lock
+// allocates a batch, so two replicas never race for the same stock.
+type BackorderAllocator struct {
+	*lockedPeriodicRunner
+
+	orders    usecase.OrderUsecase
+	batchSize int
+
+	allocatedCounter metric.Int64Counter
+}
+
+func NewBackorderAllocator(orders usecase.OrderUsecase, locker domain.Locker, interval time.Duration, batchSize int) *BackorderAllocator {
+	meter := otel.Meter("order-worker")
+	allocated, _ := meter.Int64Counter("backorders_allocated_total",
+		metric.WithDescription("Backordered orders whose stock was allocated"))
+	runs, _ := meter.Int64Counter("backorder_allocation_runs_total",
+		metric.WithDescription("Backorder allocator runs, by outcome"))
+
+	a := &BackorderAllocator{
+		orders:           orders,
+		batchSize:        batchSize,
+		allocatedCounter: allocated,
+	}
+	a.lockedPeriodicRunner = newLockedPeriodicRunner("backorder allocator", interval, locker, backorderLockName, runs, a.allocate)
+	return a
+}
+
+// allocate allocates stock to a single batch of backordered orders
+func (a *BackorderAllocator) allocate(ctx context.Context) (int, error) {
+	ids, err := a.orders.AllocateBackorders(ctx, a.batchSize)
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range ids {
+		logger.FromContext(ctx).Info("backorder allocated", zap.Int64("order_id", id))
+	}
+	a.allocatedCounter.Add(ctx, int64(len(ids)))
+	return len(ids), nil
+}
@@ -0,0 +1,57 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/order-service/internal/usecase"
+	"github.com/user/go-microservices/pkg/logger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+)
+
+const expiryLockName = "order-service:expiry-reaper"
+
+// ExpiryReaper periodically cancels unpaid orders whose reservation has
+// outlived the TTL. Only the replica holding the shared lock reaps a batch.
+type ExpiryReaper struct {
+	*lockedPeriodicRunner
+
+	orders    usecase.OrderUsecase
+	ttl       time.Duration
+	batchSize int
+
+	expiredCounter metric.Int64Counter
+}
+
+func NewExpiryReaper(orders usecase.OrderUsecase, locker domain.Locker, ttl, interval time.Duration, batchSize int) *ExpiryReaper {
+	meter := otel.Meter("order-worker")
+	expired, _ := meter.Int64Counter("orders_expired_total",
+		metric.WithDescription("Unpaid orders cancelled by the expiry reaper"))
+	runs, _ := meter.Int64Counter("order_expiry_runs_total",
+		metric.WithDescription("Expiry reaper runs, by outcome"))
+
+	r := &ExpiryReaper{
+		orders:         orders,
+		ttl:            ttl,
+		batchSize:      batchSize,
+		expiredCounter: expired,
+	}
+	r.lockedPeriodicRunner = newLockedPeriodicRunner("expiry reaper", interval, locker, expiryLockName, runs, r.expire)
+	return r
+}
+
+// expire cancels a single batch of expired orders
+func (r *ExpiryReaper) expire(ctx context.Context) (int, error) {
+	ids, err := r.orders.ExpireOrders(ctx, r.ttl, r.batchSize)
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range ids {
+		logger.FromContext(ctx).Info("order expired", zap.Int64("order_id", id), zap.Duration("ttl", r.ttl))
+	}
+	r.expiredCounter.Add(ctx, int64(len(ids)))
+	return len(ids), nil
+}
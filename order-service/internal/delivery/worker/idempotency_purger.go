@@ -0,0 +1,50 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/order-service/internal/usecase"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const idempotencyLockName = "order-service:idempotency-purger"
+
+// IdempotencyPurger deletes idempotency records past their retention
+// window. Only the replica holding the shared lock purges a batch.
+type IdempotencyPurger struct {
+	*lockedPeriodicRunner
+
+	idempotency usecase.IdempotencyUsecase
+	batchSize   int
+
+	purged metric.Int64Counter
+}
+
+func NewIdempotencyPurger(idempotency usecase.IdempotencyUsecase, locker domain.Locker, interval time.Duration, batchSize int) *IdempotencyPurger {
+	meter := otel.Meter("order-worker")
+	purged, _ := meter.Int64Counter("idempotency_keys_purged_total",
+		metric.WithDescription("Expired idempotency records deleted"))
+	runs, _ := meter.Int64Counter("idempotency_purge_runs_total",
+		metric.WithDescription("Idempotency purger runs, by outcome"))
+
+	p := &IdempotencyPurger{
+		idempotency: idempotency,
+		batchSize:   batchSize,
+		purged:      purged,
+	}
+	p.lockedPeriodicRunner = newLockedPeriodicRunner("idempotency purger", interval, locker, idempotencyLockName, runs, p.purge)
+	return p
+}
+
+// purge deletes a single batch of expired records
+func (p *IdempotencyPurger) purge(ctx context.Context) (int, error) {
+	n, err := p.idempotency.PurgeExpired(ctx, p.batchSize)
+	if err != nil {
+		return 0, err
+	}
+	p.purged.Add(ctx, n)
+	return int(n), nil
+}
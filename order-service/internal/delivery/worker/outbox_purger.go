@@ -0,0 +1,50 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/order-service/internal/usecase"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const outboxPurgerLockName = "order-service:outbox-purger"
+
+// OutboxPurger deletes outbox events published longer ago than the
+// retention window. Only the replica holding the shared lock purges a batch.
+type OutboxPurger struct {
+	*lockedPeriodicRunner
+
+	outbox    usecase.OutboxUsecase
+	batchSize int
+
+	purged metric.Int64Counter
+}
+
+func NewOutboxPurger(outbox usecase.OutboxUsecase, locker domain.Locker, interval time.Duration, batchSize int) *OutboxPurger {
+	meter := otel.Meter("order-worker")
+	purged, _ := meter.Int64Counter("outbox_events_purged_total",
+		metric.WithDescription("Published outbox events deleted"))
+	runs, _ := meter.Int64Counter("outbox_purge_runs_total",
+		metric.WithDescription("Outbox purger runs, by outcome"))
+
+	p := &OutboxPurger{
+		outbox:    outbox,
+		batchSize: batchSize,
+		purged:    purged,
+	}
+	p.lockedPeriodicRunner = newLockedPeriodicRunner("outbox purger", interval, locker, outboxPurgerLockName, runs, p.purge)
+	return p
+}
+
+// purge deletes a single batch of published events
+func (p *OutboxPurger) purge(ctx context.Context) (int, error) {
+	n, err := p.outbox.PurgePublished(ctx, p.batchSize)
+	if err != nil {
+		return 0, err
+	}
+	p.purged.Add(ctx, n)
+	return int(n), nil
+}
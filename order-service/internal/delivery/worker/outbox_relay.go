@@ -0,0 +1,51 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/order-service/internal/usecase"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const outboxLockName = "order-service:outbox-relay"
+
+// OutboxRelay publishes the order events waiting in the outbox. Only the
+// replica holding the shared lock relays a batch, which keeps each order's
+// events in order.
+type OutboxRelay struct {
+	*lockedPeriodicRunner
+
+	outbox    usecase.OutboxUsecase
+	batchSize int
+
+	published metric.Int64Counter
+}
+
+func NewOutboxRelay(outbox usecase.OutboxUsecase, locker domain.Locker, interval time.Duration, batchSize int) *OutboxRelay {
+	meter := otel.Meter("order-worker")
+	published, _ := meter.Int64Counter("events_published_total",
+		metric.WithDescription("Order events published from the outbox"))
+	runs, _ := meter.Int64Counter("outbox_relay_runs_total",
+		metric.WithDescription("Outbox relay runs, by outcome"))
+
+	r := &OutboxRelay{
+		outbox:    outbox,
+		batchSize: batchSize,
+		published: published,
+	}
+	r.lockedPeriodicRunner = newLockedPeriodicRunner("outbox relay", interval, locker, outboxLockName, runs, r.relay)
+	return r
+}
+
+// relay publishes a single batch of outbox events
+func (r *OutboxRelay) relay(ctx context.Context) (int, error) {
+	ids, err := r.outbox.PublishPending(ctx, r.batchSize)
+	if err != nil {
+		return 0, err
+	}
+	r.published.Add(ctx, int64(len(ids)))
+	return len(ids), nil
+}
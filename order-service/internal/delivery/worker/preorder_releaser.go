@@ -0,0 +1,56 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/order-service/internal/usecase"
+	"github.com/user/go-microservices/pkg/logger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+)
+
+const preorderLockName = "order-service:preorder-releaser"
+
+// PreorderReleaser periodically turns pre-orders whose products have been
+// released into ordinary pending orders. Only the replica holding the shared
+// lock releases a batch.
+type PreorderReleaser struct {
+	*lockedPeriodicRunner
+
+	orders    usecase.OrderUsecase
+	batchSize int
+
+	releasedCounter metric.Int64Counter
+}
+
+func NewPreorderReleaser(orders usecase.OrderUsecase, locker domain.Locker, interval time.Duration, batchSize int) *PreorderReleaser {
+	meter := otel.Meter("order-worker")
+	released, _ := meter.Int64Counter("preorders_released_total",
+		metric.WithDescription("Pre-orders converted into stock reservations at release"))
+	runs, _ := meter.Int64Counter("preorder_release_runs_total",
+		metric.WithDescription("Pre-order releaser runs, by outcome"))
+
+	r := &PreorderReleaser{
+		orders:          orders,
+		batchSize:       batchSize,
+		releasedCounter: released,
+	}
+	r.lockedPeriodicRunner = newLockedPeriodicRunner("pre-order releaser", interval, locker, preorderLockName, runs, r.release)
+	return r
+}
+
+// release converts a single batch of released pre-orders
+func (r *PreorderReleaser) release(ctx context.Context) (int, error) {
+	ids, err := r.orders.ReleasePreorders(ctx, r.batchSize)
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range ids {
+		logger.FromContext(ctx).Info("pre-order released", zap.Int64("order_id", id))
+	}
+	r.releasedCounter.Add(ctx, int64(len(ids)))
+	return len(ids), nil
+}
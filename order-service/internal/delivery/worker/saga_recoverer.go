@@ -0,0 +1,60 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/order-service/internal/usecase"
+	"github.com/user/go-microservices/pkg/logger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+)
+
+const sagaLockName = "order-service:saga-recoverer"
+
+// SagaRecoverer finishes placement sagas left behind by a crashed replica and
+// retries their failed stock releases. Only the replica holding the shared
+// lock recovers a batch. It also recovers once at startup, so sagas
+// interrupted by a restart are picked up right away.
+type SagaRecoverer struct {
+	*lockedPeriodicRunner
+
+	orders     usecase.OrderUsecase
+	staleAfter time.Duration
+	batchSize  int
+
+	settledCounter metric.Int64Counter
+}
+
+func NewSagaRecoverer(orders usecase.OrderUsecase, locker domain.Locker, staleAfter, interval time.Duration, batchSize int) *SagaRecoverer {
+	meter := otel.Meter("order-worker")
+	settled, _ := meter.Int64Counter("sagas_recovered_total",
+		metric.WithDescription("Placement sagas settled by the recoverer"))
+	runs, _ := meter.Int64Counter("saga_recovery_runs_total",
+		metric.WithDescription("Saga recoverer runs, by outcome"))
+
+	r := &SagaRecoverer{
+		orders:         orders,
+		staleAfter:     staleAfter,
+		batchSize:      batchSize,
+		settledCounter: settled,
+	}
+	r.lockedPeriodicRunner = newLockedPeriodicRunner("saga recoverer", interval, locker, sagaLockName, runs, r.recover)
+	r.runAtStart = true
+	return r
+}
+
+// recover settles a single batch of abandoned or compensating sagas
+func (r *SagaRecoverer) recover(ctx context.Context) (int, error) {
+	ids, err := r.orders.RecoverSagas(ctx, r.staleAfter, r.batchSize)
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range ids {
+		logger.FromContext(ctx).Info("placement saga settled", zap.Int64("saga_id", id))
+	}
+	r.settledCounter.Add(ctx, int64(len(ids)))
+	return len(ids), nil
+}
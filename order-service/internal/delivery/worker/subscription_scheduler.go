@@ -0,0 +1,55 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/order-service/internal/usecase"
+	"github.com/user/go-microservices/pkg/logger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+)
+
+const subscriptionLockName = "order-service:subscription-scheduler"
+
+// SubscriptionScheduler periodically places the orders of due subscriptions.
+// Only the replica holding the shared lock runs a batch.
+type SubscriptionScheduler struct {
+	*lockedPeriodicRunner
+
+	subscriptions usecase.SubscriptionUsecase
+	batchSize     int
+
+	placedCounter metric.Int64Counter
+}
+
+func NewSubscriptionScheduler(subscriptions usecase.SubscriptionUsecase, locker domain.Locker, interval time.Duration, batchSize int) *SubscriptionScheduler {
+	meter := otel.Meter("order-worker")
+	placed, _ := meter.Int64Counter("subscription_orders_placed_total",
+		metric.WithDescription("Orders placed by recurring subscriptions"))
+	runs, _ := meter.Int64Counter("subscription_scheduler_runs_total",
+		metric.WithDescription("Subscription scheduler runs, by outcome"))
+
+	s := &SubscriptionScheduler{
+		subscriptions: subscriptions,
+		batchSize:     batchSize,
+		placedCounter: placed,
+	}
+	s.lockedPeriodicRunner = newLockedPeriodicRunner("subscription scheduler", interval, locker, subscriptionLockName, runs, s.place)
+	return s
+}
+
+// place places the orders of a single batch of due subscriptions
+func (s *SubscriptionScheduler) place(ctx context.Context) (int, error) {
+	ids, err := s.subscriptions.RunDueSubscriptions(ctx, s.batchSize)
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range ids {
+		logger.FromContext(ctx).Info("subscription order placed", zap.Int64("order_id", id))
+	}
+	s.placedCounter.Add(ctx, int64(len(ids)))
+	return len(ids), nil
+}
@@ -0,0 +1,50 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/order-service/internal/usecase"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const taskLockName = "order-service:task-runner"
+
+// TaskRunner retries the order tasks that did not succeed when their change
+// was committed. Only the replica holding the shared lock runs a batch.
+type TaskRunner struct {
+	*lockedPeriodicRunner
+
+	tasks     usecase.TaskUsecase
+	batchSize int
+
+	doneCounter metric.Int64Counter
+}
+
+func NewTaskRunner(tasks usecase.TaskUsecase, locker domain.Locker, interval time.Duration, batchSize int) *TaskRunner {
+	meter := otel.Meter("order-worker")
+	done, _ := meter.Int64Counter("order_tasks_retried_total",
+		metric.WithDescription("Order tasks that succeeded on a retry"))
+	runs, _ := meter.Int64Counter("order_task_runs_total",
+		metric.WithDescription("Order task runner runs, by outcome"))
+
+	r := &TaskRunner{
+		tasks:       tasks,
+		batchSize:   batchSize,
+		doneCounter: done,
+	}
+	r.lockedPeriodicRunner = newLockedPeriodicRunner("order task runner", interval, locker, taskLockName, runs, r.retry)
+	return r
+}
+
+// retry runs a single batch of due tasks
+func (r *TaskRunner) retry(ctx context.Context) (int, error) {
+	ids, err := r.tasks.RunDue(ctx, r.batchSize)
+	if err != nil {
+		return 0, err
+	}
+	r.doneCounter.Add(ctx, int64(len(ids)))
+	return len(ids), nil
+}
@@ -0,0 +1,98 @@
+// Package worker hosts background jobs that drive the order usecases on a
+// schedule rather than in response to HTTP requests.
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/pkg/logger"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+)
+
+func outcome(v string) attribute.KeyValue {
+	return attribute.String("outcome", v)
+}
+
+// batchFunc processes one batch and returns how many items it handled
+type batchFunc func(ctx context.Context) (int, error)
+
+// lockedPeriodicRunner runs a batch on every interval. Only the replica
+// holding the shared lock runs a given batch, so replicas never work on the
+// same items at once. Each run is counted in runs by outcome.
+type lockedPeriodicRunner struct {
+	name     string
+	interval time.Duration
+	locker   domain.Locker
+	lockName string
+	batch    batchFunc
+	runs     metric.Int64Counter
+
+	// runAtStart runs a batch as soon as Run is called instead of waiting
+	// for the first tick
+	runAtStart bool
+}
+
+func newLockedPeriodicRunner(name string, interval time.Duration, locker domain.Locker, lockName string,
+	runs metric.Int64Counter, batch batchFunc) *lockedPeriodicRunner {
+	return &lockedPeriodicRunner{
+		name:     name,
+		interval: interval,
+		locker:   locker,
+		lockName: lockName,
+		batch:    batch,
+		runs:     runs,
+	}
+}
+
+// Run runs a batch on every interval until ctx is cancelled
+func (r *lockedPeriodicRunner) Run(ctx context.Context) {
+	log := logger.FromContext(ctx)
+	log.Info(r.name+" started", zap.Duration("interval", r.interval))
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	if r.runAtStart {
+		r.RunOnce(ctx)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info(r.name + " stopped")
+			return
+		case <-ticker.C:
+			r.RunOnce(ctx)
+		}
+	}
+}
+
+// RunOnce runs a single batch if the lock is free and returns the number of
+// items it handled
+func (r *lockedPeriodicRunner) RunOnce(ctx context.Context) int {
+	log := logger.FromContext(ctx)
+
+	unlock, acquired, err := r.locker.TryLock(ctx, r.lockName)
+	if err != nil {
+		r.runs.Add(ctx, 1, metric.WithAttributes(outcome("error")))
+		log.Error(r.name+" could not acquire lock", zap.Error(err))
+		return 0
+	}
+	if !acquired {
+		r.runs.Add(ctx, 1, metric.WithAttributes(outcome("skipped")))
+		return 0
+	}
+	defer unlock()
+
+	n, err := r.batch(ctx)
+	if err != nil {
+		r.runs.Add(ctx, 1, metric.WithAttributes(outcome("error")))
+		log.Error(r.name+" run failed", zap.Error(err))
+		return 0
+	}
+	r.runs.Add(ctx, 1, metric.WithAttributes(outcome("ok")))
+	return n
+}
@@ -0,0 +1,102 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	domainMocks "github.com/user/go-microservices/order-service/internal/domain/mocks"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+	"go.opentelemetry.io/otel"
+)
+
+func TestLockedPeriodicRunner_RunOnce(t *testing.T) {
+	logger.Init()
+	runs, _ := otel.Meter("order-worker-test").Int64Counter("test_runs_total")
+
+	t.Run("LockHeld_RunsBatch", func(t *testing.T) {
+		mockLocker := domainMocks.NewLocker(t)
+		runner := newLockedPeriodicRunner("test runner", time.Minute, mockLocker, "test-lock", runs,
+			func(ctx context.Context) (int, error) { return 3, nil })
+
+		unlocked := false
+		mockLocker.On("TryLock", mock.Anything, "test-lock").Return(func() { unlocked = true }, true, nil)
+
+		n := runner.RunOnce(context.Background())
+
+		assert.Equal(t, 3, n)
+		assert.True(t, unlocked)
+	})
+
+	t.Run("BatchFails_ReleasesLock", func(t *testing.T) {
+		mockLocker := domainMocks.NewLocker(t)
+		runner := newLockedPeriodicRunner("test runner", time.Minute, mockLocker, "test-lock", runs,
+			func(ctx context.Context) (int, error) { return 0, pkgerrors.ErrInternal })
+
+		unlocked := false
+		mockLocker.On("TryLock", mock.Anything, "test-lock").Return(func() { unlocked = true }, true, nil)
+
+		n := runner.RunOnce(context.Background())
+
+		assert.Equal(t, 0, n)
+		assert.True(t, unlocked)
+	})
+
+	t.Run("LockHeldElsewhere_Skips", func(t *testing.T) {
+		mockLocker := domainMocks.NewLocker(t)
+		called := false
+		runner := newLockedPeriodicRunner("test runner", time.Minute, mockLocker, "test-lock", runs,
+			func(ctx context.Context) (int, error) { called = true; return 1, nil })
+
+		mockLocker.On("TryLock", mock.Anything, "test-lock").Return(nil, false, nil)
+
+		n := runner.RunOnce(context.Background())
+
+		assert.Equal(t, 0, n)
+		assert.False(t, called)
+	})
+
+	t.Run("LockError_Skips", func(t *testing.T) {
+		mockLocker := domainMocks.NewLocker(t)
+		called := false
+		runner := newLockedPeriodicRunner("test runner", time.Minute, mockLocker, "test-lock", runs,
+			func(ctx context.Context) (int, error) { called = true; return 1, nil })
+
+		mockLocker.On("TryLock", mock.Anything, "test-lock").Return(nil, false, pkgerrors.ErrUnavailable)
+
+		n := runner.RunOnce(context.Background())
+
+		assert.Equal(t, 0, n)
+		assert.False(t, called)
+	})
+}
+
+func TestLockedPeriodicRunner_Run(t *testing.T) {
+	logger.Init()
+	runs, _ := otel.Meter("order-worker-test").Int64Counter("test_runs_total")
+
+	t.Run("RunAtStart_RunsBeforeFirstTick", func(t *testing.T) {
+		mockLocker := domainMocks.NewLocker(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		runner := newLockedPeriodicRunner("test runner", time.Hour, mockLocker, "test-lock", runs,
+			func(context.Context) (int, error) { cancel(); return 0, nil })
+		runner.runAtStart = true
+
+		mockLocker.On("TryLock", mock.Anything, "test-lock").Return(func() {}, true, nil)
+
+		done := make(chan struct{})
+		go func() {
+			runner.Run(ctx)
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("runner did not run at start")
+		}
+	})
+}
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Backorder marks a new order that could not reserve its stock as waiting
+// for a restock. It must be called before the order is stored.
+func (o *Order) Backorder() error {
+	if o.ID != 0 || o.State() != (OrderState{OrderPending, PaymentPending}) {
+		return fmt.Errorf("only a new order can be backordered: %w", ErrInvalidTransition)
+	}
+	o.OrderStatus = OrderBackordered
+	return nil
+}
+
+// Allocate records that a backordered order's stock has been reserved
+func (o *Order) Allocate(now time.Time) error {
+	if err := o.apply(ActionAllocate); err != nil {
+		return err
+	}
+	o.AllocatedAt = &now
+	return nil
+}
+
+// AllocationKey is the operation key stock is allocated to the waiting
+// order under, by conversion for a pre-order. It is derived from the order,
+// so an allocation repeated after it went unrecorded is applied only once,
+// and cancelling the order can reverse it by key whether or not it happened.
+func (o *Order) AllocationKey() string {
+	if o.OrderStatus == OrderPreorder {
+		return fmt.Sprintf("preorder-%d", o.ID)
+	}
+	return fmt.Sprintf("allocate-%d", o.ID)
+}
+
+// HoldsStock reports whether the order's lines are reserved in the product
+// service's stock. Backordered orders reserve nothing and pre-orders only
+// hold pre-order allocations until they are allocated.
+func (o *Order) HoldsStock() bool {
+	return o.OrderStatus != OrderBackordered && o.OrderStatus != OrderPreorder
+}
+
+// StockLines lists the quantity ordered of each product
+func (o *Order) StockLines() []StockLine {
+	lines := make([]StockLine, 0, len(o.Items))
+	for _, item := range o.Items {
+		lines = append(lines, StockLine{ProductID: item.ProductID, Quantity: item.Quantity})
+	}
+	return lines
+}
@@ -0,0 +1,192 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/valueobject"
+)
+
+// ErrCartClosed wraps ErrConflict so changing a checked out or expired cart maps to a 409.
+var ErrCartClosed = fmt.Errorf("cart is closed: %w", pkgerrors.ErrConflict)
+
+type CartStatus string
+
+const (
+	CartOpen       CartStatus = "OPEN"
+	CartCheckedOut CartStatus = "CHECKED_OUT"
+)
+
+// CartItem is a product line of a cart. Only ProductID and Quantity are
+// stored; the price fields are filled in when the cart is priced.
+type CartItem struct {
+	ProductID   int64             `json:"product_id"`
+	Quantity    int               `json:"quantity"`
+	ProductName string            `json:"product_name,omitempty"`
+	UnitPrice   valueobject.Money `json:"unit_price"`
+	LineTotal   valueobject.Money `json:"line_total"`
+}
+
+// Cart collects items a user intends to order. Unlike an order it holds no
+// reservations and no price snapshots: totals reflect the catalog at the time
+// the cart is read, and are fixed only once the cart is checked out.
+type Cart struct {
+	ID         int64             `json:"id"`
+	UserID     int64             `json:"user_id"`
+	Region     string            `json:"region"`
+	Status     CartStatus        `json:"status"`
+	Items      []CartItem        `json:"items"`
+	OrderID    *int64            `json:"order_id,omitempty"`
+	Subtotal   valueobject.Money `json:"subtotal"`
+	Tax        valueobject.Money `json:"tax"`
+	Shipping   valueobject.Money `json:"shipping"`
+	TotalPrice valueobject.Money `json:"total_price"`
+	ExpiresAt  time.Time         `json:"expires_at"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
+
+func NewCart(userID int64, region string, ttl time.Duration, now time.Time) (*Cart, error) {
+	if userID <= 0 {
+		return nil, fmt.Errorf("invalid user id: %w", pkgerrors.ErrInvalidInput)
+	}
+	if ttl <= 0 {
+		return nil, fmt.Errorf("cart ttl must be positive: %w", pkgerrors.ErrInvalidInput)
+	}
+	return &Cart{
+		UserID:    userID,
+		Region:    region,
+		Status:    CartOpen,
+		Items:     []CartItem{},
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, nil
+}
+
+// AddItem adds quantity units of a product, merging with an existing line
+func (c *Cart) AddItem(productID int64, quantity int, now time.Time) error {
+	if err := c.checkOpen(now); err != nil {
+		return err
+	}
+	if quantity <= 0 {
+		return fmt.Errorf("quantity must be greater than zero: %w", pkgerrors.ErrInvalidInput)
+	}
+	if i := c.indexOf(productID); i >= 0 {
+		c.Items[i].Quantity += quantity
+	} else {
+		c.Items = append(c.Items, CartItem{ProductID: productID, Quantity: quantity})
+	}
+	c.UpdatedAt = now
+	return nil
+}
+
+// SetQuantity replaces the quantity of a line already in the cart
+func (c *Cart) SetQuantity(productID int64, quantity int, now time.Time) error {
+	if err := c.checkOpen(now); err != nil {
+		return err
+	}
+	if quantity <= 0 {
+		return fmt.Errorf("quantity must be greater than zero: %w", pkgerrors.ErrInvalidInput)
+	}
+	i := c.indexOf(productID)
+	if i < 0 {
+		return fmt.Errorf("product %d is not in cart %d: %w", productID, c.ID, pkgerrors.ErrNotFound)
+	}
+	c.Items[i].Quantity = quantity
+	c.UpdatedAt = now
+	return nil
+}
+
+func (c *Cart) RemoveItem(productID int64, now time.Time) error {
+	if err := c.checkOpen(now); err != nil {
+		return err
+	}
+	i := c.indexOf(productID)
+	if i < 0 {
+		return fmt.Errorf("product %d is not in cart %d: %w", productID, c.ID, pkgerrors.ErrNotFound)
+	}
+	c.Items = append(c.Items[:i], c.Items[i+1:]...)
+	c.UpdatedAt = now
+	return nil
+}
+
+// Extend pushes the expiry out to now + ttl; carts in use do not expire
+func (c *Cart) Extend(ttl time.Duration, now time.Time) {
+	c.ExpiresAt = now.Add(ttl)
+}
+
+// CheckOut closes the cart so no further changes or checkouts are accepted.
+// The order it became is linked with LinkOrder once created.
+func (c *Cart) CheckOut(now time.Time) error {
+	if err := c.checkOpen(now); err != nil {
+		return err
+	}
+	if len(c.Items) == 0 {
+		return fmt.Errorf("cart %d is empty: %w", c.ID, pkgerrors.ErrInvalidInput)
+	}
+	c.Status = CartCheckedOut
+	c.UpdatedAt = now
+	return nil
+}
+
+// Reopen undoes CheckOut when the order could not be created
+func (c *Cart) Reopen(now time.Time) {
+	c.Status = CartOpen
+	c.UpdatedAt = now
+}
+
+func (c *Cart) LinkOrder(orderID int64) {
+	c.OrderID = &orderID
+}
+
+// ApplyPricing copies live prices and totals from an order priced for the
+// cart's items
+func (c *Cart) ApplyPricing(o *Order) {
+	prices := make(map[int64]OrderItem, len(o.Items))
+	for _, item := range o.Items {
+		prices[item.ProductID] = item
+	}
+	for i := range c.Items {
+		item := &c.Items[i]
+		priced := prices[item.ProductID]
+		item.ProductName = priced.ProductName
+		item.UnitPrice = priced.UnitPrice
+		item.LineTotal = priced.LineTotal
+	}
+	c.Subtotal = o.Subtotal
+	c.Tax = o.Tax
+	c.Shipping = o.Shipping
+	c.TotalPrice = o.TotalPrice
+}
+
+func (c *Cart) checkOpen(now time.Time) error {
+	if c.Status != CartOpen {
+		return fmt.Errorf("cart %d is %s: %w", c.ID, c.Status, ErrCartClosed)
+	}
+	if !now.Before(c.ExpiresAt) {
+		return fmt.Errorf("cart %d expired at %s: %w", c.ID, c.ExpiresAt.Format(time.RFC3339), ErrCartClosed)
+	}
+	return nil
+}
+
+func (c *Cart) indexOf(productID int64) int {
+	for i, item := range c.Items {
+		if item.ProductID == productID {
+			return i
+		}
+	}
+	return -1
+}
+
+//go:generate mockery --name CartRepository
+type CartRepository interface {
+	Create(ctx context.Context, c *Cart) error
+	GetByID(ctx context.Context, id int64) (*Cart, error)
+	// Update replaces the items and expiry of an open cart
+	Update(ctx context.Context, c *Cart) error
+	// UpdateStatus persists c's status and order link, guarded on the from status
+	UpdateStatus(ctx context.Context, c *Cart, from CartStatus) error
+}
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+)
+
+func TestCart(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	newCart := func(t *testing.T) *Cart {
+		c, err := NewCart(101, "US-CA", time.Hour, now)
+		assert.NoError(t, err)
+		return c
+	}
+
+	t.Run("AddItem_MergesLines", func(t *testing.T) {
+		c := newCart(t)
+
+		assert.NoError(t, c.AddItem(1, 2, now))
+		assert.NoError(t, c.AddItem(2, 1, now))
+		assert.NoError(t, c.AddItem(1, 3, now))
+
+		assert.Equal(t, []CartItem{{ProductID: 1, Quantity: 5}, {ProductID: 2, Quantity: 1}}, c.Items)
+	})
+
+	t.Run("AddItem_InvalidQuantity", func(t *testing.T) {
+		c := newCart(t)
+		assert.True(t, errors.Is(c.AddItem(1, 0, now), pkgerrors.ErrInvalidInput))
+	})
+
+	t.Run("SetQuantity_And_Remove", func(t *testing.T) {
+		c := newCart(t)
+		assert.NoError(t, c.AddItem(1, 2, now))
+
+		assert.NoError(t, c.SetQuantity(1, 7, now))
+		assert.Equal(t, 7, c.Items[0].Quantity)
+		assert.True(t, errors.Is(c.SetQuantity(9, 1, now), pkgerrors.ErrNotFound))
+
+		assert.NoError(t, c.RemoveItem(1, now))
+		assert.Empty(t, c.Items)
+		assert.True(t, errors.Is(c.RemoveItem(1, now), pkgerrors.ErrNotFound))
+	})
+
+	t.Run("Expired_RejectsChanges", func(t *testing.T) {
+		c := newCart(t)
+
+		err := c.AddItem(1, 1, now.Add(time.Hour))
+
+		assert.True(t, errors.Is(err, ErrCartClosed))
+		assert.True(t, errors.Is(err, pkgerrors.ErrConflict))
+	})
+
+	t.Run("Extend_KeepsCartOpen", func(t *testing.T) {
+		c := newCart(t)
+		later := now.Add(50 * time.Minute)
+
+		c.Extend(time.Hour, later)
+
+		assert.NoError(t, c.AddItem(1, 1, now.Add(90*time.Minute)))
+	})
+
+	t.Run("CheckOut", func(t *testing.T) {
+		c := newCart(t)
+		assert.True(t, errors.Is(c.CheckOut(now), pkgerrors.ErrInvalidInput))
+
+		assert.NoError(t, c.AddItem(1, 1, now))
+		assert.NoError(t, c.CheckOut(now))
+		assert.Equal(t, CartCheckedOut, c.Status)
+		assert.True(t, errors.Is(c.CheckOut(now), ErrCartClosed))
+		assert.True(t, errors.Is(c.AddItem(2, 1, now), ErrCartClosed))
+
+		c.Reopen(now)
+		assert.Equal(t, CartOpen, c.Status)
+	})
+}
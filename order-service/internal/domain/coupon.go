@@ -0,0 +1,191 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/valueobject"
+)
+
+// ErrCouponNotApplicable is returned when a coupon is unknown, inactive,
+// outside its validity window or its conditions are not met by the order.
+var ErrCouponNotApplicable = fmt.Errorf("coupon not applicable: %w", pkgerrors.ErrInvalidInput)
+
+// ErrCouponLimitReached is returned when redeeming a coupon would exceed its
+// total or per-user usage limit.
+var ErrCouponLimitReached = fmt.Errorf("coupon usage limit reached: %w", pkgerrors.ErrConflict)
+
+type DiscountType string
+
+const (
+	DiscountPercentage DiscountType = "PERCENTAGE"
+	DiscountFixed      DiscountType = "FIXED_AMOUNT"
+	DiscountBuyXGetY   DiscountType = "BUY_X_GET_Y"
+)
+
+// DiscountTerms describe how a coupon reduces an order. They are copied onto
+// the order with the discount, so editing a coupon never reprices existing
+// orders.
+type DiscountTerms struct {
+	Type DiscountType `json:"type"`
+	// Percentage is used by PERCENTAGE coupons, e.g. 15 for 15% off
+	Percentage float64 `json:"percentage,omitempty"`
+	// AmountOff is used by FIXED_AMOUNT coupons
+	AmountOff valueobject.Money `json:"amount_off"`
+	// ProductID, BuyQty and GetQty are used by BUY_X_GET_Y coupons: for
+	// every BuyQty units of the product bought, GetQty more are free
+	ProductID int64 `json:"product_id,omitempty"`
+	BuyQty    int   `json:"buy_qty,omitempty"`
+	GetQty    int   `json:"get_qty,omitempty"`
+	// MinSpend is the subtotal an order must reach; zero means no minimum
+	MinSpend valueobject.Money `json:"min_spend"`
+}
+
+func (t DiscountTerms) validate() error {
+	if t.MinSpend.IsNegative() {
+		return fmt.Errorf("minimum spend cannot be negative: %w", pkgerrors.ErrInvalidInput)
+	}
+	switch t.Type {
+	case DiscountPercentage:
+		if t.Percentage <= 0 || t.Percentage > 100 {
+			return fmt.Errorf("percentage must be between 0 and 100: %w", pkgerrors.ErrInvalidInput)
+		}
+	case DiscountFixed:
+		if t.AmountOff.IsNegative() || t.AmountOff.IsZero() {
+			return fmt.Errorf("amount off must be greater than zero: %w", pkgerrors.ErrInvalidInput)
+		}
+	case DiscountBuyXGetY:
+		if t.ProductID <= 0 || t.BuyQty <= 0 || t.GetQty <= 0 {
+			return fmt.Errorf("buy-x-get-y needs a product and positive quantities: %w", pkgerrors.ErrInvalidInput)
+		}
+	default:
+		return fmt.Errorf("unknown discount type %q: %w", t.Type, pkgerrors.ErrInvalidInput)
+	}
+	return nil
+}
+
+// Amount is the discount the terms give on the given lines. It never
+// exceeds their subtotal.
+func (t DiscountTerms) Amount(items []OrderItem) (valueobject.Money, error) {
+	subtotal := subtotalOf(items)
+	if t.MinSpend.GreaterThan(subtotal) {
+		return valueobject.Money{}, fmt.Errorf("order subtotal is below the minimum spend of %.2f: %w", t.MinSpend.Amount(), ErrCouponNotApplicable)
+	}
+
+	var amount valueobject.Money
+	switch t.Type {
+	case DiscountPercentage:
+		amount = subtotal.Percentage(t.Percentage)
+	case DiscountFixed:
+		amount = t.AmountOff
+	case DiscountBuyXGetY:
+		free := 0
+		for _, item := range items {
+			if item.ProductID == t.ProductID {
+				free = item.Quantity / (t.BuyQty + t.GetQty) * t.GetQty
+				amount = item.UnitPrice.Multiply(free)
+			}
+		}
+		if free == 0 {
+			return valueobject.Money{}, fmt.Errorf("buy %d of product %d to get %d free: %w", t.BuyQty, t.ProductID, t.GetQty, ErrCouponNotApplicable)
+		}
+	}
+
+	if amount.GreaterThan(subtotal) {
+		amount = subtotal
+	}
+	return amount, nil
+}
+
+type Coupon struct {
+	ID          int64  `json:"id"`
+	Code        string `json:"code"`
+	Description string `json:"description"`
+	DiscountTerms
+	// MaxUses and MaxUsesPerUser limit redemptions; zero means unlimited
+	MaxUses        int        `json:"max_uses"`
+	MaxUsesPerUser int        `json:"max_uses_per_user"`
+	UsesCount      int        `json:"uses_count"`
+	ValidFrom      *time.Time `json:"valid_from,omitempty"`
+	ValidUntil     *time.Time `json:"valid_until,omitempty"`
+	Active         bool       `json:"active"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// Validate checks a coupon definition before it is stored
+func (c *Coupon) Validate() error {
+	if c.Code == "" {
+		return fmt.Errorf("coupon code is required: %w", pkgerrors.ErrInvalidInput)
+	}
+	if c.MaxUses < 0 || c.MaxUsesPerUser < 0 {
+		return fmt.Errorf("usage limits cannot be negative: %w", pkgerrors.ErrInvalidInput)
+	}
+	if c.ValidFrom != nil && c.ValidUntil != nil && !c.ValidUntil.After(*c.ValidFrom) {
+		return fmt.Errorf("valid_until must be after valid_from: %w", pkgerrors.ErrInvalidInput)
+	}
+	return c.DiscountTerms.validate()
+}
+
+// CheckUsable reports whether the coupon can be redeemed at now. Usage limits
+// are enforced when the redemption is stored.
+func (c *Coupon) CheckUsable(now time.Time) error {
+	if !c.Active {
+		return fmt.Errorf("coupon %s is inactive: %w", c.Code, ErrCouponNotApplicable)
+	}
+	if c.ValidFrom != nil && now.Before(*c.ValidFrom) {
+		return fmt.Errorf("coupon %s is not valid yet: %w", c.Code, ErrCouponNotApplicable)
+	}
+	if c.ValidUntil != nil && !now.Before(*c.ValidUntil) {
+		return fmt.Errorf("coupon %s has expired: %w", c.Code, ErrCouponNotApplicable)
+	}
+	return nil
+}
+
+// OrderDiscount is the discount line a redeemed coupon adds to an order
+type OrderDiscount struct {
+	ID       int64             `json:"id"`
+	OrderID  int64             `json:"order_id"`
+	CouponID int64             `json:"coupon_id"`
+	Code     string            `json:"code"`
+	Terms    DiscountTerms     `json:"terms"`
+	Amount   valueobject.Money `json:"amount"`
+}
+
+// ApplyCoupon adds the coupon's discount line to the order and reprices it
+func (o *Order) ApplyCoupon(c *Coupon, now time.Time) error {
+	if err := c.CheckUsable(now); err != nil {
+		return err
+	}
+	amount, err := c.DiscountTerms.Amount(o.Items)
+	if err != nil {
+		return err
+	}
+
+	o.Discount = &OrderDiscount{
+		OrderID:  o.ID,
+		CouponID: c.ID,
+		Code:     c.Code,
+		Terms:    c.DiscountTerms,
+		Amount:   amount,
+	}
+	o.reprice()
+	return nil
+}
+
+func subtotalOf(items []OrderItem) valueobject.Money {
+	total := valueobject.NewMoney(0)
+	for _, item := range items {
+		total = total.Add(item.LineTotal)
+	}
+	return total
+}
+
+//go:generate mockery --name CouponRepository
+type CouponRepository interface {
+	Create(ctx context.Context, c *Coupon) error
+	GetByCode(ctx context.Context, code string) (*Coupon, error)
+	GetAll(ctx context.Context) ([]*Coupon, error)
+	SetActive(ctx context.Context, code string, active bool) error
+}
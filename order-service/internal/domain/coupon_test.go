@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/valueobject"
+)
+
+func TestCoupon_Validate(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		c := &Coupon{Code: "SPRING10", DiscountTerms: DiscountTerms{Type: DiscountPercentage, Percentage: 10}}
+		assert.NoError(t, c.Validate())
+	})
+
+	t.Run("PercentageOutOfRange", func(t *testing.T) {
+		c := &Coupon{Code: "TOOMUCH", DiscountTerms: DiscountTerms{Type: DiscountPercentage, Percentage: 120}}
+		assert.True(t, errors.Is(c.Validate(), pkgerrors.ErrInvalidInput))
+	})
+
+	t.Run("BuyXGetY_MissingProduct", func(t *testing.T) {
+		c := &Coupon{Code: "B2G1", DiscountTerms: DiscountTerms{Type: DiscountBuyXGetY, BuyQty: 2, GetQty: 1}}
+		assert.True(t, errors.Is(c.Validate(), pkgerrors.ErrInvalidInput))
+	})
+
+	t.Run("UnknownType", func(t *testing.T) {
+		c := &Coupon{Code: "X", DiscountTerms: DiscountTerms{Type: "FREE_STUFF"}}
+		assert.True(t, errors.Is(c.Validate(), pkgerrors.ErrInvalidInput))
+	})
+}
+
+func TestOrder_ApplyCoupon(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	newOrder := func() *Order {
+		return &Order{
+			OrderStatus:   OrderPending,
+			PaymentStatus: PaymentPending,
+			Items: []OrderItem{
+				{ProductID: 1, UnitPrice: valueobject.NewMoney(10), Quantity: 5, LineTotal: valueobject.NewMoney(50)},
+				{ProductID: 2, UnitPrice: valueobject.NewMoney(25), Quantity: 2, LineTotal: valueobject.NewMoney(50)},
+			},
+			TotalPrice: valueobject.NewMoney(100),
+		}
+	}
+
+	t.Run("Percentage", func(t *testing.T) {
+		o := newOrder()
+		c := &Coupon{ID: 1, Code: "SPRING15", Active: true, DiscountTerms: DiscountTerms{Type: DiscountPercentage, Percentage: 15}}
+
+		assert.NoError(t, o.ApplyCoupon(c, now))
+		assert.Equal(t, valueobject.NewMoney(15), o.Discount.Amount)
+		assert.Equal(t, valueobject.NewMoney(85), o.TotalPrice)
+	})
+
+	t.Run("Fixed_CappedAtSubtotal", func(t *testing.T) {
+		o := newOrder()
+		c := &Coupon{ID: 1, Code: "BIG", Active: true, DiscountTerms: DiscountTerms{Type: DiscountFixed, AmountOff: valueobject.NewMoney(150)}}
+
+		assert.NoError(t, o.ApplyCoupon(c, now))
+		assert.True(t, o.TotalPrice.IsZero())
+	})
+
+	t.Run("BuyXGetY", func(t *testing.T) {
+		o := newOrder()
+		c := &Coupon{ID: 1, Code: "B2G1", Active: true, DiscountTerms: DiscountTerms{Type: DiscountBuyXGetY, ProductID: 1, BuyQty: 2, GetQty: 1}}
+
+		assert.NoError(t, o.ApplyCoupon(c, now))
+		// 5 units of product 1 make one full group of 2+1, so one unit is free
+		assert.Equal(t, valueobject.NewMoney(10), o.Discount.Amount)
+	})
+
+	t.Run("BelowMinSpend", func(t *testing.T) {
+		o := newOrder()
+		c := &Coupon{ID: 1, Code: "MIN200", Active: true, DiscountTerms: DiscountTerms{
+			Type: DiscountFixed, AmountOff: valueobject.NewMoney(20), MinSpend: valueobject.NewMoney(200),
+		}}
+
+		err := o.ApplyCoupon(c, now)
+
+		assert.True(t, errors.Is(err, ErrCouponNotApplicable))
+		assert.Nil(t, o.Discount)
+		assert.Equal(t, valueobject.NewMoney(100), o.TotalPrice)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		o := newOrder()
+		until := now.Add(-time.Hour)
+		c := &Coupon{ID: 1, Code: "OLD", Active: true, ValidUntil: &until, DiscountTerms: DiscountTerms{Type: DiscountPercentage, Percentage: 10}}
+
+		assert.True(t, errors.Is(o.ApplyCoupon(c, now), ErrCouponNotApplicable))
+	})
+
+	t.Run("Inactive", func(t *testing.T) {
+		o := newOrder()
+		c := &Coupon{ID: 1, Code: "OFF", DiscountTerms: DiscountTerms{Type: DiscountPercentage, Percentage: 10}}
+
+		assert.True(t, errors.Is(o.ApplyCoupon(c, now), ErrCouponNotApplicable))
+	})
+
+	t.Run("Amend_BreaksMinSpend", func(t *testing.T) {
+		o := newOrder()
+		c := &Coupon{ID: 1, Code: "MIN90", Active: true, DiscountTerms: DiscountTerms{
+			Type: DiscountFixed, AmountOff: valueobject.NewMoney(10), MinSpend: valueobject.NewMoney(90),
+		}}
+		assert.NoError(t, o.ApplyCoupon(c, now))
+
+		_, err := o.AmendQuantities(map[int64]int{1: 1})
+
+		assert.True(t, errors.Is(err, ErrCouponNotApplicable))
+		assert.Equal(t, 5, o.Items[0].Quantity)
+		assert.Equal(t, valueobject.NewMoney(90), o.TotalPrice)
+	})
+}
@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventOrderCreated   EventType = "OrderCreated"
+	EventOrderPaid      EventType = "OrderPaid"
+	EventOrderCancelled EventType = "OrderCancelled"
+	EventOrderCompleted EventType = "OrderCompleted"
+)
+
+// OrderEvent announces an order state change to the rest of the platform.
+// Events are written to the outbox in the transaction that makes the change
+// and published afterwards, at least once and in order for each order;
+// consumers deduplicate on ID.
+type OrderEvent struct {
+	ID            int64         `json:"id"`
+	Type          EventType     `json:"type"`
+	OrderID       int64         `json:"order_id"`
+	OrderStatus   OrderStatus   `json:"order_status"`
+	PaymentStatus PaymentStatus `json:"payment_status"`
+	Reason        string        `json:"reason,omitempty"`
+	Actor         string        `json:"actor"`
+	OccurredAt    time.Time     `json:"occurred_at"`
+}
+
+// EventsFor returns the events a status change raises. Most changes raise
+// none; a change can raise more than one, e.g. paying and completing at once.
+func EventsFor(c *StatusChange) []OrderEvent {
+	var types []EventType
+	if c.FromOrderStatus == "" {
+		types = append(types, EventOrderCreated)
+	}
+	if c.ToPaymentStatus == PaymentPaid && c.FromPaymentStatus != PaymentPaid {
+		types = append(types, EventOrderPaid)
+	}
+	if c.ToOrderStatus != c.FromOrderStatus {
+		switch c.ToOrderStatus {
+		case OrderCancelled:
+			types = append(types, EventOrderCancelled)
+		case OrderCompleted:
+			types = append(types, EventOrderCompleted)
+		}
+	}
+
+	events := make([]OrderEvent, 0, len(types))
+	for _, t := range types {
+		events = append(events, OrderEvent{
+			Type:          t,
+			OrderID:       c.OrderID,
+			OrderStatus:   c.ToOrderStatus,
+			PaymentStatus: c.ToPaymentStatus,
+			Reason:        c.Reason,
+			Actor:         c.Actor,
+			OccurredAt:    c.CreatedAt,
+		})
+	}
+	return events
+}
+
+// EventPublisher delivers order events to a message broker. Publish returns
+// once the broker has accepted the event.
+//
+//go:generate mockery --name EventPublisher
+type EventPublisher interface {
+	Publish(ctx context.Context, event *OrderEvent) error
+}
+
+// OutboxRepository reads the events written alongside order changes
+//
+//go:generate mockery --name OutboxRepository
+type OutboxRepository interface {
+	// FindUnpublished returns up to limit unpublished events, oldest first
+	FindUnpublished(ctx context.Context, limit int) ([]*OrderEvent, error)
+	MarkPublished(ctx context.Context, id int64, at time.Time) error
+	// DeletePublished removes up to limit events published before the given
+	// time and returns how many were removed
+	DeletePublished(ctx context.Context, before time.Time, limit int) (int64, error)
+}
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventsFor(t *testing.T) {
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	types := func(c *StatusChange) []EventType {
+		var ts []EventType
+		for _, e := range EventsFor(c) {
+			ts = append(ts, e.Type)
+		}
+		return ts
+	}
+
+	t.Run("Created", func(t *testing.T) {
+		events := EventsFor(&StatusChange{OrderID: 7, ToOrderStatus: OrderPending, ToPaymentStatus: PaymentPending, Actor: "user:101", CreatedAt: now})
+
+		assert.Equal(t, []OrderEvent{{
+			Type: EventOrderCreated, OrderID: 7, OrderStatus: OrderPending, PaymentStatus: PaymentPending, Actor: "user:101", OccurredAt: now,
+		}}, events)
+	})
+
+	t.Run("PaidAndCompleted", func(t *testing.T) {
+		c := &StatusChange{
+			FromOrderStatus: OrderPending, FromPaymentStatus: PaymentPending,
+			ToOrderStatus: OrderCompleted, ToPaymentStatus: PaymentPaid,
+		}
+
+		assert.Equal(t, []EventType{EventOrderPaid, EventOrderCompleted}, types(c))
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		c := &StatusChange{
+			FromOrderStatus: OrderPending, FromPaymentStatus: PaymentPending,
+			ToOrderStatus: OrderCancelled, ToPaymentStatus: PaymentPending,
+		}
+
+		assert.Equal(t, []EventType{EventOrderCancelled}, types(c))
+	})
+
+	t.Run("PaymentOnlyChange_NoEvent", func(t *testing.T) {
+		c := &StatusChange{
+			FromOrderStatus: OrderCompleted, FromPaymentStatus: PaymentPaid,
+			ToOrderStatus: OrderCompleted, ToPaymentStatus: PaymentRefunded,
+		}
+
+		assert.Empty(t, EventsFor(c))
+	})
+}
@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+)
+
+// ErrIdempotencyKeyReused is returned when a key is sent again with a
+// request that differs from the one it was first used for
+var ErrIdempotencyKeyReused = fmt.Errorf("idempotency key was used for a different request: %w", pkgerrors.ErrUnprocessable)
+
+// claimLease is how long a request holds its key's claim. It is longer than
+// any request is allowed to run, so only the claim of a request that died
+// is ever taken over.
+const claimLease = time.Minute
+
+// IdempotencyRecord is the response to a request made under an idempotency
+// key, kept so a retry of the request gets the same response instead of
+// being executed again. While the request runs, the record is a claim on
+// the key with no status code yet.
+type IdempotencyRecord struct {
+	Key string
+	// RequestHash identifies the request the key was first used for
+	RequestHash string
+	StatusCode  int
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// NewIdempotencyClaim creates the record that marks a request under key as
+// in progress. It expires after claimLease, so a claim left behind by a
+// request that died frees the key again.
+func NewIdempotencyClaim(key, requestHash string, now time.Time) *IdempotencyRecord {
+	return &IdempotencyRecord{
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(claimLease),
+	}
+}
+
+func NewIdempotencyRecord(key, requestHash string, statusCode int, body []byte, now time.Time, retention time.Duration) *IdempotencyRecord {
+	return &IdempotencyRecord{
+		Key:         key,
+		RequestHash: requestHash,
+		StatusCode:  statusCode,
+		Body:        body,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(retention),
+	}
+}
+
+// InProgress reports whether the record is a claim whose request has not
+// finished yet
+func (r *IdempotencyRecord) InProgress() bool {
+	return r.StatusCode == 0
+}
+
+// Replays reports whether the record answers a request with the given hash,
+// or returns ErrIdempotencyKeyReused if the request differs
+func (r *IdempotencyRecord) Replays(requestHash string) error {
+	if r.RequestHash != requestHash {
+		return ErrIdempotencyKeyReused
+	}
+	return nil
+}
+
+// Retainable reports whether a response is kept for replay. Server errors
+// are not, so a request that failed on our side can be retried under the
+// same key.
+func Retainable(statusCode int) bool {
+	return statusCode < http.StatusInternalServerError
+}
+
+//go:generate mockery --name IdempotencyRepository
+type IdempotencyRepository interface {
+	// Claim stores the claim unless the key has a record that has not
+	// expired, and reports whether it did. Only one of several requests
+	// claiming a key at once succeeds.
+	Claim(ctx context.Context, claim *IdempotencyRecord) (bool, error)
+	// Get returns the key's record, or ErrNotFound if it has none or the
+	// record expired before now
+	Get(ctx context.Context, key string, now time.Time) (*IdempotencyRecord, error)
+	// Save stores the record over the key's claim for the same request or
+	// an expired record
+	Save(ctx context.Context, r *IdempotencyRecord) error
+	// Release deletes the key's claim for the request, leaving the key free
+	Release(ctx context.Context, key, requestHash string) error
+	// DeleteExpired removes up to limit records that expired before now and
+	// returns how many were removed
+	DeleteExpired(ctx context.Context, now time.Time, limit int) (int64, error)
+}
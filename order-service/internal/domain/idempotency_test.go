@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotencyRecord(t *testing.T) {
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	rec := NewIdempotencyRecord("k1", "h1", http.StatusCreated, []byte(`{"id":42}`), now, 24*time.Hour)
+
+	assert.Equal(t, now.Add(24*time.Hour), rec.ExpiresAt)
+	assert.NoError(t, rec.Replays("h1"))
+	assert.ErrorIs(t, rec.Replays("h2"), ErrIdempotencyKeyReused)
+
+	assert.False(t, rec.InProgress())
+
+	claim := NewIdempotencyClaim("k1", "h1", now)
+	assert.True(t, claim.InProgress())
+	assert.Equal(t, now.Add(claimLease), claim.ExpiresAt)
+
+	assert.True(t, Retainable(http.StatusCreated))
+	assert.True(t, Retainable(http.StatusUnprocessableEntity))
+	assert.False(t, Retainable(http.StatusServiceUnavailable))
+}
@@ -0,0 +1,102 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/valueobject"
+)
+
+// ErrOrderNotInvoiced is returned for the invoice of an order that has not
+// been paid. It wraps ErrNotFound since the invoice does not exist yet.
+var ErrOrderNotInvoiced = fmt.Errorf("order has no invoice until it is paid: %w", pkgerrors.ErrNotFound)
+
+// Invoice is the accounting record of a paid order. It copies the order's
+// snapshot fields when issued and is never changed afterwards, so renaming
+// or repricing a product does not alter it.
+type Invoice struct {
+	ID int64 `json:"id"`
+	// Number is gap-free within a calendar year, e.g. INV-2026-000123
+	Number           string            `json:"number"`
+	OrderID          int64             `json:"order_id"`
+	UserID           int64             `json:"user_id"`
+	Region           string            `json:"region"`
+	Lines            []InvoiceLine     `json:"lines"`
+	Subtotal         valueobject.Money `json:"subtotal"`
+	Discount         valueobject.Money `json:"discount"`
+	DiscountCode     string            `json:"discount_code,omitempty"`
+	Tax              valueobject.Money `json:"tax"`
+	Shipping         valueobject.Money `json:"shipping"`
+	Total            valueobject.Money `json:"total"`
+	PaymentReference string            `json:"payment_reference,omitempty"`
+	IssuedAt         time.Time         `json:"issued_at"`
+}
+
+type InvoiceLine struct {
+	ProductID   int64             `json:"product_id"`
+	Description string            `json:"description"`
+	Quantity    int               `json:"quantity"`
+	UnitPrice   valueobject.Money `json:"unit_price"`
+	TaxRate     float64           `json:"tax_rate"`
+	LineTotal   valueobject.Money `json:"line_total"`
+}
+
+// NewInvoice builds the invoice for a paid order. The number is assigned
+// when the invoice is stored.
+func NewInvoice(o *Order, now time.Time) (*Invoice, error) {
+	switch o.PaymentStatus {
+	case PaymentPaid, PaymentPartiallyRefunded, PaymentRefunded:
+	default:
+		return nil, fmt.Errorf("order %d: %w", o.ID, ErrOrderNotInvoiced)
+	}
+
+	lines := make([]InvoiceLine, 0, len(o.Items))
+	for _, item := range o.Items {
+		lines = append(lines, InvoiceLine{
+			ProductID:   item.ProductID,
+			Description: item.ProductName,
+			Quantity:    item.Quantity,
+			UnitPrice:   item.UnitPrice,
+			TaxRate:     item.TaxRate,
+			LineTotal:   item.LineTotal,
+		})
+	}
+
+	inv := &Invoice{
+		OrderID:          o.ID,
+		UserID:           o.UserID,
+		Region:           o.TaxRegion,
+		Lines:            lines,
+		Subtotal:         o.Subtotal,
+		Tax:              o.Tax,
+		Shipping:         o.Shipping,
+		Total:            o.TotalPrice,
+		PaymentReference: o.PaymentReference,
+		IssuedAt:         now.UTC(),
+	}
+	if o.Discount != nil {
+		inv.Discount = o.Discount.Amount
+		inv.DiscountCode = o.Discount.Code
+	}
+	return inv, nil
+}
+
+// Year is the numbering year of the invoice
+func (inv *Invoice) Year() int {
+	return inv.IssuedAt.UTC().Year()
+}
+
+// AssignNumber sets the invoice number from its position in the year's sequence
+func (inv *Invoice) AssignNumber(sequence int64) {
+	inv.Number = fmt.Sprintf("INV-%d-%06d", inv.Year(), sequence)
+}
+
+//go:generate mockery --name InvoiceRepository
+type InvoiceRepository interface {
+	// Issue numbers and stores the invoice. It returns ErrConflict if the
+	// order already has an invoice.
+	Issue(ctx context.Context, inv *Invoice) error
+	GetByOrderID(ctx context.Context, orderID int64) (*Invoice, error)
+}
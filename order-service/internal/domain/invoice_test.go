@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/valueobject"
+)
+
+func TestNewInvoice(t *testing.T) {
+	item, err := NewOrderItem(1, "Widget", valueobject.NewMoney(100), 2)
+	assert.NoError(t, err)
+	o, err := NewOrder(101, []OrderItem{item}, "", &TaxTable{})
+	assert.NoError(t, err)
+	o.ID = 7
+
+	t.Run("Unpaid", func(t *testing.T) {
+		_, err := NewInvoice(o, time.Now())
+
+		assert.ErrorIs(t, err, ErrOrderNotInvoiced)
+		assert.ErrorIs(t, err, pkgerrors.ErrNotFound)
+	})
+
+	t.Run("SnapshotsOrder", func(t *testing.T) {
+		assert.NoError(t, o.RecordPayment("auth_1"))
+
+		inv, err := NewInvoice(o, time.Date(2026, 5, 4, 12, 0, 0, 0, time.UTC))
+		assert.NoError(t, err)
+		inv.AssignNumber(123)
+
+		// Renaming the order line later must not reach the invoice
+		o.Items[0].ProductName = "Renamed"
+
+		assert.Equal(t, "INV-2026-000123", inv.Number)
+		assert.Equal(t, "Widget", inv.Lines[0].Description)
+		assert.Equal(t, o.TotalPrice, inv.Total)
+		assert.Equal(t, "auth_1", inv.PaymentReference)
+	})
+}
@@ -7,6 +7,8 @@ import (
 
 	mock "github.com/stretchr/testify/mock"
 	domain "github.com/user/go-microservices/order-service/internal/domain"
+
+	time "time"
 )
 
 // OrderRepository is an autogenerated mock type for the OrderRepository type
@@ -14,6 +16,34 @@ type OrderRepository struct {
 	mock.Mock
 }
 
+// CountByUserSince provides a mock function with given fields: ctx, userID, since
+func (_m *OrderRepository) CountByUserSince(ctx context.Context, userID int64, since time.Time) (int, error) {
+	ret := _m.Called(ctx, userID, since)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountByUserSince")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, time.Time) (int, error)); ok {
+		return rf(ctx, userID, since)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, time.Time) int); ok {
+		r0 = rf(ctx, userID, since)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, time.Time) error); ok {
+		r1 = rf(ctx, userID, since)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Create provides a mock function with given fields: ctx, order
 func (_m *OrderRepository) Create(ctx context.Context, order *domain.Order) error {
 	ret := _m.Called(ctx, order)
@@ -32,6 +62,114 @@ func (_m *OrderRepository) Create(ctx context.Context, order *domain.Order) erro
 	return r0
 }
 
+// CreateShipment provides a mock function with given fields: ctx, shipment, change
+func (_m *OrderRepository) CreateShipment(ctx context.Context, shipment *domain.Shipment, change *domain.StatusChange) error {
+	ret := _m.Called(ctx, shipment, change)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateShipment")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Shipment, *domain.StatusChange) error); ok {
+		r0 = rf(ctx, shipment, change)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FindBackordered provides a mock function with given fields: ctx, limit
+func (_m *OrderRepository) FindBackordered(ctx context.Context, limit int) ([]int64, error) {
+	ret := _m.Called(ctx, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindBackordered")
+	}
+
+	var r0 []int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]int64, error)); ok {
+		return rf(ctx, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []int64); ok {
+		r0 = rf(ctx, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int64)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindExpiredPending provides a mock function with given fields: ctx, createdBefore, limit
+func (_m *OrderRepository) FindExpiredPending(ctx context.Context, createdBefore time.Time, limit int) ([]int64, error) {
+	ret := _m.Called(ctx, createdBefore, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindExpiredPending")
+	}
+
+	var r0 []int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, int) ([]int64, error)); ok {
+		return rf(ctx, createdBefore, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, int) []int64); ok {
+		r0 = rf(ctx, createdBefore, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int64)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, int) error); ok {
+		r1 = rf(ctx, createdBefore, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindReleasedPreorders provides a mock function with given fields: ctx, now, limit
+func (_m *OrderRepository) FindReleasedPreorders(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+	ret := _m.Called(ctx, now, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindReleasedPreorders")
+	}
+
+	var r0 []int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, int) ([]int64, error)); ok {
+		return rf(ctx, now, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, int) []int64); ok {
+		r0 = rf(ctx, now, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int64)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, int) error); ok {
+		r1 = rf(ctx, now, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetAll provides a mock function with given fields: ctx
 func (_m *OrderRepository) GetAll(ctx context.Context) ([]*domain.Order, error) {
 	ret := _m.Called(ctx)
@@ -92,17 +230,303 @@ func (_m *OrderRepository) GetByID(ctx context.Context, id int64) (*domain.Order
 	return r0, r1
 }
 
-// UpdateStatus provides a mock function with given fields: ctx, id, status, paymentStatus
-func (_m *OrderRepository) UpdateStatus(ctx context.Context, id int64, status domain.OrderStatus, paymentStatus domain.PaymentStatus) error {
-	ret := _m.Called(ctx, id, status, paymentStatus)
+// GetByIdempotencyKey provides a mock function with given fields: ctx, key
+func (_m *OrderRepository) GetByIdempotencyKey(ctx context.Context, key string) (*domain.Order, error) {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetByIdempotencyKey")
+	}
+
+	var r0 *domain.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.Order, error)); ok {
+		return rf(ctx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.Order); ok {
+		r0 = rf(ctx, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Order)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRefunds provides a mock function with given fields: ctx, orderID
+func (_m *OrderRepository) GetRefunds(ctx context.Context, orderID int64) ([]*domain.Refund, error) {
+	ret := _m.Called(ctx, orderID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRefunds")
+	}
+
+	var r0 []*domain.Refund
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]*domain.Refund, error)); ok {
+		return rf(ctx, orderID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []*domain.Refund); ok {
+		r0 = rf(ctx, orderID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.Refund)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, orderID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetShipment provides a mock function with given fields: ctx, id
+func (_m *OrderRepository) GetShipment(ctx context.Context, id int64) (*domain.Shipment, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetShipment")
+	}
+
+	var r0 *domain.Shipment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*domain.Shipment, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *domain.Shipment); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Shipment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetShipments provides a mock function with given fields: ctx, orderID
+func (_m *OrderRepository) GetShipments(ctx context.Context, orderID int64) ([]*domain.Shipment, error) {
+	ret := _m.Called(ctx, orderID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetShipments")
+	}
+
+	var r0 []*domain.Shipment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]*domain.Shipment, error)); ok {
+		return rf(ctx, orderID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []*domain.Shipment); ok {
+		r0 = rf(ctx, orderID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.Shipment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, orderID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetStatusHistory provides a mock function with given fields: ctx, orderID
+func (_m *OrderRepository) GetStatusHistory(ctx context.Context, orderID int64) ([]*domain.StatusChange, error) {
+	ret := _m.Called(ctx, orderID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetStatusHistory")
+	}
+
+	var r0 []*domain.StatusChange
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]*domain.StatusChange, error)); ok {
+		return rf(ctx, orderID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []*domain.StatusChange); ok {
+		r0 = rf(ctx, orderID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.StatusChange)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, orderID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// HasBackorders provides a mock function with given fields: ctx, productID
+func (_m *OrderRepository) HasBackorders(ctx context.Context, productID int64) (bool, error) {
+	ret := _m.Called(ctx, productID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HasBackorders")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (bool, error)); ok {
+		return rf(ctx, productID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) bool); ok {
+		r0 = rf(ctx, productID)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, productID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MarkAllocated provides a mock function with given fields: ctx, o, change
+func (_m *OrderRepository) MarkAllocated(ctx context.Context, o *domain.Order, change *domain.StatusChange) error {
+	ret := _m.Called(ctx, o, change)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkAllocated")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Order, *domain.StatusChange) error); ok {
+		r0 = rf(ctx, o, change)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MarkDelivered provides a mock function with given fields: ctx, shipment, change
+func (_m *OrderRepository) MarkDelivered(ctx context.Context, shipment *domain.Shipment, change *domain.StatusChange) error {
+	ret := _m.Called(ctx, shipment, change)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkDelivered")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Shipment, *domain.StatusChange) error); ok {
+		r0 = rf(ctx, shipment, change)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RecordPayment provides a mock function with given fields: ctx, o, change, invoice
+func (_m *OrderRepository) RecordPayment(ctx context.Context, o *domain.Order, change *domain.StatusChange, invoice *domain.Invoice) error {
+	ret := _m.Called(ctx, o, change, invoice)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RecordPayment")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Order, *domain.StatusChange, *domain.Invoice) error); ok {
+		r0 = rf(ctx, o, change, invoice)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SavePaymentEvent provides a mock function with given fields: ctx, event, o, change, invoice
+func (_m *OrderRepository) SavePaymentEvent(ctx context.Context, event *domain.PaymentEvent, o *domain.Order, change *domain.StatusChange, invoice *domain.Invoice) error {
+	ret := _m.Called(ctx, event, o, change, invoice)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SavePaymentEvent")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.PaymentEvent, *domain.Order, *domain.StatusChange, *domain.Invoice) error); ok {
+		r0 = rf(ctx, event, o, change, invoice)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveRefund provides a mock function with given fields: ctx, refund, change
+func (_m *OrderRepository) SaveRefund(ctx context.Context, refund *domain.Refund, change *domain.StatusChange) error {
+	ret := _m.Called(ctx, refund, change)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveRefund")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Refund, *domain.StatusChange) error); ok {
+		r0 = rf(ctx, refund, change)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateItems provides a mock function with given fields: ctx, o, change
+func (_m *OrderRepository) UpdateItems(ctx context.Context, o *domain.Order, change *domain.StatusChange) error {
+	ret := _m.Called(ctx, o, change)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateItems")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Order, *domain.StatusChange) error); ok {
+		r0 = rf(ctx, o, change)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateStatus provides a mock function with given fields: ctx, change
+func (_m *OrderRepository) UpdateStatus(ctx context.Context, change *domain.StatusChange) error {
+	ret := _m.Called(ctx, change)
 
 	if len(ret) == 0 {
 		panic("no return value specified for UpdateStatus")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64, domain.OrderStatus, domain.PaymentStatus) error); ok {
-		r0 = rf(ctx, id, status, paymentStatus)
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.StatusChange) error); ok {
+		r0 = rf(ctx, change)
 	} else {
 		r0 = ret.Error(0)
 	}
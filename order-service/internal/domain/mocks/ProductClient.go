@@ -14,6 +14,60 @@ type ProductClient struct {
 	mock.Mock
 }
 
+// AllocateStock provides a mock function with given fields: ctx, key, lines
+func (_m *ProductClient) AllocateStock(ctx context.Context, key string, lines []domain.StockLine) error {
+	ret := _m.Called(ctx, key, lines)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AllocateStock")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []domain.StockLine) error); ok {
+		r0 = rf(ctx, key, lines)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ConfirmStock provides a mock function with given fields: ctx, key, id, qty
+func (_m *ProductClient) ConfirmStock(ctx context.Context, key string, id int64, qty int) error {
+	ret := _m.Called(ctx, key, id, qty)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ConfirmStock")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, int) error); ok {
+		r0 = rf(ctx, key, id, qty)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ConvertPreorder provides a mock function with given fields: ctx, key, lines
+func (_m *ProductClient) ConvertPreorder(ctx context.Context, key string, lines []domain.StockLine) error {
+	ret := _m.Called(ctx, key, lines)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ConvertPreorder")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []domain.StockLine) error); ok {
+		r0 = rf(ctx, key, lines)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // GetProduct provides a mock function with given fields: ctx, id
 func (_m *ProductClient) GetProduct(ctx context.Context, id int64) (*domain.ProductView, error) {
 	ret := _m.Called(ctx, id)
@@ -44,17 +98,71 @@ func (_m *ProductClient) GetProduct(ctx context.Context, id int64) (*domain.Prod
 	return r0, r1
 }
 
-// ReleaseStock provides a mock function with given fields: ctx, id, qty
-func (_m *ProductClient) ReleaseStock(ctx context.Context, id int64, qty int) error {
-	ret := _m.Called(ctx, id, qty)
+// ReleasePreorder provides a mock function with given fields: ctx, key, lines
+func (_m *ProductClient) ReleasePreorder(ctx context.Context, key string, lines []domain.StockLine) error {
+	ret := _m.Called(ctx, key, lines)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReleasePreorder")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []domain.StockLine) error); ok {
+		r0 = rf(ctx, key, lines)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReleaseReservation provides a mock function with given fields: ctx, key
+func (_m *ProductClient) ReleaseReservation(ctx context.Context, key string) error {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReleaseReservation")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReleaseStock provides a mock function with given fields: ctx, key, id, qty
+func (_m *ProductClient) ReleaseStock(ctx context.Context, key string, id int64, qty int) error {
+	ret := _m.Called(ctx, key, id, qty)
 
 	if len(ret) == 0 {
 		panic("no return value specified for ReleaseStock")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64, int) error); ok {
-		r0 = rf(ctx, id, qty)
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, int) error); ok {
+		r0 = rf(ctx, key, id, qty)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReservePreorder provides a mock function with given fields: ctx, key, lines
+func (_m *ProductClient) ReservePreorder(ctx context.Context, key string, lines []domain.StockLine) error {
+	ret := _m.Called(ctx, key, lines)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReservePreorder")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []domain.StockLine) error); ok {
+		r0 = rf(ctx, key, lines)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -62,17 +170,35 @@ func (_m *ProductClient) ReleaseStock(ctx context.Context, id int64, qty int) er
 	return r0
 }
 
-// ReserveStock provides a mock function with given fields: ctx, id, qty
-func (_m *ProductClient) ReserveStock(ctx context.Context, id int64, qty int) error {
-	ret := _m.Called(ctx, id, qty)
+// ReserveStock provides a mock function with given fields: ctx, key, id, qty
+func (_m *ProductClient) ReserveStock(ctx context.Context, key string, id int64, qty int) error {
+	ret := _m.Called(ctx, key, id, qty)
 
 	if len(ret) == 0 {
 		panic("no return value specified for ReserveStock")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64, int) error); ok {
-		r0 = rf(ctx, id, qty)
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, int) error); ok {
+		r0 = rf(ctx, key, id, qty)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Restock provides a mock function with given fields: ctx, key, id, qty
+func (_m *ProductClient) Restock(ctx context.Context, key string, id int64, qty int) error {
+	ret := _m.Called(ctx, key, id, qty)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Restock")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, int) error); ok {
+		r0 = rf(ctx, key, id, qty)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
 	"github.com/user/go-microservices/pkg/valueobject"
 )
 
@@ -13,77 +14,243 @@ type PaymentStatus string
 
 const (
 	OrderPending   OrderStatus = "PENDING"
+	OrderShipped   OrderStatus = "SHIPPED"
+	OrderDelivered OrderStatus = "DELIVERED"
 	OrderCompleted OrderStatus = "COMPLETED"
 	OrderCancelled OrderStatus = "CANCELLED"
+	// OrderBackordered orders wait for stock and hold no reservation
+	OrderBackordered OrderStatus = "BACKORDERED"
+	// OrderPreorder orders hold pre-order allocations of products that are
+	// not released yet
+	OrderPreorder OrderStatus = "PREORDER"
 
-	PaymentPending PaymentStatus = "PENDING"
-	PaymentPaid    PaymentStatus = "PAID"
-	PaymentFailed  PaymentStatus = "FAILED"
+	PaymentPending           PaymentStatus = "PENDING"
+	PaymentPaid              PaymentStatus = "PAID"
+	PaymentFailed            PaymentStatus = "FAILED"
+	PaymentPartiallyRefunded PaymentStatus = "PARTIALLY_REFUNDED"
+	PaymentRefunded          PaymentStatus = "REFUNDED"
 )
 
+type OrderItem struct {
+	ID          int64             `json:"id"`
+	OrderID     int64             `json:"order_id"`
+	ProductID   int64             `json:"product_id"`
+	ProductName string            `json:"product_name"` // Snapshot
+	UnitPrice   valueobject.Money `json:"unit_price"`   // Snapshot
+	Quantity    int               `json:"quantity"`
+	LineTotal   valueobject.Money `json:"line_total"`
+	TaxCategory string            `json:"tax_category"` // Snapshot
+	TaxRate     float64           `json:"tax_rate"`     // Snapshot, set by NewOrder
+	WeightGrams int               `json:"weight_grams"` // Snapshot, per unit
+	// ReleaseAt is the product's launch date if it was not released when the
+	// line was priced. It only decides whether a new order is a pre-order
+	// and is not stored.
+	ReleaseAt *time.Time `json:"-"`
+	// Backorderable is whether the product accepted orders while out of
+	// stock when the line was priced. It only decides whether a new order
+	// queues behind earlier backorders and is not stored.
+	Backorderable bool `json:"-"`
+}
+
+// NewOrderItem is a factory function for a single order line
+func NewOrderItem(productID int64, productName string, unitPrice valueobject.Money, quantity int) (OrderItem, error) {
+	if productID <= 0 {
+		return OrderItem{}, fmt.Errorf("invalid product id")
+	}
+	if quantity <= 0 {
+		return OrderItem{}, fmt.Errorf("quantity must be greater than zero")
+	}
+
+	return OrderItem{
+		ProductID:   productID,
+		ProductName: productName,
+		UnitPrice:   unitPrice,
+		Quantity:    quantity,
+		LineTotal:   unitPrice.Multiply(quantity),
+	}, nil
+}
+
 type Order struct {
-	ID            int64             `json:"id"`
-	UserID        int64             `json:"user_id"`
-	ProductID     int64             `json:"product_id"`
-	ProductName   string            `json:"product_name"` // Snapshot
-	UnitPrice     valueobject.Money `json:"unit_price"`   // Snapshot
-	Quantity      int               `json:"quantity"`
-	TotalPrice    valueobject.Money `json:"total_price"`
-	OrderStatus   OrderStatus       `json:"order_status"`
-	PaymentStatus PaymentStatus     `json:"payment_status"`
-	CreatedAt     time.Time         `json:"created_at"`
-}
-
-// NewOrder is a factory function for the Order aggregate
-func NewOrder(userID int64, productID int64, productName string, unitPrice valueobject.Money, quantity int) (*Order, error) {
+	ID             int64             `json:"id"`
+	UserID         int64             `json:"user_id"`
+	Items          []OrderItem       `json:"items"`
+	Discount       *OrderDiscount    `json:"discount,omitempty"`
+	TaxRegion      string            `json:"tax_region"` // Destination region, also selects the shipping rate
+	ShippingRate   *ShippingRate     `json:"shipping_rate,omitempty"`
+	Subtotal       valueobject.Money `json:"subtotal"`
+	Tax            valueobject.Money `json:"tax"`
+	Shipping       valueobject.Money `json:"shipping"`
+	TotalPrice     valueobject.Money `json:"total_price"` // Subtotal - discount + Tax + Shipping
+	RefundedAmount valueobject.Money `json:"refunded_amount"`
+	OrderStatus    OrderStatus       `json:"order_status"`
+	PaymentStatus  PaymentStatus     `json:"payment_status"`
+	// PaymentReference identifies the captured payment at the payment provider
+	PaymentReference string `json:"payment_reference,omitempty"`
+	// SubscriptionID links an order placed by a subscription's scheduler
+	SubscriptionID *int64 `json:"subscription_id,omitempty"`
+	// SagaID is the placement saga that reserved the order's stock
+	SagaID *int64 `json:"-"`
+	// IdempotencyKey identifies the request that placed the order, so a retry
+	// of the request finds it
+	IdempotencyKey *string `json:"-"`
+	// ReleaseAt is when the last product of a pre-order is released
+	ReleaseAt *time.Time `json:"release_at,omitempty"`
+	// AllocatedAt is when a backordered or pre-ordered order received its
+	// stock; the payment window starts then rather than at creation
+	AllocatedAt *time.Time `json:"allocated_at,omitempty"`
+	// Version counts amendments of the order's lines, so an amendment based
+	// on lines that have changed since they were read can be refused
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewOrder is a factory function for the Order aggregate. Each line's tax
+// rate for region is looked up once here and kept with the order.
+func NewOrder(userID int64, items []OrderItem, region string, taxes TaxCalculator) (*Order, error) {
 	if userID <= 0 {
 		return nil, fmt.Errorf("invalid user id")
 	}
-	if quantity <= 0 {
-		return nil, fmt.Errorf("quantity must be greater than zero")
+	if len(items) == 0 {
+		return nil, fmt.Errorf("order must contain at least one item")
 	}
 
-	return &Order{
+	seen := make(map[int64]bool, len(items))
+	for i := range items {
+		item := &items[i]
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("quantity must be greater than zero")
+		}
+		if seen[item.ProductID] {
+			return nil, fmt.Errorf("duplicate line for product %d", item.ProductID)
+		}
+		seen[item.ProductID] = true
+
+		rate, err := taxes.Rate(region, item.TaxCategory)
+		if err != nil {
+			return nil, err
+		}
+		item.TaxRate = rate
+	}
+
+	o := &Order{
 		UserID:        userID,
-		ProductID:     productID,
-		ProductName:   productName,
-		UnitPrice:     unitPrice,
-		Quantity:      quantity,
-		TotalPrice:    unitPrice.Multiply(quantity),
+		Items:         items,
+		TaxRegion:     region,
 		OrderStatus:   OrderPending,
 		PaymentStatus: PaymentPending,
 		CreatedAt:     time.Now(),
-	}, nil
+	}
+	o.reprice()
+	return o, nil
 }
 
-// Pay marks the order as paid
-func (o *Order) Pay() error {
-	if o.OrderStatus == OrderCancelled {
-		return fmt.Errorf("cannot pay for a cancelled order")
+// reprice recomputes the order amounts from its lines, discount, tax rates
+// and shipping rate. Free-shipping thresholds apply to the discounted subtotal.
+func (o *Order) reprice() {
+	var discount valueobject.Money
+	if o.Discount != nil {
+		discount = o.Discount.Amount
 	}
-	if o.PaymentStatus == PaymentPaid {
-		return fmt.Errorf("order is already paid")
+
+	o.Subtotal = subtotalOf(o.Items)
+	net := o.Subtotal.Subtract(discount)
+	o.Tax = taxLines(o.Items, o.Subtotal, discount)
+	o.Shipping = valueobject.NewMoney(0)
+	if o.ShippingRate != nil {
+		o.Shipping = o.ShippingRate.Charge(weightOf(o.Items), net)
 	}
-	o.PaymentStatus = PaymentPaid
-	return nil
+	o.TotalPrice = net.Add(o.Tax).Add(o.Shipping)
 }
 
-// Complete completes the order
-func (o *Order) Complete() error {
-	if o.PaymentStatus != PaymentPaid {
-		return fmt.Errorf("cannot complete an unpaid order")
+// QuantityChange is the difference in reserved units for one order line
+type QuantityChange struct {
+	ProductID int64
+	Delta     int
+}
+
+// AmendmentKey is the operation key an amendment reserves the increase of a
+// product's line under. It is derived from the order at the version the
+// amendment is based on, and attempt tells tries at that version apart, so
+// a retry is never taken for an earlier try whose reservation was released.
+func (o *Order) AmendmentKey(productID, attempt int64) string {
+	return fmt.Sprintf("amend-%d-v%d-%d-%d", o.ID, o.Version, productID, attempt)
+}
+
+// AmendQuantities sets new quantities for existing lines, keyed by product
+// ID, and recomputes line totals and the order amounts from the UnitPrice,
+// TaxRate and shipping rate snapshots. It returns the non-zero changes in line order.
+func (o *Order) AmendQuantities(quantities map[int64]int) ([]QuantityChange, error) {
+	if len(quantities) == 0 {
+		return nil, fmt.Errorf("amendment must change at least one item: %w", pkgerrors.ErrInvalidInput)
+	}
+	if err := o.apply(ActionAmend); err != nil {
+		return nil, err
+	}
+
+	matched := 0
+	for _, item := range o.Items {
+		qty, ok := quantities[item.ProductID]
+		if !ok {
+			continue
+		}
+		if qty <= 0 {
+			return nil, fmt.Errorf("quantity must be greater than zero: %w", pkgerrors.ErrInvalidInput)
+		}
+		matched++
+	}
+	if matched != len(quantities) {
+		return nil, fmt.Errorf("amendment refers to a product not in order %d: %w", o.ID, pkgerrors.ErrInvalidInput)
+	}
+
+	items := make([]OrderItem, len(o.Items))
+	copy(items, o.Items)
+
+	var changes []QuantityChange
+	for i := range items {
+		item := &items[i]
+		if qty, ok := quantities[item.ProductID]; ok && qty != item.Quantity {
+			changes = append(changes, QuantityChange{ProductID: item.ProductID, Delta: qty - item.Quantity})
+			item.Quantity = qty
+			item.LineTotal = item.UnitPrice.Multiply(qty)
+		}
+	}
+
+	// A discount is re-evaluated against its snapshotted terms, so an
+	// amendment that breaks the coupon's conditions is rejected
+	if o.Discount != nil {
+		amount, err := o.Discount.Terms.Amount(items)
+		if err != nil {
+			return nil, err
+		}
+		o.Discount.Amount = amount
 	}
-	o.OrderStatus = OrderCompleted
-	return nil
+
+	o.Items = items
+	o.reprice()
+	return changes, nil
 }
 
-// Cancel cancels the order
-func (o *Order) Cancel() error {
-	if o.OrderStatus == OrderCompleted {
-		return fmt.Errorf("cannot cancel a completed order")
+// Pay marks the order as paid
+func (o *Order) Pay() error {
+	return o.apply(ActionPay)
+}
+
+// Complete completes a delivered order
+func (o *Order) Complete() error {
+	return o.apply(ActionComplete)
+}
+
+// Cancel cancels the order. A paid order is refunded in full and the
+// resulting refund is returned; otherwise the refund is nil.
+func (o *Order) Cancel() (*Refund, error) {
+	due := o.RefundableAmount()
+	if err := o.apply(ActionCancel); err != nil {
+		return nil, err
 	}
-	o.OrderStatus = OrderCancelled
-	return nil
+	if o.PaymentStatus != PaymentRefunded {
+		return nil, nil
+	}
+	return o.recordRefund(due, "order cancelled"), nil
 }
 
 //go:generate mockery --name OrderRepository
@@ -91,18 +258,103 @@ type OrderRepository interface {
 	Create(ctx context.Context, order *Order) error
 	GetByID(ctx context.Context, id int64) (*Order, error)
 	GetAll(ctx context.Context) ([]*Order, error)
-	UpdateStatus(ctx context.Context, id int64, status OrderStatus, paymentStatus PaymentStatus) error
+	// UpdateStatus moves the order from change's From state to its To state and
+	// appends change to the status history in the same transaction. It returns
+	// ErrConflict if the order is no longer in the From state.
+	UpdateStatus(ctx context.Context, change *StatusChange) error
+	GetStatusHistory(ctx context.Context, orderID int64) ([]*StatusChange, error)
+	// SaveRefund stores the refund, adds it to the order's refunded amount and
+	// applies change, all in one transaction. Like UpdateStatus it returns
+	// ErrConflict if the order is no longer in change's From state.
+	SaveRefund(ctx context.Context, refund *Refund, change *StatusChange) error
+	GetRefunds(ctx context.Context, orderID int64) ([]*Refund, error)
+	// UpdateItems stores the order's line quantities and total, guarded on and
+	// recorded by change. It fails with ErrConflict unless the stored order is
+	// still at o.Version, and bumps the version otherwise.
+	UpdateItems(ctx context.Context, o *Order, change *StatusChange) error
+	// CreateShipment stores a new shipment and applies change in one transaction
+	CreateShipment(ctx context.Context, shipment *Shipment, change *StatusChange) error
+	// MarkDelivered stores the shipment's delivery time and applies change in one transaction
+	MarkDelivered(ctx context.Context, shipment *Shipment, change *StatusChange) error
+	GetShipment(ctx context.Context, id int64) (*Shipment, error)
+	GetShipments(ctx context.Context, orderID int64) ([]*Shipment, error)
+	// FindExpiredPending returns IDs of unpaid PENDING orders created before the cutoff, oldest first
+	FindExpiredPending(ctx context.Context, createdBefore time.Time, limit int) ([]int64, error)
+	// RecordPayment stores the order's payment reference, applies change and
+	// issues invoice in one transaction
+	RecordPayment(ctx context.Context, o *Order, change *StatusChange, invoice *Invoice) error
+	// SavePaymentEvent stores a processed payment event and, when change and
+	// invoice are not nil, applies the change with the order's payment
+	// reference and issues the invoice in the same transaction. It returns
+	// ErrDuplicatePaymentEvent for an event ID seen before.
+	SavePaymentEvent(ctx context.Context, event *PaymentEvent, o *Order, change *StatusChange, invoice *Invoice) error
+	// CountByUserSince counts the orders a user created at or after since
+	CountByUserSince(ctx context.Context, userID int64, since time.Time) (int, error)
+	// GetByIdempotencyKey returns the order placed by the request with the
+	// given key, or ErrNotFound
+	GetByIdempotencyKey(ctx context.Context, key string) (*Order, error)
+	// FindBackordered returns IDs of BACKORDERED orders, oldest first
+	FindBackordered(ctx context.Context, limit int) ([]int64, error)
+	// HasBackorders reports whether a BACKORDERED order is waiting for the product
+	HasBackorders(ctx context.Context, productID int64) (bool, error)
+	// FindReleasedPreorders returns IDs of PREORDER orders whose release time
+	// is not after now, oldest first
+	FindReleasedPreorders(ctx context.Context, now time.Time, limit int) ([]int64, error)
+	// MarkAllocated stores the order's allocation time and applies change in one transaction
+	MarkAllocated(ctx context.Context, o *Order, change *StatusChange) error
+}
+
+//go:generate mockery --name Locker
+type Locker interface {
+	// TryLock acquires a named lock shared by all replicas without blocking.
+	// When acquired is true the caller must call unlock once done.
+	TryLock(ctx context.Context, name string) (unlock func(), acquired bool, err error)
 }
 
+// Methods that change stock take an operation key. The product service
+// applies a non-empty key at most once, so a call that may or may not have
+// landed can be retried, or released with ReleaseReservation. An empty key is
+// not deduplicated.
+//
 //go:generate mockery --name ProductClient
 type ProductClient interface {
 	GetProduct(ctx context.Context, id int64) (*ProductView, error)
-	ReserveStock(ctx context.Context, id int64, qty int) error
-	ReleaseStock(ctx context.Context, id int64, qty int) error
+	ReserveStock(ctx context.Context, key string, id int64, qty int) error
+	ReleaseStock(ctx context.Context, key string, id int64, qty int) error
+	ConfirmStock(ctx context.Context, key string, id int64, qty int) error
+	// AllocateStock reserves every line or none of them
+	AllocateStock(ctx context.Context, key string, lines []StockLine) error
+	// Restock puts returned units back into the product's total stock
+	Restock(ctx context.Context, key string, id int64, qty int) error
+	// ReservePreorder takes every line from the pre-order caps of unreleased
+	// products, or none of them
+	ReservePreorder(ctx context.Context, key string, lines []StockLine) error
+	// ReleasePreorder gives pre-ordered units back to the caps
+	ReleasePreorder(ctx context.Context, key string, lines []StockLine) error
+	// ConvertPreorder turns pre-ordered units of released products into
+	// stock reservations, for every line or none
+	ConvertPreorder(ctx context.Context, key string, lines []StockLine) error
+	// ReleaseReservation gives back whatever the reservation made under key
+	// took. If none was made, the key is voided so that a reservation still
+	// in flight fails with ErrConflict instead of landing later.
+	ReleaseReservation(ctx context.Context, key string) error
 }
 
 type ProductView struct {
-	ID    int64             `json:"id"`
-	Name  string            `json:"name"`
-	Price valueobject.Money `json:"price"`
+	ID          int64             `json:"id"`
+	Name        string            `json:"name"`
+	Price       valueobject.Money `json:"price"`
+	TaxCategory string            `json:"tax_category"`
+	WeightGrams int               `json:"weight_grams"`
+	// Backorderable products can be ordered while out of stock
+	Backorderable bool `json:"backorderable"`
+	// ReleaseAt is set for products that launch in the future; until then
+	// they can only be pre-ordered
+	ReleaseAt *time.Time `json:"release_at,omitempty"`
+}
+
+// StockLine is a quantity of one product
+type StockLine struct {
+	ProductID int64 `json:"product_id"`
+	Quantity  int   `json:"quantity"`
 }
@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// SystemActor is recorded when a change is not triggered by a caller
+const SystemActor = "system"
+
+type actorCtxKey struct{}
+
+// WithActor attaches the identity responsible for subsequent changes
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorCtxKey{}, actor)
+}
+
+// UnverifiedActor marks an actor the caller named without the service
+// authenticating it, so the history does not present it as established
+func UnverifiedActor(name string) string {
+	return "unverified:" + name
+}
+
+// ActorFromContext returns the actor set by WithActor, or SystemActor
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorCtxKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return SystemActor
+}
+
+// StatusChange is an audit record of a single order state transition
+type StatusChange struct {
+	ID                int64         `json:"id"`
+	OrderID           int64         `json:"order_id"`
+	FromOrderStatus   OrderStatus   `json:"from_order_status"`
+	FromPaymentStatus PaymentStatus `json:"from_payment_status"`
+	ToOrderStatus     OrderStatus   `json:"to_order_status"`
+	ToPaymentStatus   PaymentStatus `json:"to_payment_status"`
+	Reason            string        `json:"reason"`
+	Actor             string        `json:"actor"`
+	CreatedAt         time.Time     `json:"created_at"`
+
+	// Tasks are the side effects owed once the change is committed
+	Tasks []*OrderTask `json:"-"`
+	// Settles lists tasks scheduled ahead of the change that committing it
+	// makes unnecessary; they are marked done with it
+	Settles []int64 `json:"-"`
+}
+
+// NewStatusChange records the move from a previous state to the order's current state
+func NewStatusChange(o *Order, from OrderState, reason, actor string) *StatusChange {
+	return &StatusChange{
+		OrderID:           o.ID,
+		FromOrderStatus:   from.Order,
+		FromPaymentStatus: from.Payment,
+		ToOrderStatus:     o.OrderStatus,
+		ToPaymentStatus:   o.PaymentStatus,
+		Reason:            reason,
+		Actor:             actor,
+		CreatedAt:         time.Now(),
+	}
+}
@@ -0,0 +1,164 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/valueobject"
+)
+
+// ErrOrderRejected wraps ErrUnprocessable so an order refused by a rule maps to a 422
+var ErrOrderRejected = fmt.Errorf("order rejected: %w", pkgerrors.ErrUnprocessable)
+
+// Reason codes reported when a rule rejects an order
+const (
+	RuleUserBlocked    = "USER_BLOCKED"
+	RuleMaxQuantity    = "MAX_QUANTITY_PER_ORDER"
+	RuleMaxOrderValue  = "MAX_ORDER_VALUE"
+	RuleMaxOrdersPerHr = "MAX_ORDERS_PER_HOUR"
+)
+
+// RuleViolation names the rule that rejected an order. It unwraps to
+// ErrOrderRejected.
+type RuleViolation struct {
+	Rule   string
+	Detail string
+}
+
+func (v *RuleViolation) Error() string {
+	return fmt.Sprintf("order rejected by rule %s: %s", v.Rule, v.Detail)
+}
+
+func (v *RuleViolation) Unwrap() error {
+	return ErrOrderRejected
+}
+
+// ViolatedRule returns the reason code of the rule that rejected the order,
+// or "" when err is not a rule rejection
+func ViolatedRule(err error) string {
+	var v *RuleViolation
+	if errors.As(err, &v) {
+		return v.Rule
+	}
+	return ""
+}
+
+// OrderRule decides whether an order may be placed. It returns a
+// *RuleViolation to reject the order; any other error aborts the check.
+type OrderRule interface {
+	Check(ctx context.Context, o *Order) error
+}
+
+// OrderRules runs rules in order and stops at the first rejection
+type OrderRules []OrderRule
+
+func (r OrderRules) Check(ctx context.Context, o *Order) error {
+	for _, rule := range r {
+		if err := rule.Check(ctx, o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OrderLimits configures the built-in rules; a zero limit disables its rule
+type OrderLimits struct {
+	BlockedUsers     []int64
+	MaxQuantity      int
+	MaxOrderValue    valueobject.Money
+	MaxOrdersPerHour int
+}
+
+// Rules builds the enabled rules, cheapest first so the order count query
+// only runs for orders that pass the others
+func (l OrderLimits) Rules(orders OrderCounter) OrderRules {
+	var rules OrderRules
+	if len(l.BlockedUsers) > 0 {
+		blocked := make(map[int64]bool, len(l.BlockedUsers))
+		for _, id := range l.BlockedUsers {
+			blocked[id] = true
+		}
+		rules = append(rules, BlocklistRule{Users: blocked})
+	}
+	if l.MaxQuantity > 0 {
+		rules = append(rules, MaxQuantityRule{Max: l.MaxQuantity})
+	}
+	if l.MaxOrderValue.GreaterThan(valueobject.NewMoney(0)) {
+		rules = append(rules, MaxOrderValueRule{Max: l.MaxOrderValue})
+	}
+	if l.MaxOrdersPerHour > 0 {
+		rules = append(rules, OrderRateRule{Max: l.MaxOrdersPerHour, Window: time.Hour, Orders: orders})
+	}
+	return rules
+}
+
+// BlocklistRule rejects every order of the listed users
+type BlocklistRule struct {
+	Users map[int64]bool
+}
+
+func (r BlocklistRule) Check(_ context.Context, o *Order) error {
+	if r.Users[o.UserID] {
+		return &RuleViolation{Rule: RuleUserBlocked, Detail: fmt.Sprintf("user %d may not place orders", o.UserID)}
+	}
+	return nil
+}
+
+// MaxQuantityRule caps the total units across all lines of an order
+type MaxQuantityRule struct {
+	Max int
+}
+
+func (r MaxQuantityRule) Check(_ context.Context, o *Order) error {
+	units := 0
+	for _, item := range o.Items {
+		units += item.Quantity
+	}
+	if units > r.Max {
+		return &RuleViolation{Rule: RuleMaxQuantity, Detail: fmt.Sprintf("%d units exceed the limit of %d", units, r.Max)}
+	}
+	return nil
+}
+
+// MaxOrderValueRule caps the order total, after discounts, tax and shipping
+type MaxOrderValueRule struct {
+	Max valueobject.Money
+}
+
+func (r MaxOrderValueRule) Check(_ context.Context, o *Order) error {
+	if o.TotalPrice.GreaterThan(r.Max) {
+		return &RuleViolation{Rule: RuleMaxOrderValue, Detail: fmt.Sprintf("total %.2f exceeds the limit of %.2f", o.TotalPrice.Amount(), r.Max.Amount())}
+	}
+	return nil
+}
+
+// OrderRateRule caps how many orders a user may place within Window. Orders
+// that already exist were counted when they were placed and are not checked
+// again.
+type OrderRateRule struct {
+	Max    int
+	Window time.Duration
+	Orders OrderCounter
+}
+
+func (r OrderRateRule) Check(ctx context.Context, o *Order) error {
+	if o.ID != 0 {
+		return nil
+	}
+	count, err := r.Orders.CountByUserSince(ctx, o.UserID, time.Now().Add(-r.Window))
+	if err != nil {
+		return err
+	}
+	if count >= r.Max {
+		return &RuleViolation{Rule: RuleMaxOrdersPerHr, Detail: fmt.Sprintf("user %d placed %d orders in the last %s", o.UserID, count, r.Window)}
+	}
+	return nil
+}
+
+// OrderCounter is the part of OrderRepository the rate rule needs
+type OrderCounter interface {
+	CountByUserSince(ctx context.Context, userID int64, since time.Time) (int, error)
+}
@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/valueobject"
+)
+
+type countFunc func(userID int64, since time.Time) (int, error)
+
+func (f countFunc) CountByUserSince(_ context.Context, userID int64, since time.Time) (int, error) {
+	return f(userID, since)
+}
+
+func TestOrderRules(t *testing.T) {
+	ctx := context.Background()
+	newOrder := func(userID int64, qty int, price float64) *Order {
+		return &Order{
+			UserID:     userID,
+			Items:      []OrderItem{{ProductID: 1, Quantity: qty}},
+			TotalPrice: valueobject.NewMoney(price),
+		}
+	}
+	noOrders := countFunc(func(int64, time.Time) (int, error) { return 0, nil })
+
+	t.Run("NoLimits_AcceptsAnything", func(t *testing.T) {
+		rules := OrderLimits{}.Rules(noOrders)
+
+		assert.Empty(t, rules)
+		assert.NoError(t, rules.Check(ctx, newOrder(1, 1000, 1e6)))
+	})
+
+	t.Run("BlockedUser", func(t *testing.T) {
+		rules := OrderLimits{BlockedUsers: []int64{7}}.Rules(noOrders)
+
+		err := rules.Check(ctx, newOrder(7, 1, 10))
+
+		assert.True(t, errors.Is(err, ErrOrderRejected))
+		assert.True(t, errors.Is(err, pkgerrors.ErrUnprocessable))
+		assert.Equal(t, RuleUserBlocked, ViolatedRule(err))
+		assert.NoError(t, rules.Check(ctx, newOrder(8, 1, 10)))
+	})
+
+	t.Run("MaxQuantity_CountsAllLines", func(t *testing.T) {
+		rules := OrderLimits{MaxQuantity: 5}.Rules(noOrders)
+		o := newOrder(1, 3, 10)
+		o.Items = append(o.Items, OrderItem{ProductID: 2, Quantity: 3})
+
+		assert.Equal(t, RuleMaxQuantity, ViolatedRule(rules.Check(ctx, o)))
+		assert.NoError(t, rules.Check(ctx, newOrder(1, 5, 10)))
+	})
+
+	t.Run("MaxOrderValue", func(t *testing.T) {
+		rules := OrderLimits{MaxOrderValue: valueobject.NewMoney(500)}.Rules(noOrders)
+
+		assert.Equal(t, RuleMaxOrderValue, ViolatedRule(rules.Check(ctx, newOrder(1, 1, 500.01))))
+		assert.NoError(t, rules.Check(ctx, newOrder(1, 1, 500)))
+	})
+
+	t.Run("MaxOrdersPerHour", func(t *testing.T) {
+		var since time.Time
+		counter := countFunc(func(userID int64, s time.Time) (int, error) {
+			since = s
+			return 3, nil
+		})
+		rules := OrderLimits{MaxOrdersPerHour: 3}.Rules(counter)
+
+		err := rules.Check(ctx, newOrder(1, 1, 10))
+
+		assert.Equal(t, RuleMaxOrdersPerHr, ViolatedRule(err))
+		assert.WithinDuration(t, time.Now().Add(-time.Hour), since, time.Second)
+	})
+
+	t.Run("MaxOrdersPerHour_SkipsExistingOrders", func(t *testing.T) {
+		counter := countFunc(func(int64, time.Time) (int, error) {
+			t.Fatal("existing orders must not be counted again")
+			return 0, nil
+		})
+		o := newOrder(1, 1, 10)
+		o.ID = 9
+
+		assert.NoError(t, OrderLimits{MaxOrdersPerHour: 1}.Rules(counter).Check(ctx, o))
+	})
+
+	t.Run("CounterError_IsNotARejection", func(t *testing.T) {
+		counter := countFunc(func(int64, time.Time) (int, error) { return 0, pkgerrors.ErrInternal })
+
+		err := OrderLimits{MaxOrdersPerHour: 1}.Rules(counter).Check(ctx, newOrder(1, 1, 10))
+
+		assert.ErrorIs(t, err, pkgerrors.ErrInternal)
+		assert.Equal(t, "", ViolatedRule(err))
+	})
+}
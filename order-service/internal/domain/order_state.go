@@ -0,0 +1,150 @@
+package domain
+
+import (
+	"fmt"
+
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+)
+
+// ErrInvalidTransition is returned when an action is not allowed from the
+// order's current state. It wraps ErrConflict so it maps to a 409.
+var ErrInvalidTransition = fmt.Errorf("invalid order transition: %w", pkgerrors.ErrConflict)
+
+type OrderAction string
+
+const (
+	ActionPay           OrderAction = "pay"
+	ActionAmend         OrderAction = "amend"
+	ActionShip          OrderAction = "ship"
+	ActionDeliver       OrderAction = "deliver"
+	ActionComplete      OrderAction = "complete"
+	ActionCancel        OrderAction = "cancel"
+	ActionPartialRefund OrderAction = "partial_refund"
+	ActionRefund        OrderAction = "refund"
+	// ActionFailPayment is taken by the system when a payment attempt fails
+	// and is not listed among the available actions
+	ActionFailPayment OrderAction = "fail_payment"
+	// ActionAllocate is taken by the system when stock arrives for a
+	// backordered order or a pre-ordered product is released
+	ActionAllocate OrderAction = "allocate"
+)
+
+// actionOrder fixes the order in which available actions are listed
+var actionOrder = []OrderAction{ActionPay, ActionAmend, ActionShip, ActionDeliver, ActionComplete, ActionCancel, ActionPartialRefund, ActionRefund}
+
+// OrderState is the combined fulfillment and payment state of an order
+type OrderState struct {
+	Order   OrderStatus
+	Payment PaymentStatus
+}
+
+// transitions is the single source of truth for which actions are legal from
+// each state and where they lead. Anything not listed is rejected.
+//
+// Backordered and pre-ordered orders become ordinary pending orders once
+// their stock is allocated and can only be cancelled until then. Only an
+// unpaid order can be amended, and a failed payment leaves it unpaid. A paid
+// order is fulfilled by shipping and then delivering it, and only a
+// delivered order can be completed. Cancelling is only possible before the
+// order ships and refunds a paid order in full. A full refund without
+// cancelling is only possible once the order is completed.
+var transitions = map[OrderState]map[OrderAction]OrderState{
+	{OrderBackordered, PaymentPending}: {
+		ActionAllocate: {OrderPending, PaymentPending},
+		ActionCancel:   {OrderCancelled, PaymentPending},
+	},
+	{OrderPreorder, PaymentPending}: {
+		ActionAllocate: {OrderPending, PaymentPending},
+		ActionCancel:   {OrderCancelled, PaymentPending},
+	},
+	{OrderPending, PaymentPending}: {
+		ActionPay:         {OrderPending, PaymentPaid},
+		ActionFailPayment: {OrderPending, PaymentFailed},
+		ActionAmend:       {OrderPending, PaymentPending},
+		ActionCancel:      {OrderCancelled, PaymentPending},
+	},
+	{OrderPending, PaymentFailed}: {
+		ActionPay:         {OrderPending, PaymentPaid},
+		ActionFailPayment: {OrderPending, PaymentFailed},
+		ActionAmend:       {OrderPending, PaymentFailed},
+		ActionCancel:      {OrderCancelled, PaymentFailed},
+	},
+	{OrderPending, PaymentPaid}: {
+		ActionShip:          {OrderShipped, PaymentPaid},
+		ActionCancel:        {OrderCancelled, PaymentRefunded},
+		ActionPartialRefund: {OrderPending, PaymentPartiallyRefunded},
+	},
+	{OrderPending, PaymentPartiallyRefunded}: {
+		ActionShip:          {OrderShipped, PaymentPartiallyRefunded},
+		ActionCancel:        {OrderCancelled, PaymentRefunded},
+		ActionPartialRefund: {OrderPending, PaymentPartiallyRefunded},
+	},
+	{OrderShipped, PaymentPaid}: {
+		ActionDeliver:       {OrderDelivered, PaymentPaid},
+		ActionPartialRefund: {OrderShipped, PaymentPartiallyRefunded},
+		ActionRefund:        {OrderShipped, PaymentRefunded},
+	},
+	{OrderShipped, PaymentPartiallyRefunded}: {
+		ActionDeliver:       {OrderDelivered, PaymentPartiallyRefunded},
+		ActionPartialRefund: {OrderShipped, PaymentPartiallyRefunded},
+		ActionRefund:        {OrderShipped, PaymentRefunded},
+	},
+	// A refunded order that is on its way still completes its delivery
+	{OrderShipped, PaymentRefunded}: {
+		ActionDeliver: {OrderDelivered, PaymentRefunded},
+	},
+	{OrderDelivered, PaymentPaid}: {
+		ActionComplete:      {OrderCompleted, PaymentPaid},
+		ActionPartialRefund: {OrderDelivered, PaymentPartiallyRefunded},
+		ActionRefund:        {OrderDelivered, PaymentRefunded},
+	},
+	{OrderDelivered, PaymentPartiallyRefunded}: {
+		ActionComplete:      {OrderCompleted, PaymentPartiallyRefunded},
+		ActionPartialRefund: {OrderDelivered, PaymentPartiallyRefunded},
+		ActionRefund:        {OrderDelivered, PaymentRefunded},
+	},
+	{OrderDelivered, PaymentRefunded}: {
+		ActionComplete: {OrderCompleted, PaymentRefunded},
+	},
+	{OrderCompleted, PaymentPaid}: {
+		ActionPartialRefund: {OrderCompleted, PaymentPartiallyRefunded},
+		ActionRefund:        {OrderCompleted, PaymentRefunded},
+	},
+	{OrderCompleted, PaymentPartiallyRefunded}: {
+		ActionPartialRefund: {OrderCompleted, PaymentPartiallyRefunded},
+		ActionRefund:        {OrderCompleted, PaymentRefunded},
+	},
+}
+
+// State returns the order's current position in the state machine
+func (o *Order) State() OrderState {
+	return OrderState{Order: o.OrderStatus, Payment: o.PaymentStatus}
+}
+
+// CanApply reports whether the action is legal from the current state
+func (o *Order) CanApply(action OrderAction) bool {
+	_, ok := transitions[o.State()][action]
+	return ok
+}
+
+// AvailableActions lists the actions that are legal from the current state
+func (o *Order) AvailableActions() []OrderAction {
+	actions := []OrderAction{}
+	for _, action := range actionOrder {
+		if o.CanApply(action) {
+			actions = append(actions, action)
+		}
+	}
+	return actions
+}
+
+// apply moves the order to the next state for the given action
+func (o *Order) apply(action OrderAction) error {
+	next, ok := transitions[o.State()][action]
+	if !ok {
+		return fmt.Errorf("cannot %s order in state %s/%s: %w", action, o.OrderStatus, o.PaymentStatus, ErrInvalidTransition)
+	}
+	o.OrderStatus = next.Order
+	o.PaymentStatus = next.Payment
+	return nil
+}
@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+)
+
+func TestOrder_StateMachine(t *testing.T) {
+	tests := []struct {
+		name     string
+		state    OrderState
+		expected []OrderAction
+	}{
+		{"PendingUnpaid", OrderState{OrderPending, PaymentPending}, []OrderAction{ActionPay, ActionAmend, ActionCancel}},
+		{"PendingPaymentFailed", OrderState{OrderPending, PaymentFailed}, []OrderAction{ActionPay, ActionAmend, ActionCancel}},
+		{"PendingPaid", OrderState{OrderPending, PaymentPaid}, []OrderAction{ActionShip, ActionCancel, ActionPartialRefund}},
+		{"Shipped", OrderState{OrderShipped, PaymentPaid}, []OrderAction{ActionDeliver, ActionPartialRefund, ActionRefund}},
+		{"ShippedRefunded", OrderState{OrderShipped, PaymentRefunded}, []OrderAction{ActionDeliver}},
+		{"Delivered", OrderState{OrderDelivered, PaymentPaid}, []OrderAction{ActionComplete, ActionPartialRefund, ActionRefund}},
+		{"DeliveredRefunded", OrderState{OrderDelivered, PaymentRefunded}, []OrderAction{ActionComplete}},
+		{"Completed", OrderState{OrderCompleted, PaymentPaid}, []OrderAction{ActionPartialRefund, ActionRefund}},
+		{"CompletedRefunded", OrderState{OrderCompleted, PaymentRefunded}, []OrderAction{}},
+		{"Cancelled", OrderState{OrderCancelled, PaymentPending}, []OrderAction{}},
+		{"Backordered", OrderState{OrderBackordered, PaymentPending}, []OrderAction{ActionCancel}},
+		{"Preorder", OrderState{OrderPreorder, PaymentPending}, []OrderAction{ActionCancel}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &Order{OrderStatus: tt.state.Order, PaymentStatus: tt.state.Payment}
+			assert.Equal(t, tt.expected, o.AvailableActions())
+		})
+	}
+}
+
+func TestOrder_InvalidTransition(t *testing.T) {
+	o := &Order{OrderStatus: OrderCompleted, PaymentStatus: PaymentPaid}
+
+	_, err := o.Cancel()
+
+	assert.True(t, errors.Is(err, ErrInvalidTransition))
+	assert.True(t, errors.Is(err, pkgerrors.ErrConflict))
+	assert.Equal(t, http.StatusConflict, pkgerrors.GetStatusCode(err))
+	assert.Equal(t, OrderCompleted, o.OrderStatus)
+}
+
+func TestOrder_FailPayment(t *testing.T) {
+	o := &Order{OrderStatus: OrderPending, PaymentStatus: PaymentPending}
+
+	assert.NoError(t, o.FailPayment())
+	assert.Equal(t, PaymentFailed, o.PaymentStatus)
+	assert.Equal(t, OrderPending, o.OrderStatus)
+
+	assert.NoError(t, o.RecordPayment("auth_1"))
+	assert.Equal(t, PaymentPaid, o.PaymentStatus)
+	assert.Equal(t, "auth_1", o.PaymentReference)
+
+	assert.ErrorIs(t, o.FailPayment(), ErrInvalidTransition)
+}
+
+func TestOrder_Backorder(t *testing.T) {
+	o := &Order{OrderStatus: OrderPending, PaymentStatus: PaymentPending}
+
+	assert.NoError(t, o.Backorder())
+	assert.Equal(t, OrderBackordered, o.OrderStatus)
+	assert.False(t, o.HoldsStock())
+	assert.ErrorIs(t, o.RecordPayment("auth_1"), ErrInvalidTransition)
+
+	now := time.Now()
+	assert.NoError(t, o.Allocate(now))
+	assert.Equal(t, OrderPending, o.OrderStatus)
+	assert.Equal(t, &now, o.AllocatedAt)
+	assert.True(t, o.HoldsStock())
+
+	assert.ErrorIs(t, o.Allocate(now), ErrInvalidTransition)
+	o.ID = 1
+	assert.ErrorIs(t, o.Backorder(), ErrInvalidTransition)
+}
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/user/go-microservices/pkg/valueobject"
@@ -10,41 +11,114 @@ import (
 func TestOrder_Aggregate(t *testing.T) {
 	price := valueobject.NewMoney(100)
 
+	newItem := func(productID int64, qty int) OrderItem {
+		item, err := NewOrderItem(productID, "Test", price, qty)
+		assert.NoError(t, err)
+		return item
+	}
+
 	t.Run("NewOrder_Valid", func(t *testing.T) {
-		order, err := NewOrder(1, 1, "Test", price, 2)
+		order, err := NewOrder(1, []OrderItem{newItem(1, 2)}, "", &TaxTable{})
 		assert.NoError(t, err)
 		assert.Equal(t, valueobject.NewMoney(200), order.TotalPrice)
 		assert.Equal(t, OrderPending, order.OrderStatus)
 	})
 
-	t.Run("NewOrder_InvalidQty", func(t *testing.T) {
-		order, err := NewOrder(1, 1, "Test", price, 0)
+	t.Run("NewOrder_MultipleItems", func(t *testing.T) {
+		order, err := NewOrder(1, []OrderItem{newItem(1, 2), newItem(2, 3)}, "", &TaxTable{})
+		assert.NoError(t, err)
+		assert.Len(t, order.Items, 2)
+		assert.Equal(t, valueobject.NewMoney(500), order.TotalPrice)
+	})
+
+	t.Run("NewOrderItem_InvalidQty", func(t *testing.T) {
+		_, err := NewOrderItem(1, "Test", price, 0)
+		assert.Error(t, err)
+	})
+
+	t.Run("NewOrder_NoItems", func(t *testing.T) {
+		order, err := NewOrder(1, nil, "", &TaxTable{})
+		assert.Error(t, err)
+		assert.Nil(t, order)
+	})
+
+	t.Run("NewOrder_DuplicateProduct", func(t *testing.T) {
+		order, err := NewOrder(1, []OrderItem{newItem(1, 1), newItem(1, 2)}, "", &TaxTable{})
 		assert.Error(t, err)
 		assert.Nil(t, order)
 	})
 
 	t.Run("StateTransitions", func(t *testing.T) {
-		order, _ := NewOrder(1, 1, "Test", price, 1)
+		order, _ := NewOrder(1, []OrderItem{newItem(1, 1)}, "", &TaxTable{})
 
 		// Pay
 		err := order.Pay()
 		assert.NoError(t, err)
 		assert.Equal(t, PaymentPaid, order.PaymentStatus)
 
+		// Complete before delivery (Fail)
+		err = order.Complete()
+		assert.Error(t, err)
+
+		// Ship
+		shipment, err := order.Ship("UPS", "1Z999", time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, OrderShipped, order.OrderStatus)
+
+		// Cancel after ship (Fail)
+		_, err = order.Cancel()
+		assert.Error(t, err)
+
+		// Deliver
+		err = order.Deliver(shipment, time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, OrderDelivered, order.OrderStatus)
+		assert.NotNil(t, shipment.DeliveredAt)
+
 		// Complete
 		err = order.Complete()
 		assert.NoError(t, err)
 		assert.Equal(t, OrderCompleted, order.OrderStatus)
 
 		// Cancel after complete (Fail)
-		err = order.Cancel()
+		_, err = order.Cancel()
 		assert.Error(t, err)
 	})
 
+	t.Run("AmendQuantities_Success", func(t *testing.T) {
+		order, _ := NewOrder(1, []OrderItem{newItem(1, 2), newItem(2, 1)}, "", &TaxTable{})
+
+		changes, err := order.AmendQuantities(map[int64]int{1: 1, 2: 3})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []QuantityChange{{ProductID: 1, Delta: -1}, {ProductID: 2, Delta: 2}}, changes)
+		assert.Equal(t, valueobject.NewMoney(400), order.TotalPrice)
+		assert.Equal(t, valueobject.NewMoney(300), order.Items[1].LineTotal)
+	})
+
+	t.Run("AmendQuantities_UnknownProduct", func(t *testing.T) {
+		order, _ := NewOrder(1, []OrderItem{newItem(1, 2)}, "", &TaxTable{})
+
+		_, err := order.AmendQuantities(map[int64]int{1: 1, 9: 1})
+
+		assert.Error(t, err)
+		assert.Equal(t, 2, order.Items[0].Quantity)
+	})
+
+	t.Run("AmendQuantities_Paid", func(t *testing.T) {
+		order, _ := NewOrder(1, []OrderItem{newItem(1, 2)}, "", &TaxTable{})
+		order.Pay()
+
+		_, err := order.AmendQuantities(map[int64]int{1: 1})
+
+		assert.ErrorIs(t, err, ErrInvalidTransition)
+	})
+
 	t.Run("Cancel_Valid", func(t *testing.T) {
-		order, _ := NewOrder(1, 1, "Test", price, 1)
-		err := order.Cancel()
+		order, _ := NewOrder(1, []OrderItem{newItem(1, 1)}, "", &TaxTable{})
+		refund, err := order.Cancel()
 		assert.NoError(t, err)
+		assert.Nil(t, refund)
 		assert.Equal(t, OrderCancelled, order.OrderStatus)
 
 		// Pay after cancel (Fail)
@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/valueobject"
+)
+
+// ErrPaymentDeclined is returned when the payment provider refuses a charge.
+// It wraps ErrUnprocessable so it maps to a 422.
+var ErrPaymentDeclined = fmt.Errorf("payment declined: %w", pkgerrors.ErrUnprocessable)
+
+// ErrPaymentUnavailable is returned when the payment provider cannot be
+// reached or does not answer in time. It wraps ErrUnavailable so it maps to a 503.
+var ErrPaymentUnavailable = fmt.Errorf("payment gateway unavailable: %w", pkgerrors.ErrUnavailable)
+
+// PaymentGateway is the port to a payment service provider. Amounts are
+// authorized first and captured separately; the reference returned by
+// Authorize identifies the payment in every later call.
+//
+//go:generate mockery --name PaymentGateway
+type PaymentGateway interface {
+	// Authorize places a hold for amount on the payment method identified by
+	// token. It fails with ErrPaymentDeclined or ErrPaymentUnavailable.
+	Authorize(ctx context.Context, orderID int64, amount valueobject.Money, token string) (reference string, err error)
+	Capture(ctx context.Context, reference string, amount valueobject.Money) error
+	// Void releases an authorization that was not captured
+	Void(ctx context.Context, reference string) error
+	// Refund returns part or all of a captured amount. A refund repeated
+	// under the same key is only made once.
+	Refund(ctx context.Context, key, reference string, amount valueobject.Money) error
+}
+
+// FailPayment records a declined or failed payment attempt. The order stays
+// pending and can be paid again.
+func (o *Order) FailPayment() error {
+	return o.apply(ActionFailPayment)
+}
+
+// RecordPayment marks the order paid by the captured payment identified by reference
+func (o *Order) RecordPayment(reference string) error {
+	if err := o.Pay(); err != nil {
+		return err
+	}
+	o.PaymentReference = reference
+	return nil
+}
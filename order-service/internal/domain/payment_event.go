@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"fmt"
+
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/valueobject"
+)
+
+// PaymentEventType is the outcome a payment provider reports asynchronously
+type PaymentEventType string
+
+const (
+	PaymentEventSucceeded PaymentEventType = "payment.succeeded"
+	PaymentEventFailed    PaymentEventType = "payment.failed"
+)
+
+// PaymentProviderActor is recorded in the status history for changes made by
+// provider notifications
+const PaymentProviderActor = "payment-provider"
+
+// ErrDuplicatePaymentEvent is returned when an event ID was already processed
+var ErrDuplicatePaymentEvent = fmt.Errorf("payment event already processed: %w", pkgerrors.ErrConflict)
+
+// ErrPaymentAmountMismatch is returned when a confirmed payment does not
+// cover the order total
+var ErrPaymentAmountMismatch = fmt.Errorf("payment amount does not match order total: %w", pkgerrors.ErrUnprocessable)
+
+// PaymentEvent is a notification from the payment provider about one order's
+// payment. ID is unique per event and is stored so redeliveries are ignored.
+type PaymentEvent struct {
+	ID        string            `json:"id"`
+	Type      PaymentEventType  `json:"type"`
+	OrderID   int64             `json:"order_id"`
+	Reference string            `json:"reference"`
+	Amount    valueobject.Money `json:"amount"`
+	// Reason explains a failed payment
+	Reason string `json:"reason,omitempty"`
+}
+
+func (e *PaymentEvent) Validate() error {
+	if e.ID == "" || e.OrderID == 0 {
+		return fmt.Errorf("payment event needs an id and an order_id: %w", pkgerrors.ErrInvalidInput)
+	}
+	switch e.Type {
+	case PaymentEventSucceeded:
+		if e.Reference == "" {
+			return fmt.Errorf("payment.succeeded event needs a reference: %w", pkgerrors.ErrInvalidInput)
+		}
+	case PaymentEventFailed:
+	default:
+		return fmt.Errorf("unknown payment event type %q: %w", e.Type, pkgerrors.ErrInvalidInput)
+	}
+	return nil
+}
+
+// Apply moves o to the state the event reports. It returns false when the
+// order already reflects the event, or when a failure arrives for an order
+// that has since been paid or closed; such events change nothing.
+func (e *PaymentEvent) Apply(o *Order) (bool, error) {
+	if e.Type == PaymentEventFailed {
+		if !o.CanApply(ActionFailPayment) {
+			return false, nil
+		}
+		return true, o.FailPayment()
+	}
+
+	if o.PaymentReference == e.Reference {
+		return false, nil
+	}
+	if e.Amount != o.TotalPrice {
+		return false, fmt.Errorf("order %d: paid %.2f, total %.2f: %w", o.ID, e.Amount.Amount(), o.TotalPrice.Amount(), ErrPaymentAmountMismatch)
+	}
+	if err := o.RecordPayment(e.Reference); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Describe is the status history reason for a change made by the event
+func (e *PaymentEvent) Describe() string {
+	if e.Type == PaymentEventFailed {
+		if e.Reason != "" {
+			return fmt.Sprintf("payment failed: %s (event %s)", e.Reason, e.ID)
+		}
+		return fmt.Sprintf("payment failed (event %s)", e.ID)
+	}
+	return fmt.Sprintf("payment confirmed (event %s)", e.ID)
+}
@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/valueobject"
+)
+
+func TestPaymentEvent_Apply(t *testing.T) {
+	total := valueobject.NewMoney(200)
+	succeeded := &PaymentEvent{ID: "evt_1", Type: PaymentEventSucceeded, OrderID: 1, Reference: "auth_1", Amount: total}
+	failed := &PaymentEvent{ID: "evt_2", Type: PaymentEventFailed, OrderID: 1, Reason: "insufficient funds"}
+
+	t.Run("Succeeded_PaysOrder", func(t *testing.T) {
+		o := &Order{ID: 1, OrderStatus: OrderPending, PaymentStatus: PaymentPending, TotalPrice: total}
+
+		changed, err := succeeded.Apply(o)
+
+		assert.NoError(t, err)
+		assert.True(t, changed)
+		assert.Equal(t, PaymentPaid, o.PaymentStatus)
+		assert.Equal(t, "auth_1", o.PaymentReference)
+	})
+
+	t.Run("Succeeded_AlreadyRecorded", func(t *testing.T) {
+		o := &Order{ID: 1, OrderStatus: OrderPending, PaymentStatus: PaymentPaid, TotalPrice: total, PaymentReference: "auth_1"}
+
+		changed, err := succeeded.Apply(o)
+
+		assert.NoError(t, err)
+		assert.False(t, changed)
+	})
+
+	t.Run("Succeeded_AmountMismatch", func(t *testing.T) {
+		o := &Order{ID: 1, OrderStatus: OrderPending, PaymentStatus: PaymentPending, TotalPrice: valueobject.NewMoney(250)}
+
+		_, err := succeeded.Apply(o)
+
+		assert.ErrorIs(t, err, pkgerrors.ErrUnprocessable)
+		assert.Equal(t, PaymentPending, o.PaymentStatus)
+	})
+
+	t.Run("Succeeded_CancelledOrder", func(t *testing.T) {
+		o := &Order{ID: 1, OrderStatus: OrderCancelled, PaymentStatus: PaymentPending, TotalPrice: total}
+
+		_, err := succeeded.Apply(o)
+
+		assert.ErrorIs(t, err, ErrInvalidTransition)
+	})
+
+	t.Run("Failed_MarksPaymentFailed", func(t *testing.T) {
+		o := &Order{ID: 1, OrderStatus: OrderPending, PaymentStatus: PaymentPending}
+
+		changed, err := failed.Apply(o)
+
+		assert.NoError(t, err)
+		assert.True(t, changed)
+		assert.Equal(t, PaymentFailed, o.PaymentStatus)
+	})
+
+	t.Run("Failed_AfterPaymentIgnored", func(t *testing.T) {
+		o := &Order{ID: 1, OrderStatus: OrderPending, PaymentStatus: PaymentPaid}
+
+		changed, err := failed.Apply(o)
+
+		assert.NoError(t, err)
+		assert.False(t, changed)
+		assert.Equal(t, PaymentPaid, o.PaymentStatus)
+	})
+}
+
+func TestPaymentEvent_Validate(t *testing.T) {
+	assert.ErrorIs(t, (&PaymentEvent{ID: "evt_1", OrderID: 1, Type: "payment.refunded"}).Validate(), pkgerrors.ErrInvalidInput)
+	assert.ErrorIs(t, (&PaymentEvent{ID: "evt_1", OrderID: 1, Type: PaymentEventSucceeded}).Validate(), pkgerrors.ErrInvalidInput)
+	assert.NoError(t, (&PaymentEvent{ID: "evt_1", OrderID: 1, Type: PaymentEventFailed}).Validate())
+}
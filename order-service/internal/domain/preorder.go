@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+)
+
+// ErrMixedPreorder is returned for an order that combines released products
+// with products that can only be pre-ordered
+var ErrMixedPreorder = fmt.Errorf("released and pre-order products must be ordered separately: %w", pkgerrors.ErrInvalidInput)
+
+// unreleased reports whether the line's product is not on sale yet at now
+func (i OrderItem) unreleased(now time.Time) bool {
+	return i.ReleaseAt != nil && now.Before(*i.ReleaseAt)
+}
+
+// SchedulePreorder turns a new order for products that are not released at
+// now into a pre-order, released when the last of its products is. An order
+// for released products is left as it is. It must be called before the
+// order is stored.
+func (o *Order) SchedulePreorder(now time.Time) error {
+	var releaseAt *time.Time
+	unreleased := 0
+	for _, item := range o.Items {
+		if !item.unreleased(now) {
+			continue
+		}
+		unreleased++
+		if releaseAt == nil || item.ReleaseAt.After(*releaseAt) {
+			releaseAt = item.ReleaseAt
+		}
+	}
+	if unreleased == 0 {
+		return nil
+	}
+	if unreleased < len(o.Items) {
+		return ErrMixedPreorder
+	}
+	if o.ID != 0 || o.State() != (OrderState{OrderPending, PaymentPending}) {
+		return fmt.Errorf("only a new order can be a pre-order: %w", ErrInvalidTransition)
+	}
+	o.OrderStatus = OrderPreorder
+	o.ReleaseAt = releaseAt
+	return nil
+}
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/valueobject"
+)
+
+func TestOrder_SchedulePreorder(t *testing.T) {
+	now := time.Now()
+	soon, later := now.Add(24*time.Hour), now.Add(48*time.Hour)
+	past := now.Add(-time.Hour)
+
+	newOrder := func(releases ...*time.Time) *Order {
+		items := make([]OrderItem, len(releases))
+		for i, release := range releases {
+			item, err := NewOrderItem(int64(i+1), "Product", valueobject.NewMoney(10), 1)
+			assert.NoError(t, err)
+			item.ReleaseAt = release
+			items[i] = item
+		}
+		o, err := NewOrder(1, items, "", &TaxTable{})
+		assert.NoError(t, err)
+		return o
+	}
+
+	t.Run("AllUnreleased_ReleasedWithLastProduct", func(t *testing.T) {
+		o := newOrder(&later, &soon)
+
+		assert.NoError(t, o.SchedulePreorder(now))
+		assert.Equal(t, OrderPreorder, o.OrderStatus)
+		assert.Equal(t, &later, o.ReleaseAt)
+		assert.False(t, o.HoldsStock())
+	})
+
+	t.Run("Released_Unchanged", func(t *testing.T) {
+		o := newOrder(nil, &past)
+
+		assert.NoError(t, o.SchedulePreorder(now))
+		assert.Equal(t, OrderPending, o.OrderStatus)
+		assert.Nil(t, o.ReleaseAt)
+	})
+
+	t.Run("Mixed_Rejected", func(t *testing.T) {
+		o := newOrder(nil, &soon)
+
+		err := o.SchedulePreorder(now)
+
+		assert.ErrorIs(t, err, ErrMixedPreorder)
+		assert.ErrorIs(t, err, pkgerrors.ErrInvalidInput)
+	})
+
+	t.Run("AllocatedAtRelease", func(t *testing.T) {
+		o := newOrder(&soon)
+		assert.NoError(t, o.SchedulePreorder(now))
+
+		assert.NoError(t, o.Allocate(soon))
+		assert.Equal(t, OrderPending, o.OrderStatus)
+		assert.True(t, o.HoldsStock())
+	})
+}
@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/valueobject"
+)
+
+// ErrQuoteExpired is returned when an order is placed against a quote whose
+// price lock has run out
+var ErrQuoteExpired = fmt.Errorf("quote expired: %w", pkgerrors.ErrConflict)
+
+// Quote locks the prices of a set of items for a short window. Lines keep the
+// same snapshots an order does, so an order placed from the quote is priced
+// exactly as quoted. A quote may be ordered more than once until it expires.
+type Quote struct {
+	ID           int64             `json:"id"`
+	UserID       int64             `json:"user_id"`
+	Region       string            `json:"region"`
+	Items        []OrderItem       `json:"items"`
+	ShippingRate *ShippingRate     `json:"shipping_rate,omitempty"`
+	Subtotal     valueobject.Money `json:"subtotal"`
+	Tax          valueobject.Money `json:"tax"`
+	Shipping     valueobject.Money `json:"shipping"`
+	TotalPrice   valueobject.Money `json:"total_price"`
+	ExpiresAt    time.Time         `json:"expires_at"`
+	CreatedAt    time.Time         `json:"created_at"`
+}
+
+// NewQuote locks the prices of a freshly priced order until now + ttl
+func NewQuote(o *Order, ttl time.Duration, now time.Time) (*Quote, error) {
+	if ttl <= 0 {
+		return nil, fmt.Errorf("quote ttl must be positive: %w", pkgerrors.ErrInvalidInput)
+	}
+	// A quoted order reserves stock when placed, which pre-order products
+	// do not have yet
+	for _, item := range o.Items {
+		if item.unreleased(now) {
+			return nil, fmt.Errorf("product %d is not released yet and cannot be quoted: %w", item.ProductID, pkgerrors.ErrInvalidInput)
+		}
+	}
+	items := make([]OrderItem, len(o.Items))
+	copy(items, o.Items)
+
+	return &Quote{
+		UserID:       o.UserID,
+		Region:       o.TaxRegion,
+		Items:        items,
+		ShippingRate: o.ShippingRate,
+		Subtotal:     o.Subtotal,
+		Tax:          o.Tax,
+		Shipping:     o.Shipping,
+		TotalPrice:   o.TotalPrice,
+		ExpiresAt:    now.Add(ttl),
+		CreatedAt:    now,
+	}, nil
+}
+
+// PlaceOrder builds a pending order for userID at the quote's locked unit
+// prices, tax rates and shipping rate
+func (q *Quote) PlaceOrder(userID int64, now time.Time) (*Order, error) {
+	if q.UserID != userID {
+		return nil, fmt.Errorf("quote %d: %w", q.ID, pkgerrors.ErrNotFound)
+	}
+	if !now.Before(q.ExpiresAt) {
+		return nil, fmt.Errorf("quote %d expired at %s: %w", q.ID, q.ExpiresAt.Format(time.RFC3339), ErrQuoteExpired)
+	}
+
+	items := make([]OrderItem, len(q.Items))
+	rates := make(lockedTaxRates, len(q.Items))
+	for i, item := range q.Items {
+		items[i] = OrderItem{
+			ProductID:   item.ProductID,
+			ProductName: item.ProductName,
+			UnitPrice:   item.UnitPrice,
+			Quantity:    item.Quantity,
+			LineTotal:   item.LineTotal,
+			TaxCategory: item.TaxCategory,
+			WeightGrams: item.WeightGrams,
+		}
+		rates[item.TaxCategory] = item.TaxRate
+	}
+
+	o, err := NewOrder(userID, items, q.Region, rates)
+	if err != nil {
+		return nil, err
+	}
+	o.ShippingRate = q.ShippingRate
+	o.reprice()
+	return o, nil
+}
+
+// lockedTaxRates replays the tax rates a quote was priced with, by category
+type lockedTaxRates map[string]float64
+
+func (r lockedTaxRates) Rate(_, category string) (float64, error) {
+	return r[category], nil
+}
+
+//go:generate mockery --name QuoteRepository
+type QuoteRepository interface {
+	Create(ctx context.Context, q *Quote) error
+	GetByID(ctx context.Context, id int64) (*Quote, error)
+}
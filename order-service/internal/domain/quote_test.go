@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/valueobject"
+)
+
+func newTestQuote(t *testing.T, now time.Time) *Quote {
+	item, err := NewOrderItem(1, "A", valueobject.NewMoney(100), 2)
+	assert.NoError(t, err)
+	item.TaxCategory = "STANDARD"
+	item.WeightGrams = 1500
+	taxes, err := NewTaxTable([]TaxRate{{Region: "US-CA", Category: "STANDARD", Rate: 10}})
+	assert.NoError(t, err)
+
+	o, err := NewOrder(101, []OrderItem{item}, "US-CA", taxes)
+	assert.NoError(t, err)
+	shipping, err := NewShippingRateTable([]ShippingRate{{Zone: "US", BaseFee: valueobject.NewMoney(5), PerKgFee: valueobject.NewMoney(2)}})
+	assert.NoError(t, err)
+	assert.NoError(t, o.SetShipping(shipping))
+
+	q, err := NewQuote(o, 15*time.Minute, now)
+	assert.NoError(t, err)
+	return q
+}
+
+func TestQuote(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("NewQuote_LocksPrices", func(t *testing.T) {
+		q := newTestQuote(t, now)
+
+		assert.Equal(t, now.Add(15*time.Minute), q.ExpiresAt)
+		assert.Equal(t, valueobject.NewMoney(200), q.Subtotal)
+		assert.Equal(t, valueobject.NewMoney(20), q.Tax)
+		assert.Equal(t, valueobject.NewMoney(11), q.Shipping)
+		assert.Equal(t, valueobject.NewMoney(231), q.TotalPrice)
+	})
+
+	t.Run("NewQuote_InvalidTTL", func(t *testing.T) {
+		_, err := NewQuote(&Order{}, 0, now)
+		assert.True(t, errors.Is(err, pkgerrors.ErrInvalidInput))
+	})
+
+	t.Run("NewQuote_UnreleasedProduct", func(t *testing.T) {
+		release := now.Add(24 * time.Hour)
+		item, err := NewOrderItem(1, "A", valueobject.NewMoney(100), 1)
+		assert.NoError(t, err)
+		item.ReleaseAt = &release
+		o, err := NewOrder(101, []OrderItem{item}, "", &TaxTable{})
+		assert.NoError(t, err)
+
+		_, err = NewQuote(o, 15*time.Minute, now)
+
+		assert.ErrorIs(t, err, pkgerrors.ErrInvalidInput)
+	})
+
+	t.Run("PlaceOrder_AtLockedPrices", func(t *testing.T) {
+		q := newTestQuote(t, now)
+
+		o, err := q.PlaceOrder(101, now.Add(10*time.Minute))
+
+		assert.NoError(t, err)
+		assert.Equal(t, OrderPending, o.OrderStatus)
+		assert.Equal(t, "US-CA", o.TaxRegion)
+		assert.Equal(t, 10.0, o.Items[0].TaxRate)
+		assert.Equal(t, q.Subtotal, o.Subtotal)
+		assert.Equal(t, q.Tax, o.Tax)
+		assert.Equal(t, q.Shipping, o.Shipping)
+		assert.Equal(t, q.TotalPrice, o.TotalPrice)
+	})
+
+	t.Run("PlaceOrder_Expired", func(t *testing.T) {
+		q := newTestQuote(t, now)
+
+		_, err := q.PlaceOrder(101, q.ExpiresAt)
+
+		assert.True(t, errors.Is(err, ErrQuoteExpired))
+		assert.True(t, errors.Is(err, pkgerrors.ErrConflict))
+	})
+
+	t.Run("PlaceOrder_OtherUser", func(t *testing.T) {
+		q := newTestQuote(t, now)
+
+		_, err := q.PlaceOrder(202, now)
+
+		assert.True(t, errors.Is(err, pkgerrors.ErrNotFound))
+	})
+}
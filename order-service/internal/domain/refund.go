@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/valueobject"
+)
+
+// ErrRefundExceedsTotal is returned when a refund would take the refunded
+// amount past the order total.
+var ErrRefundExceedsTotal = fmt.Errorf("refund exceeds refundable amount: %w", pkgerrors.ErrInvalidInput)
+
+type Refund struct {
+	ID        int64             `json:"id"`
+	OrderID   int64             `json:"order_id"`
+	Amount    valueobject.Money `json:"amount"`
+	Reason    string            `json:"reason"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// RefundableAmount is the part of the total that has been paid and not yet refunded
+func (o *Order) RefundableAmount() valueobject.Money {
+	switch o.PaymentStatus {
+	case PaymentPaid, PaymentPartiallyRefunded:
+		return o.TotalPrice.Subtract(o.RefundedAmount)
+	default:
+		return valueobject.NewMoney(0)
+	}
+}
+
+// Refund returns amount to the customer. Refunding the whole remaining
+// amount is a full refund; anything less is a partial refund. An order that
+// has not shipped is refunded in full by cancelling it, which also gives its
+// stock back, so a full refund of one is an invalid transition.
+func (o *Order) Refund(amount valueobject.Money, reason string) (*Refund, error) {
+	if amount.IsNegative() || amount.IsZero() {
+		return nil, fmt.Errorf("refund amount must be greater than zero: %w", pkgerrors.ErrInvalidInput)
+	}
+
+	remaining := o.RefundableAmount()
+	if amount.GreaterThan(remaining) {
+		return nil, ErrRefundExceedsTotal
+	}
+
+	action := ActionPartialRefund
+	if amount.Equal(remaining) {
+		action = ActionRefund
+	}
+	if err := o.apply(action); err != nil {
+		return nil, err
+	}
+	return o.recordRefund(amount, reason), nil
+}
+
+func (o *Order) recordRefund(amount valueobject.Money, reason string) *Refund {
+	o.RefundedAmount = o.RefundedAmount.Add(amount)
+	return &Refund{
+		OrderID:   o.ID,
+		Amount:    amount,
+		Reason:    reason,
+		CreatedAt: time.Now(),
+	}
+}
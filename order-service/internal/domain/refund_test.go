@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/valueobject"
+)
+
+func TestOrder_Refund(t *testing.T) {
+	paidOrder := func(status OrderStatus) *Order {
+		return &Order{
+			ID:            1,
+			TotalPrice:    valueobject.NewMoney(100),
+			OrderStatus:   status,
+			PaymentStatus: PaymentPaid,
+		}
+	}
+
+	t.Run("Partial_ThenFull", func(t *testing.T) {
+		o := paidOrder(OrderCompleted)
+
+		refund, err := o.Refund(valueobject.NewMoney(30), "damaged box")
+		assert.NoError(t, err)
+		assert.Equal(t, valueobject.NewMoney(30), refund.Amount)
+		assert.Equal(t, PaymentPartiallyRefunded, o.PaymentStatus)
+		assert.Equal(t, valueobject.NewMoney(70), o.RefundableAmount())
+
+		_, err = o.Refund(valueobject.NewMoney(70), "returned")
+		assert.NoError(t, err)
+		assert.Equal(t, PaymentRefunded, o.PaymentStatus)
+		assert.Equal(t, valueobject.NewMoney(100), o.RefundedAmount)
+	})
+
+	t.Run("ExceedsTotal", func(t *testing.T) {
+		o := paidOrder(OrderCompleted)
+
+		refund, err := o.Refund(valueobject.NewMoney(100.01), "too much")
+
+		assert.Nil(t, refund)
+		assert.True(t, errors.Is(err, ErrRefundExceedsTotal))
+		assert.True(t, errors.Is(err, pkgerrors.ErrInvalidInput))
+		assert.Equal(t, PaymentPaid, o.PaymentStatus)
+	})
+
+	t.Run("Unpaid", func(t *testing.T) {
+		o := &Order{TotalPrice: valueobject.NewMoney(100), OrderStatus: OrderPending, PaymentStatus: PaymentPending}
+
+		_, err := o.Refund(valueobject.NewMoney(10), "")
+
+		assert.Error(t, err)
+	})
+
+	t.Run("Full_FromShippedAndDelivered", func(t *testing.T) {
+		for _, status := range []OrderStatus{OrderShipped, OrderDelivered} {
+			o := paidOrder(status)
+
+			_, err := o.Refund(valueobject.NewMoney(100), "lost in transit")
+
+			assert.NoError(t, err)
+			assert.Equal(t, status, o.OrderStatus)
+			assert.Equal(t, PaymentRefunded, o.PaymentStatus)
+		}
+	})
+
+	t.Run("FullRemainder_ComparedToTheCent", func(t *testing.T) {
+		o := paidOrder(OrderDelivered)
+		o.TotalPrice = valueobject.NewMoney(0.3)
+		_, err := o.Refund(valueobject.NewMoney(0.1), "")
+		assert.NoError(t, err)
+
+		_, err = o.Refund(valueobject.NewMoney(0.2), "")
+
+		assert.NoError(t, err)
+		assert.Equal(t, PaymentRefunded, o.PaymentStatus)
+	})
+
+	t.Run("FullRefundOfPendingOrder_RequiresCancel", func(t *testing.T) {
+		o := paidOrder(OrderPending)
+
+		_, err := o.Refund(valueobject.NewMoney(100), "")
+
+		assert.True(t, errors.Is(err, ErrInvalidTransition))
+	})
+
+	t.Run("CancelPaid_RefundsRemainder", func(t *testing.T) {
+		o := paidOrder(OrderPending)
+		_, err := o.Refund(valueobject.NewMoney(25), "price match")
+		assert.NoError(t, err)
+
+		refund, err := o.Cancel()
+
+		assert.NoError(t, err)
+		assert.Equal(t, valueobject.NewMoney(75), refund.Amount)
+		assert.Equal(t, OrderCancelled, o.OrderStatus)
+		assert.Equal(t, PaymentRefunded, o.PaymentStatus)
+		assert.Equal(t, valueobject.NewMoney(100), o.RefundedAmount)
+	})
+}
@@ -0,0 +1,178 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/valueobject"
+)
+
+// ErrReturnExceedsOrdered is returned when a return asks for more units of a
+// product than were ordered and not already returned.
+var ErrReturnExceedsOrdered = fmt.Errorf("return exceeds returnable quantity: %w", pkgerrors.ErrInvalidInput)
+
+// ErrInvalidReturnTransition wraps ErrConflict so it maps to a 409.
+var ErrInvalidReturnTransition = fmt.Errorf("invalid return transition: %w", pkgerrors.ErrConflict)
+
+type ReturnStatus string
+
+const (
+	ReturnRequested ReturnStatus = "REQUESTED"
+	ReturnApproved  ReturnStatus = "APPROVED"
+	ReturnRejected  ReturnStatus = "REJECTED"
+	ReturnReceived  ReturnStatus = "RECEIVED"
+)
+
+type ReturnItem struct {
+	ID        int64             `json:"id"`
+	ReturnID  int64             `json:"return_id"`
+	ProductID int64             `json:"product_id"`
+	Quantity  int               `json:"quantity"`
+	UnitPrice valueobject.Money `json:"unit_price"`
+	Amount    valueobject.Money `json:"amount"`
+}
+
+// Return is a return merchandise authorization for units of a completed order
+type Return struct {
+	ID        int64             `json:"id"`
+	OrderID   int64             `json:"order_id"`
+	Status    ReturnStatus      `json:"status"`
+	Reason    string            `json:"reason"`
+	Note      string            `json:"note,omitempty"`
+	Items     []ReturnItem      `json:"items"`
+	Amount    valueobject.Money `json:"amount"`
+	RefundID  *int64            `json:"refund_id,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// RequestReturn opens a return for the given product quantities. Only
+// ProductID and Quantity of each line are read; amounts come from the order's
+// UnitPrice and TaxRate snapshots, less the line's share of the order
+// discount. Units covered by earlier returns that were not rejected cannot be
+// returned again.
+func (o *Order) RequestReturn(lines []ReturnItem, previous []*Return, reason string) (*Return, error) {
+	if o.OrderStatus != OrderCompleted {
+		return nil, fmt.Errorf("cannot return items of order in state %s: %w", o.OrderStatus, ErrInvalidReturnTransition)
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("return must contain at least one item: %w", pkgerrors.ErrInvalidInput)
+	}
+
+	returned := map[int64]int{}
+	for _, r := range previous {
+		if r.Status == ReturnRejected {
+			continue
+		}
+		for _, item := range r.Items {
+			returned[item.ProductID] += item.Quantity
+		}
+	}
+
+	requested := map[int64]int{}
+	for _, line := range lines {
+		if line.Quantity <= 0 {
+			return nil, fmt.Errorf("quantity must be greater than zero: %w", pkgerrors.ErrInvalidInput)
+		}
+		if _, dup := requested[line.ProductID]; dup {
+			return nil, fmt.Errorf("duplicate product %d in return: %w", line.ProductID, pkgerrors.ErrInvalidInput)
+		}
+		requested[line.ProductID] = line.Quantity
+	}
+
+	ret := &Return{
+		OrderID:   o.ID,
+		Status:    ReturnRequested,
+		Reason:    reason,
+		Amount:    valueobject.NewMoney(0),
+		CreatedAt: time.Now(),
+	}
+	ret.UpdatedAt = ret.CreatedAt
+
+	// The discount is spread over the lines in proportion to their value,
+	// the same way it is when the order's tax is computed
+	paid := 1.0
+	if subtotal := subtotalOf(o.Items); o.Discount != nil && !subtotal.IsZero() {
+		paid = subtotal.Subtract(o.Discount.Amount).Amount() / subtotal.Amount()
+	}
+
+	// Walk the order lines so the return keeps the order's line ordering
+	for _, item := range o.Items {
+		qty, ok := requested[item.ProductID]
+		if !ok {
+			continue
+		}
+		delete(requested, item.ProductID)
+		if qty > item.Quantity-returned[item.ProductID] {
+			return nil, fmt.Errorf("product %d: %w", item.ProductID, ErrReturnExceedsOrdered)
+		}
+		net := item.UnitPrice.Multiply(qty).Percentage(100 * paid)
+		amount := net.Add(net.Percentage(item.TaxRate))
+		ret.Items = append(ret.Items, ReturnItem{
+			ProductID: item.ProductID,
+			Quantity:  qty,
+			UnitPrice: item.UnitPrice,
+			Amount:    amount,
+		})
+		ret.Amount = ret.Amount.Add(amount)
+	}
+	for productID := range requested {
+		return nil, fmt.Errorf("product %d is not part of order %d: %w", productID, o.ID, pkgerrors.ErrInvalidInput)
+	}
+	return ret, nil
+}
+
+// Approve authorizes the customer to send the goods back
+func (r *Return) Approve() error {
+	return r.move(ReturnRequested, ReturnApproved, "")
+}
+
+// Reject declines the return; note tells the customer why
+func (r *Return) Reject(note string) error {
+	return r.move(ReturnRequested, ReturnRejected, note)
+}
+
+// ReceiveReturn records that the returned goods arrived and refunds their
+// value, capped at what is still refundable on the order. The refund is nil
+// when nothing is left to refund.
+func (o *Order) ReceiveReturn(r *Return) (*Refund, error) {
+	if r.OrderID != o.ID {
+		return nil, fmt.Errorf("return %d does not belong to order %d: %w", r.ID, o.ID, pkgerrors.ErrNotFound)
+	}
+	if err := r.move(ReturnApproved, ReturnReceived, r.Note); err != nil {
+		return nil, err
+	}
+
+	amount := r.Amount
+	if remaining := o.RefundableAmount(); amount.GreaterThan(remaining) {
+		amount = remaining
+	}
+	if amount.IsZero() {
+		return nil, nil
+	}
+	return o.Refund(amount, fmt.Sprintf("return #%d received", r.ID))
+}
+
+func (r *Return) move(from, to ReturnStatus, note string) error {
+	if r.Status != from {
+		return fmt.Errorf("cannot move return from %s to %s: %w", r.Status, to, ErrInvalidReturnTransition)
+	}
+	r.Status = to
+	r.Note = note
+	r.UpdatedAt = time.Now()
+	return nil
+}
+
+//go:generate mockery --name ReturnRepository
+type ReturnRepository interface {
+	Create(ctx context.Context, r *Return) error
+	GetByID(ctx context.Context, id int64) (*Return, error)
+	GetByOrderID(ctx context.Context, orderID int64) ([]*Return, error)
+	// UpdateStatus persists r's status and note, guarded on the from status
+	UpdateStatus(ctx context.Context, r *Return, from ReturnStatus) error
+	// Receive marks r received, writes tasks and, when refund is not nil,
+	// saves the refund and applies change in the same transaction
+	Receive(ctx context.Context, r *Return, refund *Refund, change *StatusChange, tasks []*OrderTask) error
+}
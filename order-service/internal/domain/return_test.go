@@ -0,0 +1,137 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/valueobject"
+)
+
+func TestOrder_Returns(t *testing.T) {
+	completedOrder := func() *Order {
+		return &Order{
+			ID: 1,
+			Items: []OrderItem{
+				{ProductID: 1, UnitPrice: valueobject.NewMoney(50), Quantity: 2, LineTotal: valueobject.NewMoney(100)},
+				{ProductID: 2, UnitPrice: valueobject.NewMoney(20), Quantity: 1, LineTotal: valueobject.NewMoney(20)},
+			},
+			TotalPrice:    valueobject.NewMoney(120),
+			OrderStatus:   OrderCompleted,
+			PaymentStatus: PaymentPaid,
+		}
+	}
+
+	t.Run("Request_PricedFromSnapshot", func(t *testing.T) {
+		o := completedOrder()
+
+		ret, err := o.RequestReturn([]ReturnItem{{ProductID: 1, Quantity: 1}}, nil, "wrong size")
+
+		assert.NoError(t, err)
+		assert.Equal(t, ReturnRequested, ret.Status)
+		assert.Equal(t, valueobject.NewMoney(50), ret.Items[0].UnitPrice)
+		assert.Equal(t, valueobject.NewMoney(50), ret.Amount)
+	})
+
+	t.Run("Request_IncludesTax", func(t *testing.T) {
+		o := completedOrder()
+		o.Items[0].TaxRate = 10
+
+		ret, err := o.RequestReturn([]ReturnItem{{ProductID: 1, Quantity: 1}}, nil, "")
+
+		assert.NoError(t, err)
+		assert.Equal(t, valueobject.NewMoney(55), ret.Amount)
+	})
+
+	t.Run("Request_ProratesDiscount", func(t *testing.T) {
+		o := completedOrder()
+		o.Items[0].TaxRate = 10
+		// 12 off a 120 subtotal: every line was paid at 90%
+		o.Discount = &OrderDiscount{Amount: valueobject.NewMoney(12)}
+
+		ret, err := o.RequestReturn([]ReturnItem{{ProductID: 1, Quantity: 1}, {ProductID: 2, Quantity: 1}}, nil, "")
+
+		assert.NoError(t, err)
+		assert.Equal(t, valueobject.NewMoney(49.5), ret.Items[0].Amount)
+		assert.Equal(t, valueobject.NewMoney(18), ret.Items[1].Amount)
+		assert.Equal(t, valueobject.NewMoney(67.5), ret.Amount)
+		// The unit price stays the undiscounted snapshot
+		assert.Equal(t, valueobject.NewMoney(50), ret.Items[0].UnitPrice)
+	})
+
+	t.Run("Request_OrderNotCompleted", func(t *testing.T) {
+		o := completedOrder()
+		o.OrderStatus = OrderShipped
+
+		_, err := o.RequestReturn([]ReturnItem{{ProductID: 1, Quantity: 1}}, nil, "")
+
+		assert.True(t, errors.Is(err, ErrInvalidReturnTransition))
+	})
+
+	t.Run("Request_ExceedsRemaining", func(t *testing.T) {
+		o := completedOrder()
+		previous := []*Return{
+			{Status: ReturnReceived, Items: []ReturnItem{{ProductID: 1, Quantity: 1}}},
+			{Status: ReturnRejected, Items: []ReturnItem{{ProductID: 1, Quantity: 2}}},
+		}
+
+		_, err := o.RequestReturn([]ReturnItem{{ProductID: 1, Quantity: 2}}, previous, "")
+
+		assert.True(t, errors.Is(err, ErrReturnExceedsOrdered))
+		assert.True(t, errors.Is(err, pkgerrors.ErrInvalidInput))
+	})
+
+	t.Run("Request_UnknownProduct", func(t *testing.T) {
+		o := completedOrder()
+
+		_, err := o.RequestReturn([]ReturnItem{{ProductID: 9, Quantity: 1}}, nil, "")
+
+		assert.True(t, errors.Is(err, pkgerrors.ErrInvalidInput))
+	})
+
+	t.Run("Receive_RefundsReturnedValue", func(t *testing.T) {
+		o := completedOrder()
+		ret, _ := o.RequestReturn([]ReturnItem{{ProductID: 1, Quantity: 2}}, nil, "")
+		assert.NoError(t, ret.Approve())
+
+		refund, err := o.ReceiveReturn(ret)
+
+		assert.NoError(t, err)
+		assert.Equal(t, ReturnReceived, ret.Status)
+		assert.Equal(t, valueobject.NewMoney(100), refund.Amount)
+		assert.Equal(t, PaymentPartiallyRefunded, o.PaymentStatus)
+	})
+
+	t.Run("Receive_CappedAtRefundable", func(t *testing.T) {
+		o := completedOrder()
+		o.PaymentStatus = PaymentPartiallyRefunded
+		o.RefundedAmount = valueobject.NewMoney(100)
+		ret := &Return{OrderID: 1, Status: ReturnApproved, Amount: valueobject.NewMoney(50)}
+
+		refund, err := o.ReceiveReturn(ret)
+
+		assert.NoError(t, err)
+		assert.Equal(t, valueobject.NewMoney(20), refund.Amount)
+		assert.Equal(t, PaymentRefunded, o.PaymentStatus)
+	})
+
+	t.Run("Receive_NotApproved", func(t *testing.T) {
+		o := completedOrder()
+		ret := &Return{OrderID: 1, Status: ReturnRequested, Amount: valueobject.NewMoney(50)}
+
+		_, err := o.ReceiveReturn(ret)
+
+		assert.True(t, errors.Is(err, ErrInvalidReturnTransition))
+		assert.Equal(t, PaymentPaid, o.PaymentStatus)
+	})
+
+	t.Run("Reject_AfterApprove", func(t *testing.T) {
+		ret := &Return{Status: ReturnRequested}
+		assert.NoError(t, ret.Approve())
+
+		err := ret.Reject("too late")
+
+		assert.True(t, errors.Is(err, pkgerrors.ErrConflict))
+	})
+}
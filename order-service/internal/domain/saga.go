@@ -0,0 +1,241 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Compensations that fail are retried after compensationBackoff, doubling
+// with each attempt up to maxCompensationBackoff, until they succeed.
+const (
+	compensationBackoff    = 30 * time.Second
+	maxCompensationBackoff = 30 * time.Minute
+)
+
+type SagaStatus string
+
+const (
+	// SagaRunning is a placement whose order has not been stored yet
+	SagaRunning SagaStatus = "RUNNING"
+	// SagaCompleted placed its order; its reservations belong to the order
+	SagaCompleted SagaStatus = "COMPLETED"
+	// SagaFailed placed no order; its reservations are given back
+	SagaFailed SagaStatus = "FAILED"
+)
+
+type CompensationStatus string
+
+const (
+	CompensationNone    CompensationStatus = "NONE"
+	CompensationPending CompensationStatus = "PENDING"
+	CompensationDone    CompensationStatus = "DONE"
+)
+
+type SagaAction string
+
+const (
+	SagaReserveStock    SagaAction = "RESERVE_STOCK"
+	SagaReservePreorder SagaAction = "RESERVE_PREORDER"
+)
+
+type SagaStepStatus string
+
+const (
+	// StepPending is recorded before the call is made; a step still pending
+	// after a crash may or may not have been applied
+	StepPending   SagaStepStatus = "PENDING"
+	StepReserved  SagaStepStatus = "RESERVED"
+	StepFailed    SagaStepStatus = "FAILED"
+	StepReleasing SagaStepStatus = "RELEASING"
+	StepReleased  SagaStepStatus = "RELEASED"
+)
+
+// SagaStep is one reservation taken while placing an order
+type SagaStep struct {
+	Seq       int            `json:"seq"`
+	Action    SagaAction     `json:"action"`
+	ProductID int64          `json:"product_id"`
+	Quantity  int            `json:"quantity"`
+	Status    SagaStepStatus `json:"status"`
+}
+
+// PlacementSaga is the durable log of the reservations taken while placing
+// an order. Each step is written before its call is made, so reservations
+// of an order that is never stored can be released after a crash.
+type PlacementSaga struct {
+	ID      int64      `json:"id"`
+	Status  SagaStatus `json:"status"`
+	OrderID *int64     `json:"order_id,omitempty"`
+	Steps   []SagaStep `json:"steps"`
+	// Compensation tracks the release of reservations that are no longer
+	// needed; a failed release is retried at NextAttemptAt
+	Compensation  CompensationStatus `json:"compensation"`
+	Attempts      int                `json:"attempts"`
+	NextAttemptAt *time.Time         `json:"next_attempt_at,omitempty"`
+	LastError     string             `json:"last_error,omitempty"`
+	CreatedAt     time.Time          `json:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+}
+
+func NewPlacementSaga(now time.Time) *PlacementSaga {
+	return &PlacementSaga{
+		Status:       SagaRunning,
+		Compensation: CompensationNone,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+}
+
+// Begin records the reservation of line before the call that takes it and
+// returns the new step
+func (s *PlacementSaga) Begin(action SagaAction, line StockLine, now time.Time) SagaStep {
+	step := SagaStep{
+		Seq:       len(s.Steps) + 1,
+		Action:    action,
+		ProductID: line.ProductID,
+		Quantity:  line.Quantity,
+		Status:    StepPending,
+	}
+	s.Steps = append(s.Steps, step)
+	s.UpdatedAt = now
+	return step
+}
+
+// StepKey is the operation key the product service records step seq's
+// reservation under. Releasing by key gives back whatever the step took,
+// which lets a step be released even when its outcome is unknown.
+func (s *PlacementSaga) StepKey(seq int) string {
+	return fmt.Sprintf("saga-%d-%d", s.ID, seq)
+}
+
+// Settle records the outcome of the call begun last
+func (s *PlacementSaga) Settle(reserved bool, now time.Time) {
+	status := StepFailed
+	if reserved {
+		status = StepReserved
+	}
+	for i := range s.Steps {
+		if s.Steps[i].Status == StepPending {
+			s.Steps[i].Status = status
+		}
+	}
+	s.UpdatedAt = now
+}
+
+// Unsettled returns the steps whose call was interrupted, or failed without
+// saying whether it reserved anything, before its outcome was recorded
+func (s *PlacementSaga) Unsettled() []SagaStep {
+	return s.stepsIn(StepPending)
+}
+
+// Compensate marks every reservation the saga holds or may hold for release.
+// Unsettled steps are included: releasing by key gives back a reservation
+// that landed and voids the key of one that did not. The first retry is
+// scheduled right away so that a release in progress is not picked up again
+// before it reports back.
+func (s *PlacementSaga) Compensate(now time.Time) {
+	marked := false
+	for i := range s.Steps {
+		if s.Steps[i].Status == StepReserved || s.Steps[i].Status == StepPending {
+			s.Steps[i].Status = StepReleasing
+			marked = true
+		}
+	}
+	if marked {
+		s.Compensation = CompensationPending
+		s.scheduleRetry(now)
+	}
+	s.UpdatedAt = now
+}
+
+// Releasing returns the steps waiting to be released
+func (s *PlacementSaga) Releasing() []SagaStep {
+	return s.stepsIn(StepReleasing)
+}
+
+// Released records that the steps numbered seqs were given back
+func (s *PlacementSaga) Released(seqs ...int) {
+	for _, seq := range seqs {
+		for i := range s.Steps {
+			if s.Steps[i].Seq == seq && s.Steps[i].Status == StepReleasing {
+				s.Steps[i].Status = StepReleased
+			}
+		}
+	}
+}
+
+// CompensationAttempted records a release attempt that ended with err. The
+// compensation is done once no step is left to release; otherwise it is
+// retried with exponential backoff.
+func (s *PlacementSaga) CompensationAttempted(err error, now time.Time) {
+	s.UpdatedAt = now
+	if len(s.Releasing()) == 0 {
+		s.Compensation = CompensationDone
+		s.NextAttemptAt = nil
+		s.LastError = ""
+		return
+	}
+	s.Attempts++
+	if err != nil {
+		s.LastError = err.Error()
+	}
+	s.scheduleRetry(now)
+}
+
+// Complete links the stored order; the reservations still held are its own
+func (s *PlacementSaga) Complete(orderID int64, now time.Time) {
+	s.Status = SagaCompleted
+	s.OrderID = &orderID
+	s.UpdatedAt = now
+}
+
+// Fail ends a saga that placed no order and marks its reservations for release
+func (s *PlacementSaga) Fail(now time.Time) {
+	s.Status = SagaFailed
+	s.Compensate(now)
+}
+
+// Abandoned reports whether the saga is still running but was last touched
+// before staleBefore, meaning the process placing the order has died
+func (s *PlacementSaga) Abandoned(staleBefore time.Time) bool {
+	return s.Status == SagaRunning && s.UpdatedAt.Before(staleBefore)
+}
+
+// Settled reports whether nothing is left to do for the saga
+func (s *PlacementSaga) Settled() bool {
+	return s.Status != SagaRunning && s.Compensation != CompensationPending
+}
+
+func (s *PlacementSaga) scheduleRetry(now time.Time) {
+	wait := maxCompensationBackoff
+	if s.Attempts < 16 {
+		wait = min(compensationBackoff<<s.Attempts, maxCompensationBackoff)
+	}
+	next := now.Add(wait)
+	s.NextAttemptAt = &next
+}
+
+func (s *PlacementSaga) stepsIn(status SagaStepStatus) []SagaStep {
+	var steps []SagaStep
+	for _, step := range s.Steps {
+		if step.Status == status {
+			steps = append(steps, step)
+		}
+	}
+	return steps
+}
+
+//go:generate mockery --name SagaRepository
+type SagaRepository interface {
+	Create(ctx context.Context, s *PlacementSaga) error
+	// Save stores the saga's state and all of its steps
+	Save(ctx context.Context, s *PlacementSaga) error
+	GetByID(ctx context.Context, id int64) (*PlacementSaga, error)
+	// FindRecoverable returns IDs of sagas running since before staleBefore
+	// and of sagas with a compensation due at or before now
+	FindRecoverable(ctx context.Context, now, staleBefore time.Time, limit int) ([]int64, error)
+	// FindOrderID returns the ID of the order placed by the saga, or
+	// ErrNotFound if none was stored
+	FindOrderID(ctx context.Context, sagaID int64) (int64, error)
+}
@@ -0,0 +1,105 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlacementSaga(t *testing.T) {
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	reserved := func() *PlacementSaga {
+		s := NewPlacementSaga(now)
+		s.Begin(SagaReserveStock, StockLine{ProductID: 1, Quantity: 2}, now)
+		s.Settle(true, now)
+		s.Begin(SagaReserveStock, StockLine{ProductID: 2, Quantity: 1}, now)
+		return s
+	}
+
+	t.Run("Settle_OnlyPendingSteps", func(t *testing.T) {
+		s := reserved()
+		s.Settle(false, now)
+
+		assert.Equal(t, StepReserved, s.Steps[0].Status)
+		assert.Equal(t, StepFailed, s.Steps[1].Status)
+	})
+
+	t.Run("Fail_ReleasesReservedAndUnsettled", func(t *testing.T) {
+		s := reserved()
+		s.Fail(now)
+
+		assert.Equal(t, SagaFailed, s.Status)
+		assert.Equal(t, CompensationPending, s.Compensation)
+		assert.Equal(t, []SagaStep{
+			{Seq: 1, Action: SagaReserveStock, ProductID: 1, Quantity: 2, Status: StepReleasing},
+			{Seq: 2, Action: SagaReserveStock, ProductID: 2, Quantity: 1, Status: StepReleasing},
+		}, s.Releasing())
+		assert.Empty(t, s.Unsettled())
+		assert.Equal(t, now.Add(compensationBackoff), *s.NextAttemptAt)
+	})
+
+	t.Run("Fail_FailedStepNotReleased", func(t *testing.T) {
+		s := reserved()
+		s.Settle(false, now)
+		s.Fail(now)
+
+		assert.Equal(t, []SagaStep{{Seq: 1, Action: SagaReserveStock, ProductID: 1, Quantity: 2, Status: StepReleasing}}, s.Releasing())
+	})
+
+	t.Run("StepKey", func(t *testing.T) {
+		s := reserved()
+		s.ID = 42
+
+		assert.Equal(t, "saga-42-2", s.StepKey(s.Steps[1].Seq))
+	})
+
+	t.Run("Fail_NothingReserved", func(t *testing.T) {
+		s := NewPlacementSaga(now)
+		s.Fail(now)
+
+		assert.Equal(t, CompensationNone, s.Compensation)
+		assert.True(t, s.Settled())
+	})
+
+	t.Run("CompensationAttempted_BacksOffUntilReleased", func(t *testing.T) {
+		s := reserved()
+		s.Settle(true, now)
+		s.Fail(now)
+
+		s.Released(2)
+		s.CompensationAttempted(errors.New("unavailable"), now)
+		assert.Equal(t, 1, s.Attempts)
+		assert.Equal(t, "unavailable", s.LastError)
+		assert.Equal(t, now.Add(2*compensationBackoff), *s.NextAttemptAt)
+		assert.False(t, s.Settled())
+
+		s.Released(1)
+		s.CompensationAttempted(nil, now)
+		assert.Equal(t, CompensationDone, s.Compensation)
+		assert.Nil(t, s.NextAttemptAt)
+		assert.True(t, s.Settled())
+	})
+
+	t.Run("Backoff_Capped", func(t *testing.T) {
+		s := reserved()
+		s.Fail(now)
+		s.Attempts = 40
+
+		s.CompensationAttempted(errors.New("unavailable"), now)
+
+		assert.Equal(t, now.Add(maxCompensationBackoff), *s.NextAttemptAt)
+	})
+
+	t.Run("Abandoned", func(t *testing.T) {
+		s := reserved()
+
+		assert.True(t, s.Abandoned(now.Add(time.Minute)))
+		assert.False(t, s.Abandoned(now))
+
+		s.Complete(42, now)
+		assert.False(t, s.Abandoned(now.Add(time.Minute)))
+	})
+}
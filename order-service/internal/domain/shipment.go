@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+)
+
+type Shipment struct {
+	ID             int64      `json:"id"`
+	OrderID        int64      `json:"order_id"`
+	Carrier        string     `json:"carrier"`
+	TrackingNumber string     `json:"tracking_number"`
+	ShippedAt      time.Time  `json:"shipped_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+}
+
+// Ship hands a paid order to a carrier and returns the new shipment
+func (o *Order) Ship(carrier, trackingNumber string, shippedAt time.Time) (*Shipment, error) {
+	if carrier == "" || trackingNumber == "" {
+		return nil, fmt.Errorf("carrier and tracking number are required: %w", pkgerrors.ErrInvalidInput)
+	}
+	if err := o.apply(ActionShip); err != nil {
+		return nil, err
+	}
+	return &Shipment{
+		OrderID:        o.ID,
+		Carrier:        carrier,
+		TrackingNumber: trackingNumber,
+		ShippedAt:      shippedAt,
+	}, nil
+}
+
+// Deliver records that the order's shipment reached the customer
+func (o *Order) Deliver(s *Shipment, deliveredAt time.Time) error {
+	if s.OrderID != o.ID {
+		return fmt.Errorf("shipment %d does not belong to order %d: %w", s.ID, o.ID, pkgerrors.ErrNotFound)
+	}
+	if deliveredAt.Before(s.ShippedAt) {
+		return fmt.Errorf("delivery cannot precede shipment: %w", pkgerrors.ErrInvalidInput)
+	}
+	if err := o.apply(ActionDeliver); err != nil {
+		return err
+	}
+	s.DeliveredAt = &deliveredAt
+	return nil
+}
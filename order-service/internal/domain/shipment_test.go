@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+)
+
+func TestOrder_Shipment(t *testing.T) {
+	paidOrder := func() *Order {
+		return &Order{ID: 1, OrderStatus: OrderPending, PaymentStatus: PaymentPaid}
+	}
+	shippedAt := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	t.Run("Ship_MissingTracking", func(t *testing.T) {
+		o := paidOrder()
+
+		shipment, err := o.Ship("UPS", "", shippedAt)
+
+		assert.Nil(t, shipment)
+		assert.True(t, errors.Is(err, pkgerrors.ErrInvalidInput))
+		assert.Equal(t, OrderPending, o.OrderStatus)
+	})
+
+	t.Run("Ship_Unpaid", func(t *testing.T) {
+		o := &Order{ID: 1, OrderStatus: OrderPending, PaymentStatus: PaymentPending}
+
+		_, err := o.Ship("UPS", "1Z999", shippedAt)
+
+		assert.True(t, errors.Is(err, ErrInvalidTransition))
+	})
+
+	t.Run("Deliver_BeforeShipped", func(t *testing.T) {
+		o := paidOrder()
+		shipment, _ := o.Ship("UPS", "1Z999", shippedAt)
+
+		err := o.Deliver(shipment, shippedAt.Add(-time.Hour))
+
+		assert.True(t, errors.Is(err, pkgerrors.ErrInvalidInput))
+		assert.Equal(t, OrderShipped, o.OrderStatus)
+		assert.Nil(t, shipment.DeliveredAt)
+	})
+
+	t.Run("Deliver_OtherOrdersShipment", func(t *testing.T) {
+		o := paidOrder()
+		o.Ship("UPS", "1Z999", shippedAt)
+
+		err := o.Deliver(&Shipment{ID: 9, OrderID: 2, ShippedAt: shippedAt}, shippedAt.Add(time.Hour))
+
+		assert.True(t, errors.Is(err, pkgerrors.ErrNotFound))
+	})
+}
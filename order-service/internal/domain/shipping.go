@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/valueobject"
+)
+
+// AnyShippingZone is the zone of a ShippingRate that applies to every
+// destination without a more specific rate
+const AnyShippingZone = "*"
+
+// ShippingRate prices delivery to one zone. A zone is a region prefix: zone
+// "US" covers destinations "US" and "US-CA", and "US-CA" covers only the
+// latter. The rate is copied onto the order, so later rate changes never
+// reprice existing orders.
+type ShippingRate struct {
+	Zone     string            `json:"zone"`
+	BaseFee  valueobject.Money `json:"base_fee"`
+	PerKgFee valueobject.Money `json:"per_kg_fee"`
+	// FreeOver waives the charge when the discounted subtotal reaches it;
+	// zero means shipping is never free
+	FreeOver valueobject.Money `json:"free_over"`
+}
+
+// Charge is the shipping cost of a parcel. Every started kilogram is charged.
+func (r ShippingRate) Charge(weightGrams int, subtotal valueobject.Money) valueobject.Money {
+	if !r.FreeOver.IsZero() && !r.FreeOver.GreaterThan(subtotal) {
+		return valueobject.NewMoney(0)
+	}
+	kilos := (weightGrams + 999) / 1000
+	return r.BaseFee.Add(r.PerKgFee.Multiply(kilos))
+}
+
+// ShippingCalculator finds the shipping rate for a destination region. A nil
+// rate means shipping to the region is free.
+//
+//go:generate mockery --name ShippingCalculator
+type ShippingCalculator interface {
+	RateFor(region string) (*ShippingRate, error)
+}
+
+// ShippingRateTable is a ShippingCalculator backed by a fixed list of rates.
+// The rate with the longest zone matching the region wins, then the
+// AnyShippingZone rate.
+type ShippingRateTable struct {
+	rates map[string]ShippingRate
+}
+
+func NewShippingRateTable(rates []ShippingRate) (*ShippingRateTable, error) {
+	t := &ShippingRateTable{rates: make(map[string]ShippingRate, len(rates))}
+	for _, r := range rates {
+		if r.Zone == "" {
+			return nil, fmt.Errorf("shipping rate needs a zone: %w", pkgerrors.ErrInvalidInput)
+		}
+		if r.BaseFee.IsNegative() || r.PerKgFee.IsNegative() || r.FreeOver.IsNegative() {
+			return nil, fmt.Errorf("shipping rate for %s cannot be negative: %w", r.Zone, pkgerrors.ErrInvalidInput)
+		}
+		if _, dup := t.rates[r.Zone]; dup {
+			return nil, fmt.Errorf("duplicate shipping rate for %s: %w", r.Zone, pkgerrors.ErrInvalidInput)
+		}
+		t.rates[r.Zone] = r
+	}
+	return t, nil
+}
+
+func (t *ShippingRateTable) RateFor(region string) (*ShippingRate, error) {
+	for zone := region; zone != ""; {
+		if r, ok := t.rates[zone]; ok {
+			return &r, nil
+		}
+		i := strings.LastIndex(zone, "-")
+		if i < 0 {
+			break
+		}
+		zone = zone[:i]
+	}
+	if r, ok := t.rates[AnyShippingZone]; ok {
+		return &r, nil
+	}
+	return nil, nil
+}
+
+// SetShipping looks up the shipping rate for the order's region, keeps it
+// with the order and reprices the order.
+func (o *Order) SetShipping(shipping ShippingCalculator) error {
+	rate, err := shipping.RateFor(o.TaxRegion)
+	if err != nil {
+		return err
+	}
+	o.ShippingRate = rate
+	o.reprice()
+	return nil
+}
+
+func weightOf(items []OrderItem) int {
+	grams := 0
+	for _, item := range items {
+		grams += item.WeightGrams * item.Quantity
+	}
+	return grams
+}
@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/valueobject"
+)
+
+func TestShippingRateTable(t *testing.T) {
+	table, err := NewShippingRateTable([]ShippingRate{
+		{Zone: "US", BaseFee: valueobject.NewMoney(5)},
+		{Zone: "US-AK", BaseFee: valueobject.NewMoney(15)},
+		{Zone: AnyShippingZone, BaseFee: valueobject.NewMoney(25)},
+	})
+	assert.NoError(t, err)
+
+	cases := []struct {
+		region string
+		want   string
+	}{
+		{"US-AK", "US-AK"},
+		{"US-CA", "US"},
+		{"US", "US"},
+		{"DE-BE", AnyShippingZone},
+		{"", AnyShippingZone},
+	}
+	for _, c := range cases {
+		rate, err := table.RateFor(c.region)
+		assert.NoError(t, err)
+		assert.Equal(t, c.want, rate.Zone, c.region)
+	}
+
+	t.Run("NoMatch_IsFree", func(t *testing.T) {
+		rate, err := (&ShippingRateTable{}).RateFor("US-CA")
+
+		assert.NoError(t, err)
+		assert.Nil(t, rate)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := NewShippingRateTable([]ShippingRate{{Zone: "US", BaseFee: valueobject.NewMoney(-1)}})
+		assert.True(t, errors.Is(err, pkgerrors.ErrInvalidInput))
+
+		_, err = NewShippingRateTable([]ShippingRate{{Zone: "US"}, {Zone: "US"}})
+		assert.True(t, errors.Is(err, pkgerrors.ErrInvalidInput))
+	})
+}
+
+func TestShippingRate_Charge(t *testing.T) {
+	rate := ShippingRate{
+		Zone:     "US",
+		BaseFee:  valueobject.NewMoney(5),
+		PerKgFee: valueobject.NewMoney(2),
+		FreeOver: valueobject.NewMoney(100),
+	}
+
+	// 2.1 kg is charged as 3 started kilograms
+	assert.Equal(t, valueobject.NewMoney(11), rate.Charge(2100, valueobject.NewMoney(50)))
+	assert.Equal(t, valueobject.NewMoney(5), rate.Charge(0, valueobject.NewMoney(50)))
+	assert.True(t, rate.Charge(2100, valueobject.NewMoney(100)).IsZero())
+
+	rate.FreeOver = valueobject.NewMoney(0)
+	assert.Equal(t, valueobject.NewMoney(7), rate.Charge(1000, valueobject.NewMoney(1000)))
+}
+
+func TestOrder_Shipping(t *testing.T) {
+	table, err := NewShippingRateTable([]ShippingRate{
+		{Zone: "US", BaseFee: valueobject.NewMoney(5), PerKgFee: valueobject.NewMoney(1), FreeOver: valueobject.NewMoney(100)},
+	})
+	assert.NoError(t, err)
+
+	newOrder := func(t *testing.T) *Order {
+		item, _ := NewOrderItem(1, "Headphones", valueobject.NewMoney(30), 2)
+		item.WeightGrams = 400
+		order, err := NewOrder(1, []OrderItem{item}, "US-CA", &TaxTable{})
+		assert.NoError(t, err)
+		assert.NoError(t, order.SetShipping(table))
+		return order
+	}
+
+	t.Run("AddedToTotal", func(t *testing.T) {
+		order := newOrder(t)
+
+		assert.Equal(t, "US", order.ShippingRate.Zone)
+		assert.Equal(t, valueobject.NewMoney(6), order.Shipping)
+		assert.Equal(t, valueobject.NewMoney(66), order.TotalPrice)
+	})
+
+	t.Run("Amend_ReachesFreeShipping", func(t *testing.T) {
+		order := newOrder(t)
+
+		_, err := order.AmendQuantities(map[int64]int{1: 4})
+
+		assert.NoError(t, err)
+		assert.True(t, order.Shipping.IsZero())
+		assert.Equal(t, valueobject.NewMoney(120), order.TotalPrice)
+	})
+
+	t.Run("Discount_FallsBelowThreshold", func(t *testing.T) {
+		item, _ := NewOrderItem(1, "Headphones", valueobject.NewMoney(30), 4)
+		item.WeightGrams = 400
+		order, _ := NewOrder(1, []OrderItem{item}, "US-CA", &TaxTable{})
+		assert.NoError(t, order.SetShipping(table))
+		assert.True(t, order.Shipping.IsZero())
+
+		c := &Coupon{ID: 1, Code: "TWENTY", Active: true, DiscountTerms: DiscountTerms{Type: DiscountFixed, AmountOff: valueobject.NewMoney(30)}}
+		assert.NoError(t, order.ApplyCoupon(c, order.CreatedAt))
+
+		// 1.6 kg, charged as 2 kg, once the discounted subtotal of 90 is below 100
+		assert.Equal(t, valueobject.NewMoney(7), order.Shipping)
+		assert.Equal(t, valueobject.NewMoney(97), order.TotalPrice)
+	})
+}
@@ -0,0 +1,211 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/valueobject"
+)
+
+// ErrSubscriptionState wraps ErrConflict so an operation the subscription's
+// status does not allow maps to a 409.
+var ErrSubscriptionState = fmt.Errorf("operation not allowed in subscription status: %w", pkgerrors.ErrConflict)
+
+// SubscriptionActor is recorded as the actor of orders the scheduler places
+const SubscriptionActor = "subscription-scheduler"
+
+type SubscriptionStatus string
+
+const (
+	SubscriptionActive    SubscriptionStatus = "ACTIVE"
+	SubscriptionPaused    SubscriptionStatus = "PAUSED"
+	SubscriptionCancelled SubscriptionStatus = "CANCELLED"
+)
+
+// RetryPolicy decides how a run whose order could not be placed, typically
+// because the product was out of stock, is retried. The wait doubles after
+// each failed attempt; after MaxAttempts the delivery is skipped and the
+// subscription waits for its next run.
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration
+}
+
+// Subscription places an order for Quantity units of a product every
+// IntervalDays days, starting at NextRunAt.
+type Subscription struct {
+	ID           int64              `json:"id"`
+	UserID       int64              `json:"user_id"`
+	ProductID    int64              `json:"product_id"`
+	Quantity     int                `json:"quantity"`
+	Region       string             `json:"region"`
+	IntervalDays int                `json:"interval_days"`
+	Status       SubscriptionStatus `json:"status"`
+	// NextRunAt is the date of the next scheduled delivery
+	NextRunAt time.Time `json:"next_run_at"`
+	// RetryAt is set while a failed run is waiting to be retried, and
+	// FailedAttempts counts the failures of that run
+	RetryAt        *time.Time `json:"retry_at,omitempty"`
+	FailedAttempts int        `json:"failed_attempts"`
+	LastOrderID    *int64     `json:"last_order_id,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// NewSubscription starts a subscription whose first run is at firstRun, or
+// at now if firstRun is zero
+func NewSubscription(userID, productID int64, quantity, intervalDays int, region string, firstRun, now time.Time) (*Subscription, error) {
+	if userID <= 0 || productID <= 0 {
+		return nil, fmt.Errorf("user and product are required: %w", pkgerrors.ErrInvalidInput)
+	}
+	if quantity <= 0 {
+		return nil, fmt.Errorf("quantity must be greater than zero: %w", pkgerrors.ErrInvalidInput)
+	}
+	if intervalDays <= 0 {
+		return nil, fmt.Errorf("interval must be at least one day: %w", pkgerrors.ErrInvalidInput)
+	}
+	if firstRun.IsZero() {
+		firstRun = now
+	}
+	return &Subscription{
+		UserID:       userID,
+		ProductID:    productID,
+		Quantity:     quantity,
+		Region:       region,
+		IntervalDays: intervalDays,
+		Status:       SubscriptionActive,
+		NextRunAt:    firstRun,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}, nil
+}
+
+// DueAt is when the subscription should next place an order
+func (s *Subscription) DueAt() time.Time {
+	if s.RetryAt != nil {
+		return *s.RetryAt
+	}
+	return s.NextRunAt
+}
+
+// Claim takes the due run before its order is placed: a pending retry is
+// consumed, otherwise the schedule moves on to the next run after now. The
+// claim is stored before ordering so a crash can miss a delivery but never
+// order it twice.
+func (s *Subscription) Claim(now time.Time) error {
+	if s.Status != SubscriptionActive || s.DueAt().After(now) {
+		return fmt.Errorf("subscription %d is %s and due at %s: %w", s.ID, s.Status, s.DueAt().Format(time.RFC3339), ErrSubscriptionState)
+	}
+	if s.RetryAt != nil {
+		s.RetryAt = nil
+	} else {
+		s.advance(now)
+	}
+	s.UpdatedAt = now
+	return nil
+}
+
+// RecordOrder links the order placed for the claimed run
+func (s *Subscription) RecordOrder(orderID int64, now time.Time) {
+	s.LastOrderID = &orderID
+	s.FailedAttempts = 0
+	s.UpdatedAt = now
+}
+
+// RecordFailure schedules a retry of the claimed run under policy and
+// reports whether the run was given up instead
+func (s *Subscription) RecordFailure(policy RetryPolicy, now time.Time) bool {
+	s.FailedAttempts++
+	s.UpdatedAt = now
+	if s.FailedAttempts >= policy.MaxAttempts {
+		s.FailedAttempts = 0
+		s.RetryAt = nil
+		return true
+	}
+	retryAt := now.Add(policy.Backoff << (s.FailedAttempts - 1))
+	s.RetryAt = &retryAt
+	return false
+}
+
+// Skip drops the next delivery: a pending retry if there is one, otherwise
+// the next scheduled run
+func (s *Subscription) Skip(now time.Time) error {
+	if s.Status == SubscriptionCancelled {
+		return fmt.Errorf("subscription %d is cancelled: %w", s.ID, ErrSubscriptionState)
+	}
+	if s.RetryAt != nil {
+		s.RetryAt = nil
+		s.FailedAttempts = 0
+	} else {
+		s.NextRunAt = s.NextRunAt.AddDate(0, 0, s.IntervalDays)
+	}
+	s.UpdatedAt = now
+	return nil
+}
+
+func (s *Subscription) Pause(now time.Time) error {
+	if s.Status != SubscriptionActive {
+		return fmt.Errorf("subscription %d is %s: %w", s.ID, s.Status, ErrSubscriptionState)
+	}
+	s.Status = SubscriptionPaused
+	s.UpdatedAt = now
+	return nil
+}
+
+// Resume reactivates a paused subscription. Runs missed while it was paused
+// and any pending retry are dropped rather than ordered all at once.
+func (s *Subscription) Resume(now time.Time) error {
+	if s.Status != SubscriptionPaused {
+		return fmt.Errorf("subscription %d is %s: %w", s.ID, s.Status, ErrSubscriptionState)
+	}
+	s.Status = SubscriptionActive
+	s.RetryAt = nil
+	s.FailedAttempts = 0
+	if s.NextRunAt.Before(now) {
+		s.advance(now)
+	}
+	s.UpdatedAt = now
+	return nil
+}
+
+func (s *Subscription) Cancel(now time.Time) error {
+	if s.Status == SubscriptionCancelled {
+		return fmt.Errorf("subscription %d is already cancelled: %w", s.ID, ErrSubscriptionState)
+	}
+	s.Status = SubscriptionCancelled
+	s.RetryAt = nil
+	s.UpdatedAt = now
+	return nil
+}
+
+// advance moves NextRunAt to the first run after now
+func (s *Subscription) advance(now time.Time) {
+	for !s.NextRunAt.After(now) {
+		s.NextRunAt = s.NextRunAt.AddDate(0, 0, s.IntervalDays)
+	}
+}
+
+// SubscriptionOrder summarises an order placed by a subscription
+type SubscriptionOrder struct {
+	OrderID       int64             `json:"order_id"`
+	OrderStatus   OrderStatus       `json:"order_status"`
+	PaymentStatus PaymentStatus     `json:"payment_status"`
+	TotalPrice    valueobject.Money `json:"total_price"`
+	CreatedAt     time.Time         `json:"created_at"`
+}
+
+//go:generate mockery --name SubscriptionRepository
+type SubscriptionRepository interface {
+	Create(ctx context.Context, s *Subscription) error
+	GetByID(ctx context.Context, id int64) (*Subscription, error)
+	// Update stores s if it was not changed since it was read at
+	// lastUpdated, and returns ErrConflict otherwise
+	Update(ctx context.Context, s *Subscription, lastUpdated time.Time) error
+	// FindDue returns IDs of ACTIVE subscriptions due at or before now,
+	// earliest first
+	FindDue(ctx context.Context, now time.Time, limit int) ([]int64, error)
+	// GetOrders lists the orders placed by a subscription, oldest first
+	GetOrders(ctx context.Context, id int64) ([]*SubscriptionOrder, error)
+}
@@ -0,0 +1,141 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+)
+
+func TestNewSubscription(t *testing.T) {
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	t.Run("NoFirstRun_DueNow", func(t *testing.T) {
+		s, err := NewSubscription(1, 2, 3, 30, "US-CA", time.Time{}, now)
+
+		assert.NoError(t, err)
+		assert.Equal(t, SubscriptionActive, s.Status)
+		assert.Equal(t, now, s.NextRunAt)
+	})
+
+	t.Run("InvalidInterval", func(t *testing.T) {
+		_, err := NewSubscription(1, 2, 3, 0, "US-CA", time.Time{}, now)
+
+		assert.ErrorIs(t, err, pkgerrors.ErrInvalidInput)
+	})
+
+	t.Run("InvalidQuantity", func(t *testing.T) {
+		_, err := NewSubscription(1, 2, 0, 30, "US-CA", time.Time{}, now)
+
+		assert.ErrorIs(t, err, pkgerrors.ErrInvalidInput)
+	})
+}
+
+func TestSubscription_Schedule(t *testing.T) {
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	policy := RetryPolicy{MaxAttempts: 3, Backoff: time.Hour}
+
+	newSub := func() *Subscription {
+		s, err := NewSubscription(1, 2, 1, 7, "US-CA", start, start)
+		assert.NoError(t, err)
+		return s
+	}
+
+	t.Run("Claim_AdvancesPastNow", func(t *testing.T) {
+		s := newSub()
+
+		// Two runs were missed; only one order is placed for them
+		assert.NoError(t, s.Claim(start.AddDate(0, 0, 15)))
+		assert.Equal(t, start.AddDate(0, 0, 21), s.NextRunAt)
+	})
+
+	t.Run("Claim_NotDue", func(t *testing.T) {
+		s := newSub()
+
+		assert.ErrorIs(t, s.Claim(start.Add(-time.Minute)), ErrSubscriptionState)
+	})
+
+	t.Run("Claim_Paused", func(t *testing.T) {
+		s := newSub()
+		assert.NoError(t, s.Pause(start))
+
+		assert.ErrorIs(t, s.Claim(start), ErrSubscriptionState)
+	})
+
+	t.Run("RecordFailure_BacksOffThenGivesUp", func(t *testing.T) {
+		s := newSub()
+		assert.NoError(t, s.Claim(start))
+		next := s.NextRunAt
+
+		assert.False(t, s.RecordFailure(policy, start))
+		assert.Equal(t, start.Add(time.Hour), s.DueAt())
+
+		assert.NoError(t, s.Claim(start.Add(time.Hour)))
+		assert.Nil(t, s.RetryAt)
+		assert.Equal(t, next, s.NextRunAt, "a retry keeps the schedule")
+
+		assert.False(t, s.RecordFailure(policy, start.Add(time.Hour)))
+		assert.Equal(t, start.Add(3*time.Hour), s.DueAt())
+
+		assert.NoError(t, s.Claim(start.Add(3*time.Hour)))
+		assert.True(t, s.RecordFailure(policy, start.Add(3*time.Hour)))
+		assert.Nil(t, s.RetryAt)
+		assert.Equal(t, 0, s.FailedAttempts)
+		assert.Equal(t, next, s.DueAt())
+	})
+
+	t.Run("RecordOrder_ResetsFailures", func(t *testing.T) {
+		s := newSub()
+		assert.NoError(t, s.Claim(start))
+		s.RecordFailure(policy, start)
+		assert.NoError(t, s.Claim(start.Add(time.Hour)))
+
+		s.RecordOrder(42, start.Add(time.Hour))
+
+		assert.Equal(t, int64(42), *s.LastOrderID)
+		assert.Equal(t, 0, s.FailedAttempts)
+	})
+
+	t.Run("Skip_NextRun", func(t *testing.T) {
+		s := newSub()
+
+		assert.NoError(t, s.Skip(start))
+		assert.Equal(t, start.AddDate(0, 0, 7), s.NextRunAt)
+	})
+
+	t.Run("Skip_PendingRetry", func(t *testing.T) {
+		s := newSub()
+		assert.NoError(t, s.Claim(start))
+		s.RecordFailure(policy, start)
+		next := s.NextRunAt
+
+		assert.NoError(t, s.Skip(start))
+		assert.Nil(t, s.RetryAt)
+		assert.Equal(t, next, s.DueAt())
+	})
+
+	t.Run("Resume_DropsMissedRuns", func(t *testing.T) {
+		s := newSub()
+		assert.NoError(t, s.Pause(start))
+
+		assert.NoError(t, s.Resume(start.AddDate(0, 0, 10)))
+		assert.Equal(t, SubscriptionActive, s.Status)
+		assert.Equal(t, start.AddDate(0, 0, 14), s.NextRunAt)
+	})
+
+	t.Run("Resume_NotPaused", func(t *testing.T) {
+		s := newSub()
+
+		assert.ErrorIs(t, s.Resume(start), pkgerrors.ErrConflict)
+	})
+
+	t.Run("Cancel_Final", func(t *testing.T) {
+		s := newSub()
+		assert.NoError(t, s.Cancel(start))
+
+		assert.ErrorIs(t, s.Cancel(start), ErrSubscriptionState)
+		assert.ErrorIs(t, s.Skip(start), ErrSubscriptionState)
+		assert.ErrorIs(t, s.Resume(start), ErrSubscriptionState)
+	})
+}
@@ -0,0 +1,135 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/user/go-microservices/pkg/valueobject"
+)
+
+// Tasks that fail are retried after taskBackoff, doubling with each attempt
+// up to maxTaskBackoff, until they succeed.
+const (
+	taskBackoff    = 30 * time.Second
+	maxTaskBackoff = 30 * time.Minute
+)
+
+// TaskKind is the side effect an order task carries out
+type TaskKind string
+
+const (
+	// TaskReleaseStock gives a product's reserved units back
+	TaskReleaseStock TaskKind = "RELEASE_STOCK"
+	// TaskReleasePreorder gives pre-ordered units back to the caps
+	TaskReleasePreorder TaskKind = "RELEASE_PREORDER"
+	// TaskConfirmStock turns a product's reservation into a permanent
+	// deduction once the units have shipped
+	TaskConfirmStock TaskKind = "CONFIRM_STOCK"
+	// TaskRestock puts a product's returned units back into stock
+	TaskRestock TaskKind = "RESTOCK"
+	// TaskRefundPayment returns a recorded refund to the payment method
+	TaskRefundPayment TaskKind = "REFUND_PAYMENT"
+	// TaskReleaseReservation gives back whatever was reserved under an
+	// operation key of the order's, or voids the key if nothing was
+	TaskReleaseReservation TaskKind = "RELEASE_RESERVATION"
+)
+
+// OrderTask is a side effect owed to another service once an order change
+// is committed. It is written in the change's transaction, so it exists if
+// and only if the change does, run right after the commit and retried until
+// it succeeds. Calls are made under the task's key, so running a task again
+// after a crash or a lost reply has no further effect.
+type OrderTask struct {
+	ID      int64       `json:"-"`
+	OrderID int64       `json:"-"`
+	Kind    TaskKind    `json:"-"`
+	Lines   []StockLine `json:"lines,omitempty"`
+
+	// PaymentReference and Amount describe a refund to make
+	PaymentReference string            `json:"payment_reference,omitempty"`
+	Amount           valueobject.Money `json:"amount,omitzero"`
+	// OperationKey is the key of the reservation to release
+	OperationKey string `json:"operation_key,omitempty"`
+
+	Attempts      int       `json:"-"`
+	NextAttemptAt time.Time `json:"-"`
+	LastError     string    `json:"-"`
+	CreatedAt     time.Time `json:"-"`
+}
+
+// NewOrderTask creates a task for the order. Its first retry is scheduled
+// one backoff away, leaving the change's own attempt to run undisturbed.
+func NewOrderTask(orderID int64, kind TaskKind, lines []StockLine) *OrderTask {
+	now := time.Now()
+	return &OrderTask{
+		OrderID:       orderID,
+		Kind:          kind,
+		Lines:         lines,
+		NextAttemptAt: now.Add(taskBackoff),
+		CreatedAt:     now,
+	}
+}
+
+// StockLineTasks creates one task per line, for calls that act on a single
+// product at a time and so need a key of their own
+func StockLineTasks(orderID int64, kind TaskKind, lines []StockLine) []*OrderTask {
+	tasks := make([]*OrderTask, 0, len(lines))
+	for _, line := range lines {
+		tasks = append(tasks, NewOrderTask(orderID, kind, []StockLine{line}))
+	}
+	return tasks
+}
+
+// RefundTasks returns the task that pays out a refund recorded on the order.
+// There is none without a refund, or for orders paid before payments went
+// through the gateway, whose refunds are settled outside the service.
+func RefundTasks(o *Order, refund *Refund) []*OrderTask {
+	if refund == nil || o.PaymentReference == "" {
+		return nil
+	}
+	t := NewOrderTask(o.ID, TaskRefundPayment, nil)
+	t.PaymentReference = o.PaymentReference
+	t.Amount = refund.Amount
+	return []*OrderTask{t}
+}
+
+// ReleaseReservationTask returns the task that releases what was reserved
+// for the order under key
+func ReleaseReservationTask(orderID int64, key string) *OrderTask {
+	t := NewOrderTask(orderID, TaskReleaseReservation, nil)
+	t.OperationKey = key
+	return t
+}
+
+// Key identifies the task's calls to other services
+func (t *OrderTask) Key() string {
+	return fmt.Sprintf("order-task-%d", t.ID)
+}
+
+// Failed records a failed attempt and schedules the next one
+func (t *OrderTask) Failed(err error, now time.Time) {
+	wait := maxTaskBackoff
+	if t.Attempts < 16 {
+		wait = min(taskBackoff<<t.Attempts, maxTaskBackoff)
+	}
+	t.Attempts++
+	t.LastError = err.Error()
+	t.NextAttemptAt = now.Add(wait)
+}
+
+// TaskRepository reads the tasks written alongside order changes
+//
+//go:generate mockery --name TaskRepository
+type TaskRepository interface {
+	// Schedule writes tasks ahead of a change that may never be committed,
+	// to undo what is done in preparation for it. A change that is committed
+	// settles them.
+	Schedule(ctx context.Context, tasks []*OrderTask) error
+	// FindDue returns up to limit unfinished tasks due at now, oldest first
+	FindDue(ctx context.Context, now time.Time, limit int) ([]*OrderTask, error)
+	MarkDone(ctx context.Context, id int64, at time.Time) error
+	// SaveAttempt stores the task's attempt count, last error and next
+	// attempt time
+	SaveAttempt(ctx context.Context, t *OrderTask) error
+}
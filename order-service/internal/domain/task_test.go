@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderTask(t *testing.T) {
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	t.Run("StockLineTasks_OnePerLine", func(t *testing.T) {
+		lines := []StockLine{{ProductID: 1, Quantity: 2}, {ProductID: 2, Quantity: 1}}
+		tasks := StockLineTasks(7, TaskReleaseStock, lines)
+
+		assert.Len(t, tasks, 2)
+		for i, task := range tasks {
+			assert.Equal(t, int64(7), task.OrderID)
+			assert.Equal(t, TaskReleaseStock, task.Kind)
+			assert.Equal(t, []StockLine{lines[i]}, task.Lines)
+		}
+	})
+
+	t.Run("Key", func(t *testing.T) {
+		task := &OrderTask{ID: 42}
+		assert.Equal(t, "order-task-42", task.Key())
+	})
+
+	t.Run("Failed_BacksOff", func(t *testing.T) {
+		task := &OrderTask{}
+		task.Failed(errors.New("product service down"), now)
+		assert.Equal(t, 1, task.Attempts)
+		assert.Equal(t, "product service down", task.LastError)
+		assert.Equal(t, now.Add(taskBackoff), task.NextAttemptAt)
+
+		task.Failed(errors.New("product service down"), now)
+		assert.Equal(t, now.Add(2*taskBackoff), task.NextAttemptAt)
+
+		task.Attempts = 20
+		task.Failed(errors.New("product service down"), now)
+		assert.Equal(t, now.Add(maxTaskBackoff), task.NextAttemptAt)
+	})
+}
@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"fmt"
+
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/valueobject"
+)
+
+// AnyTaxKey matches every region or every tax category in a TaxRate
+const AnyTaxKey = "*"
+
+// TaxCalculator looks up the tax rate that applies to a product category
+// sold into a region. Rates are percentages, e.g. 20 for 20%.
+//
+//go:generate mockery --name TaxCalculator
+type TaxCalculator interface {
+	Rate(region, category string) (float64, error)
+}
+
+// TaxRate is one row of a TaxTable. Region and Category may be AnyTaxKey.
+type TaxRate struct {
+	Region   string  `json:"region"`
+	Category string  `json:"category"`
+	Rate     float64 `json:"rate"`
+}
+
+type taxKey struct {
+	region   string
+	category string
+}
+
+// TaxTable is a TaxCalculator backed by a fixed list of rates. The most
+// specific row wins: region and category, then region only, then category
+// only, then the catch-all row. Without any matching row the rate is zero.
+type TaxTable struct {
+	rates map[taxKey]float64
+}
+
+func NewTaxTable(rates []TaxRate) (*TaxTable, error) {
+	t := &TaxTable{rates: make(map[taxKey]float64, len(rates))}
+	for _, r := range rates {
+		if r.Region == "" || r.Category == "" {
+			return nil, fmt.Errorf("tax rate needs a region and a category: %w", pkgerrors.ErrInvalidInput)
+		}
+		if r.Rate < 0 || r.Rate > 100 {
+			return nil, fmt.Errorf("tax rate for %s/%s must be between 0 and 100: %w", r.Region, r.Category, pkgerrors.ErrInvalidInput)
+		}
+		key := taxKey{region: r.Region, category: r.Category}
+		if _, dup := t.rates[key]; dup {
+			return nil, fmt.Errorf("duplicate tax rate for %s/%s: %w", r.Region, r.Category, pkgerrors.ErrInvalidInput)
+		}
+		t.rates[key] = r.Rate
+	}
+	return t, nil
+}
+
+func (t *TaxTable) Rate(region, category string) (float64, error) {
+	for _, key := range []taxKey{
+		{region: region, category: category},
+		{region: region, category: AnyTaxKey},
+		{region: AnyTaxKey, category: category},
+		{region: AnyTaxKey, category: AnyTaxKey},
+	} {
+		if rate, ok := t.rates[key]; ok {
+			return rate, nil
+		}
+	}
+	return 0, nil
+}
+
+// taxLines computes the tax on items after discount. Each line is taxed at
+// its snapshotted rate on its share of the discounted subtotal.
+func taxLines(items []OrderItem, subtotal, discount valueobject.Money) valueobject.Money {
+	tax := valueobject.NewMoney(0)
+	if subtotal.IsZero() {
+		return tax
+	}
+	share := subtotal.Subtract(discount).Amount() / subtotal.Amount()
+	for _, item := range items {
+		tax = tax.Add(item.LineTotal.Percentage(item.TaxRate * share))
+	}
+	return tax
+}
@@ -0,0 +1,120 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/valueobject"
+)
+
+func TestTaxTable(t *testing.T) {
+	table, err := NewTaxTable([]TaxRate{
+		{Region: "US-CA", Category: "STANDARD", Rate: 7.25},
+		{Region: "US-CA", Category: AnyTaxKey, Rate: 5},
+		{Region: AnyTaxKey, Category: "FOOD", Rate: 1},
+		{Region: AnyTaxKey, Category: AnyTaxKey, Rate: 10},
+	})
+	assert.NoError(t, err)
+
+	cases := []struct {
+		region, category string
+		want             float64
+	}{
+		{"US-CA", "STANDARD", 7.25},
+		{"US-CA", "FOOD", 5},
+		{"DE", "FOOD", 1},
+		{"DE", "STANDARD", 10},
+	}
+	for _, c := range cases {
+		rate, err := table.Rate(c.region, c.category)
+		assert.NoError(t, err)
+		assert.Equal(t, c.want, rate, "%s/%s", c.region, c.category)
+	}
+
+	t.Run("NoMatch_IsZero", func(t *testing.T) {
+		rate, err := (&TaxTable{}).Rate("US-CA", "STANDARD")
+
+		assert.NoError(t, err)
+		assert.Zero(t, rate)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := NewTaxTable([]TaxRate{{Region: "US-CA", Category: "STANDARD", Rate: 120}})
+		assert.True(t, errors.Is(err, pkgerrors.ErrInvalidInput))
+
+		_, err = NewTaxTable([]TaxRate{
+			{Region: "US-CA", Category: "STANDARD", Rate: 7},
+			{Region: "US-CA", Category: "STANDARD", Rate: 8},
+		})
+		assert.True(t, errors.Is(err, pkgerrors.ErrInvalidInput))
+	})
+}
+
+func TestOrder_Tax(t *testing.T) {
+	table, err := NewTaxTable([]TaxRate{
+		{Region: "US-CA", Category: "STANDARD", Rate: 10},
+		{Region: "US-CA", Category: "FOOD", Rate: 0},
+	})
+	assert.NoError(t, err)
+
+	newOrder := func(t *testing.T) *Order {
+		standard, _ := NewOrderItem(1, "Laptop", valueobject.NewMoney(50), 2)
+		standard.TaxCategory = "STANDARD"
+		food, _ := NewOrderItem(2, "Coffee", valueobject.NewMoney(10), 5)
+		food.TaxCategory = "FOOD"
+		order, err := NewOrder(1, []OrderItem{standard, food}, "US-CA", table)
+		assert.NoError(t, err)
+		return order
+	}
+
+	t.Run("NewOrder_SnapshotsRates", func(t *testing.T) {
+		order := newOrder(t)
+
+		assert.Equal(t, 10.0, order.Items[0].TaxRate)
+		assert.Equal(t, 0.0, order.Items[1].TaxRate)
+		assert.Equal(t, valueobject.NewMoney(150), order.Subtotal)
+		assert.Equal(t, valueobject.NewMoney(10), order.Tax)
+		assert.Equal(t, valueobject.NewMoney(160), order.TotalPrice)
+	})
+
+	t.Run("Discount_TaxedAfterwards", func(t *testing.T) {
+		order := newOrder(t)
+		c := &Coupon{ID: 1, Code: "THIRD", Active: true, DiscountTerms: DiscountTerms{Type: DiscountFixed, AmountOff: valueobject.NewMoney(50)}}
+
+		assert.NoError(t, order.ApplyCoupon(c, order.CreatedAt))
+
+		// the laptop line carries 2/3 of the discount, leaving 66.67 taxable at 10%
+		assert.Equal(t, valueobject.NewMoney(6.67), order.Tax)
+		assert.Equal(t, valueobject.NewMoney(106.67), order.TotalPrice)
+	})
+
+	t.Run("Amend_UsesSnapshot", func(t *testing.T) {
+		order := newOrder(t)
+
+		_, err := order.AmendQuantities(map[int64]int{1: 1})
+
+		assert.NoError(t, err)
+		assert.Equal(t, valueobject.NewMoney(5), order.Tax)
+		assert.Equal(t, valueobject.NewMoney(105), order.TotalPrice)
+	})
+
+	t.Run("CalculatorError", func(t *testing.T) {
+		item, _ := NewOrderItem(1, "Laptop", valueobject.NewMoney(50), 1)
+		taxes := &failingTaxCalculator{err: pkgerrors.ErrInternal}
+
+		order, err := NewOrder(1, []OrderItem{item}, "US-CA", taxes)
+
+		assert.Nil(t, order)
+		assert.ErrorIs(t, err, pkgerrors.ErrInternal)
+	})
+}
+
+type failingTaxCalculator struct {
+	err error
+}
+
+func (f *failingTaxCalculator) Rate(region, category string) (float64, error) {
+	return 0, f.err
+}
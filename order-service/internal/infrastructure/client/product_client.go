@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/user/go-microservices/order-service/internal/domain"
@@ -13,6 +14,10 @@ import (
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
+// operationKeyHeader carries the key under which the product service applies
+// a stock change at most once
+const operationKeyHeader = "Idempotency-Key"
+
 type productClient struct {
 	baseURL    string
 	httpClient *http.Client
@@ -60,40 +65,63 @@ type stockReq struct {
 	Quantity  int   `json:"quantity"`
 }
 
-func (c *productClient) ReserveStock(ctx context.Context, id int64, qty int) error {
-	url := fmt.Sprintf("%s/products/reserve", c.baseURL)
-	body, _ := json.Marshal(stockReq{ProductID: id, Quantity: qty})
+func (c *productClient) ReserveStock(ctx context.Context, key string, id int64, qty int) error {
+	return c.post(ctx, "/products/reserve", key, stockReq{ProductID: id, Quantity: qty})
+}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(body))
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Content-Type", "application/json")
+func (c *productClient) ReleaseStock(ctx context.Context, key string, id int64, qty int) error {
+	return c.post(ctx, "/products/release", key, stockReq{ProductID: id, Quantity: qty})
+}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
+func (c *productClient) ConfirmStock(ctx context.Context, key string, id int64, qty int) error {
+	return c.post(ctx, "/products/confirm", key, stockReq{ProductID: id, Quantity: qty})
+}
 
-	if resp.StatusCode == http.StatusUnprocessableEntity {
-		return pkgerrors.ErrInsufficientStock
-	}
-	if resp.StatusCode != http.StatusOK {
-		return pkgerrors.ErrInternal
-	}
-	return nil
+func (c *productClient) Restock(ctx context.Context, key string, id int64, qty int) error {
+	return c.post(ctx, "/products/restock", key, stockReq{ProductID: id, Quantity: qty})
 }
 
-func (c *productClient) ReleaseStock(ctx context.Context, id int64, qty int) error {
-	url := fmt.Sprintf("%s/products/release", c.baseURL)
-	body, _ := json.Marshal(stockReq{ProductID: id, Quantity: qty})
+func (c *productClient) AllocateStock(ctx context.Context, key string, lines []domain.StockLine) error {
+	return c.post(ctx, "/products/allocate", key, stockLinesReq{Items: lines})
+}
+
+func (c *productClient) ReservePreorder(ctx context.Context, key string, lines []domain.StockLine) error {
+	return c.post(ctx, "/products/preorders/reserve", key, stockLinesReq{Items: lines})
+}
+
+func (c *productClient) ReleasePreorder(ctx context.Context, key string, lines []domain.StockLine) error {
+	return c.post(ctx, "/products/preorders/release", key, stockLinesReq{Items: lines})
+}
+
+func (c *productClient) ConvertPreorder(ctx context.Context, key string, lines []domain.StockLine) error {
+	return c.post(ctx, "/products/preorders/convert", key, stockLinesReq{Items: lines})
+}
+
+func (c *productClient) ReleaseReservation(ctx context.Context, key string) error {
+	return c.post(ctx, "/products/reservations/"+url.PathEscape(key)+"/release", "", nil)
+}
+
+// stockLinesReq is the body of the all-or-nothing stock endpoints
+type stockLinesReq struct {
+	Items []domain.StockLine `json:"items"`
+}
+
+// post sends a stock change to the product service under key and maps its
+// response to an error
+func (c *productClient) post(ctx context.Context, path, key string, payload interface{}) error {
+	var body []byte
+	if payload != nil {
+		body, _ = json.Marshal(payload)
+	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+path, bytes.NewBuffer(body))
 	if err != nil {
 		return err
 	}
 	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(operationKeyHeader, key)
+	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -101,8 +129,17 @@ func (c *productClient) ReleaseStock(ctx context.Context, id int64, qty int) err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return pkgerrors.ErrInternal
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnprocessableEntity:
+		return pkgerrors.ErrInsufficientStock
+	case http.StatusNotFound:
+		return pkgerrors.ErrNotFound
+	case http.StatusBadRequest:
+		return pkgerrors.ErrInvalidInput
+	case http.StatusConflict:
+		return pkgerrors.ErrConflict
 	}
-	return nil
+	return pkgerrors.ErrInternal
 }
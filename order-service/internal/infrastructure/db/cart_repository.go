@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+	"go.uber.org/zap"
+)
+
+type cartRepository struct {
+	db *sql.DB
+}
+
+func NewCartRepository(db *sql.DB) domain.CartRepository {
+	return &cartRepository{db: db}
+}
+
+func (r *cartRepository) Create(ctx context.Context, c *domain.Cart) error {
+	query := `
+		INSERT INTO carts (user_id, region, status, expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`
+	c.CreatedAt = c.CreatedAt.UTC()
+	c.UpdatedAt = c.UpdatedAt.UTC()
+	err := r.db.QueryRowContext(ctx, query,
+		c.UserID, c.Region, c.Status, c.ExpiresAt.UTC(), c.CreatedAt, c.UpdatedAt,
+	).Scan(&c.ID)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to create cart", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	return nil
+}
+
+func (r *cartRepository) GetByID(ctx context.Context, id int64) (*domain.Cart, error) {
+	query := `SELECT id, user_id, region, status, order_id, expires_at, created_at, updated_at FROM carts WHERE id = $1`
+
+	c := &domain.Cart{Items: []domain.CartItem{}}
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&c.ID, &c.UserID, &c.Region, &c.Status, &c.OrderID, &c.ExpiresAt, &c.CreatedAt, &c.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, pkgerrors.ErrNotFound
+	}
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to get cart", zap.Error(err))
+		return nil, pkgerrors.ErrInternal
+	}
+
+	rows, err := r.db.QueryContext(ctx, `SELECT product_id, quantity FROM cart_items WHERE cart_id = $1 ORDER BY id`, id)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to get cart items", zap.Error(err))
+		return nil, pkgerrors.ErrInternal
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item domain.CartItem
+		if err := rows.Scan(&item.ProductID, &item.Quantity); err != nil {
+			logger.FromContext(ctx).Error("failed to scan cart item", zap.Error(err))
+			return nil, pkgerrors.ErrInternal
+		}
+		c.Items = append(c.Items, item)
+	}
+	return c, nil
+}
+
+// Update rewrites the cart's lines. Lines are few, so they are replaced
+// wholesale rather than diffed.
+func (r *cartRepository) Update(ctx context.Context, c *domain.Cart) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to begin transaction", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE carts SET expires_at = $1, updated_at = $2 WHERE id = $3 AND status = $4`
+	c.UpdatedAt = c.UpdatedAt.UTC()
+	res, err := tx.ExecContext(ctx, query, c.ExpiresAt.UTC(), c.UpdatedAt, c.ID, domain.CartOpen)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to update cart", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	if rows, err := res.RowsAffected(); err != nil {
+		return pkgerrors.ErrInternal
+	} else if rows == 0 {
+		return domain.ErrCartClosed
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM cart_items WHERE cart_id = $1`, c.ID); err != nil {
+		logger.FromContext(ctx).Error("failed to clear cart items", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	for _, item := range c.Items {
+		_, err := tx.ExecContext(ctx, `INSERT INTO cart_items (cart_id, product_id, quantity) VALUES ($1, $2, $3)`,
+			c.ID, item.ProductID, item.Quantity)
+		if err != nil {
+			logger.FromContext(ctx).Error("failed to insert cart item", zap.Error(err))
+			return pkgerrors.ErrInternal
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.FromContext(ctx).Error("failed to commit cart", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	return nil
+}
+
+// UpdateStatus is guarded on from so that two concurrent checkouts of the
+// same cart cannot both proceed
+func (r *cartRepository) UpdateStatus(ctx context.Context, c *domain.Cart, from domain.CartStatus) error {
+	query := `UPDATE carts SET status = $1, order_id = $2, updated_at = $3 WHERE id = $4 AND status = $5`
+	c.UpdatedAt = c.UpdatedAt.UTC()
+	res, err := r.db.ExecContext(ctx, query, c.Status, c.OrderID, c.UpdatedAt, c.ID, from)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to update cart status", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return pkgerrors.ErrInternal
+	}
+	if rows == 0 {
+		return pkgerrors.ErrConflict
+	}
+	return nil
+}
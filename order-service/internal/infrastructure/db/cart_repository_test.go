@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/user/go-microservices/order-service/internal/domain"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+)
+
+func TestCartRepository(t *testing.T) {
+	logger.Init()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer db.Close()
+
+	repo := NewCartRepository(db)
+	now := time.Now()
+
+	t.Run("Create_Success", func(t *testing.T) {
+		c := &domain.Cart{UserID: 1, Status: domain.CartOpen, ExpiresAt: now, CreatedAt: now, UpdatedAt: now}
+
+		mock.ExpectQuery("INSERT INTO carts").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+
+		err := repo.Create(context.Background(), c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(5), c.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetByID_Success", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM carts WHERE id = \\$1").
+			WithArgs(5).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "region", "status", "order_id", "expires_at", "created_at", "updated_at"}).
+				AddRow(5, 1, "US-CA", "OPEN", nil, now, now, now))
+		mock.ExpectQuery("SELECT product_id, quantity FROM cart_items WHERE cart_id = \\$1").
+			WithArgs(5).
+			WillReturnRows(sqlmock.NewRows([]string{"product_id", "quantity"}).AddRow(1, 2).AddRow(3, 1))
+
+		c, err := repo.GetByID(context.Background(), 5)
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.CartOpen, c.Status)
+		assert.Equal(t, []domain.CartItem{{ProductID: 1, Quantity: 2}, {ProductID: 3, Quantity: 1}}, c.Items)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Update_ReplacesItems", func(t *testing.T) {
+		c := &domain.Cart{ID: 5, Items: []domain.CartItem{{ProductID: 1, Quantity: 4}}, ExpiresAt: now, UpdatedAt: now}
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE carts SET expires_at").
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), int64(5), domain.CartOpen).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("DELETE FROM cart_items").WithArgs(int64(5)).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec("INSERT INTO cart_items").WithArgs(int64(5), int64(1), 4).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := repo.Update(context.Background(), c)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Update_Closed", func(t *testing.T) {
+		c := &domain.Cart{ID: 5, ExpiresAt: now, UpdatedAt: now}
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE carts SET expires_at").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := repo.Update(context.Background(), c)
+
+		assert.ErrorIs(t, err, pkgerrors.ErrConflict)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("UpdateStatus_Conflict", func(t *testing.T) {
+		c := &domain.Cart{ID: 5, Status: domain.CartCheckedOut, UpdatedAt: now}
+
+		mock.ExpectExec("UPDATE carts SET status").
+			WithArgs(domain.CartCheckedOut, sqlmock.AnyArg(), sqlmock.AnyArg(), int64(5), domain.CartOpen).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := repo.UpdateStatus(context.Background(), c, domain.CartOpen)
+
+		assert.ErrorIs(t, err, pkgerrors.ErrConflict)
+	})
+}
@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/user/go-microservices/order-service/internal/domain"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const couponColumns = `id, code, description, discount_type, percentage, amount_off, product_id, buy_qty, get_qty, min_spend,
+	max_uses, max_uses_per_user, uses_count, valid_from, valid_until, active, created_at`
+
+type couponRepository struct {
+	db *sql.DB
+}
+
+func NewCouponRepository(db *sql.DB) domain.CouponRepository {
+	return &couponRepository{db: db}
+}
+
+func (r *couponRepository) Create(ctx context.Context, c *domain.Coupon) error {
+	query := `
+		INSERT INTO coupons (code, description, discount_type, percentage, amount_off, product_id, buy_qty, get_qty, min_spend,
+			max_uses, max_uses_per_user, valid_from, valid_until, active, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		RETURNING id`
+
+	c.CreatedAt = time.Now().UTC()
+	err := r.db.QueryRowContext(ctx, query,
+		c.Code, c.Description, c.Type, c.Percentage, c.AmountOff, c.ProductID, c.BuyQty, c.GetQty, c.MinSpend,
+		c.MaxUses, c.MaxUsesPerUser, c.ValidFrom, c.ValidUntil, c.Active, c.CreatedAt,
+	).Scan(&c.ID)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return pkgerrors.ErrConflict
+	}
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to create coupon", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	return nil
+}
+
+func (r *couponRepository) GetByCode(ctx context.Context, code string) (*domain.Coupon, error) {
+	query := `SELECT ` + couponColumns + ` FROM coupons WHERE code = $1`
+
+	c, err := scanCoupon(r.db.QueryRowContext(ctx, query, code))
+	if err == sql.ErrNoRows {
+		return nil, pkgerrors.ErrNotFound
+	}
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to get coupon", zap.Error(err))
+		return nil, pkgerrors.ErrInternal
+	}
+	return c, nil
+}
+
+func (r *couponRepository) GetAll(ctx context.Context) ([]*domain.Coupon, error) {
+	query := `SELECT ` + couponColumns + ` FROM coupons ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to get coupons", zap.Error(err))
+		return nil, pkgerrors.ErrInternal
+	}
+	defer rows.Close()
+
+	coupons := []*domain.Coupon{}
+	for rows.Next() {
+		c, err := scanCoupon(rows)
+		if err != nil {
+			logger.FromContext(ctx).Error("failed to scan coupon", zap.Error(err))
+			return nil, pkgerrors.ErrInternal
+		}
+		coupons = append(coupons, c)
+	}
+	return coupons, nil
+}
+
+func (r *couponRepository) SetActive(ctx context.Context, code string, active bool) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE coupons SET active = $1 WHERE code = $2`, active, code)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to update coupon", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	rows, _ := res.RowsAffected()
+	if rows == 0 {
+		return pkgerrors.ErrNotFound
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanCoupon(row rowScanner) (*domain.Coupon, error) {
+	c := &domain.Coupon{}
+	err := row.Scan(
+		&c.ID, &c.Code, &c.Description, &c.Type, &c.Percentage, &c.AmountOff, &c.ProductID, &c.BuyQty, &c.GetQty, &c.MinSpend,
+		&c.MaxUses, &c.MaxUsesPerUser, &c.UsesCount, &c.ValidFrom, &c.ValidUntil, &c.Active, &c.CreatedAt,
+	)
+	return c, err
+}
+
+// redeemCoupon counts one use of the order's coupon. The counter update locks
+// the coupon row, so concurrent redemptions are checked one after another.
+func redeemCoupon(ctx context.Context, tx *sql.Tx, o *domain.Order) error {
+	query := `
+		UPDATE coupons SET uses_count = uses_count + 1
+		WHERE id = $1 AND (max_uses = 0 OR uses_count < max_uses)
+		RETURNING max_uses_per_user`
+
+	var perUser int
+	err := tx.QueryRowContext(ctx, query, o.Discount.CouponID).Scan(&perUser)
+	if err == sql.ErrNoRows {
+		return domain.ErrCouponLimitReached
+	}
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to redeem coupon", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	if perUser == 0 {
+		return nil
+	}
+
+	var used int
+	err = tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM order_discounts d JOIN orders o ON o.id = d.order_id
+		WHERE d.coupon_id = $1 AND o.user_id = $2`,
+		o.Discount.CouponID, o.UserID,
+	).Scan(&used)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to count coupon redemptions", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	if used >= perUser {
+		return domain.ErrCouponLimitReached
+	}
+	return nil
+}
+
+func insertOrderDiscount(ctx context.Context, tx *sql.Tx, d *domain.OrderDiscount) error {
+	terms, err := json.Marshal(d.Terms)
+	if err != nil {
+		return pkgerrors.ErrInternal
+	}
+	query := `
+		INSERT INTO order_discounts (order_id, coupon_id, code, terms, amount)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`
+	if err := tx.QueryRowContext(ctx, query, d.OrderID, d.CouponID, d.Code, terms, d.Amount).Scan(&d.ID); err != nil {
+		logger.FromContext(ctx).Error("failed to create order discount", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	return nil
+}
+
+func scanOrderDiscount(row rowScanner) (*domain.OrderDiscount, error) {
+	d := &domain.OrderDiscount{}
+	var terms []byte
+	if err := row.Scan(&d.ID, &d.OrderID, &d.CouponID, &d.Code, &terms, &d.Amount); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(terms, &d.Terms); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
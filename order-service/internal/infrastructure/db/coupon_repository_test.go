@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/user/go-microservices/order-service/internal/domain"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+)
+
+func TestCouponRepository(t *testing.T) {
+	logger.Init()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer db.Close()
+
+	repo := NewCouponRepository(db)
+	columns := []string{"id", "code", "description", "discount_type", "percentage", "amount_off", "product_id", "buy_qty", "get_qty", "min_spend",
+		"max_uses", "max_uses_per_user", "uses_count", "valid_from", "valid_until", "active", "created_at"}
+
+	t.Run("Create_Success", func(t *testing.T) {
+		c := &domain.Coupon{Code: "SPRING10", Active: true, DiscountTerms: domain.DiscountTerms{Type: domain.DiscountPercentage, Percentage: 10}}
+
+		mock.ExpectQuery("INSERT INTO coupons").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+
+		err := repo.Create(context.Background(), c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), c.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Create_DuplicateCode", func(t *testing.T) {
+		c := &domain.Coupon{Code: "SPRING10", DiscountTerms: domain.DiscountTerms{Type: domain.DiscountPercentage, Percentage: 10}}
+
+		mock.ExpectQuery("INSERT INTO coupons").
+			WillReturnError(&pq.Error{Code: "23505"})
+
+		err := repo.Create(context.Background(), c)
+
+		assert.ErrorIs(t, err, pkgerrors.ErrConflict)
+	})
+
+	t.Run("GetByCode_Success", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM coupons WHERE code = \\$1").
+			WithArgs("SPRING10").
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(7, "SPRING10", "", "PERCENTAGE", 10.0, 0.0, 0, 0, 0, 0.0, 100, 1, 3, nil, nil, true, time.Now()))
+
+		c, err := repo.GetByCode(context.Background(), "SPRING10")
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.DiscountPercentage, c.Type)
+		assert.Equal(t, 3, c.UsesCount)
+		assert.Nil(t, c.ValidUntil)
+	})
+
+	t.Run("GetByCode_NotFound", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM coupons WHERE code = \\$1").
+			WithArgs("NOPE").
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		c, err := repo.GetByCode(context.Background(), "NOPE")
+
+		assert.ErrorIs(t, err, pkgerrors.ErrNotFound)
+		assert.Nil(t, c)
+	})
+
+	t.Run("SetActive_NotFound", func(t *testing.T) {
+		mock.ExpectExec("UPDATE coupons SET active = \\$1 WHERE code = \\$2").
+			WithArgs(false, "NOPE").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := repo.SetActive(context.Background(), "NOPE", false)
+
+		assert.ErrorIs(t, err, pkgerrors.ErrNotFound)
+	})
+}
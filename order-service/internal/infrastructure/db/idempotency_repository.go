@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+	"go.uber.org/zap"
+)
+
+type idempotencyRepository struct {
+	db *sql.DB
+}
+
+func NewIdempotencyRepository(db *sql.DB) domain.IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+// Claim inserts the claim, or takes over an expired record for the key.
+// The insert is atomic, so of several requests claiming a key at once only
+// one affects a row.
+func (r *idempotencyRepository) Claim(ctx context.Context, claim *domain.IdempotencyRecord) (bool, error) {
+	query := `
+		INSERT INTO idempotency_keys (key, request_hash, status_code, response_body, created_at, expires_at)
+		VALUES ($1, $2, 0, '', $3, $4)
+		ON CONFLICT (key) DO UPDATE SET request_hash = EXCLUDED.request_hash, status_code = EXCLUDED.status_code,
+			response_body = EXCLUDED.response_body, created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= EXCLUDED.created_at`
+
+	res, err := r.db.ExecContext(ctx, query, claim.Key, claim.RequestHash, claim.CreatedAt.UTC(), claim.ExpiresAt.UTC())
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to claim idempotency key", zap.Error(err))
+		return false, pkgerrors.ErrInternal
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, pkgerrors.ErrInternal
+	}
+	return rows == 1, nil
+}
+
+func (r *idempotencyRepository) Get(ctx context.Context, key string, now time.Time) (*domain.IdempotencyRecord, error) {
+	query := `
+		SELECT key, request_hash, status_code, response_body, created_at, expires_at
+		FROM idempotency_keys WHERE key = $1 AND expires_at > $2`
+
+	rec := &domain.IdempotencyRecord{}
+	err := r.db.QueryRowContext(ctx, query, key, now.UTC()).Scan(
+		&rec.Key, &rec.RequestHash, &rec.StatusCode, &rec.Body, &rec.CreatedAt, &rec.ExpiresAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, pkgerrors.ErrNotFound
+	}
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to get idempotency key", zap.Error(err))
+		return nil, pkgerrors.ErrInternal
+	}
+	return rec, nil
+}
+
+func (r *idempotencyRepository) Save(ctx context.Context, rec *domain.IdempotencyRecord) error {
+	query := `
+		INSERT INTO idempotency_keys (key, request_hash, status_code, response_body, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (key) DO UPDATE SET request_hash = EXCLUDED.request_hash, status_code = EXCLUDED.status_code,
+			response_body = EXCLUDED.response_body, created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= EXCLUDED.created_at
+			OR (idempotency_keys.status_code = 0 AND idempotency_keys.request_hash = EXCLUDED.request_hash)`
+
+	_, err := r.db.ExecContext(ctx, query,
+		rec.Key, rec.RequestHash, rec.StatusCode, rec.Body, rec.CreatedAt.UTC(), rec.ExpiresAt.UTC())
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to save idempotency key", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	return nil
+}
+
+func (r *idempotencyRepository) Release(ctx context.Context, key, requestHash string) error {
+	query := `DELETE FROM idempotency_keys WHERE key = $1 AND request_hash = $2 AND status_code = 0`
+
+	if _, err := r.db.ExecContext(ctx, query, key, requestHash); err != nil {
+		logger.FromContext(ctx).Error("failed to release idempotency key", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	return nil
+}
+
+func (r *idempotencyRepository) DeleteExpired(ctx context.Context, now time.Time, limit int) (int64, error) {
+	query := `
+		DELETE FROM idempotency_keys WHERE key IN (
+			SELECT key FROM idempotency_keys WHERE expires_at <= $1 LIMIT $2
+		)`
+
+	res, err := r.db.ExecContext(ctx, query, now.UTC(), limit)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to delete expired idempotency keys", zap.Error(err))
+		return 0, pkgerrors.ErrInternal
+	}
+	n, _ := res.RowsAffected()
+	return n, nil
+}
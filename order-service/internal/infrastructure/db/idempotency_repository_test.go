@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/user/go-microservices/order-service/internal/domain"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+)
+
+func TestIdempotencyRepository(t *testing.T) {
+	logger.Init()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer db.Close()
+
+	repo := NewIdempotencyRepository(db)
+	now := time.Now()
+
+	t.Run("Claim_Claimed", func(t *testing.T) {
+		claim := domain.NewIdempotencyClaim("k1", "abc", now)
+
+		mock.ExpectExec("INSERT INTO idempotency_keys (.+) VALUES \\(\\$1, \\$2, 0, '', \\$3, \\$4\\) ON CONFLICT \\(key\\) DO UPDATE (.+) WHERE idempotency_keys.expires_at <= EXCLUDED.created_at").
+			WithArgs("k1", "abc", now.UTC(), claim.ExpiresAt.UTC()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		claimed, err := repo.Claim(context.Background(), claim)
+
+		assert.NoError(t, err)
+		assert.True(t, claimed)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Claim_HeldByAnother", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO idempotency_keys").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		claimed, err := repo.Claim(context.Background(), domain.NewIdempotencyClaim("k1", "abc", now))
+
+		assert.NoError(t, err)
+		assert.False(t, claimed)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Get_Success", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM idempotency_keys WHERE key = \\$1 AND expires_at > \\$2").
+			WithArgs("k1", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"key", "request_hash", "status_code", "response_body", "created_at", "expires_at"}).
+				AddRow("k1", "abc", 201, []byte(`{"id":42}`), now, now.Add(time.Hour)))
+
+		rec, err := repo.Get(context.Background(), "k1", now)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 201, rec.StatusCode)
+		assert.Equal(t, []byte(`{"id":42}`), rec.Body)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Get_NotFound", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM idempotency_keys").
+			WithArgs("k2", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"key", "request_hash", "status_code", "response_body", "created_at", "expires_at"}))
+
+		_, err := repo.Get(context.Background(), "k2", now)
+
+		assert.ErrorIs(t, err, pkgerrors.ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Save_ReplacesClaimOrExpired", func(t *testing.T) {
+		rec := domain.NewIdempotencyRecord("k1", "abc", 201, []byte(`{"id":42}`), now, time.Hour)
+
+		mock.ExpectExec("INSERT INTO idempotency_keys (.+) ON CONFLICT \\(key\\) DO UPDATE (.+) WHERE idempotency_keys.expires_at <= EXCLUDED.created_at (.+)status_code = 0 AND idempotency_keys.request_hash = EXCLUDED.request_hash").
+			WithArgs("k1", "abc", 201, []byte(`{"id":42}`), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := repo.Save(context.Background(), rec)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Release_DeletesClaimOnly", func(t *testing.T) {
+		mock.ExpectExec("DELETE FROM idempotency_keys WHERE key = \\$1 AND request_hash = \\$2 AND status_code = 0").
+			WithArgs("k1", "abc").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := repo.Release(context.Background(), "k1", "abc")
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DeleteExpired_Success", func(t *testing.T) {
+		mock.ExpectExec("DELETE FROM idempotency_keys").
+			WithArgs(sqlmock.AnyArg(), 500).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+
+		n, err := repo.DeleteExpired(context.Background(), now, 500)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), n)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/lib/pq"
+	"github.com/user/go-microservices/order-service/internal/domain"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+	"go.uber.org/zap"
+)
+
+type invoiceRepository struct {
+	db *sql.DB
+}
+
+func NewInvoiceRepository(db *sql.DB) domain.InvoiceRepository {
+	return &invoiceRepository{db: db}
+}
+
+func (r *invoiceRepository) Issue(ctx context.Context, inv *domain.Invoice) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to begin transaction", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	defer tx.Rollback()
+
+	if err := issueInvoice(ctx, tx, inv); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.FromContext(ctx).Error("failed to commit invoice", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	return nil
+}
+
+// issueInvoice takes the next number of the invoice's year and stores the
+// invoice in tx. The sequence row stays locked until tx ends, and a rolled
+// back transaction gives its number back, so numbers have no gaps.
+func issueInvoice(ctx context.Context, tx *sql.Tx, inv *domain.Invoice) error {
+	var sequence int64
+	err := tx.QueryRowContext(ctx, `
+		INSERT INTO invoice_sequences (year, last_number) VALUES ($1, 1)
+		ON CONFLICT (year) DO UPDATE SET last_number = invoice_sequences.last_number + 1
+		RETURNING last_number`, inv.Year()).Scan(&sequence)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to allocate invoice number", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	inv.AssignNumber(sequence)
+
+	lines, err := json.Marshal(inv.Lines)
+	if err != nil {
+		return pkgerrors.ErrInternal
+	}
+
+	query := `
+		INSERT INTO invoices (number, order_id, user_id, region, lines, subtotal, discount, discount_code,
+			tax_amount, shipping_amount, total, payment_reference, issued_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id`
+	err = tx.QueryRowContext(ctx, query,
+		inv.Number, inv.OrderID, inv.UserID, inv.Region, lines, inv.Subtotal, inv.Discount, inv.DiscountCode,
+		inv.Tax, inv.Shipping, inv.Total, inv.PaymentReference, inv.IssuedAt,
+	).Scan(&inv.ID)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return pkgerrors.ErrConflict
+	}
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to create invoice", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	return nil
+}
+
+func (r *invoiceRepository) GetByOrderID(ctx context.Context, orderID int64) (*domain.Invoice, error) {
+	query := `
+		SELECT id, number, order_id, user_id, region, lines, subtotal, discount, discount_code,
+			tax_amount, shipping_amount, total, payment_reference, issued_at
+		FROM invoices WHERE order_id = $1`
+
+	inv := &domain.Invoice{}
+	var lines []byte
+	err := r.db.QueryRowContext(ctx, query, orderID).Scan(
+		&inv.ID, &inv.Number, &inv.OrderID, &inv.UserID, &inv.Region, &lines, &inv.Subtotal, &inv.Discount, &inv.DiscountCode,
+		&inv.Tax, &inv.Shipping, &inv.Total, &inv.PaymentReference, &inv.IssuedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, pkgerrors.ErrNotFound
+	}
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to get invoice", zap.Error(err))
+		return nil, pkgerrors.ErrInternal
+	}
+
+	if err := json.Unmarshal(lines, &inv.Lines); err != nil {
+		logger.FromContext(ctx).Error("failed to decode invoice lines", zap.Error(err))
+		return nil, pkgerrors.ErrInternal
+	}
+	return inv, nil
+}
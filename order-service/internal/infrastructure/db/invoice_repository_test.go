@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/user/go-microservices/order-service/internal/domain"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+	"github.com/user/go-microservices/pkg/valueobject"
+)
+
+func TestInvoiceRepository(t *testing.T) {
+	logger.Init()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer db.Close()
+
+	repo := NewInvoiceRepository(db)
+	issuedAt := time.Date(2026, 12, 31, 23, 0, 0, 0, time.UTC)
+
+	t.Run("Issue_Success", func(t *testing.T) {
+		inv := &domain.Invoice{OrderID: 1, UserID: 101, Total: valueobject.NewMoney(200), IssuedAt: issuedAt}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("INSERT INTO invoice_sequences").
+			WithArgs(2026).
+			WillReturnRows(sqlmock.NewRows([]string{"last_number"}).AddRow(42))
+		mock.ExpectQuery("INSERT INTO invoices").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+		mock.ExpectCommit()
+
+		err := repo.Issue(context.Background(), inv)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "INV-2026-000042", inv.Number)
+		assert.Equal(t, int64(9), inv.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Issue_AlreadyInvoiced_RollsBackNumber", func(t *testing.T) {
+		inv := &domain.Invoice{OrderID: 1, UserID: 101, IssuedAt: issuedAt}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("INSERT INTO invoice_sequences").
+			WithArgs(2026).
+			WillReturnRows(sqlmock.NewRows([]string{"last_number"}).AddRow(43))
+		mock.ExpectQuery("INSERT INTO invoices").
+			WillReturnError(&pq.Error{Code: "23505"})
+		mock.ExpectRollback()
+
+		err := repo.Issue(context.Background(), inv)
+
+		assert.ErrorIs(t, err, pkgerrors.ErrConflict)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetByOrderID_Success", func(t *testing.T) {
+		columns := []string{"id", "number", "order_id", "user_id", "region", "lines", "subtotal", "discount", "discount_code",
+			"tax_amount", "shipping_amount", "total", "payment_reference", "issued_at"}
+		mock.ExpectQuery("SELECT (.+) FROM invoices WHERE order_id = \\$1").
+			WithArgs(int64(1)).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(
+				9, "INV-2026-000042", 1, 101, "US-CA", []byte(`[{"product_id":1,"description":"Widget","quantity":2,"unit_price":100,"tax_rate":0,"line_total":200}]`),
+				200.0, 0.0, "", 0.0, 0.0, 200.0, "auth_1", issuedAt,
+			))
+
+		inv, err := repo.GetByOrderID(context.Background(), 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "INV-2026-000042", inv.Number)
+		assert.Equal(t, "Widget", inv.Lines[0].Description)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetByOrderID_NotFound", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM invoices WHERE order_id = \\$1").
+			WithArgs(int64(2)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		_, err := repo.GetByOrderID(context.Background(), 2)
+
+		assert.ErrorIs(t, err, pkgerrors.ErrNotFound)
+	})
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// advisoryLocker implements domain.Locker with Postgres session advisory locks.
+// Each held lock pins one pooled connection until it is unlocked.
+type advisoryLocker struct {
+	db *sql.DB
+}
+
+func NewAdvisoryLocker(db *sql.DB) domain.Locker {
+	return &advisoryLocker{db: db}
+}
+
+func (l *advisoryLocker) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to get connection for lock", zap.Error(err))
+		return nil, false, pkgerrors.ErrInternal
+	}
+
+	var acquired bool
+	err = conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock(hashtext($1))`, name).Scan(&acquired)
+	if err != nil {
+		// The lock may have been taken before the error, so the session
+		// must not go back to the pool
+		discard(conn)
+		logger.FromContext(ctx).Error("failed to acquire advisory lock", zap.String("lock", name), zap.Error(err))
+		return nil, false, pkgerrors.ErrInternal
+	}
+	if !acquired {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	unlock := func() {
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock(hashtext($1))`, name); err != nil {
+			// The session may still hold the lock; ending it releases the lock
+			logger.FromContext(ctx).Error("failed to release advisory lock", zap.String("lock", name), zap.Error(err))
+			discard(conn)
+			return
+		}
+		conn.Close()
+	}
+	return unlock, true, nil
+}
+
+// discard closes conn's session instead of returning it to the pool, which
+// releases any advisory lock it still holds
+func discard(conn *sql.Conn) {
+	conn.Raw(func(any) error { return driver.ErrBadConn })
+	conn.Close()
+}
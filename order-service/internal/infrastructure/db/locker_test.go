@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+)
+
+func TestAdvisoryLocker(t *testing.T) {
+	logger.Init()
+
+	newLocker := func(t *testing.T) (*advisoryLocker, sqlmock.Sqlmock, func() int) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("failed to open sqlmock: %s", err)
+		}
+		t.Cleanup(func() { db.Close() })
+		return &advisoryLocker{db: db}, mock, func() int { return db.Stats().OpenConnections }
+	}
+
+	t.Run("Held_NotAcquired", func(t *testing.T) {
+		locker, mock, _ := newLocker(t)
+		mock.ExpectQuery("SELECT pg_try_advisory_lock\\(hashtext\\(\\$1\\)\\)").
+			WithArgs("outbox-relay").
+			WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(false))
+
+		unlock, acquired, err := locker.TryLock(context.Background(), "outbox-relay")
+
+		assert.NoError(t, err)
+		assert.False(t, acquired)
+		assert.Nil(t, unlock)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Unlock_ReturnsConnectionToPool", func(t *testing.T) {
+		locker, mock, open := newLocker(t)
+		mock.ExpectQuery("SELECT pg_try_advisory_lock").
+			WithArgs("outbox-relay").
+			WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(true))
+		mock.ExpectExec("SELECT pg_advisory_unlock\\(hashtext\\(\\$1\\)\\)").
+			WithArgs("outbox-relay").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		unlock, acquired, err := locker.TryLock(context.Background(), "outbox-relay")
+		assert.NoError(t, err)
+		assert.True(t, acquired)
+		unlock()
+
+		assert.Equal(t, 1, open())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("UnlockFails_DiscardsConnection", func(t *testing.T) {
+		locker, mock, open := newLocker(t)
+		mock.ExpectQuery("SELECT pg_try_advisory_lock").
+			WithArgs("outbox-relay").
+			WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(true))
+		mock.ExpectExec("SELECT pg_advisory_unlock").
+			WithArgs("outbox-relay").
+			WillReturnError(assert.AnError)
+
+		unlock, acquired, err := locker.TryLock(context.Background(), "outbox-relay")
+		assert.NoError(t, err)
+		assert.True(t, acquired)
+		unlock()
+
+		// The session may still hold the lock, so it must not be reused
+		assert.Equal(t, 0, open())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("LockFails_DiscardsConnection", func(t *testing.T) {
+		locker, mock, open := newLocker(t)
+		mock.ExpectQuery("SELECT pg_try_advisory_lock").
+			WithArgs("outbox-relay").
+			WillReturnError(assert.AnError)
+
+		_, acquired, err := locker.TryLock(context.Background(), "outbox-relay")
+
+		assert.ErrorIs(t, err, pkgerrors.ErrInternal)
+		assert.False(t, acquired)
+		assert.Equal(t, 0, open())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+	"go.uber.org/zap"
+)
+
+type outboxRepository struct {
+	db *sql.DB
+}
+
+func NewOutboxRepository(db *sql.DB) domain.OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+func (r *outboxRepository) FindUnpublished(ctx context.Context, limit int) ([]*domain.OrderEvent, error) {
+	query := `
+		SELECT id, payload FROM order_outbox
+		WHERE published_at IS NULL
+		ORDER BY id
+		LIMIT $1`
+
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to find unpublished events", zap.Error(err))
+		return nil, pkgerrors.ErrInternal
+	}
+	defer rows.Close()
+
+	var events []*domain.OrderEvent
+	for rows.Next() {
+		var id int64
+		var payload []byte
+		if err := rows.Scan(&id, &payload); err != nil {
+			logger.FromContext(ctx).Error("failed to scan outbox event", zap.Error(err))
+			return nil, pkgerrors.ErrInternal
+		}
+		e := &domain.OrderEvent{}
+		if err := json.Unmarshal(payload, e); err != nil {
+			logger.FromContext(ctx).Error("failed to decode outbox event", zap.Int64("event_id", id), zap.Error(err))
+			return nil, pkgerrors.ErrInternal
+		}
+		e.ID = id
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+func (r *outboxRepository) MarkPublished(ctx context.Context, id int64, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE order_outbox SET published_at = $1 WHERE id = $2`, at.UTC(), id)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to mark event published", zap.Int64("event_id", id), zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	return nil
+}
+
+func (r *outboxRepository) DeletePublished(ctx context.Context, before time.Time, limit int) (int64, error) {
+	query := `
+		DELETE FROM order_outbox WHERE id IN (
+			SELECT id FROM order_outbox WHERE published_at <= $1 LIMIT $2
+		)`
+
+	res, err := r.db.ExecContext(ctx, query, before.UTC(), limit)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to delete published events", zap.Error(err))
+		return 0, pkgerrors.ErrInternal
+	}
+	n, _ := res.RowsAffected()
+	return n, nil
+}
+
+// insertEvents writes the events raised by a status change to the outbox in
+// the change's transaction, so an event exists if and only if the change
+// was committed
+func insertEvents(ctx context.Context, tx *sql.Tx, c *domain.StatusChange) error {
+	for _, e := range domain.EventsFor(c) {
+		e.OccurredAt = e.OccurredAt.UTC()
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return pkgerrors.ErrInternal
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO order_outbox (order_id, event_type, payload, created_at)
+			VALUES ($1, $2, $3, $4)`,
+			e.OrderID, e.Type, payload, e.OccurredAt)
+		if err != nil {
+			logger.FromContext(ctx).Error("failed to write outbox event", zap.String("type", string(e.Type)), zap.Error(err))
+			return pkgerrors.ErrInternal
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/user/go-microservices/order-service/internal/domain"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+)
+
+func TestOutboxRepository(t *testing.T) {
+	logger.Init()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer db.Close()
+
+	repo := NewOutboxRepository(db)
+
+	t.Run("FindUnpublished_InWriteOrder", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id, payload FROM order_outbox (.+) ORDER BY id").
+			WithArgs(50).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "payload"}).
+				AddRow(3, []byte(`{"type":"OrderPaid","order_id":7,"order_status":"PENDING","payment_status":"PAID"}`)))
+
+		events, err := repo.FindUnpublished(context.Background(), 50)
+
+		assert.NoError(t, err)
+		assert.Len(t, events, 1)
+		assert.Equal(t, int64(3), events[0].ID)
+		assert.Equal(t, domain.EventOrderPaid, events[0].Type)
+		assert.Equal(t, int64(7), events[0].OrderID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("FindUnpublished_Error", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id, payload FROM order_outbox").
+			WithArgs(50).
+			WillReturnError(assert.AnError)
+
+		_, err := repo.FindUnpublished(context.Background(), 50)
+
+		assert.ErrorIs(t, err, pkgerrors.ErrInternal)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("MarkPublished_Success", func(t *testing.T) {
+		mock.ExpectExec("UPDATE order_outbox SET published_at = \\$1 WHERE id = \\$2").
+			WithArgs(sqlmock.AnyArg(), int64(3)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := repo.MarkPublished(context.Background(), 3, time.Now())
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("DeletePublished_Success", func(t *testing.T) {
+		mock.ExpectExec("DELETE FROM order_outbox").
+			WithArgs(sqlmock.AnyArg(), 500).
+			WillReturnResult(sqlmock.NewResult(0, 4))
+
+		n, err := repo.DeletePublished(context.Background(), time.Now(), 500)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), n)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
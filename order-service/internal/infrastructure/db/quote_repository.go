@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+	"go.uber.org/zap"
+)
+
+type quoteRepository struct {
+	db *sql.DB
+}
+
+func NewQuoteRepository(db *sql.DB) domain.QuoteRepository {
+	return &quoteRepository{db: db}
+}
+
+// Create stores the quote. Its lines are kept as a JSON document since a
+// quote is never updated.
+func (r *quoteRepository) Create(ctx context.Context, q *domain.Quote) error {
+	items, err := json.Marshal(q.Items)
+	if err != nil {
+		return pkgerrors.ErrInternal
+	}
+	shippingRate, err := marshalShippingRate(q.ShippingRate)
+	if err != nil {
+		return pkgerrors.ErrInternal
+	}
+
+	query := `
+		INSERT INTO quotes (user_id, region, items, shipping_rate, subtotal, tax_amount, shipping_amount, total_price, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id`
+	err = r.db.QueryRowContext(ctx, query,
+		q.UserID, q.Region, items, shippingRate, q.Subtotal, q.Tax, q.Shipping, q.TotalPrice, q.ExpiresAt, q.CreatedAt,
+	).Scan(&q.ID)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to create quote", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	return nil
+}
+
+func (r *quoteRepository) GetByID(ctx context.Context, id int64) (*domain.Quote, error) {
+	query := `
+		SELECT id, user_id, region, items, shipping_rate, subtotal, tax_amount, shipping_amount, total_price, expires_at, created_at
+		FROM quotes WHERE id = $1`
+
+	q := &domain.Quote{}
+	var items, shippingRate []byte
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&q.ID, &q.UserID, &q.Region, &items, &shippingRate, &q.Subtotal, &q.Tax, &q.Shipping, &q.TotalPrice, &q.ExpiresAt, &q.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, pkgerrors.ErrNotFound
+	}
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to get quote", zap.Error(err))
+		return nil, pkgerrors.ErrInternal
+	}
+
+	if err := json.Unmarshal(items, &q.Items); err != nil {
+		logger.FromContext(ctx).Error("failed to decode quote items", zap.Error(err))
+		return nil, pkgerrors.ErrInternal
+	}
+	if shippingRate != nil {
+		q.ShippingRate = &domain.ShippingRate{}
+		if err := json.Unmarshal(shippingRate, q.ShippingRate); err != nil {
+			logger.FromContext(ctx).Error("failed to decode quote shipping rate", zap.Error(err))
+			return nil, pkgerrors.ErrInternal
+		}
+	}
+	return q, nil
+}
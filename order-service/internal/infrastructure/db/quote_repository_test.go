@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/user/go-microservices/order-service/internal/domain"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+	"github.com/user/go-microservices/pkg/valueobject"
+)
+
+func TestQuoteRepository(t *testing.T) {
+	logger.Init()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer db.Close()
+
+	repo := NewQuoteRepository(db)
+	columns := []string{"id", "user_id", "region", "items", "shipping_rate", "subtotal", "tax_amount", "shipping_amount", "total_price", "expires_at", "created_at"}
+	now := time.Now()
+
+	t.Run("Create_Success", func(t *testing.T) {
+		q := &domain.Quote{UserID: 1, Items: []domain.OrderItem{{ProductID: 1, Quantity: 2}}, ExpiresAt: now, CreatedAt: now}
+
+		mock.ExpectQuery("INSERT INTO quotes").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+
+		err := repo.Create(context.Background(), q)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(5), q.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetByID_Success", func(t *testing.T) {
+		items := []byte(`[{"product_id":1,"product_name":"A","unit_price":80,"quantity":2,"line_total":160,"tax_category":"STANDARD","tax_rate":10}]`)
+		rate := []byte(`{"zone":"US","base_fee":5,"per_kg_fee":2,"free_over":0}`)
+		mock.ExpectQuery("SELECT (.+) FROM quotes WHERE id = \\$1").
+			WithArgs(5).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(5, 1, "US-CA", items, rate, 160.0, 16.0, 5.0, 181.0, now, now))
+
+		q, err := repo.GetByID(context.Background(), 5)
+
+		assert.NoError(t, err)
+		assert.Len(t, q.Items, 1)
+		assert.Equal(t, valueobject.NewMoney(80), q.Items[0].UnitPrice)
+		assert.Equal(t, 10.0, q.Items[0].TaxRate)
+		assert.Equal(t, "US", q.ShippingRate.Zone)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetByID_NotFound", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM quotes WHERE id = \\$1").
+			WithArgs(9).
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		_, err := repo.GetByID(context.Background(), 9)
+
+		assert.ErrorIs(t, err, pkgerrors.ErrNotFound)
+	})
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/user/go-microservices/order-service/internal/domain"
@@ -20,34 +21,76 @@ func NewOrderRepository(db *sql.DB) domain.OrderRepository {
 }
 
 func (r *postgresRepository) Create(ctx context.Context, o *domain.Order) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to begin transaction", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	defer tx.Rollback()
+
 	query := `
-		INSERT INTO orders (user_id, product_id, product_name, unit_price, quantity, total_price, order_status, payment_status, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO orders (user_id, tax_region, shipping_rate, subtotal, tax_amount, shipping_amount, total_price, refunded_amount, order_status, payment_status, subscription_id, saga_id, release_at, idempotency_key, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
 		RETURNING id`
 
+	shippingRate, err := marshalShippingRate(o.ShippingRate)
+	if err != nil {
+		return pkgerrors.ErrInternal
+	}
 	now := time.Now().UTC()
-	err := r.db.QueryRowContext(ctx, query,
-		o.UserID, o.ProductID, o.ProductName, o.UnitPrice, o.Quantity, o.TotalPrice,
-		o.OrderStatus, o.PaymentStatus, now,
+	err = tx.QueryRowContext(ctx, query,
+		o.UserID, o.TaxRegion, shippingRate, o.Subtotal, o.Tax, o.Shipping, o.TotalPrice, o.RefundedAmount, o.OrderStatus, o.PaymentStatus, o.SubscriptionID, o.SagaID, o.ReleaseAt, o.IdempotencyKey, now,
 	).Scan(&o.ID)
-
 	if err != nil {
 		logger.FromContext(ctx).Error("failed to create order", zap.Error(err))
 		return pkgerrors.ErrInternal
 	}
+
+	itemQuery := `
+		INSERT INTO order_items (order_id, product_id, product_name, unit_price, quantity, line_total, tax_category, tax_rate, weight_grams)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id`
+
+	for i := range o.Items {
+		item := &o.Items[i]
+		item.OrderID = o.ID
+		err = tx.QueryRowContext(ctx, itemQuery,
+			item.OrderID, item.ProductID, item.ProductName, item.UnitPrice, item.Quantity, item.LineTotal, item.TaxCategory, item.TaxRate, item.WeightGrams,
+		).Scan(&item.ID)
+		if err != nil {
+			logger.FromContext(ctx).Error("failed to create order item", zap.Error(err))
+			return pkgerrors.ErrInternal
+		}
+	}
+
+	if o.Discount != nil {
+		if err := redeemCoupon(ctx, tx, o); err != nil {
+			return err
+		}
+		o.Discount.OrderID = o.ID
+		if err := insertOrderDiscount(ctx, tx, o.Discount); err != nil {
+			return err
+		}
+	}
+
+	created := domain.NewStatusChange(o, domain.OrderState{}, "order created", domain.ActorFromContext(ctx))
+	created.CreatedAt = now
+	if err := insertStatusChange(ctx, tx, created); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.FromContext(ctx).Error("failed to commit order", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
 	o.CreatedAt = now
 	return nil
 }
 
 func (r *postgresRepository) GetByID(ctx context.Context, id int64) (*domain.Order, error) {
-	query := `SELECT id, user_id, product_id, product_name, unit_price, quantity, total_price, order_status, payment_status, created_at FROM orders WHERE id = $1`
-
-	o := &domain.Order{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&o.ID, &o.UserID, &o.ProductID, &o.ProductName, &o.UnitPrice,
-		&o.Quantity, &o.TotalPrice, &o.OrderStatus, &o.PaymentStatus, &o.CreatedAt,
-	)
+	query := `SELECT id, user_id, tax_region, shipping_rate, subtotal, tax_amount, shipping_amount, total_price, refunded_amount, order_status, payment_status, payment_reference, subscription_id, release_at, allocated_at, version, created_at FROM orders WHERE id = $1`
 
+	o, err := scanOrder(r.db.QueryRowContext(ctx, query, id))
 	if err == sql.ErrNoRows {
 		return nil, pkgerrors.ErrNotFound
 	}
@@ -55,21 +98,356 @@ func (r *postgresRepository) GetByID(ctx context.Context, id int64) (*domain.Ord
 		logger.FromContext(ctx).Error("failed to get order", zap.Error(err))
 		return nil, pkgerrors.ErrInternal
 	}
+
+	itemQuery := `SELECT id, order_id, product_id, product_name, unit_price, quantity, line_total, tax_category, tax_rate, weight_grams FROM order_items WHERE order_id = $1 ORDER BY id`
+	rows, err := r.db.QueryContext(ctx, itemQuery, id)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to get order items", zap.Error(err))
+		return nil, pkgerrors.ErrInternal
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item, err := scanOrderItem(rows)
+		if err != nil {
+			logger.FromContext(ctx).Error("failed to scan order item", zap.Error(err))
+			return nil, pkgerrors.ErrInternal
+		}
+		o.Items = append(o.Items, item)
+	}
+
+	discountQuery := `SELECT id, order_id, coupon_id, code, terms, amount FROM order_discounts WHERE order_id = $1`
+	discount, err := scanOrderDiscount(r.db.QueryRowContext(ctx, discountQuery, id))
+	if err != nil && err != sql.ErrNoRows {
+		logger.FromContext(ctx).Error("failed to get order discount", zap.Error(err))
+		return nil, pkgerrors.ErrInternal
+	}
+	o.Discount = discount
 	return o, nil
 }
 
-func (r *postgresRepository) UpdateStatus(ctx context.Context, id int64, status domain.OrderStatus, paymentStatus domain.PaymentStatus) error {
-	query := `UPDATE orders SET order_status = $1, payment_status = $2 WHERE id = $3`
-	_, err := r.db.ExecContext(ctx, query, status, paymentStatus, id)
+func (r *postgresRepository) UpdateStatus(ctx context.Context, change *domain.StatusChange) error {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		logger.FromContext(ctx).Error("failed to update order status", zap.Error(err))
+		logger.FromContext(ctx).Error("failed to begin transaction", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	defer tx.Rollback()
+
+	if err := applyStatusChange(ctx, tx, change); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.FromContext(ctx).Error("failed to commit order status", zap.Error(err))
 		return pkgerrors.ErrInternal
 	}
 	return nil
 }
 
+func (r *postgresRepository) RecordPayment(ctx context.Context, o *domain.Order, change *domain.StatusChange, invoice *domain.Invoice) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to begin transaction", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	defer tx.Rollback()
+
+	if err := applyStatusChange(ctx, tx, change); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE orders SET payment_reference = $1 WHERE id = $2`, o.PaymentReference, o.ID); err != nil {
+		logger.FromContext(ctx).Error("failed to store payment reference", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	if err := issueInvoice(ctx, tx, invoice); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.FromContext(ctx).Error("failed to commit order payment", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	return nil
+}
+
+func (r *postgresRepository) SavePaymentEvent(ctx context.Context, event *domain.PaymentEvent, o *domain.Order, change *domain.StatusChange, invoice *domain.Invoice) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to begin transaction", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	defer tx.Rollback()
+
+	// A concurrent delivery of the same event blocks here until the first
+	// commits and then inserts nothing
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO payment_events (event_id, order_id, type) VALUES ($1, $2, $3) ON CONFLICT (event_id) DO NOTHING`,
+		event.ID, event.OrderID, event.Type)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to store payment event", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		logger.FromContext(ctx).Error("failed to store payment event", zap.Error(err))
+		return pkgerrors.ErrInternal
+	} else if n == 0 {
+		return domain.ErrDuplicatePaymentEvent
+	}
+
+	if change != nil {
+		if err := applyStatusChange(ctx, tx, change); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE orders SET payment_reference = $1 WHERE id = $2`, o.PaymentReference, o.ID); err != nil {
+			logger.FromContext(ctx).Error("failed to store payment reference", zap.Error(err))
+			return pkgerrors.ErrInternal
+		}
+	}
+	if invoice != nil {
+		if err := issueInvoice(ctx, tx, invoice); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.FromContext(ctx).Error("failed to commit payment event", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	return nil
+}
+
+func (r *postgresRepository) UpdateItems(ctx context.Context, o *domain.Order, change *domain.StatusChange) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to begin transaction", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	defer tx.Rollback()
+
+	// Compare and swap the version, so of two amendments based on the same
+	// lines only the first is stored
+	res, err := tx.ExecContext(ctx,
+		`UPDATE orders SET subtotal = $1, tax_amount = $2, shipping_amount = $3, total_price = $4, version = version + 1 WHERE id = $5 AND version = $6`,
+		o.Subtotal, o.Tax, o.Shipping, o.TotalPrice, o.ID, o.Version)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to update order total", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return pkgerrors.ErrInternal
+	}
+	if rows == 0 {
+		return pkgerrors.ErrConflict
+	}
+
+	if err := applyStatusChange(ctx, tx, change); err != nil {
+		return err
+	}
+
+	itemQuery := `UPDATE order_items SET quantity = $1, line_total = $2 WHERE order_id = $3 AND product_id = $4`
+	for _, item := range o.Items {
+		if _, err := tx.ExecContext(ctx, itemQuery, item.Quantity, item.LineTotal, o.ID, item.ProductID); err != nil {
+			logger.FromContext(ctx).Error("failed to update order item", zap.Error(err))
+			return pkgerrors.ErrInternal
+		}
+	}
+
+	if o.Discount != nil {
+		_, err := tx.ExecContext(ctx, `UPDATE order_discounts SET amount = $1 WHERE order_id = $2`, o.Discount.Amount, o.ID)
+		if err != nil {
+			logger.FromContext(ctx).Error("failed to update order discount", zap.Error(err))
+			return pkgerrors.ErrInternal
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.FromContext(ctx).Error("failed to commit order items", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	o.Version++
+	return nil
+}
+
+func (r *postgresRepository) SaveRefund(ctx context.Context, refund *domain.Refund, change *domain.StatusChange) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to begin transaction", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	defer tx.Rollback()
+
+	if err := insertRefund(ctx, tx, refund, change); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.FromContext(ctx).Error("failed to commit refund", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	return nil
+}
+
+func (r *postgresRepository) GetRefunds(ctx context.Context, orderID int64) ([]*domain.Refund, error) {
+	query := `SELECT id, order_id, amount, reason, created_at FROM order_refunds WHERE order_id = $1 ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query, orderID)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to get refunds", zap.Error(err))
+		return nil, pkgerrors.ErrInternal
+	}
+	defer rows.Close()
+
+	refunds := []*domain.Refund{}
+	for rows.Next() {
+		rf := &domain.Refund{}
+		if err := rows.Scan(&rf.ID, &rf.OrderID, &rf.Amount, &rf.Reason, &rf.CreatedAt); err != nil {
+			logger.FromContext(ctx).Error("failed to scan refund", zap.Error(err))
+			return nil, pkgerrors.ErrInternal
+		}
+		refunds = append(refunds, rf)
+	}
+	return refunds, nil
+}
+
+func (r *postgresRepository) CreateShipment(ctx context.Context, shipment *domain.Shipment, change *domain.StatusChange) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to begin transaction", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	defer tx.Rollback()
+
+	if err := applyStatusChange(ctx, tx, change); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO order_shipments (order_id, carrier, tracking_number, shipped_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`
+	shipment.ShippedAt = shipment.ShippedAt.UTC()
+	err = tx.QueryRowContext(ctx, query, shipment.OrderID, shipment.Carrier, shipment.TrackingNumber, shipment.ShippedAt).Scan(&shipment.ID)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to create shipment", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.FromContext(ctx).Error("failed to commit shipment", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	return nil
+}
+
+func (r *postgresRepository) MarkDelivered(ctx context.Context, shipment *domain.Shipment, change *domain.StatusChange) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to begin transaction", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	defer tx.Rollback()
+
+	if err := applyStatusChange(ctx, tx, change); err != nil {
+		return err
+	}
+
+	deliveredAt := shipment.DeliveredAt.UTC()
+	shipment.DeliveredAt = &deliveredAt
+	res, err := tx.ExecContext(ctx,
+		`UPDATE order_shipments SET delivered_at = $1 WHERE id = $2 AND delivered_at IS NULL`,
+		deliveredAt, shipment.ID,
+	)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to mark shipment delivered", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return pkgerrors.ErrInternal
+	}
+	if rows == 0 {
+		return pkgerrors.ErrConflict
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.FromContext(ctx).Error("failed to commit delivery", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	return nil
+}
+
+func (r *postgresRepository) GetShipment(ctx context.Context, id int64) (*domain.Shipment, error) {
+	query := `SELECT id, order_id, carrier, tracking_number, shipped_at, delivered_at FROM order_shipments WHERE id = $1`
+
+	s := &domain.Shipment{}
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&s.ID, &s.OrderID, &s.Carrier, &s.TrackingNumber, &s.ShippedAt, &s.DeliveredAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, pkgerrors.ErrNotFound
+	}
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to get shipment", zap.Error(err))
+		return nil, pkgerrors.ErrInternal
+	}
+	return s, nil
+}
+
+func (r *postgresRepository) GetShipments(ctx context.Context, orderID int64) ([]*domain.Shipment, error) {
+	query := `SELECT id, order_id, carrier, tracking_number, shipped_at, delivered_at FROM order_shipments WHERE order_id = $1 ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query, orderID)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to get shipments", zap.Error(err))
+		return nil, pkgerrors.ErrInternal
+	}
+	defer rows.Close()
+
+	shipments := []*domain.Shipment{}
+	for rows.Next() {
+		s := &domain.Shipment{}
+		if err := rows.Scan(&s.ID, &s.OrderID, &s.Carrier, &s.TrackingNumber, &s.ShippedAt, &s.DeliveredAt); err != nil {
+			logger.FromContext(ctx).Error("failed to scan shipment", zap.Error(err))
+			return nil, pkgerrors.ErrInternal
+		}
+		shipments = append(shipments, s)
+	}
+	return shipments, nil
+}
+
+func (r *postgresRepository) GetStatusHistory(ctx context.Context, orderID int64) ([]*domain.StatusChange, error) {
+	query := `
+		SELECT id, order_id, from_order_status, from_payment_status, to_order_status, to_payment_status, reason, actor, created_at
+		FROM order_status_history WHERE order_id = $1 ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query, orderID)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to get order status history", zap.Error(err))
+		return nil, pkgerrors.ErrInternal
+	}
+	defer rows.Close()
+
+	history := []*domain.StatusChange{}
+	for rows.Next() {
+		c := &domain.StatusChange{}
+		err := rows.Scan(
+			&c.ID, &c.OrderID, &c.FromOrderStatus, &c.FromPaymentStatus,
+			&c.ToOrderStatus, &c.ToPaymentStatus, &c.Reason, &c.Actor, &c.CreatedAt,
+		)
+		if err != nil {
+			logger.FromContext(ctx).Error("failed to scan order status change", zap.Error(err))
+			return nil, pkgerrors.ErrInternal
+		}
+		history = append(history, c)
+	}
+	return history, nil
+}
+
 func (r *postgresRepository) GetAll(ctx context.Context) ([]*domain.Order, error) {
-	query := `SELECT id, user_id, product_id, product_name, unit_price, quantity, total_price, order_status, payment_status, created_at FROM orders`
+	query := `SELECT id, user_id, tax_region, shipping_rate, subtotal, tax_amount, shipping_amount, total_price, refunded_amount, order_status, payment_status, payment_reference, subscription_id, release_at, allocated_at, version, created_at FROM orders`
 
 	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
@@ -79,17 +457,315 @@ func (r *postgresRepository) GetAll(ctx context.Context) ([]*domain.Order, error
 	defer rows.Close()
 
 	var orders []*domain.Order
+	byID := make(map[int64]*domain.Order)
 	for rows.Next() {
-		o := &domain.Order{}
-		err := rows.Scan(
-			&o.ID, &o.UserID, &o.ProductID, &o.ProductName, &o.UnitPrice,
-			&o.Quantity, &o.TotalPrice, &o.OrderStatus, &o.PaymentStatus, &o.CreatedAt,
-		)
+		o, err := scanOrder(rows)
 		if err != nil {
 			logger.FromContext(ctx).Error("failed to scan order", zap.Error(err))
 			return nil, pkgerrors.ErrInternal
 		}
 		orders = append(orders, o)
+		byID[o.ID] = o
+	}
+
+	itemQuery := `SELECT id, order_id, product_id, product_name, unit_price, quantity, line_total, tax_category, tax_rate, weight_grams FROM order_items ORDER BY order_id, id`
+	itemRows, err := r.db.QueryContext(ctx, itemQuery)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to get order items", zap.Error(err))
+		return nil, pkgerrors.ErrInternal
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		item, err := scanOrderItem(itemRows)
+		if err != nil {
+			logger.FromContext(ctx).Error("failed to scan order item", zap.Error(err))
+			return nil, pkgerrors.ErrInternal
+		}
+		if o, ok := byID[item.OrderID]; ok {
+			o.Items = append(o.Items, item)
+		}
+	}
+
+	discountRows, err := r.db.QueryContext(ctx, `SELECT id, order_id, coupon_id, code, terms, amount FROM order_discounts`)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to get order discounts", zap.Error(err))
+		return nil, pkgerrors.ErrInternal
+	}
+	defer discountRows.Close()
+
+	for discountRows.Next() {
+		d, err := scanOrderDiscount(discountRows)
+		if err != nil {
+			logger.FromContext(ctx).Error("failed to scan order discount", zap.Error(err))
+			return nil, pkgerrors.ErrInternal
+		}
+		if o, ok := byID[d.OrderID]; ok {
+			o.Discount = d
+		}
 	}
 	return orders, nil
 }
+
+func (r *postgresRepository) FindExpiredPending(ctx context.Context, createdBefore time.Time, limit int) ([]int64, error) {
+	query := `
+		SELECT id FROM orders
+		WHERE order_status = $1 AND payment_status = $2 AND COALESCE(allocated_at, created_at) < $3
+		ORDER BY COALESCE(allocated_at, created_at)
+		LIMIT $4`
+
+	rows, err := r.db.QueryContext(ctx, query, domain.OrderPending, domain.PaymentPending, createdBefore.UTC(), limit)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to find expired orders", zap.Error(err))
+		return nil, pkgerrors.ErrInternal
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			logger.FromContext(ctx).Error("failed to scan expired order id", zap.Error(err))
+			return nil, pkgerrors.ErrInternal
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (r *postgresRepository) CountByUserSince(ctx context.Context, userID int64, since time.Time) (int, error) {
+	query := `SELECT COUNT(*) FROM orders WHERE user_id = $1 AND created_at >= $2`
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, userID, since.UTC()).Scan(&count); err != nil {
+		logger.FromContext(ctx).Error("failed to count user orders", zap.Error(err))
+		return 0, pkgerrors.ErrInternal
+	}
+	return count, nil
+}
+
+func (r *postgresRepository) GetByIdempotencyKey(ctx context.Context, key string) (*domain.Order, error) {
+	var id int64
+	err := r.db.QueryRowContext(ctx, `SELECT id FROM orders WHERE idempotency_key = $1`, key).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, pkgerrors.ErrNotFound
+	}
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to find order by idempotency key", zap.Error(err))
+		return nil, pkgerrors.ErrInternal
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *postgresRepository) FindBackordered(ctx context.Context, limit int) ([]int64, error) {
+	query := `
+		SELECT id FROM orders
+		WHERE order_status = $1
+		ORDER BY created_at, id
+		LIMIT $2`
+
+	rows, err := r.db.QueryContext(ctx, query, domain.OrderBackordered, limit)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to find backordered orders", zap.Error(err))
+		return nil, pkgerrors.ErrInternal
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			logger.FromContext(ctx).Error("failed to scan backordered order id", zap.Error(err))
+			return nil, pkgerrors.ErrInternal
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (r *postgresRepository) HasBackorders(ctx context.Context, productID int64) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM orders o JOIN order_items i ON i.order_id = o.id
+			WHERE o.order_status = $1 AND i.product_id = $2
+		)`
+
+	var waiting bool
+	if err := r.db.QueryRowContext(ctx, query, domain.OrderBackordered, productID).Scan(&waiting); err != nil {
+		logger.FromContext(ctx).Error("failed to check for backorders", zap.Int64("product_id", productID), zap.Error(err))
+		return false, pkgerrors.ErrInternal
+	}
+	return waiting, nil
+}
+
+func (r *postgresRepository) FindReleasedPreorders(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+	query := `
+		SELECT id FROM orders
+		WHERE order_status = $1 AND release_at <= $2
+		ORDER BY created_at, id
+		LIMIT $3`
+
+	rows, err := r.db.QueryContext(ctx, query, domain.OrderPreorder, now.UTC(), limit)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to find released pre-orders", zap.Error(err))
+		return nil, pkgerrors.ErrInternal
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			logger.FromContext(ctx).Error("failed to scan pre-order id", zap.Error(err))
+			return nil, pkgerrors.ErrInternal
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (r *postgresRepository) MarkAllocated(ctx context.Context, o *domain.Order, change *domain.StatusChange) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to begin transaction", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	defer tx.Rollback()
+
+	if err := applyStatusChange(ctx, tx, change); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE orders SET allocated_at = $1 WHERE id = $2`, o.AllocatedAt.UTC(), o.ID); err != nil {
+		logger.FromContext(ctx).Error("failed to store allocation time", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.FromContext(ctx).Error("failed to commit order allocation", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	return nil
+}
+
+func scanOrder(row rowScanner) (*domain.Order, error) {
+	o := &domain.Order{}
+	var shippingRate []byte
+	err := row.Scan(
+		&o.ID, &o.UserID, &o.TaxRegion, &shippingRate, &o.Subtotal, &o.Tax, &o.Shipping, &o.TotalPrice,
+		&o.RefundedAmount, &o.OrderStatus, &o.PaymentStatus, &o.PaymentReference, &o.SubscriptionID, &o.ReleaseAt, &o.AllocatedAt, &o.Version, &o.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if shippingRate != nil {
+		o.ShippingRate = &domain.ShippingRate{}
+		if err := json.Unmarshal(shippingRate, o.ShippingRate); err != nil {
+			return nil, err
+		}
+	}
+	return o, nil
+}
+
+// marshalShippingRate encodes the order's shipping rate snapshot, keeping
+// NULL for orders without one
+func marshalShippingRate(rate *domain.ShippingRate) ([]byte, error) {
+	if rate == nil {
+		return nil, nil
+	}
+	return json.Marshal(rate)
+}
+
+func scanOrderItem(rows *sql.Rows) (domain.OrderItem, error) {
+	var item domain.OrderItem
+	err := rows.Scan(
+		&item.ID, &item.OrderID, &item.ProductID, &item.ProductName,
+		&item.UnitPrice, &item.Quantity, &item.LineTotal, &item.TaxCategory, &item.TaxRate, &item.WeightGrams,
+	)
+	return item, err
+}
+
+// applyStatusChange moves the order to change's To state, guarded on its From
+// state so concurrent transitions cannot both win, and records the change.
+func applyStatusChange(ctx context.Context, tx *sql.Tx, change *domain.StatusChange) error {
+	query := `
+		UPDATE orders SET order_status = $1, payment_status = $2
+		WHERE id = $3 AND order_status = $4 AND payment_status = $5`
+	res, err := tx.ExecContext(ctx, query,
+		change.ToOrderStatus, change.ToPaymentStatus, change.OrderID,
+		change.FromOrderStatus, change.FromPaymentStatus,
+	)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to update order status", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return pkgerrors.ErrInternal
+	}
+	if rows == 0 {
+		return pkgerrors.ErrConflict
+	}
+	return insertStatusChange(ctx, tx, change)
+}
+
+// insertRefund applies the refund's status change, adds it to the order's
+// refunded amount and records it. Partial refunds keep the order's status,
+// so the status guard alone does not stop two of them from both passing;
+// the refunded amount is guarded against the total as well.
+func insertRefund(ctx context.Context, tx *sql.Tx, refund *domain.Refund, change *domain.StatusChange) error {
+	if err := applyStatusChange(ctx, tx, change); err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE orders SET refunded_amount = refunded_amount + $1 WHERE id = $2 AND refunded_amount + $1 <= total_price`,
+		refund.Amount, refund.OrderID)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to update refunded amount", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return pkgerrors.ErrInternal
+	}
+	if rows == 0 {
+		return pkgerrors.ErrConflict
+	}
+
+	query := `
+		INSERT INTO order_refunds (order_id, amount, reason, created_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`
+	refund.CreatedAt = refund.CreatedAt.UTC()
+	err = tx.QueryRowContext(ctx, query, refund.OrderID, refund.Amount, refund.Reason, refund.CreatedAt).Scan(&refund.ID)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to create refund", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	return nil
+}
+
+func insertStatusChange(ctx context.Context, tx *sql.Tx, c *domain.StatusChange) error {
+	query := `
+		INSERT INTO order_status_history (order_id, from_order_status, from_payment_status, to_order_status, to_payment_status, reason, actor, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id`
+
+	c.CreatedAt = c.CreatedAt.UTC()
+	err := tx.QueryRowContext(ctx, query,
+		c.OrderID, c.FromOrderStatus, c.FromPaymentStatus, c.ToOrderStatus, c.ToPaymentStatus,
+		c.Reason, c.Actor, c.CreatedAt,
+	).Scan(&c.ID)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to record order status change", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	if err := insertTasks(ctx, tx, c.Tasks); err != nil {
+		return err
+	}
+	if err := settleTasks(ctx, tx, c.Settles); err != nil {
+		return err
+	}
+	return insertEvents(ctx, tx, c)
+}
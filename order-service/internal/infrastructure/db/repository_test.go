@@ -8,6 +8,7 @@ import (
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
 	"github.com/user/go-microservices/order-service/internal/domain"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
 	"github.com/user/go-microservices/pkg/logger"
 	"github.com/user/go-microservices/pkg/valueobject"
 )
@@ -24,35 +25,608 @@ func TestOrderRepository(t *testing.T) {
 
 	t.Run("Create_Success", func(t *testing.T) {
 		order := &domain.Order{
-			UserID:     1,
-			ProductID:  1,
-			UnitPrice:  valueobject.NewMoney(100),
-			Quantity:   1,
-			TotalPrice: valueobject.NewMoney(100),
+			UserID:        1,
+			OrderStatus:   domain.OrderPending,
+			PaymentStatus: domain.PaymentPending,
+			Items: []domain.OrderItem{
+				{ProductID: 1, ProductName: "Product 1", UnitPrice: valueobject.NewMoney(100), Quantity: 1, LineTotal: valueobject.NewMoney(100), TaxCategory: "STANDARD", TaxRate: 7.25, WeightGrams: 500},
+			},
+			TaxRegion:    "US-CA",
+			Subtotal:     valueobject.NewMoney(100),
+			Tax:          valueobject.NewMoney(7.25),
+			Shipping:     valueobject.NewMoney(4.99),
+			TotalPrice:   valueobject.NewMoney(112.24),
+			ShippingRate: &domain.ShippingRate{Zone: "US", BaseFee: valueobject.NewMoney(4.99)},
 		}
 
+		mock.ExpectBegin()
 		mock.ExpectQuery("INSERT INTO orders").
-			WithArgs(order.UserID, order.ProductID, sqlmock.AnyArg(), order.UnitPrice.Amount(), order.Quantity, order.TotalPrice.Amount(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WithArgs(order.UserID, "US-CA", []byte(`{"zone":"US","base_fee":4.99,"per_kg_fee":0,"free_over":0}`), 100.0, 7.25, 4.99, 112.24, 0.0, sqlmock.AnyArg(), sqlmock.AnyArg(), nil, nil, nil, nil, sqlmock.AnyArg()).
 			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectQuery("INSERT INTO order_items").
+			WithArgs(int64(1), int64(1), "Product 1", 100.0, 1, 100.0, "STANDARD", 7.25, 500).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+		mock.ExpectQuery("INSERT INTO order_status_history").
+			WithArgs(int64(1), "", "", "PENDING", "PENDING", "order created", domain.SystemActor, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(100))
+		mock.ExpectExec("INSERT INTO order_outbox").
+			WithArgs(int64(1), domain.EventOrderCreated, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
 
 		err := repo.Create(context.Background(), order)
 
 		assert.NoError(t, err)
 		assert.Equal(t, int64(1), order.ID)
+		assert.Equal(t, int64(10), order.Items[0].ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
 	t.Run("GetByID_Success", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"id", "user_id", "product_id", "product_name", "unit_price", "quantity", "total_price", "order_status", "payment_status", "created_at"}).
-			AddRow(1, 1, 1, "Product 1", 100.0, 1, 100.0, "PENDING", "PENDING", time.Now())
+		rows := sqlmock.NewRows([]string{"id", "user_id", "tax_region", "shipping_rate", "subtotal", "tax_amount", "shipping_amount", "total_price", "refunded_amount", "order_status", "payment_status", "payment_reference", "subscription_id", "release_at", "allocated_at", "version", "created_at"}).
+			AddRow(1, 1, "US-CA", []byte(`{"zone":"US","base_fee":4.99}`), 100.0, 7.25, 4.99, 112.24, 0.0, "PENDING", "PENDING", "", nil, nil, nil, 2, time.Now())
+		itemRows := sqlmock.NewRows([]string{"id", "order_id", "product_id", "product_name", "unit_price", "quantity", "line_total", "tax_category", "tax_rate", "weight_grams"}).
+			AddRow(10, 1, 1, "Product 1", 100.0, 1, 100.0, "STANDARD", 7.25, 500)
 
 		mock.ExpectQuery("SELECT (.+) FROM orders WHERE id = \\$1").
 			WithArgs(int64(1)).
 			WillReturnRows(rows)
+		mock.ExpectQuery("SELECT (.+) FROM order_items WHERE order_id = \\$1").
+			WithArgs(int64(1)).
+			WillReturnRows(itemRows)
+		mock.ExpectQuery("SELECT (.+) FROM order_discounts WHERE order_id = \\$1").
+			WithArgs(int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "coupon_id", "code", "terms", "amount"}).
+				AddRow(5, 1, 7, "SPRING10", []byte(`{"type":"PERCENTAGE","percentage":10,"amount_off":0,"min_spend":0}`), 10.0))
 
 		order, err := repo.GetByID(context.Background(), 1)
 
 		assert.NoError(t, err)
 		assert.NotNil(t, order)
 		assert.Equal(t, int64(1), order.ID)
+		assert.Len(t, order.Items, 1)
+		assert.Equal(t, "Product 1", order.Items[0].ProductName)
+		assert.Equal(t, valueobject.NewMoney(7.25), order.Tax)
+		assert.Equal(t, 7.25, order.Items[0].TaxRate)
+		assert.Equal(t, 500, order.Items[0].WeightGrams)
+		assert.Equal(t, "US", order.ShippingRate.Zone)
+		assert.Equal(t, valueobject.NewMoney(4.99), order.Shipping)
+		assert.Equal(t, "SPRING10", order.Discount.Code)
+		assert.Equal(t, domain.DiscountPercentage, order.Discount.Terms.Type)
+	})
+
+	t.Run("Create_WithCoupon_RecordsDiscount", func(t *testing.T) {
+		order := &domain.Order{
+			UserID:        1,
+			OrderStatus:   domain.OrderPending,
+			PaymentStatus: domain.PaymentPending,
+			Items: []domain.OrderItem{
+				{ProductID: 1, ProductName: "Product 1", UnitPrice: valueobject.NewMoney(100), Quantity: 1, LineTotal: valueobject.NewMoney(100)},
+			},
+			Discount:   &domain.OrderDiscount{CouponID: 7, Code: "SPRING10", Terms: domain.DiscountTerms{Type: domain.DiscountPercentage, Percentage: 10}, Amount: valueobject.NewMoney(10)},
+			TotalPrice: valueobject.NewMoney(90),
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("INSERT INTO orders").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectQuery("INSERT INTO order_items").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+		mock.ExpectQuery("UPDATE coupons SET uses_count = uses_count \\+ 1").
+			WithArgs(int64(7)).
+			WillReturnRows(sqlmock.NewRows([]string{"max_uses_per_user"}).AddRow(1))
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM order_discounts").
+			WithArgs(int64(7), int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery("INSERT INTO order_discounts").
+			WithArgs(int64(1), int64(7), "SPRING10", sqlmock.AnyArg(), 10.0).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+		mock.ExpectQuery("INSERT INTO order_status_history").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(100))
+		mock.ExpectExec("INSERT INTO order_outbox").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := repo.Create(context.Background(), order)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), order.Discount.OrderID)
+		assert.Equal(t, int64(5), order.Discount.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Create_CouponLimitReached", func(t *testing.T) {
+		order := &domain.Order{
+			UserID:        1,
+			OrderStatus:   domain.OrderPending,
+			PaymentStatus: domain.PaymentPending,
+			Items: []domain.OrderItem{
+				{ProductID: 1, ProductName: "Product 1", UnitPrice: valueobject.NewMoney(100), Quantity: 1, LineTotal: valueobject.NewMoney(100)},
+			},
+			Discount:   &domain.OrderDiscount{CouponID: 7, Code: "SPRING10", Amount: valueobject.NewMoney(10)},
+			TotalPrice: valueobject.NewMoney(90),
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("INSERT INTO orders").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+		mock.ExpectQuery("INSERT INTO order_items").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11))
+		mock.ExpectQuery("UPDATE coupons SET uses_count = uses_count \\+ 1").
+			WithArgs(int64(7)).
+			WillReturnRows(sqlmock.NewRows([]string{"max_uses_per_user"}))
+		mock.ExpectRollback()
+
+		err := repo.Create(context.Background(), order)
+
+		assert.ErrorIs(t, err, domain.ErrCouponLimitReached)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("UpdateStatus_RecordsHistory", func(t *testing.T) {
+		change := &domain.StatusChange{
+			OrderID:           1,
+			FromOrderStatus:   domain.OrderPending,
+			FromPaymentStatus: domain.PaymentPending,
+			ToOrderStatus:     domain.OrderPending,
+			ToPaymentStatus:   domain.PaymentPaid,
+			Reason:            "order paid",
+			Actor:             "user-1",
+			CreatedAt:         time.Now(),
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE orders SET order_status").
+			WithArgs("PENDING", "PAID", int64(1), "PENDING", "PENDING").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("INSERT INTO order_status_history").
+			WithArgs(int64(1), "PENDING", "PENDING", "PENDING", "PAID", "order paid", "user-1", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(101))
+		mock.ExpectExec("INSERT INTO order_outbox").
+			WithArgs(int64(1), domain.EventOrderPaid, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := repo.UpdateStatus(context.Background(), change)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(101), change.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("UpdateStatus_WritesTasks", func(t *testing.T) {
+		change := &domain.StatusChange{
+			OrderID:           1,
+			FromOrderStatus:   domain.OrderPending,
+			FromPaymentStatus: domain.PaymentPending,
+			ToOrderStatus:     domain.OrderCancelled,
+			ToPaymentStatus:   domain.PaymentPending,
+			Reason:            "order cancelled",
+			Actor:             "user-1",
+			CreatedAt:         time.Now(),
+			Tasks:             domain.StockLineTasks(1, domain.TaskReleaseStock, []domain.StockLine{{ProductID: 4, Quantity: 2}}),
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE orders SET order_status").
+			WithArgs("CANCELLED", "PENDING", int64(1), "PENDING", "PENDING").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("INSERT INTO order_status_history").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(102))
+		mock.ExpectQuery("INSERT INTO order_tasks").
+			WithArgs(int64(1), domain.TaskReleaseStock, []byte(`{"lines":[{"product_id":4,"quantity":2}]}`), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+		mock.ExpectExec("INSERT INTO order_outbox").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := repo.UpdateStatus(context.Background(), change)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(9), change.Tasks[0].ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("UpdateStatus_StaleState", func(t *testing.T) {
+		change := &domain.StatusChange{
+			OrderID:           1,
+			FromOrderStatus:   domain.OrderPending,
+			FromPaymentStatus: domain.PaymentPending,
+			ToOrderStatus:     domain.OrderCancelled,
+			ToPaymentStatus:   domain.PaymentPending,
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE orders SET order_status").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := repo.UpdateStatus(context.Background(), change)
+
+		assert.ErrorIs(t, err, pkgerrors.ErrConflict)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("FindExpiredPending_OldestFirst", func(t *testing.T) {
+		cutoff := time.Now().Add(-30 * time.Minute)
+		mock.ExpectQuery("SELECT id FROM orders (.+) ORDER BY COALESCE\\(allocated_at, created_at\\)").
+			WithArgs("PENDING", "PENDING", cutoff.UTC(), 100).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3).AddRow(7))
+
+		ids, err := repo.FindExpiredPending(context.Background(), cutoff, 100)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{3, 7}, ids)
+	})
+
+	t.Run("FindBackordered_OldestFirst", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id FROM orders (.+) ORDER BY created_at, id").
+			WithArgs(domain.OrderBackordered, 50).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4).AddRow(9))
+
+		ids, err := repo.FindBackordered(context.Background(), 50)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{4, 9}, ids)
+	})
+
+	t.Run("GetByIdempotencyKey_NotFound", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id FROM orders WHERE idempotency_key = \\$1").
+			WithArgs("req-1").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		_, err := repo.GetByIdempotencyKey(context.Background(), "req-1")
+
+		assert.ErrorIs(t, err, pkgerrors.ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("HasBackorders_WaitingForProduct", func(t *testing.T) {
+		mock.ExpectQuery("SELECT EXISTS (.+) WHERE o.order_status = \\$1 AND i.product_id = \\$2").
+			WithArgs(domain.OrderBackordered, int64(2)).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+		waiting, err := repo.HasBackorders(context.Background(), 2)
+
+		assert.NoError(t, err)
+		assert.True(t, waiting)
+	})
+
+	t.Run("FindReleasedPreorders_OldestFirst", func(t *testing.T) {
+		now := time.Now()
+		mock.ExpectQuery("SELECT id FROM orders (.+) release_at <= \\$2 ORDER BY created_at, id").
+			WithArgs(domain.OrderPreorder, now.UTC(), 50).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(6))
+
+		ids, err := repo.FindReleasedPreorders(context.Background(), now, 50)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{6}, ids)
+	})
+
+	t.Run("MarkAllocated_Success", func(t *testing.T) {
+		allocatedAt := time.Now()
+		o := &domain.Order{ID: 1, OrderStatus: domain.OrderPending, PaymentStatus: domain.PaymentPending, AllocatedAt: &allocatedAt}
+		change := &domain.StatusChange{
+			OrderID: 1, FromOrderStatus: domain.OrderBackordered, FromPaymentStatus: domain.PaymentPending,
+			ToOrderStatus: domain.OrderPending, ToPaymentStatus: domain.PaymentPending, Actor: domain.SystemActor,
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE orders SET order_status").
+			WithArgs(domain.OrderPending, domain.PaymentPending, int64(1), domain.OrderBackordered, domain.PaymentPending).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("INSERT INTO order_status_history").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(13))
+		mock.ExpectExec("UPDATE orders SET allocated_at").
+			WithArgs(allocatedAt.UTC(), int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.MarkAllocated(context.Background(), o, change)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("MarkAllocated_CancelledMeanwhile", func(t *testing.T) {
+		allocatedAt := time.Now()
+		o := &domain.Order{ID: 1, OrderStatus: domain.OrderPending, PaymentStatus: domain.PaymentPending, AllocatedAt: &allocatedAt}
+		change := &domain.StatusChange{
+			OrderID: 1, FromOrderStatus: domain.OrderBackordered, FromPaymentStatus: domain.PaymentPending,
+			ToOrderStatus: domain.OrderPending, ToPaymentStatus: domain.PaymentPending, Actor: domain.SystemActor,
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE orders SET order_status").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := repo.MarkAllocated(context.Background(), o, change)
+
+		assert.ErrorIs(t, err, pkgerrors.ErrConflict)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("RecordPayment_Success", func(t *testing.T) {
+		o := &domain.Order{ID: 1, OrderStatus: domain.OrderPending, PaymentStatus: domain.PaymentPaid, PaymentReference: "auth_1"}
+		change := &domain.StatusChange{
+			OrderID: 1, FromOrderStatus: domain.OrderPending, FromPaymentStatus: domain.PaymentPending,
+			ToOrderStatus: domain.OrderPending, ToPaymentStatus: domain.PaymentPaid, Actor: "system",
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE orders SET order_status").
+			WithArgs(domain.OrderPending, domain.PaymentPaid, int64(1), domain.OrderPending, domain.PaymentPending).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("INSERT INTO order_status_history").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(12))
+		mock.ExpectExec("INSERT INTO order_outbox").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("UPDATE orders SET payment_reference").
+			WithArgs("auth_1", int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("INSERT INTO invoice_sequences").
+			WithArgs(2026).
+			WillReturnRows(sqlmock.NewRows([]string{"last_number"}).AddRow(123))
+		mock.ExpectQuery("INSERT INTO invoices").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+		mock.ExpectCommit()
+
+		invoice := &domain.Invoice{OrderID: 1, IssuedAt: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)}
+		err := repo.RecordPayment(context.Background(), o, change, invoice)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "INV-2026-000123", invoice.Number)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("SavePaymentEvent_Success", func(t *testing.T) {
+		o := &domain.Order{ID: 1, OrderStatus: domain.OrderPending, PaymentStatus: domain.PaymentPaid, PaymentReference: "auth_1"}
+		event := &domain.PaymentEvent{ID: "evt_1", Type: domain.PaymentEventSucceeded, OrderID: 1, Reference: "auth_1"}
+		change := &domain.StatusChange{
+			OrderID: 1, FromOrderStatus: domain.OrderPending, FromPaymentStatus: domain.PaymentPending,
+			ToOrderStatus: domain.OrderPending, ToPaymentStatus: domain.PaymentPaid, Actor: domain.PaymentProviderActor,
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO payment_events").
+			WithArgs("evt_1", int64(1), domain.PaymentEventSucceeded).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE orders SET order_status").
+			WithArgs(domain.OrderPending, domain.PaymentPaid, int64(1), domain.OrderPending, domain.PaymentPending).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("INSERT INTO order_status_history").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(13))
+		mock.ExpectExec("INSERT INTO order_outbox").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("UPDATE orders SET payment_reference").
+			WithArgs("auth_1", int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.SavePaymentEvent(context.Background(), event, o, change, nil)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("SavePaymentEvent_Duplicate", func(t *testing.T) {
+		event := &domain.PaymentEvent{ID: "evt_1", Type: domain.PaymentEventSucceeded, OrderID: 1, Reference: "auth_1"}
+
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO payment_events").
+			WithArgs("evt_1", int64(1), domain.PaymentEventSucceeded).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := repo.SavePaymentEvent(context.Background(), event, &domain.Order{ID: 1}, nil, nil)
+
+		assert.ErrorIs(t, err, domain.ErrDuplicatePaymentEvent)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("CountByUserSince_Success", func(t *testing.T) {
+		since := time.Now().Add(-time.Hour)
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM orders WHERE user_id = \\$1 AND created_at >= \\$2").
+			WithArgs(int64(101), since.UTC()).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(4))
+
+		count, err := repo.CountByUserSince(context.Background(), 101, since)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 4, count)
+	})
+
+	t.Run("AdvisoryLocker_NotAcquired", func(t *testing.T) {
+		locker := NewAdvisoryLocker(db)
+		mock.ExpectQuery("SELECT pg_try_advisory_lock").
+			WithArgs("job").
+			WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(false))
+
+		unlock, acquired, err := locker.TryLock(context.Background(), "job")
+
+		assert.NoError(t, err)
+		assert.False(t, acquired)
+		assert.Nil(t, unlock)
+	})
+
+	t.Run("SaveRefund_Success", func(t *testing.T) {
+		refund := &domain.Refund{OrderID: 1, Amount: valueobject.NewMoney(40), Reason: "damaged", CreatedAt: time.Now()}
+		change := &domain.StatusChange{
+			OrderID:           1,
+			FromOrderStatus:   domain.OrderCompleted,
+			FromPaymentStatus: domain.PaymentPaid,
+			ToOrderStatus:     domain.OrderCompleted,
+			ToPaymentStatus:   domain.PaymentPartiallyRefunded,
+			Reason:            "damaged",
+			Actor:             domain.SystemActor,
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE orders SET order_status").
+			WithArgs("COMPLETED", "PARTIALLY_REFUNDED", int64(1), "COMPLETED", "PAID").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("INSERT INTO order_status_history").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(200))
+		mock.ExpectExec("UPDATE orders SET refunded_amount").
+			WithArgs(40.0, int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("INSERT INTO order_refunds").
+			WithArgs(int64(1), 40.0, "damaged", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+		mock.ExpectCommit()
+
+		err := repo.SaveRefund(context.Background(), refund, change)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(9), refund.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("SaveRefund_ConcurrentPartialRefund_Conflict", func(t *testing.T) {
+		// Both partial refunds read 60 of 100 refunded; the other one
+		// committed first, so this one would take the total past 100
+		refund := &domain.Refund{OrderID: 1, Amount: valueobject.NewMoney(40), Reason: "damaged", CreatedAt: time.Now()}
+		change := &domain.StatusChange{
+			OrderID:           1,
+			FromOrderStatus:   domain.OrderCompleted,
+			FromPaymentStatus: domain.PaymentPartiallyRefunded,
+			ToOrderStatus:     domain.OrderCompleted,
+			ToPaymentStatus:   domain.PaymentPartiallyRefunded,
+			Reason:            "damaged",
+			Actor:             domain.SystemActor,
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE orders SET order_status").
+			WithArgs("COMPLETED", "PARTIALLY_REFUNDED", int64(1), "COMPLETED", "PARTIALLY_REFUNDED").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("INSERT INTO order_status_history").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(201))
+		mock.ExpectExec("UPDATE orders SET refunded_amount = refunded_amount \\+ \\$1 WHERE id = \\$2 AND refunded_amount \\+ \\$1 <= total_price").
+			WithArgs(40.0, int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := repo.SaveRefund(context.Background(), refund, change)
+
+		assert.ErrorIs(t, err, pkgerrors.ErrConflict)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("UpdateItems_Success", func(t *testing.T) {
+		order := &domain.Order{
+			ID:         1,
+			Version:    2,
+			Items:      []domain.OrderItem{{ProductID: 1, UnitPrice: valueobject.NewMoney(10), Quantity: 3, LineTotal: valueobject.NewMoney(30)}},
+			Subtotal:   valueobject.NewMoney(30),
+			TotalPrice: valueobject.NewMoney(30),
+		}
+		change := &domain.StatusChange{
+			OrderID:           1,
+			FromOrderStatus:   domain.OrderPending,
+			FromPaymentStatus: domain.PaymentPending,
+			ToOrderStatus:     domain.OrderPending,
+			ToPaymentStatus:   domain.PaymentPending,
+			Reason:            "quantities amended",
+			Actor:             domain.SystemActor,
+			Settles:           []int64{9},
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE orders SET subtotal = \\$1, tax_amount = \\$2, shipping_amount = \\$3, total_price = \\$4, version = version \\+ 1 WHERE id = \\$5 AND version = \\$6").
+			WithArgs(30.0, 0.0, 0.0, 30.0, int64(1), 2).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE orders SET order_status").
+			WithArgs("PENDING", "PENDING", int64(1), "PENDING", "PENDING").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("INSERT INTO order_status_history").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(250))
+		// The releases scheduled for the amendment's increases are settled
+		// with it
+		mock.ExpectExec("UPDATE order_tasks SET done_at = NOW\\(\\) WHERE id = \\$1").
+			WithArgs(int64(9)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE order_items SET quantity").
+			WithArgs(3, 30.0, int64(1), int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.UpdateItems(context.Background(), order, change)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, order.Version)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("UpdateItems_StaleVersion", func(t *testing.T) {
+		order := &domain.Order{ID: 1, Version: 2}
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE orders SET subtotal").
+			WithArgs(0.0, 0.0, 0.0, 0.0, int64(1), 2).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := repo.UpdateItems(context.Background(), order, &domain.StatusChange{OrderID: 1})
+
+		assert.ErrorIs(t, err, pkgerrors.ErrConflict)
+		assert.Equal(t, 2, order.Version)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("CreateShipment_Success", func(t *testing.T) {
+		shipment := &domain.Shipment{OrderID: 1, Carrier: "UPS", TrackingNumber: "1Z999", ShippedAt: time.Now()}
+		change := &domain.StatusChange{
+			OrderID:           1,
+			FromOrderStatus:   domain.OrderPending,
+			FromPaymentStatus: domain.PaymentPaid,
+			ToOrderStatus:     domain.OrderShipped,
+			ToPaymentStatus:   domain.PaymentPaid,
+			Actor:             domain.SystemActor,
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE orders SET order_status").
+			WithArgs("SHIPPED", "PAID", int64(1), "PENDING", "PAID").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("INSERT INTO order_status_history").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(300))
+		mock.ExpectQuery("INSERT INTO order_shipments").
+			WithArgs(int64(1), "UPS", "1Z999", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+		mock.ExpectCommit()
+
+		err := repo.CreateShipment(context.Background(), shipment, change)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), shipment.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("MarkDelivered_AlreadyDelivered", func(t *testing.T) {
+		deliveredAt := time.Now()
+		shipment := &domain.Shipment{ID: 7, OrderID: 1, DeliveredAt: &deliveredAt}
+		change := &domain.StatusChange{
+			OrderID:           1,
+			FromOrderStatus:   domain.OrderShipped,
+			FromPaymentStatus: domain.PaymentPaid,
+			ToOrderStatus:     domain.OrderDelivered,
+			ToPaymentStatus:   domain.PaymentPaid,
+			Actor:             domain.SystemActor,
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE orders SET order_status").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("INSERT INTO order_status_history").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(301))
+		mock.ExpectExec("UPDATE order_shipments SET delivered_at").
+			WithArgs(sqlmock.AnyArg(), int64(7)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := repo.MarkDelivered(context.Background(), shipment, change)
+
+		assert.ErrorIs(t, err, pkgerrors.ErrConflict)
+		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 }
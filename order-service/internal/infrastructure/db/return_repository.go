@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+	"go.uber.org/zap"
+)
+
+type returnRepository struct {
+	db *sql.DB
+}
+
+func NewReturnRepository(db *sql.DB) domain.ReturnRepository {
+	return &returnRepository{db: db}
+}
+
+func (r *returnRepository) Create(ctx context.Context, ret *domain.Return) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to begin transaction", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO order_returns (order_id, status, reason, note, amount, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`
+	ret.CreatedAt = ret.CreatedAt.UTC()
+	ret.UpdatedAt = ret.UpdatedAt.UTC()
+	err = tx.QueryRowContext(ctx, query,
+		ret.OrderID, ret.Status, ret.Reason, ret.Note, ret.Amount, ret.CreatedAt, ret.UpdatedAt,
+	).Scan(&ret.ID)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to create return", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+
+	itemQuery := `
+		INSERT INTO order_return_items (return_id, product_id, quantity, unit_price, amount)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`
+	for i := range ret.Items {
+		item := &ret.Items[i]
+		item.ReturnID = ret.ID
+		err := tx.QueryRowContext(ctx, itemQuery,
+			item.ReturnID, item.ProductID, item.Quantity, item.UnitPrice, item.Amount,
+		).Scan(&item.ID)
+		if err != nil {
+			logger.FromContext(ctx).Error("failed to create return item", zap.Error(err))
+			return pkgerrors.ErrInternal
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.FromContext(ctx).Error("failed to commit return", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	return nil
+}
+
+func (r *returnRepository) GetByID(ctx context.Context, id int64) (*domain.Return, error) {
+	query := `SELECT id, order_id, status, reason, note, amount, refund_id, created_at, updated_at FROM order_returns WHERE id = $1`
+
+	ret := &domain.Return{}
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&ret.ID, &ret.OrderID, &ret.Status, &ret.Reason, &ret.Note, &ret.Amount, &ret.RefundID, &ret.CreatedAt, &ret.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, pkgerrors.ErrNotFound
+	}
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to get return", zap.Error(err))
+		return nil, pkgerrors.ErrInternal
+	}
+
+	if err := r.loadItems(ctx, map[int64]*domain.Return{ret.ID: ret}, `WHERE return_id = $1`, ret.ID); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (r *returnRepository) GetByOrderID(ctx context.Context, orderID int64) ([]*domain.Return, error) {
+	query := `SELECT id, order_id, status, reason, note, amount, refund_id, created_at, updated_at FROM order_returns WHERE order_id = $1 ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query, orderID)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to get returns", zap.Error(err))
+		return nil, pkgerrors.ErrInternal
+	}
+	defer rows.Close()
+
+	returns := []*domain.Return{}
+	byID := make(map[int64]*domain.Return)
+	for rows.Next() {
+		ret := &domain.Return{}
+		err := rows.Scan(
+			&ret.ID, &ret.OrderID, &ret.Status, &ret.Reason, &ret.Note, &ret.Amount, &ret.RefundID, &ret.CreatedAt, &ret.UpdatedAt,
+		)
+		if err != nil {
+			logger.FromContext(ctx).Error("failed to scan return", zap.Error(err))
+			return nil, pkgerrors.ErrInternal
+		}
+		returns = append(returns, ret)
+		byID[ret.ID] = ret
+	}
+
+	filter := `WHERE return_id IN (SELECT id FROM order_returns WHERE order_id = $1)`
+	if err := r.loadItems(ctx, byID, filter, orderID); err != nil {
+		return nil, err
+	}
+	return returns, nil
+}
+
+// loadItems attaches the return items matched by filter to their returns
+func (r *returnRepository) loadItems(ctx context.Context, byID map[int64]*domain.Return, filter string, arg int64) error {
+	query := `SELECT id, return_id, product_id, quantity, unit_price, amount FROM order_return_items ` + filter + ` ORDER BY return_id, id`
+
+	rows, err := r.db.QueryContext(ctx, query, arg)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to get return items", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item domain.ReturnItem
+		if err := rows.Scan(&item.ID, &item.ReturnID, &item.ProductID, &item.Quantity, &item.UnitPrice, &item.Amount); err != nil {
+			logger.FromContext(ctx).Error("failed to scan return item", zap.Error(err))
+			return pkgerrors.ErrInternal
+		}
+		if ret, ok := byID[item.ReturnID]; ok {
+			ret.Items = append(ret.Items, item)
+		}
+	}
+	return nil
+}
+
+func (r *returnRepository) UpdateStatus(ctx context.Context, ret *domain.Return, from domain.ReturnStatus) error {
+	return updateReturnStatus(ctx, r.db, ret, from)
+}
+
+func (r *returnRepository) Receive(ctx context.Context, ret *domain.Return, refund *domain.Refund, change *domain.StatusChange, tasks []*domain.OrderTask) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to begin transaction", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	defer tx.Rollback()
+
+	if err := updateReturnStatus(ctx, tx, ret, domain.ReturnApproved); err != nil {
+		return err
+	}
+	if err := insertTasks(ctx, tx, tasks); err != nil {
+		return err
+	}
+
+	if refund != nil {
+		if err := insertRefund(ctx, tx, refund, change); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `UPDATE order_returns SET refund_id = $1 WHERE id = $2`, refund.ID, ret.ID)
+		if err != nil {
+			logger.FromContext(ctx).Error("failed to link refund to return", zap.Error(err))
+			return pkgerrors.ErrInternal
+		}
+		ret.RefundID = &refund.ID
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.FromContext(ctx).Error("failed to commit return receipt", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	return nil
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// updateReturnStatus persists the return's status guarded on from, so two
+// concurrent decisions on the same return cannot both win.
+func updateReturnStatus(ctx context.Context, db execer, ret *domain.Return, from domain.ReturnStatus) error {
+	query := `UPDATE order_returns SET status = $1, note = $2, updated_at = $3 WHERE id = $4 AND status = $5`
+	ret.UpdatedAt = ret.UpdatedAt.UTC()
+	res, err := db.ExecContext(ctx, query, ret.Status, ret.Note, ret.UpdatedAt, ret.ID, from)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to update return status", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return pkgerrors.ErrInternal
+	}
+	if rows == 0 {
+		return pkgerrors.ErrConflict
+	}
+	return nil
+}
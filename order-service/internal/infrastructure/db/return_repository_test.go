@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/user/go-microservices/order-service/internal/domain"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+	"github.com/user/go-microservices/pkg/valueobject"
+)
+
+func TestReturnRepository(t *testing.T) {
+	logger.Init()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer db.Close()
+
+	repo := NewReturnRepository(db)
+
+	t.Run("Create_Success", func(t *testing.T) {
+		ret := &domain.Return{
+			OrderID:   1,
+			Status:    domain.ReturnRequested,
+			Reason:    "damaged",
+			Items:     []domain.ReturnItem{{ProductID: 1, Quantity: 1, UnitPrice: valueobject.NewMoney(50), Amount: valueobject.NewMoney(50)}},
+			Amount:    valueobject.NewMoney(50),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("INSERT INTO order_returns").
+			WithArgs(int64(1), "REQUESTED", "damaged", "", 50.0, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+		mock.ExpectQuery("INSERT INTO order_return_items").
+			WithArgs(int64(3), int64(1), 1, 50.0, 50.0).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+		mock.ExpectCommit()
+
+		err := repo.Create(context.Background(), ret)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), ret.ID)
+		assert.Equal(t, int64(3), ret.Items[0].ReturnID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("UpdateStatus_StaleState", func(t *testing.T) {
+		ret := &domain.Return{ID: 3, Status: domain.ReturnApproved, UpdatedAt: time.Now()}
+
+		mock.ExpectExec("UPDATE order_returns SET status").
+			WithArgs("APPROVED", "", sqlmock.AnyArg(), int64(3), "REQUESTED").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := repo.UpdateStatus(context.Background(), ret, domain.ReturnRequested)
+
+		assert.ErrorIs(t, err, pkgerrors.ErrConflict)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Receive_WithRefund", func(t *testing.T) {
+		ret := &domain.Return{ID: 3, OrderID: 1, Status: domain.ReturnReceived, UpdatedAt: time.Now()}
+		refund := &domain.Refund{OrderID: 1, Amount: valueobject.NewMoney(50), Reason: "return #3 received", CreatedAt: time.Now()}
+		change := &domain.StatusChange{
+			OrderID:           1,
+			FromOrderStatus:   domain.OrderCompleted,
+			FromPaymentStatus: domain.PaymentPaid,
+			ToOrderStatus:     domain.OrderCompleted,
+			ToPaymentStatus:   domain.PaymentPartiallyRefunded,
+			Actor:             domain.SystemActor,
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE order_returns SET status").
+			WithArgs("RECEIVED", "", sqlmock.AnyArg(), int64(3), "APPROVED").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("INSERT INTO order_tasks").
+			WithArgs(int64(1), domain.TaskRestock, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(12))
+		mock.ExpectExec("UPDATE orders SET order_status").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("INSERT INTO order_status_history").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(400))
+		mock.ExpectExec("UPDATE orders SET refunded_amount").
+			WithArgs(50.0, int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("INSERT INTO order_refunds").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+		mock.ExpectExec("UPDATE order_returns SET refund_id").
+			WithArgs(int64(9), int64(3)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		tasks := domain.StockLineTasks(1, domain.TaskRestock, []domain.StockLine{{ProductID: 4, Quantity: 1}})
+		err := repo.Receive(context.Background(), ret, refund, change, tasks)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(9), *ret.RefundID)
+		assert.Equal(t, int64(12), tasks[0].ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+	"go.uber.org/zap"
+)
+
+type sagaRepository struct {
+	db *sql.DB
+}
+
+func NewSagaRepository(db *sql.DB) domain.SagaRepository {
+	return &sagaRepository{db: db}
+}
+
+func (r *sagaRepository) Create(ctx context.Context, s *domain.PlacementSaga) error {
+	query := `
+		INSERT INTO order_sagas (status, compensation_status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`
+	err := r.db.QueryRowContext(ctx, query, s.Status, s.Compensation, s.CreatedAt.UTC(), s.UpdatedAt.UTC()).Scan(&s.ID)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to create saga", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	return nil
+}
+
+func (r *sagaRepository) Save(ctx context.Context, s *domain.PlacementSaga) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to begin transaction", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE order_sagas
+		SET status = $1, order_id = $2, compensation_status = $3, attempts = $4, next_attempt_at = $5, last_error = $6, updated_at = $7
+		WHERE id = $8`
+	var nextAttemptAt *time.Time
+	if s.NextAttemptAt != nil {
+		t := s.NextAttemptAt.UTC()
+		nextAttemptAt = &t
+	}
+	_, err = tx.ExecContext(ctx, query,
+		s.Status, s.OrderID, s.Compensation, s.Attempts, nextAttemptAt, s.LastError, s.UpdatedAt.UTC(), s.ID,
+	)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to update saga", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+
+	for _, step := range s.Steps {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO order_saga_steps (saga_id, seq, action, product_id, quantity, status)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (saga_id, seq) DO UPDATE SET status = EXCLUDED.status`,
+			s.ID, step.Seq, step.Action, step.ProductID, step.Quantity, step.Status)
+		if err != nil {
+			logger.FromContext(ctx).Error("failed to save saga step", zap.Error(err))
+			return pkgerrors.ErrInternal
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.FromContext(ctx).Error("failed to commit saga", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	return nil
+}
+
+func (r *sagaRepository) GetByID(ctx context.Context, id int64) (*domain.PlacementSaga, error) {
+	query := `
+		SELECT id, status, order_id, compensation_status, attempts, next_attempt_at, last_error, created_at, updated_at
+		FROM order_sagas WHERE id = $1`
+
+	s := &domain.PlacementSaga{}
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&s.ID, &s.Status, &s.OrderID, &s.Compensation, &s.Attempts, &s.NextAttemptAt, &s.LastError, &s.CreatedAt, &s.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, pkgerrors.ErrNotFound
+	}
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to get saga", zap.Error(err))
+		return nil, pkgerrors.ErrInternal
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT seq, action, product_id, quantity, status
+		FROM order_saga_steps WHERE saga_id = $1 ORDER BY seq`, id)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to get saga steps", zap.Error(err))
+		return nil, pkgerrors.ErrInternal
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var step domain.SagaStep
+		if err := rows.Scan(&step.Seq, &step.Action, &step.ProductID, &step.Quantity, &step.Status); err != nil {
+			logger.FromContext(ctx).Error("failed to scan saga step", zap.Error(err))
+			return nil, pkgerrors.ErrInternal
+		}
+		s.Steps = append(s.Steps, step)
+	}
+	return s, nil
+}
+
+func (r *sagaRepository) FindRecoverable(ctx context.Context, now, staleBefore time.Time, limit int) ([]int64, error) {
+	query := `
+		SELECT id FROM order_sagas
+		WHERE (status = $1 AND updated_at < $2)
+		   OR (compensation_status = $3 AND next_attempt_at <= $4)
+		ORDER BY id
+		LIMIT $5`
+
+	rows, err := r.db.QueryContext(ctx, query, domain.SagaRunning, staleBefore.UTC(), domain.CompensationPending, now.UTC(), limit)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to find recoverable sagas", zap.Error(err))
+		return nil, pkgerrors.ErrInternal
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			logger.FromContext(ctx).Error("failed to scan saga id", zap.Error(err))
+			return nil, pkgerrors.ErrInternal
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (r *sagaRepository) FindOrderID(ctx context.Context, sagaID int64) (int64, error) {
+	var id int64
+	err := r.db.QueryRowContext(ctx, `SELECT id FROM orders WHERE saga_id = $1`, sagaID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, pkgerrors.ErrNotFound
+	}
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to find saga order", zap.Error(err))
+		return 0, pkgerrors.ErrInternal
+	}
+	return id, nil
+}
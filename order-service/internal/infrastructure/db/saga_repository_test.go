@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/user/go-microservices/order-service/internal/domain"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+)
+
+func TestSagaRepository(t *testing.T) {
+	logger.Init()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer db.Close()
+
+	repo := NewSagaRepository(db)
+	now := time.Now()
+
+	t.Run("Create_Success", func(t *testing.T) {
+		s := domain.NewPlacementSaga(now)
+
+		mock.ExpectQuery("INSERT INTO order_sagas").
+			WithArgs(domain.SagaRunning, domain.CompensationNone, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+
+		err := repo.Create(context.Background(), s)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(9), s.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Save_UpsertsSteps", func(t *testing.T) {
+		s := domain.NewPlacementSaga(now)
+		s.ID = 9
+		s.Begin(domain.SagaReserveStock, domain.StockLine{ProductID: 1, Quantity: 2}, now)
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE order_sagas SET (.+) WHERE id = \\$8").
+			WithArgs(domain.SagaRunning, nil, domain.CompensationNone, 0, nil, "", sqlmock.AnyArg(), int64(9)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO order_saga_steps (.+) ON CONFLICT \\(saga_id, seq\\) DO UPDATE SET status").
+			WithArgs(int64(9), 1, domain.SagaReserveStock, int64(1), 2, domain.StepPending).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.Save(context.Background(), s)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetByID_WithSteps", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM order_sagas WHERE id = \\$1").
+			WithArgs(9).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "status", "order_id", "compensation_status", "attempts", "next_attempt_at", "last_error", "created_at", "updated_at"}).
+				AddRow(9, "FAILED", nil, "PENDING", 1, now, "unavailable", now, now))
+		mock.ExpectQuery("SELECT (.+) FROM order_saga_steps WHERE saga_id = \\$1 ORDER BY seq").
+			WithArgs(9).
+			WillReturnRows(sqlmock.NewRows([]string{"seq", "action", "product_id", "quantity", "status"}).
+				AddRow(1, "RESERVE_STOCK", 1, 2, "RELEASING"))
+
+		s, err := repo.GetByID(context.Background(), 9)
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.SagaFailed, s.Status)
+		assert.Equal(t, domain.CompensationPending, s.Compensation)
+		assert.Equal(t, []domain.SagaStep{{Seq: 1, Action: domain.SagaReserveStock, ProductID: 1, Quantity: 2, Status: domain.StepReleasing}}, s.Steps)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("FindRecoverable_Success", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id FROM order_sagas").
+			WithArgs(domain.SagaRunning, sqlmock.AnyArg(), domain.CompensationPending, sqlmock.AnyArg(), 100).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9).AddRow(12))
+
+		ids, err := repo.FindRecoverable(context.Background(), now, now.Add(-time.Minute), 100)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{9, 12}, ids)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("FindOrderID_NotFound", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id FROM orders WHERE saga_id = \\$1").
+			WithArgs(9).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		_, err := repo.FindOrderID(context.Background(), 9)
+
+		assert.ErrorIs(t, err, pkgerrors.ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
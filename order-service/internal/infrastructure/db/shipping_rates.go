@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// LoadShippingRates reads the whole shipping_rates table. Like LoadTaxRates
+// it is meant to be called once at startup.
+func LoadShippingRates(ctx context.Context, db *sql.DB) ([]domain.ShippingRate, error) {
+	rows, err := db.QueryContext(ctx, `SELECT zone, base_fee, per_kg_fee, free_over FROM shipping_rates ORDER BY zone`)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to get shipping rates", zap.Error(err))
+		return nil, pkgerrors.ErrInternal
+	}
+	defer rows.Close()
+
+	rates := []domain.ShippingRate{}
+	for rows.Next() {
+		var r domain.ShippingRate
+		if err := rows.Scan(&r.Zone, &r.BaseFee, &r.PerKgFee, &r.FreeOver); err != nil {
+			logger.FromContext(ctx).Error("failed to scan shipping rate", zap.Error(err))
+			return nil, pkgerrors.ErrInternal
+		}
+		rates = append(rates, r)
+	}
+	return rates, nil
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/pkg/logger"
+	"github.com/user/go-microservices/pkg/valueobject"
+)
+
+func TestLoadShippingRates(t *testing.T) {
+	logger.Init()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT zone, base_fee, per_kg_fee, free_over FROM shipping_rates").
+		WillReturnRows(sqlmock.NewRows([]string{"zone", "base_fee", "per_kg_fee", "free_over"}).
+			AddRow("US", 4.99, 1.5, 50.0))
+
+	rates, err := LoadShippingRates(context.Background(), db)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.ShippingRate{{
+		Zone:     "US",
+		BaseFee:  valueobject.NewMoney(4.99),
+		PerKgFee: valueobject.NewMoney(1.5),
+		FreeOver: valueobject.NewMoney(50),
+	}}, rates)
+}
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+	"go.uber.org/zap"
+)
+
+type subscriptionRepository struct {
+	db *sql.DB
+}
+
+func NewSubscriptionRepository(db *sql.DB) domain.SubscriptionRepository {
+	return &subscriptionRepository{db: db}
+}
+
+// storedTime rounds t to the precision Postgres keeps, so an updated_at
+// written by this process still matches the stored value when it is used as
+// the guard of the next update
+func storedTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Microsecond)
+}
+
+func (r *subscriptionRepository) Create(ctx context.Context, s *domain.Subscription) error {
+	query := `
+		INSERT INTO subscriptions (user_id, product_id, quantity, region, interval_days, status, next_run_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id`
+	s.CreatedAt = storedTime(s.CreatedAt)
+	s.UpdatedAt = storedTime(s.UpdatedAt)
+	err := r.db.QueryRowContext(ctx, query,
+		s.UserID, s.ProductID, s.Quantity, s.Region, s.IntervalDays, s.Status, s.NextRunAt.UTC(), s.CreatedAt, s.UpdatedAt,
+	).Scan(&s.ID)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to create subscription", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	return nil
+}
+
+func (r *subscriptionRepository) GetByID(ctx context.Context, id int64) (*domain.Subscription, error) {
+	query := `
+		SELECT id, user_id, product_id, quantity, region, interval_days, status, next_run_at, retry_at, failed_attempts, last_order_id, created_at, updated_at
+		FROM subscriptions WHERE id = $1`
+
+	s := &domain.Subscription{}
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&s.ID, &s.UserID, &s.ProductID, &s.Quantity, &s.Region, &s.IntervalDays, &s.Status,
+		&s.NextRunAt, &s.RetryAt, &s.FailedAttempts, &s.LastOrderID, &s.CreatedAt, &s.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, pkgerrors.ErrNotFound
+	}
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to get subscription", zap.Error(err))
+		return nil, pkgerrors.ErrInternal
+	}
+	return s, nil
+}
+
+// Update is guarded on updated_at so that the scheduler and a customer
+// changing the same subscription cannot overwrite each other
+func (r *subscriptionRepository) Update(ctx context.Context, s *domain.Subscription, lastUpdated time.Time) error {
+	query := `
+		UPDATE subscriptions
+		SET status = $1, next_run_at = $2, retry_at = $3, failed_attempts = $4, last_order_id = $5, updated_at = $6
+		WHERE id = $7 AND updated_at = $8`
+
+	var retryAt *time.Time
+	if s.RetryAt != nil {
+		t := s.RetryAt.UTC()
+		retryAt = &t
+	}
+	s.UpdatedAt = storedTime(s.UpdatedAt)
+	res, err := r.db.ExecContext(ctx, query,
+		s.Status, s.NextRunAt.UTC(), retryAt, s.FailedAttempts, s.LastOrderID, s.UpdatedAt, s.ID, lastUpdated.UTC(),
+	)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to update subscription", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return pkgerrors.ErrInternal
+	}
+	if rows == 0 {
+		return pkgerrors.ErrConflict
+	}
+	return nil
+}
+
+func (r *subscriptionRepository) FindDue(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+	query := `
+		SELECT id FROM subscriptions
+		WHERE status = $1 AND COALESCE(retry_at, next_run_at) <= $2
+		ORDER BY COALESCE(retry_at, next_run_at), id
+		LIMIT $3`
+
+	rows, err := r.db.QueryContext(ctx, query, domain.SubscriptionActive, now.UTC(), limit)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to find due subscriptions", zap.Error(err))
+		return nil, pkgerrors.ErrInternal
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			logger.FromContext(ctx).Error("failed to scan subscription id", zap.Error(err))
+			return nil, pkgerrors.ErrInternal
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (r *subscriptionRepository) GetOrders(ctx context.Context, id int64) ([]*domain.SubscriptionOrder, error) {
+	query := `
+		SELECT id, order_status, payment_status, total_price, created_at
+		FROM orders WHERE subscription_id = $1 ORDER BY created_at, id`
+
+	rows, err := r.db.QueryContext(ctx, query, id)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to get subscription orders", zap.Error(err))
+		return nil, pkgerrors.ErrInternal
+	}
+	defer rows.Close()
+
+	orders := []*domain.SubscriptionOrder{}
+	for rows.Next() {
+		o := &domain.SubscriptionOrder{}
+		if err := rows.Scan(&o.OrderID, &o.OrderStatus, &o.PaymentStatus, &o.TotalPrice, &o.CreatedAt); err != nil {
+			logger.FromContext(ctx).Error("failed to scan subscription order", zap.Error(err))
+			return nil, pkgerrors.ErrInternal
+		}
+		orders = append(orders, o)
+	}
+	return orders, nil
+}
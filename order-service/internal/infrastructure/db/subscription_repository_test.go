@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/user/go-microservices/order-service/internal/domain"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+)
+
+func TestSubscriptionRepository(t *testing.T) {
+	logger.Init()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer db.Close()
+
+	repo := NewSubscriptionRepository(db)
+	now := time.Now()
+
+	t.Run("Create_Success", func(t *testing.T) {
+		s := &domain.Subscription{UserID: 1, ProductID: 2, Quantity: 3, IntervalDays: 30, Status: domain.SubscriptionActive,
+			NextRunAt: now, CreatedAt: now, UpdatedAt: now}
+
+		mock.ExpectQuery("INSERT INTO subscriptions").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+
+		err := repo.Create(context.Background(), s)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), s.ID)
+		assert.True(t, s.UpdatedAt.Equal(now.Truncate(time.Microsecond)))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetByID_NotFound", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM subscriptions WHERE id = \\$1").
+			WithArgs(7).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		_, err := repo.GetByID(context.Background(), 7)
+
+		assert.ErrorIs(t, err, pkgerrors.ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Update_Success", func(t *testing.T) {
+		s := &domain.Subscription{ID: 7, Status: domain.SubscriptionPaused, NextRunAt: now, UpdatedAt: now}
+
+		mock.ExpectExec("UPDATE subscriptions SET (.+) WHERE id = \\$7 AND updated_at = \\$8").
+			WithArgs(domain.SubscriptionPaused, sqlmock.AnyArg(), nil, 0, nil, sqlmock.AnyArg(), int64(7), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := repo.Update(context.Background(), s, now.Add(-time.Hour))
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Update_ChangedConcurrently", func(t *testing.T) {
+		s := &domain.Subscription{ID: 7, Status: domain.SubscriptionActive, NextRunAt: now, UpdatedAt: now}
+
+		mock.ExpectExec("UPDATE subscriptions").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := repo.Update(context.Background(), s, now.Add(-time.Hour))
+
+		assert.ErrorIs(t, err, pkgerrors.ErrConflict)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("FindDue_Success", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id FROM subscriptions WHERE status = \\$1 AND COALESCE\\(retry_at, next_run_at\\) <= \\$2").
+			WithArgs(domain.SubscriptionActive, sqlmock.AnyArg(), 10).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7).AddRow(9))
+
+		ids, err := repo.FindDue(context.Background(), now, 10)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{7, 9}, ids)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetOrders_Success", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM orders WHERE subscription_id = \\$1").
+			WithArgs(7).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "order_status", "payment_status", "total_price", "created_at"}).
+				AddRow(42, "PENDING", "PENDING", 20.0, now))
+
+		orders, err := repo.GetOrders(context.Background(), 7)
+
+		assert.NoError(t, err)
+		assert.Len(t, orders, 1)
+		assert.Equal(t, int64(42), orders[0].OrderID)
+		assert.Equal(t, domain.OrderPending, orders[0].OrderStatus)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+	"go.uber.org/zap"
+)
+
+type taskRepository struct {
+	db *sql.DB
+}
+
+func NewTaskRepository(db *sql.DB) domain.TaskRepository {
+	return &taskRepository{db: db}
+}
+
+func (r *taskRepository) FindDue(ctx context.Context, now time.Time, limit int) ([]*domain.OrderTask, error) {
+	query := `
+		SELECT id, order_id, kind, payload, attempts, next_attempt_at, last_error, created_at
+		FROM order_tasks
+		WHERE done_at IS NULL AND next_attempt_at <= $1
+		ORDER BY id
+		LIMIT $2`
+
+	rows, err := r.db.QueryContext(ctx, query, now.UTC(), limit)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to find due order tasks", zap.Error(err))
+		return nil, pkgerrors.ErrInternal
+	}
+	defer rows.Close()
+
+	var tasks []*domain.OrderTask
+	for rows.Next() {
+		t := &domain.OrderTask{}
+		var payload []byte
+		if err := rows.Scan(&t.ID, &t.OrderID, &t.Kind, &payload, &t.Attempts, &t.NextAttemptAt, &t.LastError, &t.CreatedAt); err != nil {
+			logger.FromContext(ctx).Error("failed to scan order task", zap.Error(err))
+			return nil, pkgerrors.ErrInternal
+		}
+		if err := json.Unmarshal(payload, t); err != nil {
+			logger.FromContext(ctx).Error("failed to decode order task", zap.Int64("task_id", t.ID), zap.Error(err))
+			return nil, pkgerrors.ErrInternal
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, nil
+}
+
+func (r *taskRepository) MarkDone(ctx context.Context, id int64, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE order_tasks SET done_at = $1 WHERE id = $2`, at.UTC(), id)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to mark order task done", zap.Int64("task_id", id), zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	return nil
+}
+
+func (r *taskRepository) SaveAttempt(ctx context.Context, t *domain.OrderTask) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE order_tasks SET attempts = $1, next_attempt_at = $2, last_error = $3 WHERE id = $4`,
+		t.Attempts, t.NextAttemptAt.UTC(), t.LastError, t.ID)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to save order task attempt", zap.Int64("task_id", t.ID), zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	return nil
+}
+
+func (r *taskRepository) Schedule(ctx context.Context, tasks []*domain.OrderTask) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to begin transaction", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	defer tx.Rollback()
+
+	if err := insertTasks(ctx, tx, tasks); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		logger.FromContext(ctx).Error("failed to commit order tasks", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	return nil
+}
+
+// insertTasks writes the tasks owed by a change in the change's transaction,
+// so a task exists if and only if the change was committed
+func insertTasks(ctx context.Context, tx *sql.Tx, tasks []*domain.OrderTask) error {
+	for _, t := range tasks {
+		payload, err := json.Marshal(t)
+		if err != nil {
+			return pkgerrors.ErrInternal
+		}
+		t.CreatedAt = t.CreatedAt.UTC()
+		t.NextAttemptAt = t.NextAttemptAt.UTC()
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO order_tasks (order_id, kind, payload, next_attempt_at, created_at)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id`,
+			t.OrderID, t.Kind, payload, t.NextAttemptAt, t.CreatedAt).Scan(&t.ID)
+		if err != nil {
+			logger.FromContext(ctx).Error("failed to write order task", zap.String("kind", string(t.Kind)), zap.Error(err))
+			return pkgerrors.ErrInternal
+		}
+	}
+	return nil
+}
+
+// settleTasks marks tasks scheduled ahead of a change done in the change's
+// transaction, so they are left to run if and only if it was not committed
+func settleTasks(ctx context.Context, tx *sql.Tx, ids []int64) error {
+	for _, id := range ids {
+		if _, err := tx.ExecContext(ctx, `UPDATE order_tasks SET done_at = NOW() WHERE id = $1`, id); err != nil {
+			logger.FromContext(ctx).Error("failed to settle order task", zap.Int64("task_id", id), zap.Error(err))
+			return pkgerrors.ErrInternal
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/user/go-microservices/order-service/internal/domain"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+)
+
+func TestTaskRepository(t *testing.T) {
+	logger.Init()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer db.Close()
+
+	repo := NewTaskRepository(db)
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	columns := []string{"id", "order_id", "kind", "payload", "attempts", "next_attempt_at", "last_error", "created_at"}
+
+	t.Run("FindDue_OldestFirst", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM order_tasks WHERE done_at IS NULL AND next_attempt_at <= \\$1 ORDER BY id LIMIT \\$2").
+			WithArgs(now, 50).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(3, 7, "RELEASE_STOCK", []byte(`{"lines":[{"product_id":1,"quantity":2}]}`), 1, now, "timeout", now))
+
+		tasks, err := repo.FindDue(context.Background(), now, 50)
+
+		assert.NoError(t, err)
+		assert.Len(t, tasks, 1)
+		assert.Equal(t, int64(3), tasks[0].ID)
+		assert.Equal(t, int64(7), tasks[0].OrderID)
+		assert.Equal(t, domain.TaskReleaseStock, tasks[0].Kind)
+		assert.Equal(t, []domain.StockLine{{ProductID: 1, Quantity: 2}}, tasks[0].Lines)
+		assert.Equal(t, 1, tasks[0].Attempts)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("FindDue_Error", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM order_tasks").
+			WithArgs(now, 50).
+			WillReturnError(assert.AnError)
+
+		_, err := repo.FindDue(context.Background(), now, 50)
+
+		assert.ErrorIs(t, err, pkgerrors.ErrInternal)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Schedule_Success", func(t *testing.T) {
+		task := domain.ReleaseReservationTask(7, "amend-7-v2-1-42")
+		mock.ExpectBegin()
+		mock.ExpectQuery("INSERT INTO order_tasks").
+			WithArgs(int64(7), domain.TaskReleaseReservation, []byte(`{"operation_key":"amend-7-v2-1-42"}`), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(12))
+		mock.ExpectCommit()
+
+		err := repo.Schedule(context.Background(), []*domain.OrderTask{task})
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(12), task.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Schedule_Error", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("INSERT INTO order_tasks").WillReturnError(assert.AnError)
+		mock.ExpectRollback()
+
+		err := repo.Schedule(context.Background(), []*domain.OrderTask{domain.ReleaseReservationTask(7, "amend-7-v2-1-42")})
+
+		assert.ErrorIs(t, err, pkgerrors.ErrInternal)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("MarkDone_Success", func(t *testing.T) {
+		mock.ExpectExec("UPDATE order_tasks SET done_at = \\$1 WHERE id = \\$2").
+			WithArgs(now, int64(3)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := repo.MarkDone(context.Background(), 3, now)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("SaveAttempt_Success", func(t *testing.T) {
+		task := &domain.OrderTask{ID: 3, Attempts: 2, NextAttemptAt: now, LastError: "timeout"}
+		mock.ExpectExec("UPDATE order_tasks SET attempts = \\$1, next_attempt_at = \\$2, last_error = \\$3 WHERE id = \\$4").
+			WithArgs(2, now, "timeout", int64(3)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := repo.SaveAttempt(context.Background(), task)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// LoadTaxRates reads the whole tax_rates table. It is meant to be called
+// once at startup to build a domain.TaxTable.
+func LoadTaxRates(ctx context.Context, db *sql.DB) ([]domain.TaxRate, error) {
+	rows, err := db.QueryContext(ctx, `SELECT region, category, rate FROM tax_rates ORDER BY region, category`)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to get tax rates", zap.Error(err))
+		return nil, pkgerrors.ErrInternal
+	}
+	defer rows.Close()
+
+	rates := []domain.TaxRate{}
+	for rows.Next() {
+		var r domain.TaxRate
+		if err := rows.Scan(&r.Region, &r.Category, &r.Rate); err != nil {
+			logger.FromContext(ctx).Error("failed to scan tax rate", zap.Error(err))
+			return nil, pkgerrors.ErrInternal
+		}
+		rates = append(rates, r)
+	}
+	return rates, nil
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/user/go-microservices/order-service/internal/domain"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+)
+
+func TestLoadTaxRates(t *testing.T) {
+	logger.Init()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer db.Close()
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery("SELECT region, category, rate FROM tax_rates").
+			WillReturnRows(sqlmock.NewRows([]string{"region", "category", "rate"}).
+				AddRow("*", "*", 0.0).
+				AddRow("US-CA", "STANDARD", 7.25).
+				AddRow("US-NY", "STANDARD", 8.875))
+
+		rates, err := LoadTaxRates(context.Background(), db)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []domain.TaxRate{
+			{Region: "*", Category: "*", Rate: 0},
+			{Region: "US-CA", Category: "STANDARD", Rate: 7.25},
+			{Region: "US-NY", Category: "STANDARD", Rate: 8.875},
+		}, rates)
+	})
+
+	t.Run("QueryError", func(t *testing.T) {
+		mock.ExpectQuery("SELECT region, category, rate FROM tax_rates").
+			WillReturnError(assert.AnError)
+
+		_, err := LoadTaxRates(context.Background(), db)
+
+		assert.ErrorIs(t, err, pkgerrors.ErrInternal)
+	})
+}
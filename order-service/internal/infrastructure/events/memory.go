@@ -0,0 +1,43 @@
+// Package events holds EventPublisher adapters.
+package events
+
+import (
+	"context"
+	"sync"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+)
+
+// MemoryPublisher keeps published events in memory, in publish order. It is
+// meant for tests and for running the service without a broker. Once it
+// holds limit events it drops the oldest; a limit of 0 keeps them all.
+type MemoryPublisher struct {
+	limit int
+
+	mu     sync.Mutex
+	events []domain.OrderEvent
+}
+
+func NewMemoryPublisher(limit int) *MemoryPublisher {
+	return &MemoryPublisher{limit: limit}
+}
+
+func (p *MemoryPublisher) Publish(ctx context.Context, event *domain.OrderEvent) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, *event)
+	if p.limit > 0 && len(p.events) > p.limit {
+		p.events = append(p.events[:0], p.events[len(p.events)-p.limit:]...)
+	}
+	return nil
+}
+
+// Events returns a copy of the events published so far
+func (p *MemoryPublisher) Events() []domain.OrderEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]domain.OrderEvent(nil), p.events...)
+}
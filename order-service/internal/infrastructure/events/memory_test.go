@@ -0,0 +1,34 @@
+package events
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/user/go-microservices/order-service/internal/domain"
+)
+
+func TestMemoryPublisher(t *testing.T) {
+	p := NewMemoryPublisher(0)
+
+	assert.NoError(t, p.Publish(context.Background(), &domain.OrderEvent{ID: 1, Type: domain.EventOrderCreated, OrderID: 7}))
+	assert.NoError(t, p.Publish(context.Background(), &domain.OrderEvent{ID: 2, Type: domain.EventOrderPaid, OrderID: 7}))
+
+	events := p.Events()
+	assert.Len(t, events, 2)
+	assert.Equal(t, domain.EventOrderCreated, events[0].Type)
+	assert.Equal(t, domain.EventOrderPaid, events[1].Type)
+}
+
+func TestMemoryPublisher_Limit(t *testing.T) {
+	p := NewMemoryPublisher(2)
+
+	for id := int64(1); id <= 3; id++ {
+		assert.NoError(t, p.Publish(context.Background(), &domain.OrderEvent{ID: id, Type: domain.EventOrderCreated, OrderID: id}))
+	}
+
+	events := p.Events()
+	assert.Len(t, events, 2)
+	assert.Equal(t, int64(2), events[0].ID)
+	assert.Equal(t, int64(3), events[1].ID)
+}
@@ -0,0 +1,51 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/user/go-microservices/order-service/internal/domain"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+)
+
+// RedisStreamPublisher appends events to a Redis stream with XADD. Each
+// entry carries the event ID, type and order ID as fields next to the JSON
+// payload, so consumers can filter without decoding it.
+type RedisStreamPublisher struct {
+	client *redis.Client
+	stream string
+}
+
+func NewRedisStreamPublisher(addr, stream string) *RedisStreamPublisher {
+	return &RedisStreamPublisher{
+		client: redis.NewClient(&redis.Options{Addr: addr}),
+		stream: stream,
+	}
+}
+
+func (p *RedisStreamPublisher) Publish(ctx context.Context, event *domain.OrderEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encode event %d: %w", event.ID, pkgerrors.ErrInternal)
+	}
+	err = p.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: p.stream,
+		Values: []any{
+			"id", event.ID,
+			"type", string(event.Type),
+			"order_id", event.OrderID,
+			"payload", payload,
+		},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("publish event %d to %s: %v: %w", event.ID, p.stream, err, pkgerrors.ErrUnavailable)
+	}
+	return nil
+}
+
+// Close closes the client's connections
+func (p *RedisStreamPublisher) Close() error {
+	return p.client.Close()
+}
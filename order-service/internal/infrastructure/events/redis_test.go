@@ -0,0 +1,57 @@
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/go-microservices/order-service/internal/domain"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+)
+
+func TestRedisStreamPublisher(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	event := &domain.OrderEvent{ID: 3, Type: domain.EventOrderPaid, OrderID: 7, OrderStatus: domain.OrderPending}
+
+	t.Run("Publish_XAdd", func(t *testing.T) {
+		srv := miniredis.RunT(t)
+		p := NewRedisStreamPublisher(srv.Addr(), "order-events")
+		defer p.Close()
+
+		err := p.Publish(ctx, event)
+
+		assert.NoError(t, err)
+		entries, err := srv.Stream("order-events")
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		values := entries[0].Values
+		assert.Equal(t, []string{"id", "3", "type", "OrderPaid", "order_id", "7", "payload"}, values[:7])
+		assert.JSONEq(t, `{"id":3,"type":"OrderPaid","order_id":7,"order_status":"PENDING","payment_status":"","actor":"","occurred_at":"0001-01-01T00:00:00Z"}`, values[7])
+	})
+
+	t.Run("BrokerDown_Unavailable", func(t *testing.T) {
+		srv := miniredis.RunT(t)
+		p := NewRedisStreamPublisher(srv.Addr(), "order-events")
+		defer p.Close()
+		srv.Close()
+
+		assert.ErrorIs(t, p.Publish(ctx, event), pkgerrors.ErrUnavailable)
+	})
+
+	t.Run("BrokerRestarted_Reconnects", func(t *testing.T) {
+		srv := miniredis.RunT(t)
+		p := NewRedisStreamPublisher(srv.Addr(), "order-events")
+		defer p.Close()
+		require.NoError(t, p.Publish(ctx, event))
+
+		srv.Close()
+		assert.ErrorIs(t, p.Publish(ctx, event), pkgerrors.ErrUnavailable)
+		require.NoError(t, srv.Restart())
+
+		assert.NoError(t, p.Publish(ctx, event))
+	})
+}
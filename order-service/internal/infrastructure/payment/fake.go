@@ -0,0 +1,178 @@
+// Package payment holds PaymentGateway adapters.
+package payment
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/valueobject"
+)
+
+// Tokens that make the fake gateway fail an authorization every time,
+// whatever the configured rates
+const (
+	TokenDecline = "tok_decline"
+	TokenTimeout = "tok_timeout"
+)
+
+// FakeConfig controls how the fake gateway misbehaves
+type FakeConfig struct {
+	// Latency is added to every call
+	Latency time.Duration
+	// DeclineRate and TimeoutRate are the fractions, between 0 and 1, of
+	// authorizations that are declined or time out at random
+	DeclineRate float64
+	TimeoutRate float64
+	// TimeoutAfter is how long a simulated timeout blocks before failing,
+	// unless the caller's context ends first
+	TimeoutAfter time.Duration
+}
+
+// FakeGateway is an in-process PaymentGateway that keeps authorizations in
+// memory. It enforces the same bookkeeping a real provider would: captures
+// and refunds cannot exceed what was authorized and captured.
+type FakeGateway struct {
+	cfg    FakeConfig
+	random func() float64
+
+	mu    sync.Mutex
+	seq   int64
+	auths map[string]*fakeAuthorization
+	// refunds holds the keys of refunds already made
+	refunds map[string]bool
+}
+
+type fakeAuthorization struct {
+	amount   valueobject.Money
+	captured valueobject.Money
+	refunded valueobject.Money
+	voided   bool
+}
+
+func NewFakeGateway(cfg FakeConfig) *FakeGateway {
+	return &FakeGateway{
+		cfg:     cfg,
+		random:  rand.Float64,
+		auths:   make(map[string]*fakeAuthorization),
+		refunds: make(map[string]bool),
+	}
+}
+
+func (g *FakeGateway) Authorize(ctx context.Context, orderID int64, amount valueobject.Money, token string) (string, error) {
+	if err := g.wait(ctx, g.cfg.Latency); err != nil {
+		return "", err
+	}
+	if amount.IsNegative() || amount.IsZero() {
+		return "", fmt.Errorf("authorization amount must be positive: %w", pkgerrors.ErrInvalidInput)
+	}
+
+	roll := g.random()
+	switch {
+	case token == TokenDecline || roll < g.cfg.DeclineRate:
+		return "", fmt.Errorf("order %d: card declined: %w", orderID, domain.ErrPaymentDeclined)
+	case token == TokenTimeout || roll < g.cfg.DeclineRate+g.cfg.TimeoutRate:
+		if err := g.wait(ctx, g.cfg.TimeoutAfter); err != nil {
+			return "", err
+		}
+		return "", fmt.Errorf("order %d: authorization timed out: %w", orderID, domain.ErrPaymentUnavailable)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.seq++
+	reference := fmt.Sprintf("fake_auth_%d_%d", orderID, g.seq)
+	g.auths[reference] = &fakeAuthorization{amount: amount}
+	return reference, nil
+}
+
+func (g *FakeGateway) Capture(ctx context.Context, reference string, amount valueobject.Money) error {
+	if err := g.wait(ctx, g.cfg.Latency); err != nil {
+		return err
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	auth, err := g.lookup(reference)
+	if err != nil {
+		return err
+	}
+	if auth.voided {
+		return fmt.Errorf("authorization %s was voided: %w", reference, pkgerrors.ErrConflict)
+	}
+	if amount.Add(auth.captured).GreaterThan(auth.amount) {
+		return fmt.Errorf("capture exceeds authorized amount: %w", pkgerrors.ErrInvalidInput)
+	}
+	auth.captured = auth.captured.Add(amount)
+	return nil
+}
+
+func (g *FakeGateway) Void(ctx context.Context, reference string) error {
+	if err := g.wait(ctx, g.cfg.Latency); err != nil {
+		return err
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	auth, err := g.lookup(reference)
+	if err != nil {
+		return err
+	}
+	if !auth.captured.IsZero() {
+		return fmt.Errorf("authorization %s was already captured: %w", reference, pkgerrors.ErrConflict)
+	}
+	auth.voided = true
+	return nil
+}
+
+func (g *FakeGateway) Refund(ctx context.Context, key, reference string, amount valueobject.Money) error {
+	if err := g.wait(ctx, g.cfg.Latency); err != nil {
+		return err
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if key != "" && g.refunds[key] {
+		return nil
+	}
+
+	auth, err := g.lookup(reference)
+	if err != nil {
+		return err
+	}
+	if amount.Add(auth.refunded).GreaterThan(auth.captured) {
+		return fmt.Errorf("refund exceeds captured amount: %w", pkgerrors.ErrInvalidInput)
+	}
+	auth.refunded = auth.refunded.Add(amount)
+	if key != "" {
+		g.refunds[key] = true
+	}
+	return nil
+}
+
+func (g *FakeGateway) lookup(reference string) (*fakeAuthorization, error) {
+	auth, ok := g.auths[reference]
+	if !ok {
+		return nil, fmt.Errorf("authorization %s: %w", reference, pkgerrors.ErrNotFound)
+	}
+	return auth, nil
+}
+
+// wait sleeps for d, failing with ErrPaymentUnavailable if ctx ends first
+func (g *FakeGateway) wait(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%v: %w", ctx.Err(), domain.ErrPaymentUnavailable)
+	}
+}
@@ -0,0 +1,105 @@
+package payment
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/user/go-microservices/order-service/internal/domain"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/valueobject"
+)
+
+func TestFakeGateway(t *testing.T) {
+	ctx := context.Background()
+	amount := valueobject.NewMoney(100)
+
+	t.Run("CaptureAndRefund", func(t *testing.T) {
+		g := NewFakeGateway(FakeConfig{})
+
+		ref, err := g.Authorize(ctx, 1, amount, "tok_visa")
+		assert.NoError(t, err)
+		assert.NoError(t, g.Capture(ctx, ref, amount))
+
+		assert.NoError(t, g.Refund(ctx, "r1", ref, valueobject.NewMoney(60)))
+		assert.ErrorIs(t, g.Refund(ctx, "r2", ref, valueobject.NewMoney(60)), pkgerrors.ErrInvalidInput)
+		assert.NoError(t, g.Refund(ctx, "r3", ref, valueobject.NewMoney(40)))
+	})
+
+	t.Run("RefundRepeatedUnderKey_MadeOnce", func(t *testing.T) {
+		g := NewFakeGateway(FakeConfig{})
+
+		ref, err := g.Authorize(ctx, 1, amount, "tok_visa")
+		assert.NoError(t, err)
+		assert.NoError(t, g.Capture(ctx, ref, amount))
+
+		assert.NoError(t, g.Refund(ctx, "r1", ref, valueobject.NewMoney(60)))
+		assert.NoError(t, g.Refund(ctx, "r1", ref, valueobject.NewMoney(60)))
+		assert.NoError(t, g.Refund(ctx, "r2", ref, valueobject.NewMoney(40)))
+	})
+
+	t.Run("CaptureExceedsAuthorization", func(t *testing.T) {
+		g := NewFakeGateway(FakeConfig{})
+
+		ref, err := g.Authorize(ctx, 1, amount, "")
+		assert.NoError(t, err)
+		assert.ErrorIs(t, g.Capture(ctx, ref, valueobject.NewMoney(101)), pkgerrors.ErrInvalidInput)
+	})
+
+	t.Run("VoidAfterCapture", func(t *testing.T) {
+		g := NewFakeGateway(FakeConfig{})
+
+		ref, err := g.Authorize(ctx, 1, amount, "")
+		assert.NoError(t, err)
+		assert.NoError(t, g.Capture(ctx, ref, amount))
+		assert.ErrorIs(t, g.Void(ctx, ref), pkgerrors.ErrConflict)
+	})
+
+	t.Run("CaptureAfterVoid", func(t *testing.T) {
+		g := NewFakeGateway(FakeConfig{})
+
+		ref, err := g.Authorize(ctx, 1, amount, "")
+		assert.NoError(t, err)
+		assert.NoError(t, g.Void(ctx, ref))
+		assert.ErrorIs(t, g.Capture(ctx, ref, amount), pkgerrors.ErrConflict)
+	})
+
+	t.Run("UnknownReference", func(t *testing.T) {
+		g := NewFakeGateway(FakeConfig{})
+
+		assert.ErrorIs(t, g.Capture(ctx, "missing", amount), pkgerrors.ErrNotFound)
+	})
+
+	t.Run("DeclineToken", func(t *testing.T) {
+		g := NewFakeGateway(FakeConfig{})
+
+		_, err := g.Authorize(ctx, 1, amount, TokenDecline)
+		assert.ErrorIs(t, err, domain.ErrPaymentDeclined)
+	})
+
+	t.Run("TimeoutToken", func(t *testing.T) {
+		g := NewFakeGateway(FakeConfig{TimeoutAfter: time.Hour})
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+
+		_, err := g.Authorize(ctx, 1, amount, TokenTimeout)
+		assert.ErrorIs(t, err, domain.ErrPaymentUnavailable)
+	})
+
+	t.Run("RandomDeclines", func(t *testing.T) {
+		g := NewFakeGateway(FakeConfig{DeclineRate: 0.2, TimeoutRate: 0.1})
+
+		g.random = func() float64 { return 0.1 }
+		_, err := g.Authorize(ctx, 1, amount, "")
+		assert.ErrorIs(t, err, domain.ErrPaymentDeclined)
+
+		g.random = func() float64 { return 0.25 }
+		_, err = g.Authorize(ctx, 1, amount, "")
+		assert.ErrorIs(t, err, domain.ErrPaymentUnavailable)
+
+		g.random = func() float64 { return 0.5 }
+		_, err = g.Authorize(ctx, 1, amount, "")
+		assert.NoError(t, err)
+	})
+}
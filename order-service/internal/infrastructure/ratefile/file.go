@@ -0,0 +1,41 @@
+// Package ratefile loads pricing tables kept in local JSON files.
+package ratefile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+)
+
+// LoadTaxRates reads tax rates from a JSON file holding an array of
+// {"region", "category", "rate"} objects.
+func LoadTaxRates(path string) ([]domain.TaxRate, error) {
+	var rates []domain.TaxRate
+	if err := load(path, &rates); err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+// LoadShippingRates reads shipping rates from a JSON file holding an array of
+// {"zone", "base_fee", "per_kg_fee", "free_over"} objects.
+func LoadShippingRates(path string) ([]domain.ShippingRate, error) {
+	var rates []domain.ShippingRate
+	if err := load(path, &rates); err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+func load(path string, v interface{}) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read rates: %w", err)
+	}
+	if err := json.Unmarshal(content, v); err != nil {
+		return fmt.Errorf("parse rates %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package ratefile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/pkg/valueobject"
+)
+
+func writeFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadTaxRates(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		path := writeFile(t, `[{"region":"US-CA","category":"STANDARD","rate":7.25},{"region":"*","category":"*","rate":0}]`)
+
+		rates, err := LoadTaxRates(path)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []domain.TaxRate{
+			{Region: "US-CA", Category: "STANDARD", Rate: 7.25},
+			{Region: "*", Category: "*", Rate: 0},
+		}, rates)
+	})
+
+	t.Run("Malformed", func(t *testing.T) {
+		_, err := LoadTaxRates(writeFile(t, "{"))
+
+		assert.Error(t, err)
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		_, err := LoadTaxRates(filepath.Join(t.TempDir(), "nope.json"))
+
+		assert.Error(t, err)
+	})
+}
+
+func TestLoadShippingRates(t *testing.T) {
+	path := writeFile(t, `[{"zone":"US","base_fee":4.99,"per_kg_fee":1.5,"free_over":50}]`)
+
+	rates, err := LoadShippingRates(path)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.ShippingRate{{
+		Zone:     "US",
+		BaseFee:  valueobject.NewMoney(4.99),
+		PerKgFee: valueobject.NewMoney(1.5),
+		FreeOver: valueobject.NewMoney(50),
+	}}, rates)
+}
@@ -30,3 +30,10 @@ Feature: Order Processing
     And an order exists with ID 600 for product ID 1 and user 101
     When I cancel the order with ID 600
     Then the order status should be "CANCELLED"
+
+  Scenario: Cancelling an order twice is a no-op
+    Given a product exists with ID 1, name "Wireless Mouse", price 25.0, and stock 10
+    And an order exists with ID 700 for product ID 1 and user 101
+    When I cancel the order with ID 700
+    And I cancel the order with ID 700
+    Then the order status should be "CANCELLED"
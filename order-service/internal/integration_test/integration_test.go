@@ -4,9 +4,11 @@ import (
 	"testing"
 
 	"github.com/cucumber/godog"
+	"github.com/user/go-microservices/pkg/logger"
 )
 
 func TestFeatures(t *testing.T) {
+	logger.Init()
 	suite := godog.TestSuite{
 		ScenarioInitializer: InitializeScenario,
 		Options: &godog.Options{
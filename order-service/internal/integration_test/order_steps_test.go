@@ -11,11 +11,14 @@ import (
 	"github.com/user/go-microservices/order-service/internal/domain"
 	repoMocks "github.com/user/go-microservices/order-service/internal/domain/mocks"
 	"github.com/user/go-microservices/order-service/internal/usecase"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
 	"github.com/user/go-microservices/pkg/valueobject"
 )
 
 type orderTestContext struct {
 	repo          *repoMocks.OrderRepository
+	sagas         *repoMocks.SagaRepository
+	tasks         *repoMocks.TaskRepository
 	productClient *repoMocks.ProductClient
 	uc            usecase.OrderUsecase
 	lastOrder     *domain.Order
@@ -47,25 +50,29 @@ func (c *orderTestContext) anOrderExistsWithIDForProductIDAndUser(orderID, produ
 		Name:  "Test Product",
 		Price: valueobject.NewMoney(25.0),
 	}
-	order, _ := domain.NewOrder(int64(userID), int64(productID), product.Name, product.Price, 1)
+	item, _ := domain.NewOrderItem(int64(productID), product.Name, product.Price, 1)
+	order, _ := domain.NewOrder(int64(userID), []domain.OrderItem{item}, "", &domain.TaxTable{})
 	order.ID = int64(orderID)
 	c.mockOrders[int64(orderID)] = order
 
 	c.repo.On("GetByID", mock.Anything, int64(orderID)).Return(order, nil)
+	c.productClient.On("ReleaseStock", mock.Anything, mock.Anything, int64(productID), item.Quantity).Return(nil)
+	c.repo.On("UpdateStatus", mock.Anything, mock.Anything).Return(nil)
 	return nil
 }
 
 func (c *orderTestContext) iCreateAnOrderForProductIDWithQuantityForUser(productID int, quantity int, userID int) error {
 	// Mock reservation
 	if c.productStock[int64(productID)] >= quantity {
-		c.productClient.On("ReserveStock", mock.Anything, int64(productID), quantity).Return(nil).Once()
+		c.productClient.On("ReserveStock", mock.Anything, mock.Anything, int64(productID), quantity).Return(nil).Once()
 		c.repo.On("Create", mock.Anything, mock.Anything).Return(nil).Once()
 		c.productStock[int64(productID)] -= quantity
 	} else {
-		c.productClient.On("ReserveStock", mock.Anything, int64(productID), quantity).Return(errors.New("insufficient stock")).Once()
+		c.productClient.On("ReserveStock", mock.Anything, mock.Anything, int64(productID), quantity).Return(pkgerrors.ErrInsufficientStock).Once()
 	}
 
-	c.lastOrder, c.lastError = c.uc.CreateOrder(context.Background(), int64(userID), int64(productID), quantity)
+	items := []usecase.OrderItemInput{{ProductID: int64(productID), Quantity: quantity}}
+	c.lastOrder, c.lastError = c.uc.CreateOrder(context.Background(), usecase.CreateOrderInput{UserID: int64(userID), Items: items})
 	return nil
 }
 
@@ -113,7 +120,7 @@ func (c *orderTestContext) iCancelTheOrderWithID(id int) error {
 		return fmt.Errorf("order %d not found", id)
 	}
 	c.lastOrder = order
-	c.lastError = c.uc.CancelOrder(context.Background(), int64(id))
+	_, c.lastError = c.uc.CancelOrder(context.Background(), int64(id), "")
 	return nil
 }
 
@@ -137,11 +144,16 @@ func (c *orderTestContext) theOrderStatusShouldBe(expectedStatus string) error {
 func InitializeScenario(ctx *godog.ScenarioContext) {
 	c := &orderTestContext{
 		repo:          new(repoMocks.OrderRepository),
+		sagas:         new(repoMocks.SagaRepository),
+		tasks:         new(repoMocks.TaskRepository),
 		productClient: new(repoMocks.ProductClient),
 		productStock:  make(map[int64]int),
 		mockOrders:    make(map[int64]*domain.Order),
 	}
-	c.uc = usecase.NewOrderUsecase(c.repo, c.productClient, 5*time.Second)
+	c.sagas.On("Create", mock.Anything, mock.Anything).Return(nil)
+	c.sagas.On("Save", mock.Anything, mock.Anything).Return(nil)
+	c.tasks.On("MarkDone", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	c.uc = usecase.NewOrderUsecase(c.repo, c.sagas, c.tasks, c.productClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, 5*time.Second)
 
 	ctx.Step(`^a product exists with ID (\d+), name "([^"]*)", price ([\d.]+), and stock (\d+)$`, c.aProductExistsWithIDNamePriceAndStock)
 	ctx.Step(`^product ID (\d+) does not exist$`, c.productDoesNotExist)
@@ -0,0 +1,179 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/pkg/logger"
+	"go.uber.org/zap"
+)
+
+//go:generate mockery --name CartUsecase
+type CartUsecase interface {
+	CreateCart(ctx context.Context, userID int64, region string) (*domain.Cart, error)
+	// GetCart returns the cart priced at current catalog prices
+	GetCart(ctx context.Context, id int64) (*domain.Cart, error)
+	AddItem(ctx context.Context, id, productID int64, quantity int) (*domain.Cart, error)
+	UpdateItem(ctx context.Context, id, productID int64, quantity int) (*domain.Cart, error)
+	RemoveItem(ctx context.Context, id, productID int64) (*domain.Cart, error)
+	// Checkout places an order for the cart's items through OrderUsecase and
+	// closes the cart
+	Checkout(ctx context.Context, id int64, couponCode string) (*domain.Order, error)
+}
+
+type cartUsecase struct {
+	repo           domain.CartRepository
+	orders         OrderUsecase
+	productClient  domain.ProductClient
+	taxes          domain.TaxCalculator
+	shipping       domain.ShippingCalculator
+	ttl            time.Duration
+	contextTimeout time.Duration
+}
+
+func NewCartUsecase(repo domain.CartRepository, orders OrderUsecase, pClient domain.ProductClient, taxes domain.TaxCalculator, shipping domain.ShippingCalculator, ttl, timeout time.Duration) CartUsecase {
+	return &cartUsecase{
+		repo:           repo,
+		orders:         orders,
+		productClient:  pClient,
+		taxes:          taxes,
+		shipping:       shipping,
+		ttl:            ttl,
+		contextTimeout: timeout,
+	}
+}
+
+func (u *cartUsecase) CreateCart(ctx context.Context, userID int64, region string) (*domain.Cart, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	cart, err := domain.NewCart(userID, region, u.ttl, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := u.repo.Create(ctx, cart); err != nil {
+		return nil, err
+	}
+	return cart, nil
+}
+
+func (u *cartUsecase) GetCart(ctx context.Context, id int64) (*domain.Cart, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	cart, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.price(ctx, cart); err != nil {
+		return nil, err
+	}
+	return cart, nil
+}
+
+func (u *cartUsecase) AddItem(ctx context.Context, id, productID int64, quantity int) (*domain.Cart, error) {
+	return u.modify(ctx, id, func(cart *domain.Cart, now time.Time) error {
+		return cart.AddItem(productID, quantity, now)
+	})
+}
+
+func (u *cartUsecase) UpdateItem(ctx context.Context, id, productID int64, quantity int) (*domain.Cart, error) {
+	return u.modify(ctx, id, func(cart *domain.Cart, now time.Time) error {
+		return cart.SetQuantity(productID, quantity, now)
+	})
+}
+
+func (u *cartUsecase) RemoveItem(ctx context.Context, id, productID int64) (*domain.Cart, error) {
+	return u.modify(ctx, id, func(cart *domain.Cart, now time.Time) error {
+		return cart.RemoveItem(productID, now)
+	})
+}
+
+// modify applies change to the cart, prices it so unknown products are
+// rejected before anything is stored, and persists it with a fresh expiry
+func (u *cartUsecase) modify(ctx context.Context, id int64, change func(*domain.Cart, time.Time) error) (*domain.Cart, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	cart, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if err := change(cart, now); err != nil {
+		return nil, err
+	}
+	if err := u.price(ctx, cart); err != nil {
+		return nil, err
+	}
+
+	cart.Extend(u.ttl, now)
+	if err := u.repo.Update(ctx, cart); err != nil {
+		return nil, err
+	}
+	return cart, nil
+}
+
+func (u *cartUsecase) Checkout(ctx context.Context, id int64, couponCode string) (*domain.Order, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	cart, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Close the cart before ordering so a concurrent checkout cannot place
+	// a second order for it
+	from := cart.Status
+	if err := cart.CheckOut(time.Now()); err != nil {
+		return nil, err
+	}
+	if err := u.repo.UpdateStatus(ctx, cart, from); err != nil {
+		return nil, err
+	}
+
+	items := make([]OrderItemInput, 0, len(cart.Items))
+	for _, item := range cart.Items {
+		items = append(items, OrderItemInput{ProductID: item.ProductID, Quantity: item.Quantity})
+	}
+	order, err := u.orders.CreateOrder(ctx, CreateOrderInput{
+		UserID:     cart.UserID,
+		Items:      items,
+		Region:     cart.Region,
+		CouponCode: couponCode,
+	})
+	if err != nil {
+		cart.Reopen(time.Now())
+		if rerr := u.repo.UpdateStatus(ctx, cart, domain.CartCheckedOut); rerr != nil {
+			logger.FromContext(ctx).Error("failed to reopen cart after failed checkout",
+				zap.Int64("cart_id", cart.ID), zap.Error(rerr))
+		}
+		return nil, err
+	}
+
+	cart.LinkOrder(order.ID)
+	if err := u.repo.UpdateStatus(ctx, cart, domain.CartCheckedOut); err != nil {
+		logger.FromContext(ctx).Error("order placed but not linked to its cart",
+			zap.Int64("cart_id", cart.ID), zap.Int64("order_id", order.ID), zap.Error(err))
+	}
+	return order, nil
+}
+
+// price fills in live prices and totals; an empty cart totals zero
+func (u *cartUsecase) price(ctx context.Context, cart *domain.Cart) error {
+	if len(cart.Items) == 0 {
+		return nil
+	}
+	items := make([]OrderItemInput, 0, len(cart.Items))
+	for _, item := range cart.Items {
+		items = append(items, OrderItemInput{ProductID: item.ProductID, Quantity: item.Quantity})
+	}
+	order, err := priceOrder(ctx, u.productClient, u.taxes, u.shipping, cart.UserID, items, cart.Region)
+	if err != nil {
+		return err
+	}
+	cart.ApplyPricing(order)
+	return nil
+}
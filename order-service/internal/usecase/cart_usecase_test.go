@@ -0,0 +1,148 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/order-service/internal/domain/mocks"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+	"github.com/user/go-microservices/pkg/valueobject"
+)
+
+func newTestCart(items ...domain.CartItem) *domain.Cart {
+	return &domain.Cart{
+		ID: 5, UserID: 101, Region: "US-CA", Status: domain.CartOpen,
+		Items: items, ExpiresAt: time.Now().Add(time.Hour),
+	}
+}
+
+// stubOrders stands in for OrderUsecase; the generated mock lives in a
+// package that imports this one
+type stubOrders struct {
+	OrderUsecase
+	created []CreateOrderInput
+	order   *domain.Order
+	err     error
+}
+
+func (s *stubOrders) CreateOrder(_ context.Context, in CreateOrderInput) (*domain.Order, error) {
+	s.created = append(s.created, in)
+	return s.order, s.err
+}
+
+func TestCartUsecase(t *testing.T) {
+	logger.Init()
+	timeout := 5 * time.Second
+	ttl := 72 * time.Hour
+
+	t.Run("GetCart_PricedLive", func(t *testing.T) {
+		mockRepo := mocks.NewCartRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		mockTaxes := mocks.NewTaxCalculator(t)
+		uc := NewCartUsecase(mockRepo, nil, mockProductClient, mockTaxes, &domain.ShippingRateTable{}, ttl, timeout)
+
+		mockRepo.On("GetByID", mock.Anything, int64(5)).Return(newTestCart(domain.CartItem{ProductID: 1, Quantity: 2}), nil)
+		mockProductClient.On("GetProduct", mock.Anything, int64(1)).
+			Return(&domain.ProductView{ID: 1, Name: "A", Price: valueobject.NewMoney(50), TaxCategory: "STANDARD"}, nil)
+		mockTaxes.On("Rate", "US-CA", "STANDARD").Return(10.0, nil)
+
+		cart, err := uc.GetCart(context.Background(), 5)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "A", cart.Items[0].ProductName)
+		assert.Equal(t, valueobject.NewMoney(100), cart.Items[0].LineTotal)
+		assert.Equal(t, valueobject.NewMoney(10), cart.Tax)
+		assert.Equal(t, valueobject.NewMoney(110), cart.TotalPrice)
+		mockProductClient.AssertNotCalled(t, "ReserveStock", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("AddItem_ExtendsExpiry", func(t *testing.T) {
+		mockRepo := mocks.NewCartRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewCartUsecase(mockRepo, nil, mockProductClient, &domain.TaxTable{}, &domain.ShippingRateTable{}, ttl, timeout)
+
+		mockRepo.On("GetByID", mock.Anything, int64(5)).Return(newTestCart(), nil)
+		mockProductClient.On("GetProduct", mock.Anything, int64(1)).Return(&domain.ProductView{ID: 1, Name: "A", Price: valueobject.NewMoney(50)}, nil)
+		mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(c *domain.Cart) bool {
+			return len(c.Items) == 1 && c.Items[0].Quantity == 3 && c.ExpiresAt.After(time.Now().Add(ttl-time.Minute))
+		})).Return(nil)
+
+		cart, err := uc.AddItem(context.Background(), 5, 1, 3)
+
+		assert.NoError(t, err)
+		assert.Equal(t, valueobject.NewMoney(150), cart.TotalPrice)
+	})
+
+	t.Run("AddItem_UnknownProduct", func(t *testing.T) {
+		mockRepo := mocks.NewCartRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewCartUsecase(mockRepo, nil, mockProductClient, &domain.TaxTable{}, &domain.ShippingRateTable{}, ttl, timeout)
+
+		mockRepo.On("GetByID", mock.Anything, int64(5)).Return(newTestCart(), nil)
+		mockProductClient.On("GetProduct", mock.Anything, int64(9)).Return(nil, pkgerrors.ErrNotFound)
+
+		cart, err := uc.AddItem(context.Background(), 5, 9, 1)
+
+		assert.ErrorIs(t, err, pkgerrors.ErrNotFound)
+		assert.Nil(t, cart)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Checkout_Success", func(t *testing.T) {
+		mockRepo := mocks.NewCartRepository(t)
+		orders := &stubOrders{order: &domain.Order{ID: 42}}
+		uc := NewCartUsecase(mockRepo, orders, mocks.NewProductClient(t), &domain.TaxTable{}, &domain.ShippingRateTable{}, ttl, timeout)
+
+		mockRepo.On("GetByID", mock.Anything, int64(5)).Return(newTestCart(domain.CartItem{ProductID: 1, Quantity: 2}), nil)
+		mockRepo.On("UpdateStatus", mock.Anything, mock.MatchedBy(func(c *domain.Cart) bool {
+			return c.Status == domain.CartCheckedOut && c.OrderID == nil
+		}), domain.CartOpen).Return(nil).Once()
+		mockRepo.On("UpdateStatus", mock.Anything, mock.MatchedBy(func(c *domain.Cart) bool {
+			return c.OrderID != nil && *c.OrderID == 42
+		}), domain.CartCheckedOut).Return(nil).Once()
+
+		order, err := uc.Checkout(context.Background(), 5, "SPRING10")
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(42), order.ID)
+		assert.Equal(t, []CreateOrderInput{{
+			UserID: 101, Items: []OrderItemInput{{ProductID: 1, Quantity: 2}}, Region: "US-CA", CouponCode: "SPRING10",
+		}}, orders.created)
+	})
+
+	t.Run("Checkout_OrderFails_ReopensCart", func(t *testing.T) {
+		mockRepo := mocks.NewCartRepository(t)
+		uc := NewCartUsecase(mockRepo, &stubOrders{err: pkgerrors.ErrInsufficientStock}, mocks.NewProductClient(t), &domain.TaxTable{}, &domain.ShippingRateTable{}, ttl, timeout)
+
+		mockRepo.On("GetByID", mock.Anything, int64(5)).Return(newTestCart(domain.CartItem{ProductID: 1, Quantity: 2}), nil)
+		mockRepo.On("UpdateStatus", mock.Anything, mock.Anything, domain.CartOpen).Return(nil).Once()
+		mockRepo.On("UpdateStatus", mock.Anything, mock.MatchedBy(func(c *domain.Cart) bool {
+			return c.Status == domain.CartOpen
+		}), domain.CartCheckedOut).Return(nil).Once()
+
+		order, err := uc.Checkout(context.Background(), 5, "")
+
+		assert.ErrorIs(t, err, pkgerrors.ErrInsufficientStock)
+		assert.Nil(t, order)
+	})
+
+	t.Run("Checkout_Concurrent", func(t *testing.T) {
+		mockRepo := mocks.NewCartRepository(t)
+		orders := &stubOrders{}
+		uc := NewCartUsecase(mockRepo, orders, mocks.NewProductClient(t), &domain.TaxTable{}, &domain.ShippingRateTable{}, ttl, timeout)
+
+		mockRepo.On("GetByID", mock.Anything, int64(5)).Return(newTestCart(domain.CartItem{ProductID: 1, Quantity: 2}), nil)
+		mockRepo.On("UpdateStatus", mock.Anything, mock.Anything, domain.CartOpen).Return(pkgerrors.ErrConflict)
+
+		order, err := uc.Checkout(context.Background(), 5, "")
+
+		assert.ErrorIs(t, err, pkgerrors.ErrConflict)
+		assert.Nil(t, order)
+		assert.Empty(t, orders.created)
+	})
+}
@@ -0,0 +1,71 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+)
+
+//go:generate mockery --name CouponUsecase
+type CouponUsecase interface {
+	// CreateCoupon stores a new coupon; codes are case-insensitive and kept upper case
+	CreateCoupon(ctx context.Context, c *domain.Coupon) (*domain.Coupon, error)
+	GetCoupon(ctx context.Context, code string) (*domain.Coupon, error)
+	GetAllCoupons(ctx context.Context) ([]*domain.Coupon, error)
+	// DeactivateCoupon stops a campaign; orders that already redeemed it keep their discount
+	DeactivateCoupon(ctx context.Context, code string) (*domain.Coupon, error)
+}
+
+type couponUsecase struct {
+	repo           domain.CouponRepository
+	contextTimeout time.Duration
+}
+
+func NewCouponUsecase(repo domain.CouponRepository, timeout time.Duration) CouponUsecase {
+	return &couponUsecase{
+		repo:           repo,
+		contextTimeout: timeout,
+	}
+}
+
+func (u *couponUsecase) CreateCoupon(ctx context.Context, c *domain.Coupon) (*domain.Coupon, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	c.Code = strings.ToUpper(strings.TrimSpace(c.Code))
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	c.UsesCount = 0
+	c.Active = true
+
+	if err := u.repo.Create(ctx, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (u *couponUsecase) GetCoupon(ctx context.Context, code string) (*domain.Coupon, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+	return u.repo.GetByCode(ctx, strings.ToUpper(code))
+}
+
+func (u *couponUsecase) GetAllCoupons(ctx context.Context) ([]*domain.Coupon, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+	return u.repo.GetAll(ctx)
+}
+
+func (u *couponUsecase) DeactivateCoupon(ctx context.Context, code string) (*domain.Coupon, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	code = strings.ToUpper(code)
+	if err := u.repo.SetActive(ctx, code, false); err != nil {
+		return nil, err
+	}
+	return u.repo.GetByCode(ctx, code)
+}
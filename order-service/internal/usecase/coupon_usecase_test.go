@@ -0,0 +1,78 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/order-service/internal/domain/mocks"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+)
+
+func TestCouponUsecase_CreateCoupon(t *testing.T) {
+	logger.Init()
+	timeout := 5 * time.Second
+
+	t.Run("Success_NormalizesCode", func(t *testing.T) {
+		mockRepo := mocks.NewCouponRepository(t)
+		uc := NewCouponUsecase(mockRepo, timeout)
+
+		mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(c *domain.Coupon) bool {
+			return c.Code == "SPRING10" && c.Active
+		})).Return(nil)
+
+		coupon, err := uc.CreateCoupon(context.Background(), &domain.Coupon{
+			Code:          " spring10 ",
+			DiscountTerms: domain.DiscountTerms{Type: domain.DiscountPercentage, Percentage: 10},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "SPRING10", coupon.Code)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		uc := NewCouponUsecase(mocks.NewCouponRepository(t), timeout)
+
+		coupon, err := uc.CreateCoupon(context.Background(), &domain.Coupon{
+			Code:          "ZERO",
+			DiscountTerms: domain.DiscountTerms{Type: domain.DiscountFixed},
+		})
+
+		assert.ErrorIs(t, err, pkgerrors.ErrInvalidInput)
+		assert.Nil(t, coupon)
+	})
+}
+
+func TestCouponUsecase_DeactivateCoupon(t *testing.T) {
+	logger.Init()
+	timeout := 5 * time.Second
+
+	t.Run("Success", func(t *testing.T) {
+		mockRepo := mocks.NewCouponRepository(t)
+		uc := NewCouponUsecase(mockRepo, timeout)
+
+		mockRepo.On("SetActive", mock.Anything, "SPRING10", false).Return(nil)
+		mockRepo.On("GetByCode", mock.Anything, "SPRING10").Return(&domain.Coupon{Code: "SPRING10"}, nil)
+
+		coupon, err := uc.DeactivateCoupon(context.Background(), "spring10")
+
+		assert.NoError(t, err)
+		assert.False(t, coupon.Active)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		mockRepo := mocks.NewCouponRepository(t)
+		uc := NewCouponUsecase(mockRepo, timeout)
+
+		mockRepo.On("SetActive", mock.Anything, "NOPE", false).Return(pkgerrors.ErrNotFound)
+
+		coupon, err := uc.DeactivateCoupon(context.Background(), "NOPE")
+
+		assert.ErrorIs(t, err, pkgerrors.ErrNotFound)
+		assert.Nil(t, coupon)
+	})
+}
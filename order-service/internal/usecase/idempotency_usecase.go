@@ -0,0 +1,123 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// IdempotentFunc handles a request and returns its response
+type IdempotentFunc func(ctx context.Context) (statusCode int, body []byte)
+
+//go:generate mockery --name IdempotencyUsecase
+type IdempotencyUsecase interface {
+	// Execute runs fn at most once per key within the retention window. A
+	// repeat sent while the first request is running waits for it; a repeat
+	// sent afterwards gets the stored response and replayed is true. A key
+	// sent with a different request fails with ErrIdempotencyKeyReused.
+	// Server errors are not kept, so a repeat after one runs fn again; fn
+	// must then find whatever its first run stored.
+	Execute(ctx context.Context, key, requestHash string, fn IdempotentFunc) (rec *domain.IdempotencyRecord, replayed bool, err error)
+	// PurgeExpired deletes up to limit records past their retention window
+	PurgeExpired(ctx context.Context, limit int) (int64, error)
+}
+
+// idempotencyPollInterval is how often a repeat sent while the first
+// request is running checks whether it has finished
+const idempotencyPollInterval = 50 * time.Millisecond
+
+type idempotencyUsecase struct {
+	repo           domain.IdempotencyRepository
+	retention      time.Duration
+	pollInterval   time.Duration
+	contextTimeout time.Duration
+}
+
+func NewIdempotencyUsecase(repo domain.IdempotencyRepository, retention, timeout time.Duration) IdempotencyUsecase {
+	return &idempotencyUsecase{
+		repo:           repo,
+		retention:      retention,
+		pollInterval:   idempotencyPollInterval,
+		contextTimeout: timeout,
+	}
+}
+
+func (u *idempotencyUsecase) Execute(ctx context.Context, key, requestHash string, fn IdempotentFunc) (*domain.IdempotencyRecord, bool, error) {
+	rec, err := u.claim(ctx, domain.NewIdempotencyClaim(key, requestHash, time.Now()))
+	if err != nil {
+		return nil, false, err
+	}
+	if rec != nil {
+		return rec, true, nil
+	}
+
+	statusCode, body := fn(ctx)
+	rec = domain.NewIdempotencyRecord(key, requestHash, statusCode, body, time.Now(), u.retention)
+
+	// Stored even if the client has gone, since its retry is what the record
+	// is for
+	storeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), u.contextTimeout)
+	defer cancel()
+	if domain.Retainable(statusCode) {
+		if err := u.repo.Save(storeCtx, rec); err != nil {
+			logger.FromContext(ctx).Error("failed to store idempotent response", zap.String("idempotency_key", key), zap.Error(err))
+		}
+	} else if err := u.repo.Release(storeCtx, key, requestHash); err != nil {
+		// The claim expires on its own, after which the key is free again
+		logger.FromContext(ctx).Error("failed to release idempotency key", zap.String("idempotency_key", key), zap.Error(err))
+	}
+	return rec, false, nil
+}
+
+// claim claims the key for the request, returning nil once it holds the
+// claim. While another request holds it, claim polls until that request
+// stores its response and returns the response, or gives up with
+// ErrConflict. No connection is held while waiting.
+func (u *idempotencyUsecase) claim(ctx context.Context, c *domain.IdempotencyRecord) (*domain.IdempotencyRecord, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	for {
+		claimed, err := u.repo.Claim(ctx, c)
+		if err != nil {
+			return nil, err
+		}
+		if claimed {
+			return nil, nil
+		}
+
+		rec, err := u.repo.Get(ctx, c.Key, time.Now())
+		switch {
+		case errors.Is(err, pkgerrors.ErrNotFound):
+			// Released or expired since the claim failed; claim it again
+		case err != nil:
+			return nil, err
+		default:
+			if err := rec.Replays(c.RequestHash); err != nil {
+				return nil, err
+			}
+			if !rec.InProgress() {
+				return rec, nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("request with idempotency key still in progress: %w", pkgerrors.ErrConflict)
+		case <-time.After(u.pollInterval):
+		}
+	}
+}
+
+func (u *idempotencyUsecase) PurgeExpired(ctx context.Context, limit int) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	return u.repo.DeleteExpired(ctx, time.Now(), limit)
+}
@@ -0,0 +1,197 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/order-service/internal/domain/mocks"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+)
+
+// memoryIdempotency keeps records in memory, claiming keys atomically like
+// the database insert does
+type memoryIdempotency struct {
+	domain.IdempotencyRepository
+	mu      sync.Mutex
+	records map[string]*domain.IdempotencyRecord
+}
+
+func (m *memoryIdempotency) Claim(_ context.Context, claim *domain.IdempotencyRecord) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.records[claim.Key]; ok {
+		return false, nil
+	}
+	m.records[claim.Key] = claim
+	return true, nil
+}
+
+func (m *memoryIdempotency) Get(_ context.Context, key string, _ time.Time) (*domain.IdempotencyRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if rec, ok := m.records[key]; ok {
+		return rec, nil
+	}
+	return nil, pkgerrors.ErrNotFound
+}
+
+func (m *memoryIdempotency) Save(_ context.Context, rec *domain.IdempotencyRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records[rec.Key] = rec
+	return nil
+}
+
+func TestIdempotencyUsecase_Execute(t *testing.T) {
+	logger.Init()
+	timeout := 5 * time.Second
+	retention := 24 * time.Hour
+
+	created := func(calls *int) IdempotentFunc {
+		return func(ctx context.Context) (int, []byte) {
+			*calls++
+			return http.StatusCreated, []byte(`{"id":42}`)
+		}
+	}
+
+	t.Run("FirstRequest_RunsAndStores", func(t *testing.T) {
+		mockRepo := mocks.NewIdempotencyRepository(t)
+		uc := NewIdempotencyUsecase(mockRepo, retention, timeout)
+
+		mockRepo.On("Claim", mock.Anything, mock.MatchedBy(func(c *domain.IdempotencyRecord) bool {
+			return c.Key == "k1" && c.RequestHash == "h1" && c.InProgress()
+		})).Return(true, nil)
+		mockRepo.On("Save", mock.Anything, mock.MatchedBy(func(r *domain.IdempotencyRecord) bool {
+			return r.Key == "k1" && r.RequestHash == "h1" && r.StatusCode == http.StatusCreated &&
+				r.ExpiresAt.Sub(r.CreatedAt) == retention
+		})).Return(nil)
+
+		calls := 0
+		rec, replayed, err := uc.Execute(context.Background(), "k1", "h1", created(&calls))
+
+		assert.NoError(t, err)
+		assert.False(t, replayed)
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, []byte(`{"id":42}`), rec.Body)
+	})
+
+	t.Run("Repeat_Replayed", func(t *testing.T) {
+		mockRepo := mocks.NewIdempotencyRepository(t)
+		uc := NewIdempotencyUsecase(mockRepo, retention, timeout)
+		stored := domain.NewIdempotencyRecord("k1", "h1", http.StatusCreated, []byte(`{"id":42}`), time.Now(), retention)
+
+		mockRepo.On("Claim", mock.Anything, mock.Anything).Return(false, nil)
+		mockRepo.On("Get", mock.Anything, "k1", mock.Anything).Return(stored, nil)
+
+		calls := 0
+		rec, replayed, err := uc.Execute(context.Background(), "k1", "h1", created(&calls))
+
+		assert.NoError(t, err)
+		assert.True(t, replayed)
+		assert.Equal(t, 0, calls)
+		assert.Equal(t, stored, rec)
+	})
+
+	t.Run("KeyReused_Rejected", func(t *testing.T) {
+		mockRepo := mocks.NewIdempotencyRepository(t)
+		uc := NewIdempotencyUsecase(mockRepo, retention, timeout)
+		stored := domain.NewIdempotencyRecord("k1", "h1", http.StatusCreated, []byte(`{"id":42}`), time.Now(), retention)
+
+		mockRepo.On("Claim", mock.Anything, mock.Anything).Return(false, nil)
+		mockRepo.On("Get", mock.Anything, "k1", mock.Anything).Return(stored, nil)
+
+		calls := 0
+		_, _, err := uc.Execute(context.Background(), "k1", "h2", created(&calls))
+
+		assert.ErrorIs(t, err, domain.ErrIdempotencyKeyReused)
+		assert.ErrorIs(t, err, pkgerrors.ErrUnprocessable)
+		assert.Equal(t, 0, calls)
+	})
+
+	t.Run("ServerError_Released", func(t *testing.T) {
+		mockRepo := mocks.NewIdempotencyRepository(t)
+		uc := NewIdempotencyUsecase(mockRepo, retention, timeout)
+
+		mockRepo.On("Claim", mock.Anything, mock.Anything).Return(true, nil)
+		mockRepo.On("Release", mock.Anything, "k1", "h1").Return(nil)
+
+		rec, replayed, err := uc.Execute(context.Background(), "k1", "h1", func(ctx context.Context) (int, []byte) {
+			return http.StatusServiceUnavailable, []byte(`{"error":"unavailable"}`)
+		})
+
+		assert.NoError(t, err)
+		assert.False(t, replayed)
+		assert.Equal(t, http.StatusServiceUnavailable, rec.StatusCode)
+		mockRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	})
+
+	t.Run("StillInProgress_Conflict", func(t *testing.T) {
+		mockRepo := mocks.NewIdempotencyRepository(t)
+		uc := NewIdempotencyUsecase(mockRepo, retention, 20*time.Millisecond)
+
+		mockRepo.On("Claim", mock.Anything, mock.Anything).Return(false, nil)
+		mockRepo.On("Get", mock.Anything, "k1", mock.Anything).Return(domain.NewIdempotencyClaim("k1", "h1", time.Now()), nil)
+
+		calls := 0
+		_, _, err := uc.Execute(context.Background(), "k1", "h1", created(&calls))
+
+		assert.ErrorIs(t, err, pkgerrors.ErrConflict)
+		assert.Equal(t, 0, calls)
+	})
+
+	t.Run("ClaimedThenFinished_Replayed", func(t *testing.T) {
+		mockRepo := mocks.NewIdempotencyRepository(t)
+		uc := NewIdempotencyUsecase(mockRepo, retention, timeout)
+		stored := domain.NewIdempotencyRecord("k1", "h1", http.StatusCreated, []byte(`{"id":42}`), time.Now(), retention)
+
+		mockRepo.On("Claim", mock.Anything, mock.Anything).Return(false, nil)
+		mockRepo.On("Get", mock.Anything, "k1", mock.Anything).Return(domain.NewIdempotencyClaim("k1", "h1", time.Now()), nil).Once()
+		mockRepo.On("Get", mock.Anything, "k1", mock.Anything).Return(stored, nil).Once()
+
+		calls := 0
+		rec, replayed, err := uc.Execute(context.Background(), "k1", "h1", created(&calls))
+
+		assert.NoError(t, err)
+		assert.True(t, replayed)
+		assert.Equal(t, 0, calls)
+		assert.Equal(t, stored, rec)
+	})
+
+	t.Run("ConcurrentRepeats_RunOnce", func(t *testing.T) {
+		uc := NewIdempotencyUsecase(&memoryIdempotency{records: make(map[string]*domain.IdempotencyRecord)}, retention, timeout)
+
+		var calls, replays int
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, replayed, err := uc.Execute(context.Background(), "k1", "h1", func(ctx context.Context) (int, []byte) {
+					mu.Lock()
+					calls++
+					mu.Unlock()
+					time.Sleep(time.Millisecond)
+					return http.StatusCreated, []byte(`{"id":42}`)
+				})
+				assert.NoError(t, err)
+				if replayed {
+					mu.Lock()
+					replays++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, 9, replays)
+	})
+}
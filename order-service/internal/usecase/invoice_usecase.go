@@ -0,0 +1,59 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+)
+
+//go:generate mockery --name InvoiceUsecase
+type InvoiceUsecase interface {
+	// GetInvoice returns the order's invoice. Orders paid before invoicing
+	// existed are invoiced on first request.
+	GetInvoice(ctx context.Context, orderID int64) (*domain.Invoice, error)
+}
+
+type invoiceUsecase struct {
+	invoices       domain.InvoiceRepository
+	orders         domain.OrderRepository
+	contextTimeout time.Duration
+}
+
+func NewInvoiceUsecase(invoices domain.InvoiceRepository, orders domain.OrderRepository, timeout time.Duration) InvoiceUsecase {
+	return &invoiceUsecase{
+		invoices:       invoices,
+		orders:         orders,
+		contextTimeout: timeout,
+	}
+}
+
+func (u *invoiceUsecase) GetInvoice(ctx context.Context, orderID int64) (*domain.Invoice, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	invoice, err := u.invoices.GetByOrderID(ctx, orderID)
+	if !errors.Is(err, pkgerrors.ErrNotFound) {
+		return invoice, err
+	}
+
+	order, err := u.orders.GetByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	invoice, err = domain.NewInvoice(order, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	err = u.invoices.Issue(ctx, invoice)
+	if errors.Is(err, pkgerrors.ErrConflict) {
+		// A concurrent request issued it first
+		return u.invoices.GetByOrderID(ctx, orderID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return invoice, nil
+}
@@ -0,0 +1,64 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/order-service/internal/domain/mocks"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+)
+
+func TestInvoiceUsecase_GetInvoice(t *testing.T) {
+	timeout := 5 * time.Second
+
+	t.Run("Existing", func(t *testing.T) {
+		mockInvoices := mocks.NewInvoiceRepository(t)
+		uc := NewInvoiceUsecase(mockInvoices, mocks.NewOrderRepository(t), timeout)
+
+		mockInvoices.On("GetByOrderID", mock.Anything, int64(1)).Return(&domain.Invoice{Number: "INV-2026-000001"}, nil)
+
+		inv, err := uc.GetInvoice(context.Background(), 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "INV-2026-000001", inv.Number)
+	})
+
+	t.Run("PaidWithoutInvoice_Issues", func(t *testing.T) {
+		mockInvoices := mocks.NewInvoiceRepository(t)
+		mockOrders := mocks.NewOrderRepository(t)
+		uc := NewInvoiceUsecase(mockInvoices, mockOrders, timeout)
+
+		order := newTestOrder(t)
+		order.ID = 1
+		order.PaymentStatus = domain.PaymentPaid
+
+		mockInvoices.On("GetByOrderID", mock.Anything, int64(1)).Return(nil, pkgerrors.ErrNotFound)
+		mockOrders.On("GetByID", mock.Anything, int64(1)).Return(order, nil)
+		mockInvoices.On("Issue", mock.Anything, mock.MatchedBy(func(inv *domain.Invoice) bool { return inv.OrderID == 1 })).Return(nil)
+
+		inv, err := uc.GetInvoice(context.Background(), 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, order.TotalPrice, inv.Total)
+	})
+
+	t.Run("Unpaid", func(t *testing.T) {
+		mockInvoices := mocks.NewInvoiceRepository(t)
+		mockOrders := mocks.NewOrderRepository(t)
+		uc := NewInvoiceUsecase(mockInvoices, mockOrders, timeout)
+
+		order := newTestOrder(t)
+		order.ID = 1
+
+		mockInvoices.On("GetByOrderID", mock.Anything, int64(1)).Return(nil, pkgerrors.ErrNotFound)
+		mockOrders.On("GetByID", mock.Anything, int64(1)).Return(order, nil)
+
+		_, err := uc.GetInvoice(context.Background(), 1)
+
+		assert.ErrorIs(t, err, domain.ErrOrderNotInvoiced)
+	})
+}
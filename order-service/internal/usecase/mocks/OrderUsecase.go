@@ -7,6 +7,12 @@ import (
 
 	mock "github.com/stretchr/testify/mock"
 	domain "github.com/user/go-microservices/order-service/internal/domain"
+
+	time "time"
+
+	usecase "github.com/user/go-microservices/order-service/internal/usecase"
+
+	valueobject "github.com/user/go-microservices/pkg/valueobject"
 )
 
 // OrderUsecase is an autogenerated mock type for the OrderUsecase type
@@ -14,27 +20,129 @@ type OrderUsecase struct {
 	mock.Mock
 }
 
-// CancelOrder provides a mock function with given fields: ctx, id
-func (_m *OrderUsecase) CancelOrder(ctx context.Context, id int64) error {
-	ret := _m.Called(ctx, id)
+// AllocateBackorders provides a mock function with given fields: ctx, limit
+func (_m *OrderUsecase) AllocateBackorders(ctx context.Context, limit int) ([]int64, error) {
+	ret := _m.Called(ctx, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AllocateBackorders")
+	}
+
+	var r0 []int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]int64, error)); ok {
+		return rf(ctx, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []int64); ok {
+		r0 = rf(ctx, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int64)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AmendOrder provides a mock function with given fields: ctx, id, items
+func (_m *OrderUsecase) AmendOrder(ctx context.Context, id int64, items []usecase.OrderItemInput) (*domain.Order, error) {
+	ret := _m.Called(ctx, id, items)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AmendOrder")
+	}
+
+	var r0 *domain.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, []usecase.OrderItemInput) (*domain.Order, error)); ok {
+		return rf(ctx, id, items)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, []usecase.OrderItemInput) *domain.Order); ok {
+		r0 = rf(ctx, id, items)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Order)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, []usecase.OrderItemInput) error); ok {
+		r1 = rf(ctx, id, items)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CancelOrder provides a mock function with given fields: ctx, id, reason
+func (_m *OrderUsecase) CancelOrder(ctx context.Context, id int64, reason string) (*domain.Order, error) {
+	ret := _m.Called(ctx, id, reason)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CancelOrder")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+	var r0 *domain.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) (*domain.Order, error)); ok {
+		return rf(ctx, id, reason)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) *domain.Order); ok {
+		r0 = rf(ctx, id, reason)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Order)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string) error); ok {
+		r1 = rf(ctx, id, reason)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CompleteOrder provides a mock function with given fields: ctx, id
+func (_m *OrderUsecase) CompleteOrder(ctx context.Context, id int64) (*domain.Order, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CompleteOrder")
+	}
+
+	var r0 *domain.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*domain.Order, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *domain.Order); ok {
 		r0 = rf(ctx, id)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Order)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// CreateOrder provides a mock function with given fields: ctx, userID, productID, qty
-func (_m *OrderUsecase) CreateOrder(ctx context.Context, userID int64, productID int64, qty int) (*domain.Order, error) {
-	ret := _m.Called(ctx, userID, productID, qty)
+// CreateOrder provides a mock function with given fields: ctx, in
+func (_m *OrderUsecase) CreateOrder(ctx context.Context, in usecase.CreateOrderInput) (*domain.Order, error) {
+	ret := _m.Called(ctx, in)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CreateOrder")
@@ -42,19 +150,79 @@ func (_m *OrderUsecase) CreateOrder(ctx context.Context, userID int64, productID
 
 	var r0 *domain.Order
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int) (*domain.Order, error)); ok {
-		return rf(ctx, userID, productID, qty)
+	if rf, ok := ret.Get(0).(func(context.Context, usecase.CreateOrderInput) (*domain.Order, error)); ok {
+		return rf(ctx, in)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int) *domain.Order); ok {
-		r0 = rf(ctx, userID, productID, qty)
+	if rf, ok := ret.Get(0).(func(context.Context, usecase.CreateOrderInput) *domain.Order); ok {
+		r0 = rf(ctx, in)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*domain.Order)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, int) error); ok {
-		r1 = rf(ctx, userID, productID, qty)
+	if rf, ok := ret.Get(1).(func(context.Context, usecase.CreateOrderInput) error); ok {
+		r1 = rf(ctx, in)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeliverShipment provides a mock function with given fields: ctx, orderID, shipmentID, deliveredAt
+func (_m *OrderUsecase) DeliverShipment(ctx context.Context, orderID int64, shipmentID int64, deliveredAt time.Time) (*domain.Order, error) {
+	ret := _m.Called(ctx, orderID, shipmentID, deliveredAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeliverShipment")
+	}
+
+	var r0 *domain.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, time.Time) (*domain.Order, error)); ok {
+		return rf(ctx, orderID, shipmentID, deliveredAt)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, time.Time) *domain.Order); ok {
+		r0 = rf(ctx, orderID, shipmentID, deliveredAt)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Order)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, time.Time) error); ok {
+		r1 = rf(ctx, orderID, shipmentID, deliveredAt)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ExpireOrders provides a mock function with given fields: ctx, ttl, limit
+func (_m *OrderUsecase) ExpireOrders(ctx context.Context, ttl time.Duration, limit int) ([]int64, error) {
+	ret := _m.Called(ctx, ttl, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExpireOrders")
+	}
+
+	var r0 []int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Duration, int) ([]int64, error)); ok {
+		return rf(ctx, ttl, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Duration, int) []int64); ok {
+		r0 = rf(ctx, ttl, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int64)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Duration, int) error); ok {
+		r1 = rf(ctx, ttl, limit)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -122,6 +290,276 @@ func (_m *OrderUsecase) GetOrder(ctx context.Context, id int64) (*domain.Order,
 	return r0, r1
 }
 
+// GetOrderHistory provides a mock function with given fields: ctx, id
+func (_m *OrderUsecase) GetOrderHistory(ctx context.Context, id int64) ([]*domain.StatusChange, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetOrderHistory")
+	}
+
+	var r0 []*domain.StatusChange
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]*domain.StatusChange, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []*domain.StatusChange); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.StatusChange)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRefunds provides a mock function with given fields: ctx, id
+func (_m *OrderUsecase) GetRefunds(ctx context.Context, id int64) ([]*domain.Refund, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRefunds")
+	}
+
+	var r0 []*domain.Refund
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]*domain.Refund, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []*domain.Refund); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.Refund)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetShipments provides a mock function with given fields: ctx, id
+func (_m *OrderUsecase) GetShipments(ctx context.Context, id int64) ([]*domain.Shipment, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetShipments")
+	}
+
+	var r0 []*domain.Shipment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]*domain.Shipment, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []*domain.Shipment); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.Shipment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// HandlePaymentEvent provides a mock function with given fields: ctx, event
+func (_m *OrderUsecase) HandlePaymentEvent(ctx context.Context, event *domain.PaymentEvent) (*domain.Order, error) {
+	ret := _m.Called(ctx, event)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HandlePaymentEvent")
+	}
+
+	var r0 *domain.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.PaymentEvent) (*domain.Order, error)); ok {
+		return rf(ctx, event)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.PaymentEvent) *domain.Order); ok {
+		r0 = rf(ctx, event)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Order)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.PaymentEvent) error); ok {
+		r1 = rf(ctx, event)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PayOrder provides a mock function with given fields: ctx, id, paymentToken
+func (_m *OrderUsecase) PayOrder(ctx context.Context, id int64, paymentToken string) (*domain.Order, error) {
+	ret := _m.Called(ctx, id, paymentToken)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PayOrder")
+	}
+
+	var r0 *domain.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) (*domain.Order, error)); ok {
+		return rf(ctx, id, paymentToken)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) *domain.Order); ok {
+		r0 = rf(ctx, id, paymentToken)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Order)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string) error); ok {
+		r1 = rf(ctx, id, paymentToken)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RecoverSagas provides a mock function with given fields: ctx, staleAfter, limit
+func (_m *OrderUsecase) RecoverSagas(ctx context.Context, staleAfter time.Duration, limit int) ([]int64, error) {
+	ret := _m.Called(ctx, staleAfter, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RecoverSagas")
+	}
+
+	var r0 []int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Duration, int) ([]int64, error)); ok {
+		return rf(ctx, staleAfter, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Duration, int) []int64); ok {
+		r0 = rf(ctx, staleAfter, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int64)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Duration, int) error); ok {
+		r1 = rf(ctx, staleAfter, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RefundOrder provides a mock function with given fields: ctx, id, amount, reason
+func (_m *OrderUsecase) RefundOrder(ctx context.Context, id int64, amount valueobject.Money, reason string) (*domain.Refund, error) {
+	ret := _m.Called(ctx, id, amount, reason)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RefundOrder")
+	}
+
+	var r0 *domain.Refund
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, valueobject.Money, string) (*domain.Refund, error)); ok {
+		return rf(ctx, id, amount, reason)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, valueobject.Money, string) *domain.Refund); ok {
+		r0 = rf(ctx, id, amount, reason)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Refund)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, valueobject.Money, string) error); ok {
+		r1 = rf(ctx, id, amount, reason)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReleasePreorders provides a mock function with given fields: ctx, limit
+func (_m *OrderUsecase) ReleasePreorders(ctx context.Context, limit int) ([]int64, error) {
+	ret := _m.Called(ctx, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReleasePreorders")
+	}
+
+	var r0 []int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]int64, error)); ok {
+		return rf(ctx, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []int64); ok {
+		r0 = rf(ctx, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int64)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ShipOrder provides a mock function with given fields: ctx, id, carrier, trackingNumber, shippedAt
+func (_m *OrderUsecase) ShipOrder(ctx context.Context, id int64, carrier string, trackingNumber string, shippedAt time.Time) (*domain.Shipment, error) {
+	ret := _m.Called(ctx, id, carrier, trackingNumber, shippedAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ShipOrder")
+	}
+
+	var r0 *domain.Shipment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, string, time.Time) (*domain.Shipment, error)); ok {
+		return rf(ctx, id, carrier, trackingNumber, shippedAt)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, string, time.Time) *domain.Shipment); ok {
+		r0 = rf(ctx, id, carrier, trackingNumber, shippedAt)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Shipment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string, string, time.Time) error); ok {
+		r1 = rf(ctx, id, carrier, trackingNumber, shippedAt)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewOrderUsecase creates a new instance of OrderUsecase. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewOrderUsecase(t interface {
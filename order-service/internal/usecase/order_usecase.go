@@ -2,75 +2,273 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/user/go-microservices/order-service/internal/domain"
 	pkgerrors "github.com/user/go-microservices/pkg/errors"
 	"github.com/user/go-microservices/pkg/logger"
+	"github.com/user/go-microservices/pkg/valueobject"
 	"go.uber.org/zap"
 )
 
+// OrderItemInput is a requested order line before it is priced and reserved
+type OrderItemInput struct {
+	ProductID int64
+	Quantity  int
+}
+
+// CreateOrderInput describes an order to place
+type CreateOrderInput struct {
+	UserID int64
+	Items  []OrderItemInput
+	// Region is the destination; it selects tax and shipping rates
+	Region     string
+	CouponCode string
+	// QuoteID places the order at a quote's locked prices. The quote then
+	// defines the items and region, so Items must be empty and Region empty
+	// or equal to the quote's.
+	QuoteID int64
+	// SubscriptionID links the order to the subscription that placed it
+	SubscriptionID int64
+	// IdempotencyKey identifies the request. It is stored with the order, so
+	// a retry of a request whose order was placed gets that order back
+	// rather than placing another.
+	IdempotencyKey string
+}
+
 //go:generate mockery --name OrderUsecase
 type OrderUsecase interface {
-	CreateOrder(ctx context.Context, userID, productID int64, qty int) (*domain.Order, error)
+	// CreateOrder prices, taxes and ships the items and reserves them; a
+	// coupon code adds its discount to the order. Orders refused by the
+	// configured rules fail with a *domain.RuleViolation.
+	CreateOrder(ctx context.Context, in CreateOrderInput) (*domain.Order, error)
 	GetOrder(ctx context.Context, id int64) (*domain.Order, error)
 	GetAllOrders(ctx context.Context) ([]*domain.Order, error)
-	CancelOrder(ctx context.Context, id int64) error
+	// AmendOrder changes line quantities of an unpaid order, reserving or
+	// releasing only the difference.
+	AmendOrder(ctx context.Context, id int64, items []OrderItemInput) (*domain.Order, error)
+	CancelOrder(ctx context.Context, id int64, reason string) (*domain.Order, error)
+	// PayOrder charges the order total to the payment method identified by
+	// paymentToken. A declined or failed charge moves the order to payment
+	// FAILED and it can be paid again.
+	PayOrder(ctx context.Context, id int64, paymentToken string) (*domain.Order, error)
+	// HandlePaymentEvent applies an asynchronous payment notification. An
+	// event that was already processed returns the order unchanged.
+	HandlePaymentEvent(ctx context.Context, event *domain.PaymentEvent) (*domain.Order, error)
+	CompleteOrder(ctx context.Context, id int64) (*domain.Order, error)
+	ShipOrder(ctx context.Context, id int64, carrier, trackingNumber string, shippedAt time.Time) (*domain.Shipment, error)
+	// DeliverShipment records the delivery of a shipment and completes its order
+	DeliverShipment(ctx context.Context, orderID, shipmentID int64, deliveredAt time.Time) (*domain.Order, error)
+	GetShipments(ctx context.Context, id int64) ([]*domain.Shipment, error)
+	GetOrderHistory(ctx context.Context, id int64) ([]*domain.StatusChange, error)
+	RefundOrder(ctx context.Context, id int64, amount valueobject.Money, reason string) (*domain.Refund, error)
+	GetRefunds(ctx context.Context, id int64) ([]*domain.Refund, error)
+	// ExpireOrders cancels up to limit unpaid PENDING orders older than ttl and
+	// returns the IDs that were expired.
+	ExpireOrders(ctx context.Context, ttl time.Duration, limit int) ([]int64, error)
+	// AllocateBackorders reserves stock for up to limit BACKORDERED orders,
+	// oldest first, and returns the IDs that moved back to PENDING.
+	AllocateBackorders(ctx context.Context, limit int) ([]int64, error)
+	// ReleasePreorders converts the pre-order allocations of up to limit
+	// PREORDER orders whose products have been released into stock
+	// reservations, oldest first, and returns the IDs that moved to PENDING.
+	ReleasePreorders(ctx context.Context, limit int) ([]int64, error)
+	// RecoverSagas finishes up to limit placement sagas abandoned for longer
+	// than staleAfter or with a compensation due, and returns the IDs of the
+	// sagas left with nothing to do.
+	RecoverSagas(ctx context.Context, staleAfter time.Duration, limit int) ([]int64, error)
 }
 
 type orderUsecase struct {
 	repo           domain.OrderRepository
+	sagas          domain.SagaRepository
+	tasks          *taskUsecase
 	productClient  domain.ProductClient
+	coupons        domain.CouponRepository
+	quotes         domain.QuoteRepository
+	taxes          domain.TaxCalculator
+	shipping       domain.ShippingCalculator
+	rules          domain.OrderRules
+	payments       domain.PaymentGateway
 	contextTimeout time.Duration
 }
 
-func NewOrderUsecase(repo domain.OrderRepository, pClient domain.ProductClient, timeout time.Duration) OrderUsecase {
+func NewOrderUsecase(repo domain.OrderRepository, sagas domain.SagaRepository, tasks domain.TaskRepository, pClient domain.ProductClient, coupons domain.CouponRepository, quotes domain.QuoteRepository, taxes domain.TaxCalculator, shipping domain.ShippingCalculator, rules domain.OrderRules, payments domain.PaymentGateway, timeout time.Duration) OrderUsecase {
 	return &orderUsecase{
 		repo:           repo,
+		sagas:          sagas,
+		tasks:          newTaskUsecase(tasks, pClient, payments, timeout),
 		productClient:  pClient,
+		coupons:        coupons,
+		quotes:         quotes,
+		taxes:          taxes,
+		shipping:       shipping,
+		rules:          rules,
+		payments:       payments,
 		contextTimeout: timeout,
 	}
 }
 
-func (u *orderUsecase) CreateOrder(ctx context.Context, userID, productID int64, qty int) (*domain.Order, error) {
+func (u *orderUsecase) CreateOrder(ctx context.Context, in CreateOrderInput) (*domain.Order, error) {
 	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
 	defer cancel()
 
-	// 1. Get Product Details (Snapshot)
-	product, err := u.productClient.GetProduct(ctx, productID)
-	if err != nil {
-		return nil, err
+	if in.IdempotencyKey != "" {
+		placed, err := u.repo.GetByIdempotencyKey(ctx, in.IdempotencyKey)
+		if err == nil {
+			return placed, nil
+		}
+		if !errors.Is(err, pkgerrors.ErrNotFound) {
+			return nil, err
+		}
 	}
 
-	// 2. Reserve Stock
-	err = u.productClient.ReserveStock(ctx, productID, qty)
+	// 1-2. Create Order Aggregate from a quote or the current catalog (Snapshot)
+	var order *domain.Order
+	var err error
+	if in.QuoteID != 0 {
+		order, err = u.orderFromQuote(ctx, in)
+	} else {
+		order, err = priceOrder(ctx, u.productClient, u.taxes, u.shipping, in.UserID, in.Items, in.Region)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	// 3. Create Order Aggregate
-	order, err := domain.NewOrder(userID, productID, product.Name, product.Price, qty)
-	if err != nil {
-		// Rollback: Release Stock
-		logger.FromContext(ctx).Warn("invalid order parameters, rolling back stock", zap.Int64("product_id", productID))
-		if rbErr := u.productClient.ReleaseStock(context.Background(), productID, qty); rbErr != nil {
-			logger.FromContext(ctx).Error("failed to rollback stock", zap.Error(rbErr))
+	if in.SubscriptionID != 0 {
+		order.SubscriptionID = &in.SubscriptionID
+	}
+	if in.IdempotencyKey != "" {
+		order.IdempotencyKey = &in.IdempotencyKey
+	}
+
+	// 3. Apply Coupon (usage limits are checked when the order is stored)
+	if in.CouponCode != "" {
+		coupon, err := u.coupons.GetByCode(ctx, strings.ToUpper(in.CouponCode))
+		if errors.Is(err, pkgerrors.ErrNotFound) {
+			return nil, domain.ErrCouponNotApplicable
 		}
+		if err != nil {
+			return nil, err
+		}
+		if err := order.ApplyCoupon(coupon, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	// 4. Screen the order against the fraud and limit rules
+	if err := u.rules.Check(ctx, order); err != nil {
+		logger.FromContext(ctx).Warn("order rejected", zap.Int64("user_id", order.UserID), zap.String("rule", domain.ViolatedRule(err)))
+		return nil, err
+	}
+
+	// 5. Reserve Stock (all-or-nothing across lines) under a placement saga,
+	// so reservations are released even if this process dies before the
+	// order is stored
+	saga := domain.NewPlacementSaga(time.Now())
+	if err := u.sagas.Create(ctx, saga); err != nil {
+		return nil, err
+	}
+	if err := u.reserve(ctx, order, saga); err != nil {
+		saga.Fail(time.Now())
+		u.saveSaga(ctx, saga)
 		return nil, err
 	}
 
+	order.SagaID = &saga.ID
 	if err := u.repo.Create(ctx, order); err != nil {
 		// Rollback: Release Stock
-		logger.FromContext(ctx).Warn("rolling back stock reservation due to order creation failure", zap.Int64("product_id", productID))
-		if rbErr := u.productClient.ReleaseStock(context.Background(), productID, qty); rbErr != nil {
-			logger.FromContext(ctx).Error("failed to rollback stock", zap.Error(rbErr))
+		saga.Fail(time.Now())
+		u.compensate(ctx, saga)
+		if errors.Is(err, domain.ErrCouponLimitReached) {
+			return nil, err
 		}
 		return nil, pkgerrors.ErrInternal
 	}
 
+	// Should this fail, RecoverSagas finds the order by its saga ID
+	saga.Complete(order.ID, time.Now())
+	u.saveSaga(ctx, saga)
 	return order, nil
 }
 
+func (u *orderUsecase) orderFromQuote(ctx context.Context, in CreateOrderInput) (*domain.Order, error) {
+	quote, err := u.quotes.GetByID(ctx, in.QuoteID)
+	if err != nil {
+		return nil, err
+	}
+	if len(in.Items) > 0 || (in.Region != "" && in.Region != quote.Region) {
+		return nil, fmt.Errorf("items and region of a quoted order come from quote %d: %w", quote.ID, pkgerrors.ErrInvalidInput)
+	}
+	return quote.PlaceOrder(in.UserID, time.Now())
+}
+
+// priceOrder builds a pending order at current catalog prices, taxed and
+// shipped to region
+func priceOrder(ctx context.Context, products domain.ProductClient, taxes domain.TaxCalculator, shipping domain.ShippingCalculator,
+	userID int64, inputs []OrderItemInput, region string) (*domain.Order, error) {
+	items := make([]domain.OrderItem, 0, len(inputs))
+	for _, in := range inputs {
+		product, err := products.GetProduct(ctx, in.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		item, err := domain.NewOrderItem(in.ProductID, product.Name, product.Price, in.Quantity)
+		if err != nil {
+			return nil, err
+		}
+		item.TaxCategory = product.TaxCategory
+		item.WeightGrams = product.WeightGrams
+		item.ReleaseAt = product.ReleaseAt
+		item.Backorderable = product.Backorderable
+		items = append(items, item)
+	}
+
+	order, err := domain.NewOrder(userID, items, region, taxes)
+	if err != nil {
+		return nil, err
+	}
+	if err := order.SetShipping(shipping); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// backorderable reports whether the product accepts orders while out of
+// stock. A product that cannot be looked up is treated as not backorderable.
+func (u *orderUsecase) backorderable(ctx context.Context, productID int64) bool {
+	product, err := u.productClient.GetProduct(ctx, productID)
+	if err != nil {
+		logger.FromContext(ctx).Warn("could not check whether product is backorderable", zap.Int64("product_id", productID), zap.Error(err))
+		return false
+	}
+	return product.Backorderable
+}
+
+// queuedBehindBackorders reports whether the order has a backorderable line
+// whose product earlier backorders are still waiting for. Such an order is
+// backordered too rather than take restocked units ahead of them. Should
+// the check fail, the order reserves as usual.
+func (u *orderUsecase) queuedBehindBackorders(ctx context.Context, order *domain.Order) bool {
+	for _, item := range order.Items {
+		if !item.Backorderable {
+			continue
+		}
+		waiting, err := u.repo.HasBackorders(ctx, item.ProductID)
+		if err != nil {
+			logger.FromContext(ctx).Warn("could not check for waiting backorders", zap.Int64("product_id", item.ProductID), zap.Error(err))
+			continue
+		}
+		if waiting {
+			return true
+		}
+	}
+	return false
+}
+
 func (u *orderUsecase) GetOrder(ctx context.Context, id int64) (*domain.Order, error) {
 	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
 	defer cancel()
@@ -83,20 +281,564 @@ func (u *orderUsecase) GetAllOrders(ctx context.Context) ([]*domain.Order, error
 	return u.repo.GetAll(ctx)
 }
 
-func (u *orderUsecase) CancelOrder(ctx context.Context, id int64) error {
+func (u *orderUsecase) AmendOrder(ctx context.Context, id int64, inputs []OrderItemInput) (*domain.Order, error) {
 	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
 	defer cancel()
 
 	order, err := u.repo.GetByID(ctx, id)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	quantities := make(map[int64]int, len(inputs))
+	for _, in := range inputs {
+		if _, dup := quantities[in.ProductID]; dup {
+			return nil, pkgerrors.ErrInvalidInput
+		}
+		quantities[in.ProductID] = in.Quantity
+	}
+
+	from := order.State()
+	changes, err := order.AmendQuantities(quantities)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.rules.Check(ctx, order); err != nil {
+		return nil, err
+	}
+
+	// Reserve increases up front; decreases are only released once the new
+	// quantities are stored, so stock is never promised twice. Each increase
+	// is reserved under a key of its own, whose release is written as a task
+	// before any call is made: storing the amendment settles the releases,
+	// and otherwise they give the units back even if this process dies
+	// first. They fall due a backoff away, long after the amendment has been
+	// stored or given up.
+	attempt := time.Now().UnixNano()
+	var increases []domain.QuantityChange
+	var releases []*domain.OrderTask
+	for _, c := range changes {
+		if c.Delta > 0 {
+			increases = append(increases, c)
+			releases = append(releases, domain.ReleaseReservationTask(order.ID, order.AmendmentKey(c.ProductID, attempt)))
+		}
+	}
+	if err := u.tasks.schedule(ctx, releases); err != nil {
+		return nil, err
+	}
+	for i, c := range increases {
+		if err := u.productClient.ReserveStock(ctx, releases[i].OperationKey, c.ProductID, c.Delta); err != nil {
+			u.tasks.runCommitted(ctx, releases)
+			return nil, err
+		}
+	}
+
+	var decreases []domain.StockLine
+	for _, c := range changes {
+		if c.Delta < 0 {
+			decreases = append(decreases, domain.StockLine{ProductID: c.ProductID, Quantity: -c.Delta})
+		}
+	}
+
+	// UpdateItems refuses the amendment with ErrConflict if another one was
+	// stored since the order was read, so decreases are released only once
+	change := domain.NewStatusChange(order, from, "quantities amended", domain.ActorFromContext(ctx))
+	change.Tasks = domain.StockLineTasks(order.ID, domain.TaskReleaseStock, decreases)
+	for _, t := range releases {
+		change.Settles = append(change.Settles, t.ID)
+	}
+	if err := u.repo.UpdateItems(ctx, order, change); err != nil {
+		// A conflict was refused before anything was stored, so the
+		// increases are given back now. After any other error the amendment
+		// may have been stored with its releases settled, so they are left
+		// to the task runner, which only runs them if it was not.
+		if errors.Is(err, pkgerrors.ErrConflict) {
+			u.tasks.runCommitted(ctx, releases)
+		}
+		return nil, err
+	}
+	u.tasks.runCommitted(ctx, change.Tasks)
+	return order, nil
+}
+
+func (u *orderUsecase) CancelOrder(ctx context.Context, id int64, reason string) (*domain.Order, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	order, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := order.Cancel(); err != nil {
+	// Cancelling twice is a no-op so clients can safely retry
+	if order.OrderStatus == domain.OrderCancelled {
+		return order, nil
+	}
+
+	if reason == "" {
+		reason = "order cancelled"
+	}
+	if err := u.cancel(ctx, order, reason); err != nil {
+		// A concurrent cancellation that got there first counts as ours
+		if errors.Is(err, pkgerrors.ErrConflict) {
+			if current, gerr := u.repo.GetByID(ctx, id); gerr == nil && current.OrderStatus == domain.OrderCancelled {
+				return current, nil
+			}
+		}
+		return nil, err
+	}
+	return order, nil
+}
+
+// cancel applies the cancellation and persists it, together with the refund
+// owed if the order was already paid, before giving back what the order
+// holds and paying out the refund. The status guard lets only one
+// cancellation through, and the releases and the refund are written as tasks
+// alongside it, so each happens exactly once even when cancellations race or
+// the service dies half way.
+func (u *orderUsecase) cancel(ctx context.Context, order *domain.Order, reason string) error {
+	from := order.State()
+	holdsStock := order.HoldsStock()
+	allocationKey := order.AllocationKey()
+	refund, err := order.Cancel()
+	if err != nil {
 		return err
 	}
 
-	// In a real scenario, we might want to release stock here too.
-	// For this exercise, we'll just focus on the state transition.
+	// A backordered order has nothing reserved yet and a pre-order only
+	// holds pre-order allocations, but either may have had stock allocated
+	// that was not recorded. That allocation is reversed by its key, which
+	// also voids the key should the allocation still be in flight.
+	change := domain.NewStatusChange(order, from, reason, domain.ActorFromContext(ctx))
+	if holdsStock {
+		change.Tasks = domain.StockLineTasks(order.ID, domain.TaskReleaseStock, order.StockLines())
+	} else {
+		change.Tasks = append(change.Tasks, domain.ReleaseReservationTask(order.ID, allocationKey))
+	}
+	if from.Order == domain.OrderPreorder {
+		// Released after the conversion is reversed, once its units are
+		// back on the cap
+		change.Tasks = append(change.Tasks, domain.NewOrderTask(order.ID, domain.TaskReleasePreorder, order.StockLines()))
+	}
+	change.Tasks = append(change.Tasks, domain.RefundTasks(order, refund)...)
+
+	if refund != nil {
+		err = u.repo.SaveRefund(ctx, refund, change)
+	} else {
+		err = u.repo.UpdateStatus(ctx, change)
+	}
+	if err != nil {
+		return err
+	}
+	u.tasks.runCommitted(ctx, change.Tasks)
 	return nil
 }
+
+func (u *orderUsecase) PayOrder(ctx context.Context, id int64, paymentToken string) (*domain.Order, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	order, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !order.CanApply(domain.ActionPay) {
+		return nil, fmt.Errorf("cannot pay order in state %s/%s: %w", order.OrderStatus, order.PaymentStatus, domain.ErrInvalidTransition)
+	}
+
+	reference, err := u.charge(ctx, order, paymentToken)
+	if err != nil {
+		// A timed out attempt leaves the order pending: the charge may still
+		// land, and the provider reports its outcome through a payment event
+		if errors.Is(err, domain.ErrPaymentDeclined) {
+			u.failPayment(ctx, order, err)
+		}
+		return nil, err
+	}
+
+	from := order.State()
+	if err := order.RecordPayment(reference); err != nil {
+		return nil, err
+	}
+	invoice, err := domain.NewInvoice(order, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := u.repo.RecordPayment(ctx, order, domain.NewStatusChange(order, from, "order paid", domain.ActorFromContext(ctx)), invoice); err != nil {
+		return u.unrecordedPayment(ctx, id, reference, order.TotalPrice, err)
+	}
+	return order, nil
+}
+
+// unrecordedPayment settles a captured payment that RecordPayment failed to
+// store. The commit may have landed all the same, so the order is read again
+// first: if it shows the payment, the payment stands. Otherwise the money is
+// given back so the customer can simply pay again. If the order cannot be
+// read, nothing is refunded, since that could refund a paid order.
+func (u *orderUsecase) unrecordedPayment(ctx context.Context, id int64, reference string, amount valueobject.Money, cause error) (*domain.Order, error) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), u.contextTimeout)
+	defer cancel()
+	current, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		logger.FromContext(ctx).Error("payment captured but its recording could not be checked",
+			zap.Int64("order_id", id), zap.String("payment_reference", reference), zap.Error(err))
+		return nil, cause
+	}
+	if current.PaymentReference == reference {
+		return current, nil
+	}
+	if err := u.payments.Refund(ctx, "unrecorded-"+reference, reference, amount); err != nil {
+		logger.FromContext(ctx).Error("payment captured but neither recorded nor refunded",
+			zap.Int64("order_id", id), zap.String("payment_reference", reference), zap.Error(err))
+	}
+	return nil, cause
+}
+
+// charge authorizes and captures the order total, voiding the authorization
+// if the capture fails
+func (u *orderUsecase) charge(ctx context.Context, order *domain.Order, token string) (string, error) {
+	reference, err := u.payments.Authorize(ctx, order.ID, order.TotalPrice, token)
+	if err != nil {
+		return "", err
+	}
+	if err := u.payments.Capture(ctx, reference, order.TotalPrice); err != nil {
+		if verr := u.payments.Void(ctx, reference); verr != nil {
+			logger.FromContext(ctx).Error("failed to void authorization after failed capture",
+				zap.Int64("order_id", order.ID), zap.String("payment_reference", reference), zap.Error(verr))
+		}
+		return "", err
+	}
+	return reference, nil
+}
+
+// failPayment records a declined payment attempt; the customer may retry
+func (u *orderUsecase) failPayment(ctx context.Context, order *domain.Order, cause error) {
+	from := order.State()
+	if err := order.FailPayment(); err != nil {
+		return
+	}
+	change := domain.NewStatusChange(order, from, "payment failed: "+cause.Error(), domain.ActorFromContext(ctx))
+	if err := u.repo.UpdateStatus(ctx, change); err != nil {
+		logger.FromContext(ctx).Warn("failed to record failed payment", zap.Int64("order_id", order.ID), zap.Error(err))
+	}
+}
+
+func (u *orderUsecase) HandlePaymentEvent(ctx context.Context, event *domain.PaymentEvent) (*domain.Order, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	if err := event.Validate(); err != nil {
+		return nil, err
+	}
+	order, err := u.repo.GetByID(ctx, event.OrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	from := order.State()
+	changed, err := event.Apply(order)
+	if err != nil {
+		return nil, err
+	}
+	var change *domain.StatusChange
+	var invoice *domain.Invoice
+	if changed {
+		change = domain.NewStatusChange(order, from, event.Describe(), domain.PaymentProviderActor)
+		if event.Type == domain.PaymentEventSucceeded {
+			if invoice, err = domain.NewInvoice(order, time.Now()); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	err = u.repo.SavePaymentEvent(ctx, event, order, change, invoice)
+	if errors.Is(err, domain.ErrDuplicatePaymentEvent) {
+		logger.FromContext(ctx).Info("ignoring duplicate payment event", zap.String("event_id", event.ID))
+		return u.repo.GetByID(ctx, event.OrderID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func (u *orderUsecase) CompleteOrder(ctx context.Context, id int64) (*domain.Order, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	order, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.complete(ctx, order, "order completed"); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func (u *orderUsecase) complete(ctx context.Context, order *domain.Order, reason string) error {
+	from := order.State()
+	if err := order.Complete(); err != nil {
+		return err
+	}
+	return u.repo.UpdateStatus(ctx, domain.NewStatusChange(order, from, reason, domain.ActorFromContext(ctx)))
+}
+
+func (u *orderUsecase) ShipOrder(ctx context.Context, id int64, carrier, trackingNumber string, shippedAt time.Time) (*domain.Shipment, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	order, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if shippedAt.IsZero() {
+		shippedAt = time.Now()
+	}
+	from := order.State()
+	shipment, err := order.Ship(carrier, trackingNumber, shippedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	// Stock leaves the warehouse with the shipment, so the reservation taken
+	// at creation time becomes a permanent deduction. The shipment claims
+	// the order first; the confirmations are written as tasks alongside it,
+	// so a retried or concurrent shipment cannot confirm the stock twice.
+	change := domain.NewStatusChange(order, from, "shipped via "+carrier, domain.ActorFromContext(ctx))
+	change.Tasks = domain.StockLineTasks(order.ID, domain.TaskConfirmStock, order.StockLines())
+	if err := u.repo.CreateShipment(ctx, shipment, change); err != nil {
+		return nil, err
+	}
+	u.tasks.runCommitted(ctx, change.Tasks)
+	return shipment, nil
+}
+
+func (u *orderUsecase) DeliverShipment(ctx context.Context, orderID, shipmentID int64, deliveredAt time.Time) (*domain.Order, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	order, err := u.repo.GetByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	shipment, err := u.repo.GetShipment(ctx, shipmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if deliveredAt.IsZero() {
+		deliveredAt = time.Now()
+	}
+	from := order.State()
+	if err := order.Deliver(shipment, deliveredAt); err != nil {
+		return nil, err
+	}
+
+	change := domain.NewStatusChange(order, from, "delivered by "+shipment.Carrier, domain.ActorFromContext(ctx))
+	if err := u.repo.MarkDelivered(ctx, shipment, change); err != nil {
+		return nil, err
+	}
+
+	// The delivery is recorded even if completing fails; the order then stays
+	// DELIVERED and can be completed explicitly.
+	if err := u.complete(ctx, order, "completed on delivery"); err != nil {
+		logger.FromContext(ctx).Error("delivery recorded but order was not completed",
+			zap.Int64("order_id", order.ID), zap.Error(err))
+		return nil, err
+	}
+	return order, nil
+}
+
+func (u *orderUsecase) GetShipments(ctx context.Context, id int64) ([]*domain.Shipment, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	if _, err := u.repo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return u.repo.GetShipments(ctx, id)
+}
+
+func (u *orderUsecase) GetOrderHistory(ctx context.Context, id int64) ([]*domain.StatusChange, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	if _, err := u.repo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return u.repo.GetStatusHistory(ctx, id)
+}
+
+func (u *orderUsecase) RefundOrder(ctx context.Context, id int64, amount valueobject.Money, reason string) (*domain.Refund, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	order, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if reason == "" {
+		reason = "refund issued"
+	}
+	from := order.State()
+	refund, err := order.Refund(amount, reason)
+	if err != nil {
+		return nil, err
+	}
+	// The refund is recorded before the payment provider is asked for the
+	// money, so two refunds racing for the same remainder cannot both be
+	// paid out; the loser fails on the refunded amount guard
+	change := domain.NewStatusChange(order, from, reason, domain.ActorFromContext(ctx))
+	change.Tasks = domain.RefundTasks(order, refund)
+	if err := u.repo.SaveRefund(ctx, refund, change); err != nil {
+		return nil, err
+	}
+	u.tasks.runCommitted(ctx, change.Tasks)
+	return refund, nil
+}
+
+func (u *orderUsecase) GetRefunds(ctx context.Context, id int64) ([]*domain.Refund, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	if _, err := u.repo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return u.repo.GetRefunds(ctx, id)
+}
+
+func (u *orderUsecase) ExpireOrders(ctx context.Context, ttl time.Duration, limit int) ([]int64, error) {
+	findCtx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	ids, err := u.repo.FindExpiredPending(findCtx, time.Now().Add(-ttl), limit)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+
+	expired := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if err := u.expireOrder(ctx, id, ttl); err != nil {
+			logger.FromContext(ctx).Error("failed to expire order", zap.Int64("order_id", id), zap.Error(err))
+			continue
+		}
+		expired = append(expired, id)
+	}
+	return expired, nil
+}
+
+func (u *orderUsecase) expireOrder(ctx context.Context, id int64, ttl time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	order, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// The order may have been paid or cancelled since it was selected
+	if order.State() != (domain.OrderState{Order: domain.OrderPending, Payment: domain.PaymentPending}) {
+		return domain.ErrInvalidTransition
+	}
+	// A payment landing after this check makes the guarded cancellation fail
+	// with ErrConflict, and cancel releases nothing until it has won
+	return u.cancel(domain.WithActor(ctx, domain.SystemActor), order, "expired: unpaid after "+ttl.String())
+}
+
+func (u *orderUsecase) AllocateBackorders(ctx context.Context, limit int) ([]int64, error) {
+	findCtx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	ids, err := u.repo.FindBackordered(findCtx, limit)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+	return u.allocateInTurn(ctx, ids, domain.OrderBackordered, u.productClient.AllocateStock, "stock allocated"), nil
+}
+
+func (u *orderUsecase) ReleasePreorders(ctx context.Context, limit int) ([]int64, error) {
+	findCtx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	ids, err := u.repo.FindReleasedPreorders(findCtx, time.Now(), limit)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+	return u.allocateInTurn(ctx, ids, domain.OrderPreorder, u.productClient.ConvertPreorder, "pre-order released"), nil
+}
+
+// allocateInTurn reserves stock with reserve for each order in ids that is
+// still in status and moves it to PENDING, returning the IDs that moved.
+// Orders are served first come, first served: once an order cannot be
+// filled, younger orders for any of its products wait behind it so they
+// cannot take the stock it is waiting for.
+func (u *orderUsecase) allocateInTurn(ctx context.Context, ids []int64, status domain.OrderStatus,
+	reserve func(context.Context, string, []domain.StockLine) error, reason string) []int64 {
+	waiting := make(map[int64]bool)
+	allocated := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		ok, err := u.allocate(ctx, id, status, reserve, reason, waiting)
+		if err != nil {
+			logger.FromContext(ctx).Error("failed to allocate order", zap.Int64("order_id", id), zap.String("status", string(status)), zap.Error(err))
+			continue
+		}
+		if ok {
+			allocated = append(allocated, id)
+		}
+	}
+	return allocated
+}
+
+// allocate reserves every line of a waiting order in one call and moves it
+// to PENDING. It reports false when the order has to keep waiting, adding
+// its products to waiting.
+func (u *orderUsecase) allocate(ctx context.Context, id int64, status domain.OrderStatus,
+	reserve func(context.Context, string, []domain.StockLine) error, reason string, waiting map[int64]bool) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	order, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		return false, err
+	}
+
+	// The order may have been cancelled since it was selected
+	if order.OrderStatus != status {
+		return false, nil
+	}
+
+	key := order.AllocationKey()
+	from := order.State()
+	if err := order.Allocate(time.Now()); err != nil {
+		return false, err
+	}
+
+	lines := order.StockLines()
+	blocked := false
+	for _, line := range lines {
+		blocked = blocked || waiting[line.ProductID]
+	}
+	if !blocked {
+		err = reserve(ctx, key, lines)
+		if err != nil && !errors.Is(err, pkgerrors.ErrInsufficientStock) {
+			return false, err
+		}
+		blocked = err != nil
+	}
+	if blocked {
+		for _, line := range lines {
+			waiting[line.ProductID] = true
+		}
+		return false, nil
+	}
+
+	change := domain.NewStatusChange(order, from, reason, domain.SystemActor)
+	if err := u.repo.MarkAllocated(ctx, order, change); err != nil {
+		// The allocation is left in place: the next run repeats it under the
+		// same key, which is applied only once, and cancelling the order
+		// reverses it
+		return false, err
+	}
+	return true, nil
+}
@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -9,10 +10,83 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/user/go-microservices/order-service/internal/domain"
 	"github.com/user/go-microservices/order-service/internal/domain/mocks"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
 	"github.com/user/go-microservices/pkg/logger"
 	"github.com/user/go-microservices/pkg/valueobject"
 )
 
+// newTestOrder builds a pending order for product 1 with quantity 2
+func newTestOrder(t *testing.T) *domain.Order {
+	item, err := domain.NewOrderItem(1, "Test Product", valueobject.NewMoney(100.0), 2)
+	assert.NoError(t, err)
+	order, err := domain.NewOrder(101, []domain.OrderItem{item}, "", &domain.TaxTable{})
+	assert.NoError(t, err)
+	return order
+}
+
+// statusChange matches a transition of order 1 from PENDING with the given payment status
+func statusChange(fromPayment domain.PaymentStatus, to domain.OrderStatus, toPayment domain.PaymentStatus) interface{} {
+	return mock.MatchedBy(func(c *domain.StatusChange) bool {
+		return c.OrderID == 1 &&
+			c.FromOrderStatus == domain.OrderPending && c.FromPaymentStatus == fromPayment &&
+			c.ToOrderStatus == to && c.ToPaymentStatus == toPayment
+	})
+}
+
+// memorySagas keeps placement sagas in memory so tests can check what a
+// placement reserved and released
+type memorySagas struct {
+	domain.SagaRepository
+	saved map[int64]domain.PlacementSaga
+}
+
+func newMemorySagas() *memorySagas {
+	return &memorySagas{saved: make(map[int64]domain.PlacementSaga)}
+}
+
+func (m *memorySagas) Create(ctx context.Context, s *domain.PlacementSaga) error {
+	s.ID = int64(len(m.saved) + 1)
+	return m.Save(ctx, s)
+}
+
+func (m *memorySagas) Save(_ context.Context, s *domain.PlacementSaga) error {
+	saved := *s
+	saved.Steps = append([]domain.SagaStep(nil), s.Steps...)
+	m.saved[s.ID] = saved
+	return nil
+}
+
+// memoryTasks keeps order tasks in memory so tests can check which side
+// effects a change left done or pending
+type memoryTasks struct {
+	domain.TaskRepository
+	scheduled []*domain.OrderTask
+	done      map[int64]bool
+	attempts  map[int64]int
+}
+
+func newMemoryTasks() *memoryTasks {
+	return &memoryTasks{done: make(map[int64]bool), attempts: make(map[int64]int)}
+}
+
+func (m *memoryTasks) Schedule(_ context.Context, tasks []*domain.OrderTask) error {
+	for _, t := range tasks {
+		t.ID = int64(100 + len(m.scheduled))
+		m.scheduled = append(m.scheduled, t)
+	}
+	return nil
+}
+
+func (m *memoryTasks) MarkDone(_ context.Context, id int64, _ time.Time) error {
+	m.done[id] = true
+	return nil
+}
+
+func (m *memoryTasks) SaveAttempt(_ context.Context, t *domain.OrderTask) error {
+	m.attempts[t.ID] = t.Attempts
+	return nil
+}
+
 func TestOrderUsecase_CreateOrder(t *testing.T) {
 	logger.Init()
 	timeout := 5 * time.Second
@@ -20,7 +94,7 @@ func TestOrderUsecase_CreateOrder(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		mockRepo := mocks.NewOrderRepository(t)
 		mockProductClient := mocks.NewProductClient(t)
-		uc := NewOrderUsecase(mockRepo, mockProductClient, timeout)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
 
 		product := &domain.ProductView{
 			ID:    1,
@@ -29,10 +103,10 @@ func TestOrderUsecase_CreateOrder(t *testing.T) {
 		}
 
 		mockProductClient.On("GetProduct", mock.Anything, int64(1)).Return(product, nil)
-		mockProductClient.On("ReserveStock", mock.Anything, int64(1), 2).Return(nil)
+		mockProductClient.On("ReserveStock", mock.Anything, mock.Anything, int64(1), 2).Return(nil)
 		mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Order")).Return(nil)
 
-		order, err := uc.CreateOrder(context.Background(), 101, 1, 2)
+		order, err := uc.CreateOrder(context.Background(), CreateOrderInput{UserID: 101, Items: []OrderItemInput{{ProductID: 1, Quantity: 2}}})
 
 		assert.NoError(t, err)
 		assert.NotNil(t, order)
@@ -40,14 +114,50 @@ func TestOrderUsecase_CreateOrder(t *testing.T) {
 		assert.Equal(t, valueobject.NewMoney(200.0), order.TotalPrice)
 	})
 
+	t.Run("IdempotencyKey_RecordedWithOrder", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		mockRepo.On("GetByIdempotencyKey", mock.Anything, "req-1").Return(nil, pkgerrors.ErrNotFound)
+		mockProductClient.On("GetProduct", mock.Anything, int64(1)).Return(&domain.ProductView{ID: 1, Name: "A", Price: valueobject.NewMoney(10)}, nil)
+		mockProductClient.On("ReserveStock", mock.Anything, mock.Anything, int64(1), 2).Return(nil)
+		mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(o *domain.Order) bool {
+			return o.IdempotencyKey != nil && *o.IdempotencyKey == "req-1"
+		})).Return(nil)
+
+		order, err := uc.CreateOrder(context.Background(), CreateOrderInput{UserID: 101, Items: []OrderItemInput{{ProductID: 1, Quantity: 2}}, IdempotencyKey: "req-1"})
+
+		assert.NoError(t, err)
+		assert.NotNil(t, order)
+	})
+
+	t.Run("IdempotencyKey_RetryGetsPlacedOrder", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		// The first attempt stored its order but its response was lost
+		placed := newTestOrder(t)
+		placed.ID = 42
+		mockRepo.On("GetByIdempotencyKey", mock.Anything, "req-1").Return(placed, nil)
+
+		order, err := uc.CreateOrder(context.Background(), CreateOrderInput{UserID: 101, Items: []OrderItemInput{{ProductID: 1, Quantity: 2}}, IdempotencyKey: "req-1"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, placed, order)
+		mockProductClient.AssertNotCalled(t, "ReserveStock", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
 	t.Run("ProductNotFound", func(t *testing.T) {
 		mockRepo := mocks.NewOrderRepository(t)
 		mockProductClient := mocks.NewProductClient(t)
-		uc := NewOrderUsecase(mockRepo, mockProductClient, timeout)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
 
 		mockProductClient.On("GetProduct", mock.Anything, int64(2)).Return(nil, assert.AnError)
 
-		order, err := uc.CreateOrder(context.Background(), 101, 2, 1)
+		order, err := uc.CreateOrder(context.Background(), CreateOrderInput{UserID: 101, Items: []OrderItemInput{{ProductID: 2, Quantity: 1}}})
 
 		assert.Error(t, err)
 		assert.Nil(t, order)
@@ -56,7 +166,7 @@ func TestOrderUsecase_CreateOrder(t *testing.T) {
 	t.Run("InsufficientStock", func(t *testing.T) {
 		mockRepo := mocks.NewOrderRepository(t)
 		mockProductClient := mocks.NewProductClient(t)
-		uc := NewOrderUsecase(mockRepo, mockProductClient, timeout)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
 
 		product := &domain.ProductView{
 			ID:    1,
@@ -64,9 +174,9 @@ func TestOrderUsecase_CreateOrder(t *testing.T) {
 			Price: valueobject.NewMoney(100.0),
 		}
 		mockProductClient.On("GetProduct", mock.Anything, int64(1)).Return(product, nil)
-		mockProductClient.On("ReserveStock", mock.Anything, int64(1), 10).Return(assert.AnError)
+		mockProductClient.On("ReserveStock", mock.Anything, mock.Anything, int64(1), 10).Return(pkgerrors.ErrInsufficientStock)
 
-		order, err := uc.CreateOrder(context.Background(), 101, 1, 10)
+		order, err := uc.CreateOrder(context.Background(), CreateOrderInput{UserID: 101, Items: []OrderItemInput{{ProductID: 1, Quantity: 10}}})
 
 		assert.Error(t, err)
 		assert.Nil(t, order)
@@ -75,7 +185,7 @@ func TestOrderUsecase_CreateOrder(t *testing.T) {
 	t.Run("RepoFailure_WithRollback", func(t *testing.T) {
 		mockRepo := mocks.NewOrderRepository(t)
 		mockProductClient := mocks.NewProductClient(t)
-		uc := NewOrderUsecase(mockRepo, mockProductClient, timeout)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
 
 		product := &domain.ProductView{
 			ID:    1,
@@ -83,13 +193,1391 @@ func TestOrderUsecase_CreateOrder(t *testing.T) {
 			Price: valueobject.NewMoney(100.0),
 		}
 		mockProductClient.On("GetProduct", mock.Anything, int64(1)).Return(product, nil)
-		mockProductClient.On("ReserveStock", mock.Anything, int64(1), 1).Return(nil)
+		mockProductClient.On("ReserveStock", mock.Anything, mock.Anything, int64(1), 1).Return(nil)
+		mockRepo.On("Create", mock.Anything, mock.Anything).Return(assert.AnError)
+		mockProductClient.On("ReleaseReservation", mock.Anything, "saga-1-1").Return(nil)
+
+		order, err := uc.CreateOrder(context.Background(), CreateOrderInput{UserID: 101, Items: []OrderItemInput{{ProductID: 1, Quantity: 1}}})
+
+		assert.Error(t, err)
+		assert.Nil(t, order)
+	})
+
+	t.Run("MultiLine_PartialReservationRolledBack", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		mockProductClient.On("GetProduct", mock.Anything, int64(1)).Return(&domain.ProductView{ID: 1, Name: "A", Price: valueobject.NewMoney(10)}, nil)
+		mockProductClient.On("GetProduct", mock.Anything, int64(2)).Return(&domain.ProductView{ID: 2, Name: "B", Price: valueobject.NewMoney(20)}, nil)
+		mockProductClient.On("ReserveStock", mock.Anything, mock.Anything, int64(1), 1).Return(nil)
+		mockProductClient.On("ReserveStock", mock.Anything, mock.Anything, int64(2), 3).Return(pkgerrors.ErrInsufficientStock)
+		mockProductClient.On("ReleaseReservation", mock.Anything, "saga-1-1").Return(nil)
+
+		order, err := uc.CreateOrder(context.Background(), CreateOrderInput{UserID: 101, Items: []OrderItemInput{
+			{ProductID: 1, Quantity: 1},
+			{ProductID: 2, Quantity: 3},
+		}})
+
+		assert.ErrorIs(t, err, pkgerrors.ErrInsufficientStock)
+		assert.Nil(t, order)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("BackorderableProductShort_Backorders", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		mockProductClient.On("GetProduct", mock.Anything, int64(1)).Return(&domain.ProductView{ID: 1, Name: "A", Price: valueobject.NewMoney(10)}, nil)
+		mockProductClient.On("GetProduct", mock.Anything, int64(2)).Return(&domain.ProductView{ID: 2, Name: "B", Price: valueobject.NewMoney(20), Backorderable: true}, nil)
+		mockRepo.On("HasBackorders", mock.Anything, int64(2)).Return(false, nil)
+		mockProductClient.On("ReserveStock", mock.Anything, mock.Anything, int64(1), 1).Return(nil)
+		mockProductClient.On("ReserveStock", mock.Anything, mock.Anything, int64(2), 3).Return(pkgerrors.ErrInsufficientStock)
+		mockProductClient.On("ReleaseReservation", mock.Anything, "saga-1-1").Return(nil)
+		mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(o *domain.Order) bool {
+			return o.OrderStatus == domain.OrderBackordered
+		})).Return(nil)
+
+		order, err := uc.CreateOrder(context.Background(), CreateOrderInput{UserID: 101, Items: []OrderItemInput{
+			{ProductID: 1, Quantity: 1},
+			{ProductID: 2, Quantity: 3},
+		}})
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.OrderBackordered, order.OrderStatus)
+		assert.False(t, order.HoldsStock())
+	})
+
+	t.Run("EarlierBackordersWaiting_QueuesBehindThem", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		mockProductClient.On("GetProduct", mock.Anything, int64(1)).Return(&domain.ProductView{ID: 1, Name: "A", Price: valueobject.NewMoney(10)}, nil)
+		mockProductClient.On("GetProduct", mock.Anything, int64(2)).Return(&domain.ProductView{ID: 2, Name: "B", Price: valueobject.NewMoney(20), Backorderable: true}, nil)
+		// Stock has come back but the allocator has not served the queue yet
+		mockRepo.On("HasBackorders", mock.Anything, int64(2)).Return(true, nil)
+		mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(o *domain.Order) bool {
+			return o.OrderStatus == domain.OrderBackordered
+		})).Return(nil)
+
+		order, err := uc.CreateOrder(context.Background(), CreateOrderInput{UserID: 101, Items: []OrderItemInput{
+			{ProductID: 1, Quantity: 1},
+			{ProductID: 2, Quantity: 3},
+		}})
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.OrderBackordered, order.OrderStatus)
+		mockProductClient.AssertNotCalled(t, "ReserveStock", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("UnreleasedProduct_Preorders", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		release := time.Now().Add(72 * time.Hour)
+		mockProductClient.On("GetProduct", mock.Anything, int64(1)).Return(&domain.ProductView{ID: 1, Name: "A", Price: valueobject.NewMoney(10), ReleaseAt: &release}, nil)
+		mockProductClient.On("ReservePreorder", mock.Anything, mock.Anything, []domain.StockLine{{ProductID: 1, Quantity: 2}}).Return(nil)
+		mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(o *domain.Order) bool {
+			return o.OrderStatus == domain.OrderPreorder
+		})).Return(nil)
+
+		order, err := uc.CreateOrder(context.Background(), CreateOrderInput{UserID: 101, Items: []OrderItemInput{{ProductID: 1, Quantity: 2}}})
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.OrderPreorder, order.OrderStatus)
+		assert.Equal(t, &release, order.ReleaseAt)
+		mockProductClient.AssertNotCalled(t, "ReserveStock", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Preorder_RepoFailureReleasesAllocation", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		release := time.Now().Add(72 * time.Hour)
+		lines := []domain.StockLine{{ProductID: 1, Quantity: 2}}
+		mockProductClient.On("GetProduct", mock.Anything, int64(1)).Return(&domain.ProductView{ID: 1, Name: "A", Price: valueobject.NewMoney(10), ReleaseAt: &release}, nil)
+		mockProductClient.On("ReservePreorder", mock.Anything, mock.Anything, lines).Return(nil)
 		mockRepo.On("Create", mock.Anything, mock.Anything).Return(assert.AnError)
-		mockProductClient.On("ReleaseStock", mock.Anything, int64(1), 1).Return(nil)
+		mockProductClient.On("ReleaseReservation", mock.Anything, "saga-1-1").Return(nil)
 
-		order, err := uc.CreateOrder(context.Background(), 101, 1, 1)
+		order, err := uc.CreateOrder(context.Background(), CreateOrderInput{UserID: 101, Items: []OrderItemInput{{ProductID: 1, Quantity: 2}}})
 
 		assert.Error(t, err)
 		assert.Nil(t, order)
 	})
+
+	t.Run("ReleasedAndUnreleased_Rejected", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		release := time.Now().Add(72 * time.Hour)
+		mockProductClient.On("GetProduct", mock.Anything, int64(1)).Return(&domain.ProductView{ID: 1, Name: "A", Price: valueobject.NewMoney(10)}, nil)
+		mockProductClient.On("GetProduct", mock.Anything, int64(2)).Return(&domain.ProductView{ID: 2, Name: "B", Price: valueobject.NewMoney(20), ReleaseAt: &release}, nil)
+
+		order, err := uc.CreateOrder(context.Background(), CreateOrderInput{UserID: 101, Items: []OrderItemInput{
+			{ProductID: 1, Quantity: 1},
+			{ProductID: 2, Quantity: 1},
+		}})
+
+		assert.ErrorIs(t, err, domain.ErrMixedPreorder)
+		assert.Nil(t, order)
+	})
+
+	t.Run("MultiLine_Success", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		mockProductClient.On("GetProduct", mock.Anything, int64(1)).Return(&domain.ProductView{ID: 1, Name: "A", Price: valueobject.NewMoney(10)}, nil)
+		mockProductClient.On("GetProduct", mock.Anything, int64(2)).Return(&domain.ProductView{ID: 2, Name: "B", Price: valueobject.NewMoney(20)}, nil)
+		mockProductClient.On("ReserveStock", mock.Anything, mock.Anything, int64(1), 1).Return(nil)
+		mockProductClient.On("ReserveStock", mock.Anything, mock.Anything, int64(2), 3).Return(nil)
+		mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Order")).Return(nil)
+
+		order, err := uc.CreateOrder(context.Background(), CreateOrderInput{UserID: 101, Items: []OrderItemInput{
+			{ProductID: 1, Quantity: 1},
+			{ProductID: 2, Quantity: 3},
+		}})
+
+		assert.NoError(t, err)
+		assert.Len(t, order.Items, 2)
+		assert.Equal(t, valueobject.NewMoney(70), order.TotalPrice)
+	})
+
+	t.Run("Taxed_ByProductCategory", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		mockTaxes := mocks.NewTaxCalculator(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, mockTaxes, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		mockProductClient.On("GetProduct", mock.Anything, int64(1)).
+			Return(&domain.ProductView{ID: 1, Name: "A", Price: valueobject.NewMoney(100), TaxCategory: "STANDARD"}, nil)
+		mockTaxes.On("Rate", "US-CA", "STANDARD").Return(7.25, nil)
+		mockProductClient.On("ReserveStock", mock.Anything, mock.Anything, int64(1), 2).Return(nil)
+		mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Order")).Return(nil)
+
+		order, err := uc.CreateOrder(context.Background(), CreateOrderInput{UserID: 101, Items: []OrderItemInput{{ProductID: 1, Quantity: 2}}, Region: "US-CA"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "US-CA", order.TaxRegion)
+		assert.Equal(t, valueobject.NewMoney(200), order.Subtotal)
+		assert.Equal(t, valueobject.NewMoney(14.5), order.Tax)
+		assert.Equal(t, valueobject.NewMoney(214.5), order.TotalPrice)
+	})
+
+	t.Run("Shipping_ByWeight", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		mockShipping := mocks.NewShippingCalculator(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, mockShipping, nil, nil, timeout)
+
+		mockProductClient.On("GetProduct", mock.Anything, int64(1)).
+			Return(&domain.ProductView{ID: 1, Name: "A", Price: valueobject.NewMoney(10), WeightGrams: 1500}, nil)
+		mockShipping.On("RateFor", "US-CA").
+			Return(&domain.ShippingRate{Zone: "US", BaseFee: valueobject.NewMoney(5), PerKgFee: valueobject.NewMoney(2)}, nil)
+		mockProductClient.On("ReserveStock", mock.Anything, mock.Anything, int64(1), 2).Return(nil)
+		mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Order")).Return(nil)
+
+		order, err := uc.CreateOrder(context.Background(), CreateOrderInput{UserID: 101, Items: []OrderItemInput{{ProductID: 1, Quantity: 2}}, Region: "US-CA"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1500, order.Items[0].WeightGrams)
+		assert.Equal(t, valueobject.NewMoney(11), order.Shipping)
+		assert.Equal(t, valueobject.NewMoney(31), order.TotalPrice)
+	})
+
+	t.Run("Coupon_AppliesDiscount", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		mockCoupons := mocks.NewCouponRepository(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, mockCoupons, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		mockProductClient.On("GetProduct", mock.Anything, int64(1)).Return(&domain.ProductView{ID: 1, Name: "A", Price: valueobject.NewMoney(100)}, nil)
+		mockCoupons.On("GetByCode", mock.Anything, "SPRING10").Return(&domain.Coupon{
+			ID: 7, Code: "SPRING10", Active: true,
+			DiscountTerms: domain.DiscountTerms{Type: domain.DiscountPercentage, Percentage: 10},
+		}, nil)
+		mockProductClient.On("ReserveStock", mock.Anything, mock.Anything, int64(1), 2).Return(nil)
+		mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(o *domain.Order) bool {
+			return o.Discount != nil && o.Discount.CouponID == 7
+		})).Return(nil)
+
+		order, err := uc.CreateOrder(context.Background(), CreateOrderInput{UserID: 101, Items: []OrderItemInput{{ProductID: 1, Quantity: 2}}, CouponCode: "spring10"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, valueobject.NewMoney(20), order.Discount.Amount)
+		assert.Equal(t, valueobject.NewMoney(180), order.TotalPrice)
+	})
+
+	t.Run("Coupon_Unknown", func(t *testing.T) {
+		mockProductClient := mocks.NewProductClient(t)
+		mockCoupons := mocks.NewCouponRepository(t)
+		uc := NewOrderUsecase(mocks.NewOrderRepository(t), newMemorySagas(), newMemoryTasks(), mockProductClient, mockCoupons, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		mockProductClient.On("GetProduct", mock.Anything, int64(1)).Return(&domain.ProductView{ID: 1, Name: "A", Price: valueobject.NewMoney(100)}, nil)
+		mockCoupons.On("GetByCode", mock.Anything, "NOPE").Return(nil, pkgerrors.ErrNotFound)
+
+		order, err := uc.CreateOrder(context.Background(), CreateOrderInput{UserID: 101, Items: []OrderItemInput{{ProductID: 1, Quantity: 1}}, CouponCode: "nope"})
+
+		assert.ErrorIs(t, err, domain.ErrCouponNotApplicable)
+		assert.Nil(t, order)
+	})
+
+	t.Run("Coupon_LimitReached_ReleasesStock", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		mockCoupons := mocks.NewCouponRepository(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, mockCoupons, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		mockProductClient.On("GetProduct", mock.Anything, int64(1)).Return(&domain.ProductView{ID: 1, Name: "A", Price: valueobject.NewMoney(100)}, nil)
+		mockCoupons.On("GetByCode", mock.Anything, "ONCE").Return(&domain.Coupon{
+			ID: 8, Code: "ONCE", Active: true, MaxUses: 1,
+			DiscountTerms: domain.DiscountTerms{Type: domain.DiscountFixed, AmountOff: valueobject.NewMoney(5)},
+		}, nil)
+		mockProductClient.On("ReserveStock", mock.Anything, mock.Anything, int64(1), 1).Return(nil)
+		mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Order")).Return(domain.ErrCouponLimitReached)
+		mockProductClient.On("ReleaseReservation", mock.Anything, "saga-1-1").Return(nil)
+
+		order, err := uc.CreateOrder(context.Background(), CreateOrderInput{UserID: 101, Items: []OrderItemInput{{ProductID: 1, Quantity: 1}}, CouponCode: "ONCE"})
+
+		assert.ErrorIs(t, err, domain.ErrCouponLimitReached)
+		assert.Nil(t, order)
+	})
+
+	t.Run("RejectedByRule_BeforeReservation", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		rules := domain.OrderLimits{MaxOrdersPerHour: 2}.Rules(mockRepo)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, rules, nil, timeout)
+
+		mockProductClient.On("GetProduct", mock.Anything, int64(1)).Return(&domain.ProductView{ID: 1, Name: "A", Price: valueobject.NewMoney(10)}, nil)
+		mockRepo.On("CountByUserSince", mock.Anything, int64(101), mock.AnythingOfType("time.Time")).Return(2, nil)
+
+		order, err := uc.CreateOrder(context.Background(), CreateOrderInput{UserID: 101, Items: []OrderItemInput{{ProductID: 1, Quantity: 1}}})
+
+		assert.ErrorIs(t, err, domain.ErrOrderRejected)
+		assert.Equal(t, domain.RuleMaxOrdersPerHr, domain.ViolatedRule(err))
+		assert.Nil(t, order)
+		mockProductClient.AssertNotCalled(t, "ReserveStock", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("FromQuote_UsesLockedPrices", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		mockQuotes := mocks.NewQuoteRepository(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, mockQuotes, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		mockQuotes.On("GetByID", mock.Anything, int64(4)).Return(&domain.Quote{
+			ID: 4, UserID: 101, Region: "US-CA", ExpiresAt: time.Now().Add(time.Minute),
+			Items: []domain.OrderItem{{ProductID: 1, ProductName: "A", UnitPrice: valueobject.NewMoney(80), Quantity: 2,
+				LineTotal: valueobject.NewMoney(160), TaxCategory: "STANDARD", TaxRate: 10}},
+		}, nil)
+		mockProductClient.On("ReserveStock", mock.Anything, mock.Anything, int64(1), 2).Return(nil)
+		mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Order")).Return(nil)
+
+		order, err := uc.CreateOrder(context.Background(), CreateOrderInput{UserID: 101, QuoteID: 4})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "US-CA", order.TaxRegion)
+		assert.Equal(t, valueobject.NewMoney(160), order.Subtotal)
+		assert.Equal(t, valueobject.NewMoney(16), order.Tax)
+		assert.Equal(t, valueobject.NewMoney(176), order.TotalPrice)
+		mockProductClient.AssertNotCalled(t, "GetProduct", mock.Anything, mock.Anything)
+	})
+
+	t.Run("FromQuote_Expired", func(t *testing.T) {
+		mockQuotes := mocks.NewQuoteRepository(t)
+		uc := NewOrderUsecase(mocks.NewOrderRepository(t), newMemorySagas(), newMemoryTasks(), mocks.NewProductClient(t), nil, mockQuotes, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		mockQuotes.On("GetByID", mock.Anything, int64(4)).Return(&domain.Quote{
+			ID: 4, UserID: 101, ExpiresAt: time.Now().Add(-time.Minute),
+			Items: []domain.OrderItem{{ProductID: 1, UnitPrice: valueobject.NewMoney(80), Quantity: 1, LineTotal: valueobject.NewMoney(80)}},
+		}, nil)
+
+		order, err := uc.CreateOrder(context.Background(), CreateOrderInput{UserID: 101, QuoteID: 4})
+
+		assert.ErrorIs(t, err, domain.ErrQuoteExpired)
+		assert.Nil(t, order)
+	})
+
+	t.Run("FromQuote_WithItems", func(t *testing.T) {
+		mockQuotes := mocks.NewQuoteRepository(t)
+		uc := NewOrderUsecase(mocks.NewOrderRepository(t), newMemorySagas(), newMemoryTasks(), mocks.NewProductClient(t), nil, mockQuotes, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		mockQuotes.On("GetByID", mock.Anything, int64(4)).Return(&domain.Quote{ID: 4, UserID: 101, ExpiresAt: time.Now().Add(time.Minute)}, nil)
+
+		order, err := uc.CreateOrder(context.Background(), CreateOrderInput{UserID: 101, QuoteID: 4, Items: []OrderItemInput{{ProductID: 1, Quantity: 1}}})
+
+		assert.ErrorIs(t, err, pkgerrors.ErrInvalidInput)
+		assert.Nil(t, order)
+	})
+}
+
+func TestOrderUsecase_PayOrder(t *testing.T) {
+	logger.Init()
+	timeout := 5 * time.Second
+
+	t.Run("Success", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockPayments := mocks.NewPaymentGateway(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mocks.NewProductClient(t), nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, mockPayments, timeout)
+
+		order := newTestOrder(t)
+		order.ID = 1
+
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(order, nil)
+		mockPayments.On("Authorize", mock.Anything, int64(1), order.TotalPrice, "tok_visa").Return("auth_1", nil)
+		mockPayments.On("Capture", mock.Anything, "auth_1", order.TotalPrice).Return(nil)
+		mockRepo.On("RecordPayment", mock.Anything,
+			mock.MatchedBy(func(o *domain.Order) bool { return o.PaymentReference == "auth_1" }),
+			statusChange(domain.PaymentPending, domain.OrderPending, domain.PaymentPaid),
+			mock.MatchedBy(func(inv *domain.Invoice) bool { return inv.OrderID == 1 && inv.Total == order.TotalPrice })).Return(nil)
+
+		paid, err := uc.PayOrder(context.Background(), 1, "tok_visa")
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.PaymentPaid, paid.PaymentStatus)
+		assert.Equal(t, "auth_1", paid.PaymentReference)
+	})
+
+	t.Run("Declined_RecordsFailure", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockPayments := mocks.NewPaymentGateway(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mocks.NewProductClient(t), nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, mockPayments, timeout)
+
+		order := newTestOrder(t)
+		order.ID = 1
+
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(order, nil)
+		mockPayments.On("Authorize", mock.Anything, int64(1), order.TotalPrice, "tok_decline").Return("", domain.ErrPaymentDeclined)
+		mockRepo.On("UpdateStatus", mock.Anything, statusChange(domain.PaymentPending, domain.OrderPending, domain.PaymentFailed)).Return(nil)
+
+		paid, err := uc.PayOrder(context.Background(), 1, "tok_decline")
+
+		assert.ErrorIs(t, err, pkgerrors.ErrUnprocessable)
+		assert.Nil(t, paid)
+		assert.Equal(t, domain.PaymentFailed, order.PaymentStatus)
+	})
+
+	t.Run("CaptureTimesOut_VoidsAuthorizationAndStaysPending", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockPayments := mocks.NewPaymentGateway(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mocks.NewProductClient(t), nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, mockPayments, timeout)
+
+		order := newTestOrder(t)
+		order.ID = 1
+
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(order, nil)
+		mockPayments.On("Authorize", mock.Anything, int64(1), order.TotalPrice, "").Return("auth_1", nil)
+		mockPayments.On("Capture", mock.Anything, "auth_1", order.TotalPrice).Return(domain.ErrPaymentUnavailable)
+		mockPayments.On("Void", mock.Anything, "auth_1").Return(nil)
+
+		paid, err := uc.PayOrder(context.Background(), 1, "")
+
+		assert.ErrorIs(t, err, pkgerrors.ErrUnavailable)
+		assert.Nil(t, paid)
+		// The outcome is unknown, so no failure is recorded
+		assert.Equal(t, domain.PaymentPending, order.PaymentStatus)
+		mockRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything)
+	})
+
+	t.Run("RecordFails_Unrecorded_Refunds", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockPayments := mocks.NewPaymentGateway(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mocks.NewProductClient(t), nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, mockPayments, timeout)
+
+		order := newTestOrder(t)
+		order.ID = 1
+
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(func(context.Context, int64) *domain.Order {
+			o := newTestOrder(t)
+			o.ID = 1
+			return o
+		}, nil)
+		mockPayments.On("Authorize", mock.Anything, int64(1), order.TotalPrice, "tok_visa").Return("auth_1", nil)
+		mockPayments.On("Capture", mock.Anything, "auth_1", order.TotalPrice).Return(nil)
+		mockRepo.On("RecordPayment", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(pkgerrors.ErrInternal)
+		mockPayments.On("Refund", mock.Anything, "unrecorded-auth_1", "auth_1", order.TotalPrice).Return(nil)
+
+		paid, err := uc.PayOrder(context.Background(), 1, "tok_visa")
+
+		assert.ErrorIs(t, err, pkgerrors.ErrInternal)
+		assert.Nil(t, paid)
+	})
+
+	t.Run("RecordFails_CommitLanded_KeepsPayment", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockPayments := mocks.NewPaymentGateway(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mocks.NewProductClient(t), nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, mockPayments, timeout)
+
+		order := newTestOrder(t)
+		order.ID = 1
+		stored := newTestOrder(t)
+		stored.ID = 1
+		stored.PaymentStatus = domain.PaymentPaid
+		stored.PaymentReference = "auth_1"
+
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(order, nil).Once()
+		mockPayments.On("Authorize", mock.Anything, int64(1), order.TotalPrice, "tok_visa").Return("auth_1", nil)
+		mockPayments.On("Capture", mock.Anything, "auth_1", order.TotalPrice).Return(nil)
+		// The reply was lost after the commit
+		mockRepo.On("RecordPayment", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(pkgerrors.ErrInternal)
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(stored, nil).Once()
+
+		paid, err := uc.PayOrder(context.Background(), 1, "tok_visa")
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.PaymentPaid, paid.PaymentStatus)
+		mockPayments.AssertNotCalled(t, "Refund", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("AlreadyPaid", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mocks.NewProductClient(t), nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, mocks.NewPaymentGateway(t), timeout)
+
+		order := newTestOrder(t)
+		order.ID = 1
+		order.PaymentStatus = domain.PaymentPaid
+
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(order, nil)
+
+		paid, err := uc.PayOrder(context.Background(), 1, "")
+
+		assert.ErrorIs(t, err, domain.ErrInvalidTransition)
+		assert.Nil(t, paid)
+	})
+}
+
+func TestOrderUsecase_HandlePaymentEvent(t *testing.T) {
+	logger.Init()
+	timeout := 5 * time.Second
+
+	t.Run("Succeeded", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mocks.NewProductClient(t), nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		order := newTestOrder(t)
+		order.ID = 1
+		event := &domain.PaymentEvent{ID: "evt_1", Type: domain.PaymentEventSucceeded, OrderID: 1, Reference: "auth_1", Amount: order.TotalPrice}
+
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(order, nil)
+		mockRepo.On("SavePaymentEvent", mock.Anything, event, order, mock.MatchedBy(func(c *domain.StatusChange) bool {
+			return c.ToPaymentStatus == domain.PaymentPaid && c.Actor == domain.PaymentProviderActor
+		}), mock.MatchedBy(func(inv *domain.Invoice) bool { return inv.PaymentReference == "auth_1" })).Return(nil)
+
+		paid, err := uc.HandlePaymentEvent(context.Background(), event)
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.PaymentPaid, paid.PaymentStatus)
+	})
+
+	t.Run("Duplicate_ReturnsStoredOrder", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mocks.NewProductClient(t), nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		order := newTestOrder(t)
+		order.ID = 1
+		order.PaymentStatus = domain.PaymentFailed
+		event := &domain.PaymentEvent{ID: "evt_2", Type: domain.PaymentEventFailed, OrderID: 1}
+
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(order, nil)
+		mockRepo.On("SavePaymentEvent", mock.Anything, event, order, mock.Anything, mock.Anything).Return(domain.ErrDuplicatePaymentEvent)
+
+		got, err := uc.HandlePaymentEvent(context.Background(), event)
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.PaymentFailed, got.PaymentStatus)
+	})
+}
+
+func TestOrderUsecase_CompleteOrder(t *testing.T) {
+	logger.Init()
+	timeout := 5 * time.Second
+
+	t.Run("Success", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		order := newTestOrder(t)
+		order.ID = 1
+		order.OrderStatus = domain.OrderDelivered
+		order.PaymentStatus = domain.PaymentPaid
+
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(order, nil)
+		mockRepo.On("UpdateStatus", mock.Anything, mock.MatchedBy(func(c *domain.StatusChange) bool {
+			return c.FromOrderStatus == domain.OrderDelivered && c.ToOrderStatus == domain.OrderCompleted
+		})).Return(nil)
+
+		completed, err := uc.CompleteOrder(context.Background(), 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.OrderCompleted, completed.OrderStatus)
+	})
+
+	t.Run("NotDelivered", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		order := newTestOrder(t)
+		order.ID = 1
+		order.PaymentStatus = domain.PaymentPaid
+
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(order, nil)
+
+		completed, err := uc.CompleteOrder(context.Background(), 1)
+
+		assert.ErrorIs(t, err, domain.ErrInvalidTransition)
+		assert.Nil(t, completed)
+	})
+}
+
+func TestOrderUsecase_ShipOrder(t *testing.T) {
+	logger.Init()
+	timeout := 5 * time.Second
+
+	t.Run("Success_ConfirmsStock", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		order := newTestOrder(t)
+		order.ID = 1
+		order.PaymentStatus = domain.PaymentPaid
+
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(order, nil)
+		mockProductClient.On("ConfirmStock", mock.Anything, mock.Anything, int64(1), 2).Return(nil)
+		mockRepo.On("CreateShipment", mock.Anything,
+			mock.MatchedBy(func(s *domain.Shipment) bool {
+				return s.OrderID == 1 && s.Carrier == "UPS" && s.TrackingNumber == "1Z999" && !s.ShippedAt.IsZero()
+			}),
+			statusChange(domain.PaymentPaid, domain.OrderShipped, domain.PaymentPaid),
+		).Return(nil)
+
+		shipment, err := uc.ShipOrder(context.Background(), 1, "UPS", "1Z999", time.Time{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "1Z999", shipment.TrackingNumber)
+	})
+
+	t.Run("Unpaid", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		order := newTestOrder(t)
+		order.ID = 1
+
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(order, nil)
+
+		shipment, err := uc.ShipOrder(context.Background(), 1, "UPS", "1Z999", time.Now())
+
+		assert.ErrorIs(t, err, domain.ErrInvalidTransition)
+		assert.Nil(t, shipment)
+	})
+
+	t.Run("ConfirmFailure_LeftForRetry", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		tasks := newMemoryTasks()
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), tasks, mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		order := newTestOrder(t)
+		order.ID = 1
+		order.PaymentStatus = domain.PaymentPaid
+
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(order, nil)
+		mockRepo.On("CreateShipment", mock.Anything, mock.Anything, mock.MatchedBy(func(c *domain.StatusChange) bool {
+			return len(c.Tasks) == 1 && c.Tasks[0].Kind == domain.TaskConfirmStock
+		})).Run(func(args mock.Arguments) {
+			args.Get(2).(*domain.StatusChange).Tasks[0].ID = 6
+		}).Return(nil)
+		mockProductClient.On("ConfirmStock", mock.Anything, "order-task-6", int64(1), 2).Return(assert.AnError)
+
+		shipment, err := uc.ShipOrder(context.Background(), 1, "UPS", "1Z999", time.Now())
+
+		// The shipment stands; the confirmation is retried by the task runner
+		assert.NoError(t, err)
+		assert.NotNil(t, shipment)
+		assert.Equal(t, 1, tasks.attempts[6])
+	})
+
+	t.Run("AlreadyShipped_ConfirmsNothing", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		order := newTestOrder(t)
+		order.ID = 1
+		order.PaymentStatus = domain.PaymentPaid
+
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(order, nil)
+		// A concurrent shipment claimed the order first
+		mockRepo.On("CreateShipment", mock.Anything, mock.Anything, mock.Anything).Return(pkgerrors.ErrConflict)
+
+		shipment, err := uc.ShipOrder(context.Background(), 1, "UPS", "1Z999", time.Now())
+
+		assert.ErrorIs(t, err, pkgerrors.ErrConflict)
+		assert.Nil(t, shipment)
+		mockProductClient.AssertNotCalled(t, "ConfirmStock", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestOrderUsecase_DeliverShipment(t *testing.T) {
+	logger.Init()
+	timeout := 5 * time.Second
+	shippedAt := time.Now().Add(-24 * time.Hour)
+
+	t.Run("Success_CompletesOrder", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		order := newTestOrder(t)
+		order.ID = 1
+		order.OrderStatus = domain.OrderShipped
+		order.PaymentStatus = domain.PaymentPaid
+		shipment := &domain.Shipment{ID: 7, OrderID: 1, Carrier: "UPS", TrackingNumber: "1Z999", ShippedAt: shippedAt}
+
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(order, nil)
+		mockRepo.On("GetShipment", mock.Anything, int64(7)).Return(shipment, nil)
+		mockRepo.On("MarkDelivered", mock.Anything, shipment, mock.MatchedBy(func(c *domain.StatusChange) bool {
+			return c.FromOrderStatus == domain.OrderShipped && c.ToOrderStatus == domain.OrderDelivered
+		})).Return(nil)
+		mockRepo.On("UpdateStatus", mock.Anything, mock.MatchedBy(func(c *domain.StatusChange) bool {
+			return c.FromOrderStatus == domain.OrderDelivered && c.ToOrderStatus == domain.OrderCompleted
+		})).Return(nil)
+
+		delivered, err := uc.DeliverShipment(context.Background(), 1, 7, time.Time{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.OrderCompleted, delivered.OrderStatus)
+		assert.NotNil(t, shipment.DeliveredAt)
+	})
+
+	t.Run("ShipmentNotFound", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		order := newTestOrder(t)
+		order.ID = 1
+		order.OrderStatus = domain.OrderShipped
+		order.PaymentStatus = domain.PaymentPaid
+
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(order, nil)
+		mockRepo.On("GetShipment", mock.Anything, int64(7)).Return(nil, pkgerrors.ErrNotFound)
+
+		delivered, err := uc.DeliverShipment(context.Background(), 1, 7, time.Now())
+
+		assert.ErrorIs(t, err, pkgerrors.ErrNotFound)
+		assert.Nil(t, delivered)
+	})
+
+	t.Run("AlreadyDelivered", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		order := newTestOrder(t)
+		order.ID = 1
+		order.OrderStatus = domain.OrderCompleted
+		order.PaymentStatus = domain.PaymentPaid
+		shipment := &domain.Shipment{ID: 7, OrderID: 1, Carrier: "UPS", ShippedAt: shippedAt}
+
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(order, nil)
+		mockRepo.On("GetShipment", mock.Anything, int64(7)).Return(shipment, nil)
+
+		delivered, err := uc.DeliverShipment(context.Background(), 1, 7, time.Now())
+
+		assert.ErrorIs(t, err, pkgerrors.ErrConflict)
+		assert.Nil(t, delivered)
+	})
+}
+
+func TestOrderUsecase_AmendOrder(t *testing.T) {
+	logger.Init()
+	timeout := 5 * time.Second
+
+	amended := func(qty int) interface{} {
+		return mock.MatchedBy(func(o *domain.Order) bool {
+			return o.Items[0].Quantity == qty && o.TotalPrice == valueobject.NewMoney(100).Multiply(qty)
+		})
+	}
+
+	t.Run("Increase_ReservesDeltaAndSettlesItsRelease", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		tasks := newMemoryTasks()
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), tasks, mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		order := newTestOrder(t)
+		order.ID = 1
+		order.Version = 4
+
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(order, nil)
+		// The increase is reserved under the key its scheduled release gives
+		// back, and storing the amendment settles that release
+		mockProductClient.On("ReserveStock", mock.Anything, mock.MatchedBy(func(key string) bool {
+			return strings.HasPrefix(key, "amend-1-v4-1-") && len(tasks.scheduled) == 1 && tasks.scheduled[0].OperationKey == key
+		}), int64(1), 3).Return(nil)
+		mockRepo.On("UpdateItems", mock.Anything, amended(5), mock.MatchedBy(func(c *domain.StatusChange) bool {
+			return len(c.Tasks) == 0 && assert.ObjectsAreEqual([]int64{100}, c.Settles)
+		})).Return(nil)
+
+		res, err := uc.AmendOrder(context.Background(), 1, []OrderItemInput{{ProductID: 1, Quantity: 5}})
+
+		assert.NoError(t, err)
+		assert.Equal(t, valueobject.NewMoney(500), res.TotalPrice)
+		assert.Equal(t, domain.TaskReleaseReservation, tasks.scheduled[0].Kind)
+		mockProductClient.AssertNotCalled(t, "ReleaseReservation", mock.Anything, mock.Anything)
+	})
+
+	t.Run("ReserveFails_ReleasesEarlierIncreasesByKey", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		tasks := newMemoryTasks()
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), tasks, mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		order := newTestOrder(t)
+		order.ID = 1
+		second, err := domain.NewOrderItem(2, "Other Product", valueobject.NewMoney(10), 1)
+		assert.NoError(t, err)
+		order.Items = append(order.Items, second)
+
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(order, nil)
+		mockProductClient.On("ReserveStock", mock.Anything, mock.Anything, int64(1), 1).Return(nil)
+		mockProductClient.On("ReserveStock", mock.Anything, mock.Anything, int64(2), 4).Return(pkgerrors.ErrInsufficientStock)
+		// Both keys are released: the first gives its units back and the
+		// second is voided in case its reservation landed after all
+		mockProductClient.On("ReleaseReservation", mock.Anything, mock.Anything).Return(nil).Twice()
+
+		res, err := uc.AmendOrder(context.Background(), 1, []OrderItemInput{{ProductID: 1, Quantity: 3}, {ProductID: 2, Quantity: 5}})
+
+		assert.ErrorIs(t, err, pkgerrors.ErrInsufficientStock)
+		assert.Nil(t, res)
+		assert.True(t, tasks.done[100])
+		assert.True(t, tasks.done[101])
+		mockProductClient.AssertCalled(t, "ReleaseReservation", mock.Anything, tasks.scheduled[0].OperationKey)
+		mockProductClient.AssertCalled(t, "ReleaseReservation", mock.Anything, tasks.scheduled[1].OperationKey)
+		mockProductClient.AssertNotCalled(t, "ReleaseStock", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Decrease_ReleasesDeltaAfterSave", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		tasks := newMemoryTasks()
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), tasks, mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		order := newTestOrder(t)
+		order.ID = 1
+
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(order, nil)
+		// The release is persisted with the new quantities and keyed by its task
+		mockRepo.On("UpdateItems", mock.Anything, amended(1), mock.MatchedBy(func(c *domain.StatusChange) bool {
+			return len(c.Tasks) == 1 && c.Tasks[0].Kind == domain.TaskReleaseStock
+		})).Run(func(args mock.Arguments) {
+			args.Get(2).(*domain.StatusChange).Tasks[0].ID = 8
+		}).Return(nil)
+		mockProductClient.On("ReleaseStock", mock.Anything, "order-task-8", int64(1), 1).Return(nil)
+
+		res, err := uc.AmendOrder(context.Background(), 1, []OrderItemInput{{ProductID: 1, Quantity: 1}})
+
+		assert.NoError(t, err)
+		assert.Equal(t, valueobject.NewMoney(100), res.TotalPrice)
+		assert.True(t, tasks.done[8])
+	})
+
+	t.Run("ConcurrentAmend_ReleasesNothing", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		order := newTestOrder(t)
+		order.ID = 1
+
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(order, nil)
+		// Another amendment was stored since the order was read
+		mockRepo.On("UpdateItems", mock.Anything, mock.Anything, mock.Anything).Return(pkgerrors.ErrConflict)
+
+		res, err := uc.AmendOrder(context.Background(), 1, []OrderItemInput{{ProductID: 1, Quantity: 1}})
+
+		assert.ErrorIs(t, err, pkgerrors.ErrConflict)
+		assert.Nil(t, res)
+		mockProductClient.AssertNotCalled(t, "ReleaseStock", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Conflict_ReleasesReservedDeltaByKey", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		tasks := newMemoryTasks()
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), tasks, mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		order := newTestOrder(t)
+		order.ID = 1
+
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(order, nil)
+		mockProductClient.On("ReserveStock", mock.Anything, mock.Anything, int64(1), 1).Return(nil)
+		mockRepo.On("UpdateItems", mock.Anything, mock.Anything, mock.Anything).Return(pkgerrors.ErrConflict)
+		mockProductClient.On("ReleaseReservation", mock.Anything, mock.MatchedBy(func(key string) bool {
+			return key == tasks.scheduled[0].OperationKey
+		})).Return(nil)
+
+		res, err := uc.AmendOrder(context.Background(), 1, []OrderItemInput{{ProductID: 1, Quantity: 3}})
+
+		assert.ErrorIs(t, err, pkgerrors.ErrConflict)
+		assert.Nil(t, res)
+		assert.True(t, tasks.done[100])
+	})
+
+	t.Run("UnknownOutcome_LeavesReleaseToTaskRunner", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		tasks := newMemoryTasks()
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), tasks, mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		order := newTestOrder(t)
+		order.ID = 1
+
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(order, nil)
+		mockProductClient.On("ReserveStock", mock.Anything, mock.Anything, int64(1), 1).Return(nil)
+		// The commit may have landed and settled the release
+		mockRepo.On("UpdateItems", mock.Anything, mock.Anything, mock.Anything).Return(pkgerrors.ErrInternal)
+
+		res, err := uc.AmendOrder(context.Background(), 1, []OrderItemInput{{ProductID: 1, Quantity: 3}})
+
+		assert.ErrorIs(t, err, pkgerrors.ErrInternal)
+		assert.Nil(t, res)
+		assert.Len(t, tasks.scheduled, 1)
+		assert.False(t, tasks.done[100])
+		mockProductClient.AssertNotCalled(t, "ReleaseReservation", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Paid_Rejected", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		order := newTestOrder(t)
+		order.ID = 1
+		order.PaymentStatus = domain.PaymentPaid
+
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(order, nil)
+
+		res, err := uc.AmendOrder(context.Background(), 1, []OrderItemInput{{ProductID: 1, Quantity: 3}})
+
+		assert.ErrorIs(t, err, domain.ErrInvalidTransition)
+		assert.Nil(t, res)
+	})
+}
+
+func TestOrderUsecase_CancelOrder(t *testing.T) {
+	logger.Init()
+	timeout := 5 * time.Second
+
+	t.Run("Success_ReleasesStock", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		tasks := newMemoryTasks()
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), tasks, mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		order := newTestOrder(t)
+		order.ID = 1
+
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(order, nil)
+		// The release is persisted with the cancellation and keyed by its task
+		mockRepo.On("UpdateStatus", mock.Anything, mock.MatchedBy(func(c *domain.StatusChange) bool {
+			return c.ToOrderStatus == domain.OrderCancelled && c.Reason == "changed my mind" && c.Actor == "support-agent" &&
+				len(c.Tasks) == 1 && c.Tasks[0].Kind == domain.TaskReleaseStock
+		})).Run(func(args mock.Arguments) {
+			args.Get(1).(*domain.StatusChange).Tasks[0].ID = 5
+		}).Return(nil)
+		mockProductClient.On("ReleaseStock", mock.Anything, "order-task-5", int64(1), 2).Return(nil)
+
+		ctx := domain.WithActor(context.Background(), "support-agent")
+		cancelled, err := uc.CancelOrder(ctx, 1, "changed my mind")
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.OrderCancelled, cancelled.OrderStatus)
+		assert.True(t, tasks.done[5])
+	})
+
+	t.Run("AlreadyCancelled_Idempotent", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		order := newTestOrder(t)
+		order.ID = 1
+		order.OrderStatus = domain.OrderCancelled
+
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(order, nil)
+
+		cancelled, err := uc.CancelOrder(context.Background(), 1, "")
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.OrderCancelled, cancelled.OrderStatus)
+		mockProductClient.AssertNotCalled(t, "ReleaseStock", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("ReleaseFailure_LeftForRetry", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		tasks := newMemoryTasks()
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), tasks, mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		order := newTestOrder(t)
+		order.ID = 1
+
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(order, nil)
+		mockRepo.On("UpdateStatus", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			args.Get(1).(*domain.StatusChange).Tasks[0].ID = 5
+		}).Return(nil)
+		mockProductClient.On("ReleaseStock", mock.Anything, "order-task-5", int64(1), 2).Return(assert.AnError)
+
+		cancelled, err := uc.CancelOrder(context.Background(), 1, "")
+
+		// The cancellation stands; the release is retried by the task runner
+		assert.NoError(t, err)
+		assert.Equal(t, domain.OrderCancelled, cancelled.OrderStatus)
+		assert.False(t, tasks.done[5])
+		assert.Equal(t, 1, tasks.attempts[5])
+	})
+
+	t.Run("ConcurrentCancel_ReleasesOnce", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		order := newTestOrder(t)
+		order.ID = 1
+		winner := newTestOrder(t)
+		winner.ID = 1
+		winner.OrderStatus = domain.OrderCancelled
+
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(order, nil).Once()
+		mockRepo.On("UpdateStatus", mock.Anything, mock.Anything).Return(pkgerrors.ErrConflict)
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(winner, nil).Once()
+
+		cancelled, err := uc.CancelOrder(context.Background(), 1, "")
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.OrderCancelled, cancelled.OrderStatus)
+		mockProductClient.AssertNotCalled(t, "ReleaseStock", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestOrderUsecase_GetOrderHistory(t *testing.T) {
+	logger.Init()
+	timeout := 5 * time.Second
+
+	t.Run("Success", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		history := []*domain.StatusChange{
+			{ID: 1, OrderID: 1, ToOrderStatus: domain.OrderPending, ToPaymentStatus: domain.PaymentPending},
+		}
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(newTestOrder(t), nil)
+		mockRepo.On("GetStatusHistory", mock.Anything, int64(1)).Return(history, nil)
+
+		res, err := uc.GetOrderHistory(context.Background(), 1)
+
+		assert.NoError(t, err)
+		assert.Len(t, res, 1)
+	})
+
+	t.Run("OrderNotFound", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		mockRepo.On("GetByID", mock.Anything, int64(9)).Return(nil, pkgerrors.ErrNotFound)
+
+		res, err := uc.GetOrderHistory(context.Background(), 9)
+
+		assert.ErrorIs(t, err, pkgerrors.ErrNotFound)
+		assert.Nil(t, res)
+	})
+}
+
+func TestOrderUsecase_ExpireOrders(t *testing.T) {
+	logger.Init()
+	timeout := 5 * time.Second
+
+	t.Run("CancelsAndReleases", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		order := newTestOrder(t)
+		order.ID = 1
+
+		mockRepo.On("FindExpiredPending", mock.Anything, mock.AnythingOfType("time.Time"), 10).Return([]int64{1}, nil)
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(order, nil)
+		mockProductClient.On("ReleaseStock", mock.Anything, mock.Anything, int64(1), 2).Return(nil)
+		mockRepo.On("UpdateStatus", mock.Anything, mock.MatchedBy(func(c *domain.StatusChange) bool {
+			return c.ToOrderStatus == domain.OrderCancelled && c.Actor == domain.SystemActor
+		})).Return(nil)
+
+		ids, err := uc.ExpireOrders(context.Background(), 30*time.Minute, 10)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{1}, ids)
+	})
+
+	t.Run("PartialFailure_OthersStillExpire", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		order := newTestOrder(t)
+		order.ID = 2
+
+		mockRepo.On("FindExpiredPending", mock.Anything, mock.AnythingOfType("time.Time"), 10).Return([]int64{1, 2}, nil)
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(nil, pkgerrors.ErrInternal)
+		mockRepo.On("GetByID", mock.Anything, int64(2)).Return(order, nil)
+		mockProductClient.On("ReleaseStock", mock.Anything, mock.Anything, int64(1), 2).Return(nil)
+		mockRepo.On("UpdateStatus", mock.Anything, mock.MatchedBy(func(c *domain.StatusChange) bool {
+			return c.OrderID == 2 && c.ToOrderStatus == domain.OrderCancelled
+		})).Return(nil)
+
+		ids, err := uc.ExpireOrders(context.Background(), 30*time.Minute, 10)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{2}, ids)
+	})
+
+	t.Run("SkipsOrderPaidMeanwhile", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		order := newTestOrder(t)
+		order.ID = 1
+		order.PaymentStatus = domain.PaymentPaid
+
+		mockRepo.On("FindExpiredPending", mock.Anything, mock.AnythingOfType("time.Time"), 10).Return([]int64{1}, nil)
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(order, nil)
+
+		ids, err := uc.ExpireOrders(context.Background(), 30*time.Minute, 10)
+
+		assert.NoError(t, err)
+		assert.Empty(t, ids)
+		mockProductClient.AssertNotCalled(t, "ReleaseStock", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("PaidDuringExpiry_ReleasesNothing", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		order := newTestOrder(t)
+		order.ID = 1
+
+		mockRepo.On("FindExpiredPending", mock.Anything, mock.AnythingOfType("time.Time"), 10).Return([]int64{1}, nil)
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(order, nil)
+		// The payment wins the status guard after the order was read
+		mockRepo.On("UpdateStatus", mock.Anything, mock.Anything).Return(pkgerrors.ErrConflict)
+
+		ids, err := uc.ExpireOrders(context.Background(), 30*time.Minute, 10)
+
+		assert.NoError(t, err)
+		assert.Empty(t, ids)
+		mockProductClient.AssertNotCalled(t, "ReleaseStock", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestOrderUsecase_AllocateBackorders(t *testing.T) {
+	logger.Init()
+	timeout := 5 * time.Second
+
+	backorder := func(id, productID int64, qty int) *domain.Order {
+		item, err := domain.NewOrderItem(productID, "Test Product", valueobject.NewMoney(10), qty)
+		assert.NoError(t, err)
+		order, err := domain.NewOrder(101, []domain.OrderItem{item}, "", &domain.TaxTable{})
+		assert.NoError(t, err)
+		assert.NoError(t, order.Backorder())
+		order.ID = id
+		return order
+	}
+
+	t.Run("OldestFirst_BlockedProductHoldsYoungerOrders", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		mockRepo.On("FindBackordered", mock.Anything, 10).Return([]int64{1, 2, 3}, nil)
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(backorder(1, 7, 5), nil)
+		mockRepo.On("GetByID", mock.Anything, int64(2)).Return(backorder(2, 7, 1), nil)
+		mockRepo.On("GetByID", mock.Anything, int64(3)).Return(backorder(3, 8, 2), nil)
+		mockProductClient.On("AllocateStock", mock.Anything, mock.Anything, []domain.StockLine{{ProductID: 7, Quantity: 5}}).Return(pkgerrors.ErrInsufficientStock)
+		mockProductClient.On("AllocateStock", mock.Anything, mock.Anything, []domain.StockLine{{ProductID: 8, Quantity: 2}}).Return(nil)
+		mockRepo.On("MarkAllocated", mock.Anything,
+			mock.MatchedBy(func(o *domain.Order) bool { return o.ID == 3 && o.AllocatedAt != nil }),
+			mock.MatchedBy(func(c *domain.StatusChange) bool {
+				return c.FromOrderStatus == domain.OrderBackordered && c.ToOrderStatus == domain.OrderPending && c.Actor == domain.SystemActor
+			}),
+		).Return(nil)
+
+		ids, err := uc.AllocateBackorders(context.Background(), 10)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{3}, ids)
+		// Order 2 would fit but must not jump ahead of order 1
+		mockProductClient.AssertNumberOfCalls(t, "AllocateStock", 2)
+	})
+
+	t.Run("PersistFails_AllocationRepeatedUnderSameKey", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		mockRepo.On("FindBackordered", mock.Anything, 10).Return([]int64{1}, nil)
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(func(context.Context, int64) *domain.Order { return backorder(1, 7, 5) }, nil)
+		// The product service applies the key once, so the retry does not
+		// reserve the units again
+		mockProductClient.On("AllocateStock", mock.Anything, "allocate-1", []domain.StockLine{{ProductID: 7, Quantity: 5}}).Return(nil).Twice()
+		mockRepo.On("MarkAllocated", mock.Anything, mock.Anything, mock.Anything).Return(pkgerrors.ErrConflict).Once()
+		mockRepo.On("MarkAllocated", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+
+		ids, err := uc.AllocateBackorders(context.Background(), 10)
+		assert.NoError(t, err)
+		assert.Empty(t, ids)
+
+		ids, err = uc.AllocateBackorders(context.Background(), 10)
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{1}, ids)
+		mockProductClient.AssertNotCalled(t, "ReleaseStock", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("CancelledBackorder_ReleasesAllocationByKey", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(backorder(1, 7, 5), nil)
+		// An allocation that went unrecorded is reversed, or the key voided
+		mockProductClient.On("ReleaseReservation", mock.Anything, "allocate-1").Return(nil)
+		mockRepo.On("UpdateStatus", mock.Anything, mock.MatchedBy(func(c *domain.StatusChange) bool {
+			return c.FromOrderStatus == domain.OrderBackordered && c.ToOrderStatus == domain.OrderCancelled
+		})).Return(nil)
+
+		order, err := uc.CancelOrder(context.Background(), 1, "")
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.OrderCancelled, order.OrderStatus)
+		mockProductClient.AssertNotCalled(t, "ReleaseStock", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestOrderUsecase_ReleasePreorders(t *testing.T) {
+	logger.Init()
+	timeout := 5 * time.Second
+
+	preorder := func(id int64) *domain.Order {
+		release := time.Now().Add(-time.Minute)
+		item, err := domain.NewOrderItem(7, "Test Product", valueobject.NewMoney(10), 3)
+		assert.NoError(t, err)
+		item.ReleaseAt = &release
+		order, err := domain.NewOrder(101, []domain.OrderItem{item}, "", &domain.TaxTable{})
+		assert.NoError(t, err)
+		order.OrderStatus = domain.OrderPreorder
+		order.ReleaseAt = &release
+		order.ID = id
+		return order
+	}
+
+	t.Run("ConvertsReleasedPreorders", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		mockRepo.On("FindReleasedPreorders", mock.Anything, mock.AnythingOfType("time.Time"), 10).Return([]int64{1, 2}, nil)
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(preorder(1), nil)
+		mockRepo.On("GetByID", mock.Anything, int64(2)).Return(preorder(2), nil)
+		mockProductClient.On("ConvertPreorder", mock.Anything, "preorder-1", []domain.StockLine{{ProductID: 7, Quantity: 3}}).Return(nil).Once()
+		mockProductClient.On("ConvertPreorder", mock.Anything, "preorder-2", []domain.StockLine{{ProductID: 7, Quantity: 3}}).Return(pkgerrors.ErrInsufficientStock).Once()
+		mockRepo.On("MarkAllocated", mock.Anything,
+			mock.MatchedBy(func(o *domain.Order) bool { return o.ID == 1 && o.OrderStatus == domain.OrderPending }),
+			mock.MatchedBy(func(c *domain.StatusChange) bool {
+				return c.FromOrderStatus == domain.OrderPreorder && c.ToOrderStatus == domain.OrderPending && c.Reason == "pre-order released"
+			}),
+		).Return(nil)
+
+		ids, err := uc.ReleasePreorders(context.Background(), 10)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{1}, ids)
+	})
+
+	t.Run("PersistFails_ConversionRepeatedUnderSameKey", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		mockRepo.On("FindReleasedPreorders", mock.Anything, mock.AnythingOfType("time.Time"), 10).Return([]int64{1}, nil)
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(func(context.Context, int64) *domain.Order { return preorder(1) }, nil)
+		// The product service applies the key once, so the retry does not
+		// take the units off the cap again
+		mockProductClient.On("ConvertPreorder", mock.Anything, "preorder-1", []domain.StockLine{{ProductID: 7, Quantity: 3}}).Return(nil).Twice()
+		mockRepo.On("MarkAllocated", mock.Anything, mock.Anything, mock.Anything).Return(pkgerrors.ErrInternal).Once()
+		mockRepo.On("MarkAllocated", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+
+		ids, err := uc.ReleasePreorders(context.Background(), 10)
+		assert.NoError(t, err)
+		assert.Empty(t, ids)
+
+		ids, err = uc.ReleasePreorders(context.Background(), 10)
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{1}, ids)
+		mockProductClient.AssertNotCalled(t, "ReleaseStock", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		mockProductClient.AssertNotCalled(t, "ReleaseReservation", mock.Anything, mock.Anything)
+	})
+
+	t.Run("CancelPreorder_ReleasesAllocation", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(preorder(1), nil)
+		// A conversion that went unrecorded is reversed onto the cap first
+		mockProductClient.On("ReleaseReservation", mock.Anything, "preorder-1").Return(nil)
+		mockProductClient.On("ReleasePreorder", mock.Anything, mock.Anything, []domain.StockLine{{ProductID: 7, Quantity: 3}}).Return(nil)
+		mockRepo.On("UpdateStatus", mock.Anything, mock.MatchedBy(func(c *domain.StatusChange) bool {
+			return c.FromOrderStatus == domain.OrderPreorder && c.ToOrderStatus == domain.OrderCancelled
+		})).Return(nil)
+
+		order, err := uc.CancelOrder(context.Background(), 1, "")
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.OrderCancelled, order.OrderStatus)
+		mockProductClient.AssertNotCalled(t, "ReleaseStock", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestOrderUsecase_RefundOrder(t *testing.T) {
+	logger.Init()
+	timeout := 5 * time.Second
+
+	t.Run("PartialRefund", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		order := newTestOrder(t)
+		order.ID = 1
+		order.OrderStatus = domain.OrderCompleted
+		order.PaymentStatus = domain.PaymentPaid
+
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(order, nil)
+		mockRepo.On("SaveRefund", mock.Anything,
+			mock.MatchedBy(func(r *domain.Refund) bool { return r.Amount == valueobject.NewMoney(50) }),
+			mock.MatchedBy(func(c *domain.StatusChange) bool { return c.ToPaymentStatus == domain.PaymentPartiallyRefunded }),
+		).Return(nil)
+
+		refund, err := uc.RefundOrder(context.Background(), 1, valueobject.NewMoney(50), "")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "refund issued", refund.Reason)
+	})
+
+	t.Run("RecordedBeforePaidOut", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockPayments := mocks.NewPaymentGateway(t)
+		tasks := newMemoryTasks()
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), tasks, mocks.NewProductClient(t), nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, mockPayments, timeout)
+
+		order := newTestOrder(t)
+		order.ID = 1
+		order.OrderStatus = domain.OrderCompleted
+		order.PaymentStatus = domain.PaymentPaid
+		order.PaymentReference = "auth_1"
+
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(order, nil)
+		mockRepo.On("SaveRefund", mock.Anything, mock.Anything, mock.MatchedBy(func(c *domain.StatusChange) bool {
+			return len(c.Tasks) == 1 && c.Tasks[0].Kind == domain.TaskRefundPayment &&
+				c.Tasks[0].PaymentReference == "auth_1" && c.Tasks[0].Amount == valueobject.NewMoney(50)
+		})).Run(func(args mock.Arguments) {
+			args.Get(2).(*domain.StatusChange).Tasks[0].ID = 8
+		}).Return(nil)
+		mockPayments.On("Refund", mock.Anything, "order-task-8", "auth_1", valueobject.NewMoney(50)).Return(nil)
+
+		_, err := uc.RefundOrder(context.Background(), 1, valueobject.NewMoney(50), "")
+
+		assert.NoError(t, err)
+		assert.True(t, tasks.done[8])
+	})
+
+	t.Run("ConcurrentRefund_NotPaidOut", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockPayments := mocks.NewPaymentGateway(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mocks.NewProductClient(t), nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, mockPayments, timeout)
+
+		order := newTestOrder(t)
+		order.ID = 1
+		order.OrderStatus = domain.OrderCompleted
+		order.PaymentStatus = domain.PaymentPaid
+		order.PaymentReference = "auth_1"
+
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(order, nil)
+		// Another refund took the remainder first
+		mockRepo.On("SaveRefund", mock.Anything, mock.Anything, mock.Anything).Return(pkgerrors.ErrConflict)
+
+		refund, err := uc.RefundOrder(context.Background(), 1, valueobject.NewMoney(50), "")
+
+		assert.ErrorIs(t, err, pkgerrors.ErrConflict)
+		assert.Nil(t, refund)
+		mockPayments.AssertNotCalled(t, "Refund", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("ExceedsTotal", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		order := newTestOrder(t)
+		order.ID = 1
+		order.OrderStatus = domain.OrderCompleted
+		order.PaymentStatus = domain.PaymentPaid
+
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(order, nil)
+
+		refund, err := uc.RefundOrder(context.Background(), 1, valueobject.NewMoney(500), "")
+
+		assert.ErrorIs(t, err, domain.ErrRefundExceedsTotal)
+		assert.Nil(t, refund)
+	})
+
+	t.Run("CancelPaidOrder_Refunds", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewOrderUsecase(mockRepo, newMemorySagas(), newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		order := newTestOrder(t)
+		order.ID = 1
+		order.PaymentStatus = domain.PaymentPaid
+
+		mockRepo.On("GetByID", mock.Anything, int64(1)).Return(order, nil)
+		mockProductClient.On("ReleaseStock", mock.Anything, mock.Anything, int64(1), 2).Return(nil)
+		mockRepo.On("SaveRefund", mock.Anything,
+			mock.MatchedBy(func(r *domain.Refund) bool { return r.Amount == valueobject.NewMoney(200) }),
+			mock.MatchedBy(func(c *domain.StatusChange) bool {
+				return c.ToOrderStatus == domain.OrderCancelled && c.ToPaymentStatus == domain.PaymentRefunded
+			}),
+		).Return(nil)
+
+		cancelled, err := uc.CancelOrder(context.Background(), 1, "")
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.PaymentRefunded, cancelled.PaymentStatus)
+		mockRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything)
+	})
 }
@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/pkg/logger"
+	"go.uber.org/zap"
+)
+
+//go:generate mockery --name OutboxUsecase
+type OutboxUsecase interface {
+	// PublishPending publishes up to limit outbox events, oldest first, and
+	// returns the IDs of those published
+	PublishPending(ctx context.Context, limit int) ([]int64, error)
+	// PurgePublished deletes up to limit events published longer ago than
+	// the retention window
+	PurgePublished(ctx context.Context, limit int) (int64, error)
+}
+
+type outboxUsecase struct {
+	outbox         domain.OutboxRepository
+	publisher      domain.EventPublisher
+	retention      time.Duration
+	contextTimeout time.Duration
+}
+
+func NewOutboxUsecase(outbox domain.OutboxRepository, publisher domain.EventPublisher, retention, timeout time.Duration) OutboxUsecase {
+	return &outboxUsecase{
+		outbox:         outbox,
+		publisher:      publisher,
+		retention:      retention,
+		contextTimeout: timeout,
+	}
+}
+
+func (u *outboxUsecase) PublishPending(ctx context.Context, limit int) ([]int64, error) {
+	findCtx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	events, err := u.outbox.FindUnpublished(findCtx, limit)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+
+	// Once an order's event is not published, or not recorded as published,
+	// its later events wait for the next run so they cannot overtake it
+	blocked := make(map[int64]bool)
+	published := make([]int64, 0, len(events))
+	for _, e := range events {
+		if blocked[e.OrderID] {
+			continue
+		}
+		if err := u.publish(ctx, e); err != nil {
+			logger.FromContext(ctx).Error("failed to publish order event",
+				zap.Int64("event_id", e.ID), zap.Int64("order_id", e.OrderID), zap.Error(err))
+			blocked[e.OrderID] = true
+			continue
+		}
+		published = append(published, e.ID)
+	}
+	return published, nil
+}
+
+// publish hands the event to the broker and then marks it published. A crash
+// between the two publishes the event again, which consumers absorb by
+// deduplicating on its ID.
+func (u *outboxUsecase) publish(ctx context.Context, e *domain.OrderEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	if err := u.publisher.Publish(ctx, e); err != nil {
+		return err
+	}
+	return u.outbox.MarkPublished(ctx, e.ID, time.Now())
+}
+
+func (u *outboxUsecase) PurgePublished(ctx context.Context, limit int) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	return u.outbox.DeletePublished(ctx, time.Now().Add(-u.retention), limit)
+}
@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/order-service/internal/domain/mocks"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+)
+
+func TestOutboxUsecase_PublishPending(t *testing.T) {
+	logger.Init()
+	timeout := 5 * time.Second
+
+	events := func() []*domain.OrderEvent {
+		return []*domain.OrderEvent{
+			{ID: 1, Type: domain.EventOrderCreated, OrderID: 7},
+			{ID: 2, Type: domain.EventOrderCreated, OrderID: 8},
+			{ID: 3, Type: domain.EventOrderPaid, OrderID: 7},
+		}
+	}
+
+	t.Run("AllPublished_InOrder", func(t *testing.T) {
+		mockOutbox := mocks.NewOutboxRepository(t)
+		mockPublisher := mocks.NewEventPublisher(t)
+		uc := NewOutboxUsecase(mockOutbox, mockPublisher, time.Hour, timeout)
+
+		var order []int64
+		mockOutbox.On("FindUnpublished", mock.Anything, 50).Return(events(), nil)
+		mockPublisher.On("Publish", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			order = append(order, args.Get(1).(*domain.OrderEvent).ID)
+		}).Return(nil)
+		mockOutbox.On("MarkPublished", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+		published, err := uc.PublishPending(context.Background(), 50)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{1, 2, 3}, published)
+		assert.Equal(t, []int64{1, 2, 3}, order)
+	})
+
+	t.Run("PublishFails_LaterEventsOfOrderHeldBack", func(t *testing.T) {
+		mockOutbox := mocks.NewOutboxRepository(t)
+		mockPublisher := mocks.NewEventPublisher(t)
+		uc := NewOutboxUsecase(mockOutbox, mockPublisher, time.Hour, timeout)
+
+		mockOutbox.On("FindUnpublished", mock.Anything, 50).Return(events(), nil)
+		mockPublisher.On("Publish", mock.Anything, mock.MatchedBy(func(e *domain.OrderEvent) bool { return e.ID == 1 })).Return(pkgerrors.ErrUnavailable)
+		mockPublisher.On("Publish", mock.Anything, mock.MatchedBy(func(e *domain.OrderEvent) bool { return e.ID == 2 })).Return(nil)
+		mockOutbox.On("MarkPublished", mock.Anything, int64(2), mock.Anything).Return(nil)
+
+		published, err := uc.PublishPending(context.Background(), 50)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{2}, published)
+		mockPublisher.AssertNumberOfCalls(t, "Publish", 2)
+	})
+
+	t.Run("MarkFails_LaterEventsOfOrderHeldBack", func(t *testing.T) {
+		mockOutbox := mocks.NewOutboxRepository(t)
+		mockPublisher := mocks.NewEventPublisher(t)
+		uc := NewOutboxUsecase(mockOutbox, mockPublisher, time.Hour, timeout)
+
+		mockOutbox.On("FindUnpublished", mock.Anything, 50).Return(events(), nil)
+		mockPublisher.On("Publish", mock.Anything, mock.Anything).Return(nil)
+		mockOutbox.On("MarkPublished", mock.Anything, int64(1), mock.Anything).Return(pkgerrors.ErrInternal)
+		mockOutbox.On("MarkPublished", mock.Anything, int64(2), mock.Anything).Return(nil)
+
+		published, err := uc.PublishPending(context.Background(), 50)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{2}, published)
+		mockPublisher.AssertNumberOfCalls(t, "Publish", 2)
+	})
+
+	t.Run("FindFails", func(t *testing.T) {
+		mockOutbox := mocks.NewOutboxRepository(t)
+		mockPublisher := mocks.NewEventPublisher(t)
+		uc := NewOutboxUsecase(mockOutbox, mockPublisher, time.Hour, timeout)
+
+		mockOutbox.On("FindUnpublished", mock.Anything, 50).Return(nil, pkgerrors.ErrInternal)
+
+		_, err := uc.PublishPending(context.Background(), 50)
+
+		assert.ErrorIs(t, err, pkgerrors.ErrInternal)
+	})
+}
+
+func TestOutboxUsecase_PurgePublished(t *testing.T) {
+	mockOutbox := mocks.NewOutboxRepository(t)
+	uc := NewOutboxUsecase(mockOutbox, mocks.NewEventPublisher(t), 24*time.Hour, 5*time.Second)
+
+	mockOutbox.On("DeletePublished", mock.Anything, mock.MatchedBy(func(before time.Time) bool {
+		return time.Since(before) >= 24*time.Hour && time.Since(before) < 25*time.Hour
+	}), 1000).Return(int64(6), nil)
+
+	n, err := uc.PurgePublished(context.Background(), 1000)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6), n)
+}
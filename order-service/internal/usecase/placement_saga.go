@@ -0,0 +1,178 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// reserve reserves the new order's lines, logging each reservation in saga.
+// Orders for unreleased products take pre-order allocations instead. An
+// order that runs short of a backorderable product, or that would jump the
+// queue of backorders waiting for one, is backordered and reserves nothing
+// until stock is allocated to it, first come, first served.
+func (u *orderUsecase) reserve(ctx context.Context, order *domain.Order, saga *domain.PlacementSaga) error {
+	if err := order.SchedulePreorder(time.Now()); err != nil {
+		return err
+	}
+	action := domain.SagaReserveStock
+	if order.OrderStatus == domain.OrderPreorder {
+		action = domain.SagaReservePreorder
+	} else if u.queuedBehindBackorders(ctx, order) {
+		return order.Backorder()
+	}
+
+	for _, line := range order.StockLines() {
+		err := u.step(ctx, saga, action, line, func(key string) error {
+			if action == domain.SagaReservePreorder {
+				return u.productClient.ReservePreorder(ctx, key, []domain.StockLine{line})
+			}
+			return u.productClient.ReserveStock(ctx, key, line.ProductID, line.Quantity)
+		})
+		if err == nil {
+			continue
+		}
+		logger.FromContext(ctx).Warn("reservation failed, rolling back earlier lines", zap.Int64("product_id", line.ProductID))
+		u.compensate(ctx, saga)
+		if action == domain.SagaReservePreorder || !errors.Is(err, pkgerrors.ErrInsufficientStock) || !u.backorderable(ctx, line.ProductID) {
+			return err
+		}
+		return order.Backorder()
+	}
+	return nil
+}
+
+// step logs a reservation of line before making it with call, which is given
+// the step's operation key, and records its outcome afterwards. A reservation
+// that cannot be logged is not made. A call that fails without the product
+// service refusing it may still have reserved, so its step is left unsettled
+// for compensation to release by key.
+func (u *orderUsecase) step(ctx context.Context, saga *domain.PlacementSaga, action domain.SagaAction,
+	line domain.StockLine, call func(key string) error) error {
+	step := saga.Begin(action, line, time.Now())
+	if err := u.sagas.Save(ctx, saga); err != nil {
+		saga.Settle(false, time.Now())
+		return err
+	}
+	err := call(saga.StepKey(step.Seq))
+	if err == nil || refused(err) {
+		saga.Settle(err == nil, time.Now())
+	}
+	u.saveSaga(ctx, saga)
+	return err
+}
+
+// refused reports whether err is the product service turning a stock call
+// down, as opposed to a failure that leaves its outcome unknown
+func refused(err error) bool {
+	return errors.Is(err, pkgerrors.ErrInsufficientStock) || errors.Is(err, pkgerrors.ErrNotFound) ||
+		errors.Is(err, pkgerrors.ErrInvalidInput) || errors.Is(err, pkgerrors.ErrConflict)
+}
+
+// compensate releases every reservation the saga holds or may hold. Releases
+// that fail are left to RecoverSagas, which retries them with backoff.
+func (u *orderUsecase) compensate(ctx context.Context, saga *domain.PlacementSaga) {
+	// Release even if the caller has given up on the request
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), u.contextTimeout)
+	defer cancel()
+
+	saga.Compensate(time.Now())
+	u.saveSaga(ctx, saga)
+	u.release(ctx, saga)
+}
+
+// release makes one attempt at giving back the saga's releasing steps and
+// stores the outcome. Steps are released by key, so a step whose
+// reservation never landed is released too: its key is voided.
+func (u *orderUsecase) release(ctx context.Context, saga *domain.PlacementSaga) {
+	var errs []error
+	for _, step := range saga.Releasing() {
+		if err := u.productClient.ReleaseReservation(ctx, saga.StepKey(step.Seq)); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		saga.Released(step.Seq)
+	}
+
+	err := errors.Join(errs...)
+	saga.CompensationAttempted(err, time.Now())
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to release reservations, will retry",
+			zap.Int64("saga_id", saga.ID), zap.Int("attempts", saga.Attempts), zap.Timep("next_attempt_at", saga.NextAttemptAt), zap.Error(err))
+	}
+	u.saveSaga(ctx, saga)
+}
+
+// saveSaga stores the saga's progress. A failure is logged rather than
+// returned: the saga is saved again at its next step, and until then
+// recovery works from the last state that was stored.
+func (u *orderUsecase) saveSaga(ctx context.Context, saga *domain.PlacementSaga) {
+	if err := u.sagas.Save(ctx, saga); err != nil {
+		logger.FromContext(ctx).Error("failed to save placement saga", zap.Int64("saga_id", saga.ID), zap.Error(err))
+	}
+}
+
+func (u *orderUsecase) RecoverSagas(ctx context.Context, staleAfter time.Duration, limit int) ([]int64, error) {
+	now := time.Now()
+	staleBefore := now.Add(-staleAfter)
+
+	findCtx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	ids, err := u.sagas.FindRecoverable(findCtx, now, staleBefore, limit)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+
+	settled := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		ok, err := u.recoverSaga(ctx, id, staleBefore)
+		if err != nil {
+			logger.FromContext(ctx).Error("failed to recover saga", zap.Int64("saga_id", id), zap.Error(err))
+			continue
+		}
+		if ok {
+			settled = append(settled, id)
+		}
+	}
+	return settled, nil
+}
+
+// recoverSaga finishes an abandoned saga, completing it if its order was
+// stored and failing it otherwise, then retries its pending compensation. It
+// reports whether the saga is settled.
+func (u *orderUsecase) recoverSaga(ctx context.Context, id int64, staleBefore time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	saga, err := u.sagas.GetByID(ctx, id)
+	if err != nil {
+		return false, err
+	}
+
+	if saga.Abandoned(staleBefore) {
+		orderID, err := u.sagas.FindOrderID(ctx, saga.ID)
+		switch {
+		case err == nil:
+			saga.Complete(orderID, time.Now())
+		case errors.Is(err, pkgerrors.ErrNotFound):
+			// Reservations interrupted mid-call are released along with the
+			// rest; releasing by key is safe whether or not they landed
+			saga.Fail(time.Now())
+		default:
+			return false, err
+		}
+		if err := u.sagas.Save(ctx, saga); err != nil {
+			return false, err
+		}
+	}
+
+	if saga.Compensation == domain.CompensationPending {
+		u.release(ctx, saga)
+	}
+	return saga.Settled(), nil
+}
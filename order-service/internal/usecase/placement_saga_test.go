@@ -0,0 +1,218 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/order-service/internal/domain/mocks"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+	"github.com/user/go-microservices/pkg/valueobject"
+)
+
+func TestOrderUsecase_PlacementSaga(t *testing.T) {
+	logger.Init()
+	timeout := 5 * time.Second
+	items := []OrderItemInput{{ProductID: 1, Quantity: 1}, {ProductID: 2, Quantity: 3}}
+
+	newProducts := func(t *testing.T) *mocks.ProductClient {
+		mockProductClient := mocks.NewProductClient(t)
+		mockProductClient.On("GetProduct", mock.Anything, int64(1)).Return(&domain.ProductView{ID: 1, Name: "A", Price: valueobject.NewMoney(10)}, nil)
+		mockProductClient.On("GetProduct", mock.Anything, int64(2)).Return(&domain.ProductView{ID: 2, Name: "B", Price: valueobject.NewMoney(20)}, nil)
+		return mockProductClient
+	}
+
+	t.Run("OrderStored_SagaCompleted", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := newProducts(t)
+		sagas := newMemorySagas()
+		uc := NewOrderUsecase(mockRepo, sagas, newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		mockProductClient.On("ReserveStock", mock.Anything, mock.Anything, int64(1), 1).Return(nil)
+		mockProductClient.On("ReserveStock", mock.Anything, mock.Anything, int64(2), 3).Return(nil)
+		mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(o *domain.Order) bool {
+			return o.SagaID != nil && *o.SagaID == 1
+		})).Run(func(args mock.Arguments) {
+			args.Get(1).(*domain.Order).ID = 42
+		}).Return(nil)
+
+		_, err := uc.CreateOrder(context.Background(), CreateOrderInput{UserID: 101, Items: items})
+
+		assert.NoError(t, err)
+		saga := sagas.saved[1]
+		assert.Equal(t, domain.SagaCompleted, saga.Status)
+		assert.Equal(t, int64(42), *saga.OrderID)
+		assert.Equal(t, []domain.SagaStep{
+			{Seq: 1, Action: domain.SagaReserveStock, ProductID: 1, Quantity: 1, Status: domain.StepReserved},
+			{Seq: 2, Action: domain.SagaReserveStock, ProductID: 2, Quantity: 3, Status: domain.StepReserved},
+		}, saga.Steps)
+	})
+
+	t.Run("OrderNotStored_ReservationsReleased", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := newProducts(t)
+		sagas := newMemorySagas()
+		uc := NewOrderUsecase(mockRepo, sagas, newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		mockProductClient.On("ReserveStock", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("Create", mock.Anything, mock.Anything).Return(assert.AnError)
+		mockProductClient.On("ReleaseReservation", mock.Anything, "saga-1-1").Return(nil)
+		mockProductClient.On("ReleaseReservation", mock.Anything, "saga-1-2").Return(nil)
+
+		_, err := uc.CreateOrder(context.Background(), CreateOrderInput{UserID: 101, Items: items})
+
+		assert.ErrorIs(t, err, pkgerrors.ErrInternal)
+		saga := sagas.saved[1]
+		assert.Equal(t, domain.SagaFailed, saga.Status)
+		assert.Equal(t, domain.CompensationDone, saga.Compensation)
+		assert.Empty(t, saga.Releasing())
+	})
+
+	t.Run("ReleaseFails_LeftForRetry", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := newProducts(t)
+		sagas := newMemorySagas()
+		uc := NewOrderUsecase(mockRepo, sagas, newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		mockProductClient.On("ReserveStock", mock.Anything, mock.Anything, int64(1), 1).Return(nil)
+		mockProductClient.On("ReserveStock", mock.Anything, mock.Anything, int64(2), 3).Return(pkgerrors.ErrInsufficientStock)
+		mockProductClient.On("ReleaseReservation", mock.Anything, "saga-1-1").Return(pkgerrors.ErrUnavailable)
+
+		_, err := uc.CreateOrder(context.Background(), CreateOrderInput{UserID: 101, Items: items})
+
+		assert.ErrorIs(t, err, pkgerrors.ErrInsufficientStock)
+		saga := sagas.saved[1]
+		assert.Equal(t, domain.SagaFailed, saga.Status)
+		assert.Equal(t, domain.CompensationPending, saga.Compensation)
+		assert.Equal(t, 1, saga.Attempts)
+		assert.NotNil(t, saga.NextAttemptAt)
+		assert.Equal(t, []domain.SagaStep{
+			{Seq: 1, Action: domain.SagaReserveStock, ProductID: 1, Quantity: 1, Status: domain.StepReleasing},
+			{Seq: 2, Action: domain.SagaReserveStock, ProductID: 2, Quantity: 3, Status: domain.StepFailed},
+		}, saga.Steps)
+	})
+
+	t.Run("OutcomeUnknown_StepReleasedByKey", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := newProducts(t)
+		sagas := newMemorySagas()
+		uc := NewOrderUsecase(mockRepo, sagas, newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		mockProductClient.On("ReserveStock", mock.Anything, "saga-1-1", int64(1), 1).Return(nil)
+		mockProductClient.On("ReserveStock", mock.Anything, "saga-1-2", int64(2), 3).Return(context.DeadlineExceeded)
+		mockProductClient.On("ReleaseReservation", mock.Anything, "saga-1-1").Return(nil)
+		mockProductClient.On("ReleaseReservation", mock.Anything, "saga-1-2").Return(nil)
+
+		_, err := uc.CreateOrder(context.Background(), CreateOrderInput{UserID: 101, Items: items})
+
+		assert.Error(t, err)
+		saga := sagas.saved[1]
+		assert.Equal(t, domain.CompensationDone, saga.Compensation)
+		assert.Equal(t, []domain.SagaStep{
+			{Seq: 1, Action: domain.SagaReserveStock, ProductID: 1, Quantity: 1, Status: domain.StepReleased},
+			{Seq: 2, Action: domain.SagaReserveStock, ProductID: 2, Quantity: 3, Status: domain.StepReleased},
+		}, saga.Steps)
+	})
+
+	t.Run("StepNotLogged_NotReserved", func(t *testing.T) {
+		mockRepo := mocks.NewOrderRepository(t)
+		mockProductClient := newProducts(t)
+		mockSagas := mocks.NewSagaRepository(t)
+		uc := NewOrderUsecase(mockRepo, mockSagas, newMemoryTasks(), mockProductClient, nil, nil, &domain.TaxTable{}, &domain.ShippingRateTable{}, nil, nil, timeout)
+
+		mockSagas.On("Create", mock.Anything, mock.Anything).Return(nil)
+		mockSagas.On("Save", mock.Anything, mock.Anything).Return(pkgerrors.ErrInternal)
+
+		_, err := uc.CreateOrder(context.Background(), CreateOrderInput{UserID: 101, Items: items})
+
+		assert.ErrorIs(t, err, pkgerrors.ErrInternal)
+		mockProductClient.AssertNotCalled(t, "ReserveStock", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestOrderUsecase_RecoverSagas(t *testing.T) {
+	logger.Init()
+	timeout := 5 * time.Second
+	staleAfter := time.Minute
+
+	abandoned := func() *domain.PlacementSaga {
+		saga := domain.NewPlacementSaga(time.Now().Add(-time.Hour))
+		saga.ID = 9
+		saga.Begin(domain.SagaReserveStock, domain.StockLine{ProductID: 1, Quantity: 2}, saga.CreatedAt)
+		saga.Settle(true, saga.CreatedAt)
+		saga.Begin(domain.SagaReserveStock, domain.StockLine{ProductID: 2, Quantity: 1}, saga.CreatedAt)
+		return saga
+	}
+
+	t.Run("AbandonedWithoutOrder_AllStepsReleased", func(t *testing.T) {
+		mockSagas := mocks.NewSagaRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewOrderUsecase(nil, mockSagas, newMemoryTasks(), mockProductClient, nil, nil, nil, nil, nil, nil, timeout)
+		saga := abandoned()
+
+		mockSagas.On("FindRecoverable", mock.Anything, mock.Anything, mock.Anything, 10).Return([]int64{9}, nil)
+		mockSagas.On("GetByID", mock.Anything, int64(9)).Return(saga, nil)
+		mockSagas.On("FindOrderID", mock.Anything, int64(9)).Return(int64(0), pkgerrors.ErrNotFound)
+		mockSagas.On("Save", mock.Anything, saga).Return(nil)
+		mockProductClient.On("ReleaseReservation", mock.Anything, "saga-9-1").Return(nil)
+		// The interrupted reservation of product 2 is released by its key
+		// too, whether or not it landed
+		mockProductClient.On("ReleaseReservation", mock.Anything, "saga-9-2").Return(nil)
+
+		settled, err := uc.RecoverSagas(context.Background(), staleAfter, 10)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{9}, settled)
+		assert.Equal(t, domain.SagaFailed, saga.Status)
+		assert.Equal(t, domain.CompensationDone, saga.Compensation)
+		assert.Empty(t, saga.Unsettled())
+	})
+
+	t.Run("AbandonedAfterOrderStored_Completed", func(t *testing.T) {
+		mockSagas := mocks.NewSagaRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewOrderUsecase(nil, mockSagas, newMemoryTasks(), mockProductClient, nil, nil, nil, nil, nil, nil, timeout)
+		saga := abandoned()
+
+		mockSagas.On("FindRecoverable", mock.Anything, mock.Anything, mock.Anything, 10).Return([]int64{9}, nil)
+		mockSagas.On("GetByID", mock.Anything, int64(9)).Return(saga, nil)
+		mockSagas.On("FindOrderID", mock.Anything, int64(9)).Return(int64(42), nil)
+		mockSagas.On("Save", mock.Anything, saga).Return(nil)
+
+		settled, err := uc.RecoverSagas(context.Background(), staleAfter, 10)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{9}, settled)
+		assert.Equal(t, domain.SagaCompleted, saga.Status)
+		assert.Equal(t, int64(42), *saga.OrderID)
+		mockProductClient.AssertNotCalled(t, "ReleaseReservation", mock.Anything, mock.Anything)
+	})
+
+	t.Run("CompensationStillFailing_BacksOff", func(t *testing.T) {
+		mockSagas := mocks.NewSagaRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewOrderUsecase(nil, mockSagas, newMemoryTasks(), mockProductClient, nil, nil, nil, nil, nil, nil, timeout)
+		saga := abandoned()
+		saga.Settle(false, saga.CreatedAt)
+		saga.Fail(saga.CreatedAt)
+		saga.CompensationAttempted(assert.AnError, saga.CreatedAt)
+
+		mockSagas.On("FindRecoverable", mock.Anything, mock.Anything, mock.Anything, 10).Return([]int64{9}, nil)
+		mockSagas.On("GetByID", mock.Anything, int64(9)).Return(saga, nil)
+		mockSagas.On("Save", mock.Anything, saga).Return(nil)
+		mockProductClient.On("ReleaseReservation", mock.Anything, "saga-9-1").Return(pkgerrors.ErrUnavailable)
+
+		settled, err := uc.RecoverSagas(context.Background(), staleAfter, 10)
+
+		assert.NoError(t, err)
+		assert.Empty(t, settled)
+		assert.Equal(t, domain.CompensationPending, saga.Compensation)
+		assert.Equal(t, 2, saga.Attempts)
+		assert.WithinDuration(t, time.Now().Add(2*time.Minute), *saga.NextAttemptAt, time.Second)
+	})
+}
@@ -0,0 +1,61 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+)
+
+//go:generate mockery --name QuoteUsecase
+type QuoteUsecase interface {
+	// CreateQuote prices the items as CreateOrder would and locks those
+	// prices for the configured window. No stock is reserved.
+	CreateQuote(ctx context.Context, userID int64, items []OrderItemInput, region string) (*domain.Quote, error)
+	GetQuote(ctx context.Context, id int64) (*domain.Quote, error)
+}
+
+type quoteUsecase struct {
+	repo           domain.QuoteRepository
+	productClient  domain.ProductClient
+	taxes          domain.TaxCalculator
+	shipping       domain.ShippingCalculator
+	ttl            time.Duration
+	contextTimeout time.Duration
+}
+
+func NewQuoteUsecase(repo domain.QuoteRepository, pClient domain.ProductClient, taxes domain.TaxCalculator, shipping domain.ShippingCalculator, ttl, timeout time.Duration) QuoteUsecase {
+	return &quoteUsecase{
+		repo:           repo,
+		productClient:  pClient,
+		taxes:          taxes,
+		shipping:       shipping,
+		ttl:            ttl,
+		contextTimeout: timeout,
+	}
+}
+
+func (u *quoteUsecase) CreateQuote(ctx context.Context, userID int64, items []OrderItemInput, region string) (*domain.Quote, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	order, err := priceOrder(ctx, u.productClient, u.taxes, u.shipping, userID, items, region)
+	if err != nil {
+		return nil, err
+	}
+	quote, err := domain.NewQuote(order, u.ttl, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.repo.Create(ctx, quote); err != nil {
+		return nil, err
+	}
+	return quote, nil
+}
+
+func (u *quoteUsecase) GetQuote(ctx context.Context, id int64) (*domain.Quote, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+	return u.repo.GetByID(ctx, id)
+}
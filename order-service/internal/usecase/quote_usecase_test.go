@@ -0,0 +1,53 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/order-service/internal/domain/mocks"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+	"github.com/user/go-microservices/pkg/valueobject"
+)
+
+func TestQuoteUsecase_CreateQuote(t *testing.T) {
+	logger.Init()
+	timeout := 5 * time.Second
+
+	t.Run("Success_LocksCurrentPrices", func(t *testing.T) {
+		mockRepo := mocks.NewQuoteRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		mockTaxes := mocks.NewTaxCalculator(t)
+		uc := NewQuoteUsecase(mockRepo, mockProductClient, mockTaxes, &domain.ShippingRateTable{}, 15*time.Minute, timeout)
+
+		mockProductClient.On("GetProduct", mock.Anything, int64(1)).
+			Return(&domain.ProductView{ID: 1, Name: "A", Price: valueobject.NewMoney(100), TaxCategory: "STANDARD"}, nil)
+		mockTaxes.On("Rate", "US-CA", "STANDARD").Return(10.0, nil)
+		mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Quote")).Return(nil)
+
+		before := time.Now()
+		quote, err := uc.CreateQuote(context.Background(), 101, []OrderItemInput{{ProductID: 1, Quantity: 2}}, "US-CA")
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(101), quote.UserID)
+		assert.Equal(t, valueobject.NewMoney(220), quote.TotalPrice)
+		assert.False(t, quote.ExpiresAt.Before(before.Add(15*time.Minute)))
+		mockProductClient.AssertNotCalled(t, "ReserveStock", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("ProductNotFound", func(t *testing.T) {
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewQuoteUsecase(mocks.NewQuoteRepository(t), mockProductClient, &domain.TaxTable{}, &domain.ShippingRateTable{}, 15*time.Minute, timeout)
+
+		mockProductClient.On("GetProduct", mock.Anything, int64(9)).Return(nil, pkgerrors.ErrNotFound)
+
+		quote, err := uc.CreateQuote(context.Background(), 101, []OrderItemInput{{ProductID: 9, Quantity: 1}}, "")
+
+		assert.ErrorIs(t, err, pkgerrors.ErrNotFound)
+		assert.Nil(t, quote)
+	})
+}
@@ -0,0 +1,164 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+)
+
+//go:generate mockery --name ReturnUsecase
+type ReturnUsecase interface {
+	// RequestReturn opens a return; only ProductID and Quantity of each item are read
+	RequestReturn(ctx context.Context, orderID int64, items []domain.ReturnItem, reason string) (*domain.Return, error)
+	ApproveReturn(ctx context.Context, orderID, returnID int64) (*domain.Return, error)
+	RejectReturn(ctx context.Context, orderID, returnID int64, note string) (*domain.Return, error)
+	// ReceiveReturn records the receipt, then restocks the returned units and
+	// refunds their value
+	ReceiveReturn(ctx context.Context, orderID, returnID int64) (*domain.Return, error)
+	GetReturns(ctx context.Context, orderID int64) ([]*domain.Return, error)
+}
+
+type returnUsecase struct {
+	returns        domain.ReturnRepository
+	orders         domain.OrderRepository
+	tasks          *taskUsecase
+	contextTimeout time.Duration
+}
+
+func NewReturnUsecase(returns domain.ReturnRepository, orders domain.OrderRepository, tasks domain.TaskRepository, pClient domain.ProductClient, payments domain.PaymentGateway, timeout time.Duration) ReturnUsecase {
+	return &returnUsecase{
+		returns:        returns,
+		orders:         orders,
+		tasks:          newTaskUsecase(tasks, pClient, payments, timeout),
+		contextTimeout: timeout,
+	}
+}
+
+func (u *returnUsecase) RequestReturn(ctx context.Context, orderID int64, items []domain.ReturnItem, reason string) (*domain.Return, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	order, err := u.orders.GetByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := u.returns.GetByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	ret, err := order.RequestReturn(items, previous, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.returns.Create(ctx, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (u *returnUsecase) ApproveReturn(ctx context.Context, orderID, returnID int64) (*domain.Return, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	ret, err := u.getReturn(ctx, orderID, returnID)
+	if err != nil {
+		return nil, err
+	}
+
+	from := ret.Status
+	if err := ret.Approve(); err != nil {
+		return nil, err
+	}
+	if err := u.returns.UpdateStatus(ctx, ret, from); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (u *returnUsecase) RejectReturn(ctx context.Context, orderID, returnID int64, note string) (*domain.Return, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	ret, err := u.getReturn(ctx, orderID, returnID)
+	if err != nil {
+		return nil, err
+	}
+
+	from := ret.Status
+	if err := ret.Reject(note); err != nil {
+		return nil, err
+	}
+	if err := u.returns.UpdateStatus(ctx, ret, from); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (u *returnUsecase) ReceiveReturn(ctx context.Context, orderID, returnID int64) (*domain.Return, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	order, err := u.orders.GetByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	ret, err := u.getReturn(ctx, orderID, returnID)
+	if err != nil {
+		return nil, err
+	}
+
+	from := order.State()
+	refund, err := order.ReceiveReturn(ret)
+	if err != nil {
+		return nil, err
+	}
+
+	// The receipt is guarded on the return's status, so only one of two
+	// concurrent receipts is stored; the restock and the refund are written
+	// as tasks alongside it and run once it is
+	lines := make([]domain.StockLine, 0, len(ret.Items))
+	for _, item := range ret.Items {
+		lines = append(lines, domain.StockLine{ProductID: item.ProductID, Quantity: item.Quantity})
+	}
+	tasks := domain.StockLineTasks(order.ID, domain.TaskRestock, lines)
+
+	var change *domain.StatusChange
+	if refund != nil {
+		change = domain.NewStatusChange(order, from, refund.Reason, domain.ActorFromContext(ctx))
+		change.Tasks = domain.RefundTasks(order, refund)
+	}
+	if err := u.returns.Receive(ctx, ret, refund, change, tasks); err != nil {
+		return nil, err
+	}
+	if change != nil {
+		tasks = append(tasks, change.Tasks...)
+	}
+	u.tasks.runCommitted(ctx, tasks)
+	return ret, nil
+}
+
+func (u *returnUsecase) GetReturns(ctx context.Context, orderID int64) ([]*domain.Return, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	if _, err := u.orders.GetByID(ctx, orderID); err != nil {
+		return nil, err
+	}
+	return u.returns.GetByOrderID(ctx, orderID)
+}
+
+// getReturn loads a return and makes sure it belongs to the order in the path
+func (u *returnUsecase) getReturn(ctx context.Context, orderID, returnID int64) (*domain.Return, error) {
+	ret, err := u.returns.GetByID(ctx, returnID)
+	if err != nil {
+		return nil, err
+	}
+	if ret.OrderID != orderID {
+		return nil, pkgerrors.ErrNotFound
+	}
+	return ret, nil
+}
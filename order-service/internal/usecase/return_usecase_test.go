@@ -0,0 +1,193 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/order-service/internal/domain/mocks"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+	"github.com/user/go-microservices/pkg/valueobject"
+)
+
+// newCompletedOrder builds order 1 as delivered and completed
+func newCompletedOrder(t *testing.T) *domain.Order {
+	order := newTestOrder(t)
+	order.ID = 1
+	order.OrderStatus = domain.OrderCompleted
+	order.PaymentStatus = domain.PaymentPaid
+	return order
+}
+
+func TestReturnUsecase_RequestReturn(t *testing.T) {
+	logger.Init()
+	timeout := 5 * time.Second
+
+	t.Run("Success", func(t *testing.T) {
+		mockReturns := mocks.NewReturnRepository(t)
+		mockOrders := mocks.NewOrderRepository(t)
+		uc := NewReturnUsecase(mockReturns, mockOrders, newMemoryTasks(), mocks.NewProductClient(t), nil, timeout)
+
+		mockOrders.On("GetByID", mock.Anything, int64(1)).Return(newCompletedOrder(t), nil)
+		mockReturns.On("GetByOrderID", mock.Anything, int64(1)).Return([]*domain.Return{}, nil)
+		mockReturns.On("Create", mock.Anything, mock.MatchedBy(func(r *domain.Return) bool {
+			return r.OrderID == 1 && r.Status == domain.ReturnRequested && r.Amount == valueobject.NewMoney(100)
+		})).Return(nil)
+
+		ret, err := uc.RequestReturn(context.Background(), 1, []domain.ReturnItem{{ProductID: 1, Quantity: 1}}, "damaged")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "damaged", ret.Reason)
+	})
+
+	t.Run("AlreadyReturned", func(t *testing.T) {
+		mockReturns := mocks.NewReturnRepository(t)
+		mockOrders := mocks.NewOrderRepository(t)
+		uc := NewReturnUsecase(mockReturns, mockOrders, newMemoryTasks(), mocks.NewProductClient(t), nil, timeout)
+
+		mockOrders.On("GetByID", mock.Anything, int64(1)).Return(newCompletedOrder(t), nil)
+		mockReturns.On("GetByOrderID", mock.Anything, int64(1)).Return([]*domain.Return{
+			{ID: 3, OrderID: 1, Status: domain.ReturnApproved, Items: []domain.ReturnItem{{ProductID: 1, Quantity: 2}}},
+		}, nil)
+
+		ret, err := uc.RequestReturn(context.Background(), 1, []domain.ReturnItem{{ProductID: 1, Quantity: 1}}, "")
+
+		assert.ErrorIs(t, err, domain.ErrReturnExceedsOrdered)
+		assert.Nil(t, ret)
+	})
+}
+
+func TestReturnUsecase_ApproveReturn(t *testing.T) {
+	logger.Init()
+	timeout := 5 * time.Second
+
+	t.Run("Success", func(t *testing.T) {
+		mockReturns := mocks.NewReturnRepository(t)
+		uc := NewReturnUsecase(mockReturns, mocks.NewOrderRepository(t), newMemoryTasks(), mocks.NewProductClient(t), nil, timeout)
+
+		mockReturns.On("GetByID", mock.Anything, int64(3)).Return(&domain.Return{ID: 3, OrderID: 1, Status: domain.ReturnRequested}, nil)
+		mockReturns.On("UpdateStatus", mock.Anything, mock.MatchedBy(func(r *domain.Return) bool {
+			return r.Status == domain.ReturnApproved
+		}), domain.ReturnRequested).Return(nil)
+
+		ret, err := uc.ApproveReturn(context.Background(), 1, 3)
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.ReturnApproved, ret.Status)
+	})
+
+	t.Run("OtherOrder", func(t *testing.T) {
+		mockReturns := mocks.NewReturnRepository(t)
+		uc := NewReturnUsecase(mockReturns, mocks.NewOrderRepository(t), newMemoryTasks(), mocks.NewProductClient(t), nil, timeout)
+
+		mockReturns.On("GetByID", mock.Anything, int64(3)).Return(&domain.Return{ID: 3, OrderID: 2, Status: domain.ReturnRequested}, nil)
+
+		ret, err := uc.ApproveReturn(context.Background(), 1, 3)
+
+		assert.ErrorIs(t, err, pkgerrors.ErrNotFound)
+		assert.Nil(t, ret)
+	})
+}
+
+func TestReturnUsecase_ReceiveReturn(t *testing.T) {
+	logger.Init()
+	timeout := 5 * time.Second
+
+	approved := func() *domain.Return {
+		return &domain.Return{
+			ID:      3,
+			OrderID: 1,
+			Status:  domain.ReturnApproved,
+			Items:   []domain.ReturnItem{{ProductID: 1, Quantity: 1, UnitPrice: valueobject.NewMoney(100), Amount: valueobject.NewMoney(100)}},
+			Amount:  valueobject.NewMoney(100),
+		}
+	}
+
+	t.Run("Success_RestocksAndRefunds", func(t *testing.T) {
+		mockReturns := mocks.NewReturnRepository(t)
+		mockOrders := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewReturnUsecase(mockReturns, mockOrders, newMemoryTasks(), mockProductClient, nil, timeout)
+
+		mockOrders.On("GetByID", mock.Anything, int64(1)).Return(newCompletedOrder(t), nil)
+		mockReturns.On("GetByID", mock.Anything, int64(3)).Return(approved(), nil)
+		mockReturns.On("Receive", mock.Anything,
+			mock.MatchedBy(func(r *domain.Return) bool { return r.Status == domain.ReturnReceived }),
+			mock.MatchedBy(func(rf *domain.Refund) bool { return rf.Amount == valueobject.NewMoney(100) }),
+			mock.MatchedBy(func(c *domain.StatusChange) bool {
+				return c.FromPaymentStatus == domain.PaymentPaid && c.ToPaymentStatus == domain.PaymentPartiallyRefunded
+			}),
+			mock.MatchedBy(func(tasks []*domain.OrderTask) bool {
+				return len(tasks) == 1 && tasks[0].Kind == domain.TaskRestock
+			}),
+		).Run(func(args mock.Arguments) {
+			args.Get(4).([]*domain.OrderTask)[0].ID = 11
+		}).Return(nil)
+		mockProductClient.On("Restock", mock.Anything, "order-task-11", int64(1), 1).Return(nil)
+
+		ret, err := uc.ReceiveReturn(context.Background(), 1, 3)
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.ReturnReceived, ret.Status)
+	})
+
+	t.Run("AlreadyReceived_RestocksNothing", func(t *testing.T) {
+		mockReturns := mocks.NewReturnRepository(t)
+		mockOrders := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewReturnUsecase(mockReturns, mockOrders, newMemoryTasks(), mockProductClient, nil, timeout)
+
+		mockOrders.On("GetByID", mock.Anything, int64(1)).Return(newCompletedOrder(t), nil)
+		mockReturns.On("GetByID", mock.Anything, int64(3)).Return(approved(), nil)
+		// A concurrent receipt of the same return was stored first
+		mockReturns.On("Receive", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(pkgerrors.ErrConflict)
+
+		ret, err := uc.ReceiveReturn(context.Background(), 1, 3)
+
+		assert.ErrorIs(t, err, pkgerrors.ErrConflict)
+		assert.Nil(t, ret)
+		mockProductClient.AssertNotCalled(t, "Restock", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("RestockFailure_LeftForRetry", func(t *testing.T) {
+		mockReturns := mocks.NewReturnRepository(t)
+		mockOrders := mocks.NewOrderRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		tasks := newMemoryTasks()
+		uc := NewReturnUsecase(mockReturns, mockOrders, tasks, mockProductClient, nil, timeout)
+
+		mockOrders.On("GetByID", mock.Anything, int64(1)).Return(newCompletedOrder(t), nil)
+		mockReturns.On("GetByID", mock.Anything, int64(3)).Return(approved(), nil)
+		mockReturns.On("Receive", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			args.Get(4).([]*domain.OrderTask)[0].ID = 11
+		}).Return(nil)
+		mockProductClient.On("Restock", mock.Anything, "order-task-11", int64(1), 1).Return(assert.AnError)
+
+		ret, err := uc.ReceiveReturn(context.Background(), 1, 3)
+
+		// The receipt stands; the restock is retried by the task runner
+		assert.NoError(t, err)
+		assert.Equal(t, domain.ReturnReceived, ret.Status)
+		assert.Equal(t, 1, tasks.attempts[11])
+	})
+
+	t.Run("NotApproved", func(t *testing.T) {
+		mockReturns := mocks.NewReturnRepository(t)
+		mockOrders := mocks.NewOrderRepository(t)
+		uc := NewReturnUsecase(mockReturns, mockOrders, newMemoryTasks(), mocks.NewProductClient(t), nil, timeout)
+
+		requested := approved()
+		requested.Status = domain.ReturnRequested
+		mockOrders.On("GetByID", mock.Anything, int64(1)).Return(newCompletedOrder(t), nil)
+		mockReturns.On("GetByID", mock.Anything, int64(3)).Return(requested, nil)
+
+		ret, err := uc.ReceiveReturn(context.Background(), 1, 3)
+
+		assert.ErrorIs(t, err, pkgerrors.ErrConflict)
+		assert.Nil(t, ret)
+	})
+}
@@ -0,0 +1,201 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// CreateSubscriptionInput describes a recurring order
+type CreateSubscriptionInput struct {
+	UserID       int64
+	ProductID    int64
+	Quantity     int
+	Region       string
+	IntervalDays int
+	// FirstRunAt is when the first order is placed; zero means right away
+	FirstRunAt time.Time
+}
+
+//go:generate mockery --name SubscriptionUsecase
+type SubscriptionUsecase interface {
+	CreateSubscription(ctx context.Context, in CreateSubscriptionInput) (*domain.Subscription, error)
+	GetSubscription(ctx context.Context, id int64) (*domain.Subscription, error)
+	PauseSubscription(ctx context.Context, id int64) (*domain.Subscription, error)
+	ResumeSubscription(ctx context.Context, id int64) (*domain.Subscription, error)
+	// SkipSubscription drops the next delivery without placing an order
+	SkipSubscription(ctx context.Context, id int64) (*domain.Subscription, error)
+	CancelSubscription(ctx context.Context, id int64) (*domain.Subscription, error)
+	GetSubscriptionOrders(ctx context.Context, id int64) ([]*domain.SubscriptionOrder, error)
+	// RunDueSubscriptions places orders for up to limit due subscriptions
+	// and returns the IDs of the orders placed
+	RunDueSubscriptions(ctx context.Context, limit int) ([]int64, error)
+}
+
+type subscriptionUsecase struct {
+	repo           domain.SubscriptionRepository
+	orders         OrderUsecase
+	productClient  domain.ProductClient
+	retry          domain.RetryPolicy
+	contextTimeout time.Duration
+}
+
+func NewSubscriptionUsecase(repo domain.SubscriptionRepository, orders OrderUsecase, pClient domain.ProductClient, retry domain.RetryPolicy, timeout time.Duration) SubscriptionUsecase {
+	return &subscriptionUsecase{
+		repo:           repo,
+		orders:         orders,
+		productClient:  pClient,
+		retry:          retry,
+		contextTimeout: timeout,
+	}
+}
+
+func (u *subscriptionUsecase) CreateSubscription(ctx context.Context, in CreateSubscriptionInput) (*domain.Subscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	sub, err := domain.NewSubscription(in.UserID, in.ProductID, in.Quantity, in.IntervalDays, in.Region, in.FirstRunAt, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	// Reject unknown products now rather than on every run
+	if _, err := u.productClient.GetProduct(ctx, in.ProductID); err != nil {
+		return nil, err
+	}
+	if err := u.repo.Create(ctx, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (u *subscriptionUsecase) GetSubscription(ctx context.Context, id int64) (*domain.Subscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+	return u.repo.GetByID(ctx, id)
+}
+
+func (u *subscriptionUsecase) PauseSubscription(ctx context.Context, id int64) (*domain.Subscription, error) {
+	return u.modify(ctx, id, (*domain.Subscription).Pause)
+}
+
+func (u *subscriptionUsecase) ResumeSubscription(ctx context.Context, id int64) (*domain.Subscription, error) {
+	return u.modify(ctx, id, (*domain.Subscription).Resume)
+}
+
+func (u *subscriptionUsecase) SkipSubscription(ctx context.Context, id int64) (*domain.Subscription, error) {
+	return u.modify(ctx, id, (*domain.Subscription).Skip)
+}
+
+func (u *subscriptionUsecase) CancelSubscription(ctx context.Context, id int64) (*domain.Subscription, error) {
+	return u.modify(ctx, id, (*domain.Subscription).Cancel)
+}
+
+// modify applies change to the subscription and stores it, failing with
+// ErrConflict if the subscription changed in the meantime
+func (u *subscriptionUsecase) modify(ctx context.Context, id int64, change func(*domain.Subscription, time.Time) error) (*domain.Subscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	sub, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	lastUpdated := sub.UpdatedAt
+	if err := change(sub, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := u.repo.Update(ctx, sub, lastUpdated); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (u *subscriptionUsecase) GetSubscriptionOrders(ctx context.Context, id int64) ([]*domain.SubscriptionOrder, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	if _, err := u.repo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return u.repo.GetOrders(ctx, id)
+}
+
+func (u *subscriptionUsecase) RunDueSubscriptions(ctx context.Context, limit int) ([]int64, error) {
+	findCtx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	ids, err := u.repo.FindDue(findCtx, time.Now(), limit)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+
+	placed := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		orderID, err := u.run(ctx, id)
+		if err != nil {
+			logger.FromContext(ctx).Error("subscription run failed", zap.Int64("subscription_id", id), zap.Error(err))
+			continue
+		}
+		if orderID != 0 {
+			placed = append(placed, orderID)
+		}
+	}
+	return placed, nil
+}
+
+// run claims the subscription's due run and places its order. An order that
+// cannot be placed is retried under the retry policy. It returns the ID of
+// the order placed, or zero if none was.
+func (u *subscriptionUsecase) run(ctx context.Context, id int64) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	sub, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+
+	// Claim the run first so a concurrent change or a crash after ordering
+	// cannot lead to a second order for it
+	lastUpdated := sub.UpdatedAt
+	if err := sub.Claim(time.Now()); err != nil {
+		// Paused, cancelled or rescheduled since it was selected
+		if errors.Is(err, domain.ErrSubscriptionState) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	if err := u.repo.Update(ctx, sub, lastUpdated); err != nil {
+		return 0, err
+	}
+
+	order, err := u.orders.CreateOrder(domain.WithActor(ctx, domain.SubscriptionActor), CreateOrderInput{
+		UserID:         sub.UserID,
+		Items:          []OrderItemInput{{ProductID: sub.ProductID, Quantity: sub.Quantity}},
+		Region:         sub.Region,
+		SubscriptionID: sub.ID,
+	})
+	lastUpdated = sub.UpdatedAt
+	if err != nil {
+		gaveUp := sub.RecordFailure(u.retry, time.Now())
+		logger.FromContext(ctx).Warn("subscription order not placed",
+			zap.Int64("subscription_id", sub.ID), zap.Bool("out_of_stock", errors.Is(err, pkgerrors.ErrInsufficientStock)),
+			zap.Int("failed_attempts", sub.FailedAttempts), zap.Bool("skipped", gaveUp), zap.Error(err))
+		if uerr := u.repo.Update(ctx, sub, lastUpdated); uerr != nil {
+			logger.FromContext(ctx).Error("failed to schedule subscription retry", zap.Int64("subscription_id", sub.ID), zap.Error(uerr))
+		}
+		return 0, nil
+	}
+
+	sub.RecordOrder(order.ID, time.Now())
+	if err := u.repo.Update(ctx, sub, lastUpdated); err != nil {
+		// The order already carries the subscription ID, only the summary is stale
+		logger.FromContext(ctx).Error("order placed but not recorded on its subscription",
+			zap.Int64("subscription_id", sub.ID), zap.Int64("order_id", order.ID), zap.Error(err))
+	}
+	return order.ID, nil
+}
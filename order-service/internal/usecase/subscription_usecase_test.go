@@ -0,0 +1,149 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/order-service/internal/domain/mocks"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+)
+
+func newTestSubscription(due time.Time) *domain.Subscription {
+	updated := due.Add(-time.Hour)
+	return &domain.Subscription{
+		ID: 7, UserID: 101, ProductID: 1, Quantity: 2, Region: "US-CA", IntervalDays: 30,
+		Status: domain.SubscriptionActive, NextRunAt: due, CreatedAt: updated, UpdatedAt: updated,
+	}
+}
+
+func TestSubscriptionUsecase(t *testing.T) {
+	logger.Init()
+	timeout := 5 * time.Second
+	policy := domain.RetryPolicy{MaxAttempts: 3, Backoff: time.Hour}
+
+	t.Run("CreateSubscription_UnknownProduct", func(t *testing.T) {
+		mockRepo := mocks.NewSubscriptionRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewSubscriptionUsecase(mockRepo, nil, mockProductClient, policy, timeout)
+
+		mockProductClient.On("GetProduct", mock.Anything, int64(1)).Return(nil, pkgerrors.ErrNotFound)
+
+		_, err := uc.CreateSubscription(context.Background(), CreateSubscriptionInput{UserID: 101, ProductID: 1, Quantity: 2, IntervalDays: 30})
+
+		assert.ErrorIs(t, err, pkgerrors.ErrNotFound)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("RunDue_PlacesLinkedOrder", func(t *testing.T) {
+		mockRepo := mocks.NewSubscriptionRepository(t)
+		orders := &stubOrders{order: &domain.Order{ID: 42}}
+		uc := NewSubscriptionUsecase(mockRepo, orders, nil, policy, timeout)
+		sub := newTestSubscription(time.Now().Add(-time.Minute))
+		lastUpdated := sub.UpdatedAt
+
+		mockRepo.On("FindDue", mock.Anything, mock.Anything, 10).Return([]int64{7}, nil)
+		mockRepo.On("GetByID", mock.Anything, int64(7)).Return(sub, nil)
+		// The claim is stored before the order is placed
+		mockRepo.On("Update", mock.Anything, sub, lastUpdated).Run(func(args mock.Arguments) {
+			assert.Empty(t, orders.created)
+		}).Return(nil).Once()
+		mockRepo.On("Update", mock.Anything, sub, mock.MatchedBy(func(t time.Time) bool { return t.After(lastUpdated) })).Return(nil).Once()
+
+		placed, err := uc.RunDueSubscriptions(context.Background(), 10)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{42}, placed)
+		assert.Len(t, orders.created, 1)
+		assert.Equal(t, int64(7), orders.created[0].SubscriptionID)
+		assert.Equal(t, []OrderItemInput{{ProductID: 1, Quantity: 2}}, orders.created[0].Items)
+		assert.Equal(t, int64(42), *sub.LastOrderID)
+		assert.True(t, sub.NextRunAt.After(time.Now()))
+	})
+
+	t.Run("RunDue_OutOfStock_SchedulesRetry", func(t *testing.T) {
+		mockRepo := mocks.NewSubscriptionRepository(t)
+		orders := &stubOrders{err: pkgerrors.ErrInsufficientStock}
+		uc := NewSubscriptionUsecase(mockRepo, orders, nil, policy, timeout)
+		sub := newTestSubscription(time.Now().Add(-time.Minute))
+
+		mockRepo.On("FindDue", mock.Anything, mock.Anything, 10).Return([]int64{7}, nil)
+		mockRepo.On("GetByID", mock.Anything, int64(7)).Return(sub, nil)
+		mockRepo.On("Update", mock.Anything, sub, mock.Anything).Return(nil).Twice()
+
+		placed, err := uc.RunDueSubscriptions(context.Background(), 10)
+
+		assert.NoError(t, err)
+		assert.Empty(t, placed)
+		assert.Equal(t, 1, sub.FailedAttempts)
+		assert.NotNil(t, sub.RetryAt)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), *sub.RetryAt, time.Minute)
+	})
+
+	t.Run("RunDue_ClaimConflict_NoOrder", func(t *testing.T) {
+		mockRepo := mocks.NewSubscriptionRepository(t)
+		orders := &stubOrders{}
+		uc := NewSubscriptionUsecase(mockRepo, orders, nil, policy, timeout)
+		sub := newTestSubscription(time.Now().Add(-time.Minute))
+
+		mockRepo.On("FindDue", mock.Anything, mock.Anything, 10).Return([]int64{7}, nil)
+		mockRepo.On("GetByID", mock.Anything, int64(7)).Return(sub, nil)
+		mockRepo.On("Update", mock.Anything, sub, mock.Anything).Return(pkgerrors.ErrConflict)
+
+		placed, err := uc.RunDueSubscriptions(context.Background(), 10)
+
+		assert.NoError(t, err)
+		assert.Empty(t, placed)
+		assert.Empty(t, orders.created)
+	})
+
+	t.Run("RunDue_PausedSinceSelected_Skipped", func(t *testing.T) {
+		mockRepo := mocks.NewSubscriptionRepository(t)
+		orders := &stubOrders{}
+		uc := NewSubscriptionUsecase(mockRepo, orders, nil, policy, timeout)
+		sub := newTestSubscription(time.Now().Add(-time.Minute))
+		sub.Status = domain.SubscriptionPaused
+
+		mockRepo.On("FindDue", mock.Anything, mock.Anything, 10).Return([]int64{7}, nil)
+		mockRepo.On("GetByID", mock.Anything, int64(7)).Return(sub, nil)
+
+		placed, err := uc.RunDueSubscriptions(context.Background(), 10)
+
+		assert.NoError(t, err)
+		assert.Empty(t, placed)
+		assert.Empty(t, orders.created)
+	})
+
+	t.Run("PauseSubscription_GuardedUpdate", func(t *testing.T) {
+		mockRepo := mocks.NewSubscriptionRepository(t)
+		uc := NewSubscriptionUsecase(mockRepo, nil, nil, policy, timeout)
+		sub := newTestSubscription(time.Now().Add(time.Hour))
+		lastUpdated := sub.UpdatedAt
+
+		mockRepo.On("GetByID", mock.Anything, int64(7)).Return(sub, nil)
+		mockRepo.On("Update", mock.Anything, sub, lastUpdated).Return(nil)
+
+		res, err := uc.PauseSubscription(context.Background(), 7)
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.SubscriptionPaused, res.Status)
+	})
+
+	t.Run("CancelSubscription_AlreadyCancelled", func(t *testing.T) {
+		mockRepo := mocks.NewSubscriptionRepository(t)
+		uc := NewSubscriptionUsecase(mockRepo, nil, nil, policy, timeout)
+		sub := newTestSubscription(time.Now())
+		sub.Status = domain.SubscriptionCancelled
+
+		mockRepo.On("GetByID", mock.Anything, int64(7)).Return(sub, nil)
+
+		_, err := uc.CancelSubscription(context.Background(), 7)
+
+		assert.ErrorIs(t, err, pkgerrors.ErrConflict)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,131 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/pkg/logger"
+	"go.uber.org/zap"
+)
+
+//go:generate mockery --name TaskUsecase
+type TaskUsecase interface {
+	// RunDue runs up to limit tasks whose next attempt is due, oldest first,
+	// and returns the IDs of those that succeeded
+	RunDue(ctx context.Context, limit int) ([]int64, error)
+}
+
+type taskUsecase struct {
+	tasks          domain.TaskRepository
+	productClient  domain.ProductClient
+	payments       domain.PaymentGateway
+	contextTimeout time.Duration
+}
+
+func NewTaskUsecase(tasks domain.TaskRepository, pClient domain.ProductClient, payments domain.PaymentGateway, timeout time.Duration) TaskUsecase {
+	return newTaskUsecase(tasks, pClient, payments, timeout)
+}
+
+func newTaskUsecase(tasks domain.TaskRepository, pClient domain.ProductClient, payments domain.PaymentGateway, timeout time.Duration) *taskUsecase {
+	return &taskUsecase{
+		tasks:          tasks,
+		productClient:  pClient,
+		payments:       payments,
+		contextTimeout: timeout,
+	}
+}
+
+func (u *taskUsecase) RunDue(ctx context.Context, limit int) ([]int64, error) {
+	findCtx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	tasks, err := u.tasks.FindDue(findCtx, time.Now(), limit)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+
+	done := make([]int64, 0, len(tasks))
+	for _, t := range tasks {
+		if err := u.run(ctx, t); err != nil {
+			logger.FromContext(ctx).Warn("order task failed",
+				zap.Int64("task_id", t.ID), zap.Int64("order_id", t.OrderID), zap.String("kind", string(t.Kind)),
+				zap.Int("attempts", t.Attempts), zap.Error(err))
+			continue
+		}
+		done = append(done, t.ID)
+	}
+	return done, nil
+}
+
+// runCommitted runs the tasks written by a change that was just committed,
+// or scheduled ahead of one that can no longer be. The change stands or
+// falls either way, so failures are only logged and left for RunDue to
+// retry.
+func (u *taskUsecase) runCommitted(ctx context.Context, tasks []*domain.OrderTask) {
+	ctx = context.WithoutCancel(ctx)
+	for _, t := range tasks {
+		if err := u.run(ctx, t); err != nil {
+			logger.FromContext(ctx).Warn("order task failed; it will be retried",
+				zap.Int64("task_id", t.ID), zap.Int64("order_id", t.OrderID), zap.String("kind", string(t.Kind)), zap.Error(err))
+		}
+	}
+}
+
+// schedule writes tasks ahead of a change; see TaskRepository.Schedule
+func (u *taskUsecase) schedule(ctx context.Context, tasks []*domain.OrderTask) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	return u.tasks.Schedule(ctx, tasks)
+}
+
+// run carries out the task and records the outcome. A crash before the
+// outcome is recorded runs the task again, which its key makes harmless.
+func (u *taskUsecase) run(ctx context.Context, t *domain.OrderTask) error {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	if err := u.apply(ctx, t); err != nil {
+		t.Failed(err, time.Now())
+		if serr := u.tasks.SaveAttempt(ctx, t); serr != nil {
+			logger.FromContext(ctx).Error("failed to record order task attempt", zap.Int64("task_id", t.ID), zap.Error(serr))
+		}
+		return err
+	}
+	return u.tasks.MarkDone(ctx, t.ID, time.Now())
+}
+
+func (u *taskUsecase) apply(ctx context.Context, t *domain.OrderTask) error {
+	switch t.Kind {
+	case domain.TaskReleaseStock:
+		for _, line := range t.Lines {
+			if err := u.productClient.ReleaseStock(ctx, t.Key(), line.ProductID, line.Quantity); err != nil {
+				return err
+			}
+		}
+		return nil
+	case domain.TaskConfirmStock:
+		for _, line := range t.Lines {
+			if err := u.productClient.ConfirmStock(ctx, t.Key(), line.ProductID, line.Quantity); err != nil {
+				return err
+			}
+		}
+		return nil
+	case domain.TaskRestock:
+		for _, line := range t.Lines {
+			if err := u.productClient.Restock(ctx, t.Key(), line.ProductID, line.Quantity); err != nil {
+				return err
+			}
+		}
+		return nil
+	case domain.TaskReleasePreorder:
+		return u.productClient.ReleasePreorder(ctx, t.Key(), t.Lines)
+	case domain.TaskReleaseReservation:
+		return u.productClient.ReleaseReservation(ctx, t.OperationKey)
+	case domain.TaskRefundPayment:
+		return u.payments.Refund(ctx, t.Key(), t.PaymentReference, t.Amount)
+	default:
+		return fmt.Errorf("unknown order task kind %q", t.Kind)
+	}
+}
@@ -0,0 +1,82 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/order-service/internal/domain/mocks"
+	"github.com/user/go-microservices/pkg/logger"
+)
+
+func TestTaskUsecase_RunDue(t *testing.T) {
+	logger.Init()
+	timeout := 5 * time.Second
+
+	t.Run("RunsEachTaskUnderItsKey", func(t *testing.T) {
+		mockTasks := mocks.NewTaskRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewTaskUsecase(mockTasks, mockProductClient, nil, timeout)
+
+		lines := []domain.StockLine{{ProductID: 1, Quantity: 2}}
+		mockTasks.On("FindDue", mock.Anything, mock.AnythingOfType("time.Time"), 10).Return([]*domain.OrderTask{
+			{ID: 3, OrderID: 7, Kind: domain.TaskReleaseStock, Lines: lines},
+			{ID: 4, OrderID: 8, Kind: domain.TaskReleasePreorder, Lines: lines},
+			// A reservation is released under the key it was made with
+			{ID: 5, OrderID: 8, Kind: domain.TaskReleaseReservation, OperationKey: "preorder-8"},
+		}, nil)
+		mockProductClient.On("ReleaseStock", mock.Anything, "order-task-3", int64(1), 2).Return(nil)
+		mockProductClient.On("ReleasePreorder", mock.Anything, "order-task-4", lines).Return(nil)
+		mockProductClient.On("ReleaseReservation", mock.Anything, "preorder-8").Return(nil)
+		mockTasks.On("MarkDone", mock.Anything, int64(3), mock.AnythingOfType("time.Time")).Return(nil)
+		mockTasks.On("MarkDone", mock.Anything, int64(4), mock.AnythingOfType("time.Time")).Return(nil)
+		mockTasks.On("MarkDone", mock.Anything, int64(5), mock.AnythingOfType("time.Time")).Return(nil)
+
+		ids, err := uc.RunDue(context.Background(), 10)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{3, 4, 5}, ids)
+	})
+
+	t.Run("PartialFailure_LaterTasksStillRun", func(t *testing.T) {
+		mockTasks := mocks.NewTaskRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewTaskUsecase(mockTasks, mockProductClient, nil, timeout)
+
+		mockTasks.On("FindDue", mock.Anything, mock.AnythingOfType("time.Time"), 10).Return([]*domain.OrderTask{
+			{ID: 3, OrderID: 7, Kind: domain.TaskReleaseStock, Lines: []domain.StockLine{{ProductID: 1, Quantity: 2}}},
+			{ID: 4, OrderID: 8, Kind: domain.TaskReleaseStock, Lines: []domain.StockLine{{ProductID: 2, Quantity: 1}}},
+		}, nil)
+		mockProductClient.On("ReleaseStock", mock.Anything, "order-task-3", int64(1), 2).Return(assert.AnError)
+		mockTasks.On("SaveAttempt", mock.Anything, mock.MatchedBy(func(task *domain.OrderTask) bool { return task.ID == 3 })).Return(nil)
+		mockProductClient.On("ReleaseStock", mock.Anything, "order-task-4", int64(2), 1).Return(nil)
+		mockTasks.On("MarkDone", mock.Anything, int64(4), mock.AnythingOfType("time.Time")).Return(nil)
+
+		ids, err := uc.RunDue(context.Background(), 10)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{4}, ids)
+	})
+
+	t.Run("Failure_RecordsAttempt", func(t *testing.T) {
+		mockTasks := mocks.NewTaskRepository(t)
+		mockProductClient := mocks.NewProductClient(t)
+		uc := NewTaskUsecase(mockTasks, mockProductClient, nil, timeout)
+
+		mockTasks.On("FindDue", mock.Anything, mock.AnythingOfType("time.Time"), 10).Return([]*domain.OrderTask{
+			{ID: 3, OrderID: 7, Kind: domain.TaskReleaseStock, Attempts: 1, Lines: []domain.StockLine{{ProductID: 1, Quantity: 2}}},
+		}, nil)
+		mockProductClient.On("ReleaseStock", mock.Anything, "order-task-3", int64(1), 2).Return(assert.AnError)
+		mockTasks.On("SaveAttempt", mock.Anything, mock.MatchedBy(func(task *domain.OrderTask) bool {
+			return task.ID == 3 && task.Attempts == 2 && task.LastError == assert.AnError.Error()
+		})).Return(nil)
+
+		ids, err := uc.RunDue(context.Background(), 10)
+
+		assert.NoError(t, err)
+		assert.Empty(t, ids)
+	})
+}
@@ -2,8 +2,10 @@ package usecase
 
 import (
 	"context"
+	"time"
 
 	"github.com/user/go-microservices/order-service/internal/domain"
+	"github.com/user/go-microservices/pkg/valueobject"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/trace"
 )
@@ -20,10 +22,10 @@ func NewTracingOrderUsecase(next OrderUsecase) OrderUsecase {
 	}
 }
 
-func (u *tracingOrderUsecase) CreateOrder(ctx context.Context, userID, productID int64, qty int) (*domain.Order, error) {
+func (u *tracingOrderUsecase) CreateOrder(ctx context.Context, in CreateOrderInput) (*domain.Order, error) {
 	ctx, span := u.tracer.Start(ctx, "CreateOrder")
 	defer span.End()
-	return u.next.CreateOrder(ctx, userID, productID, qty)
+	return u.next.CreateOrder(ctx, in)
 }
 
 func (u *tracingOrderUsecase) GetOrder(ctx context.Context, id int64) (*domain.Order, error) {
@@ -38,8 +40,386 @@ func (u *tracingOrderUsecase) GetAllOrders(ctx context.Context) ([]*domain.Order
 	return u.next.GetAllOrders(ctx)
 }
 
-func (u *tracingOrderUsecase) CancelOrder(ctx context.Context, id int64) error {
+func (u *tracingOrderUsecase) CancelOrder(ctx context.Context, id int64, reason string) (*domain.Order, error) {
 	ctx, span := u.tracer.Start(ctx, "CancelOrder")
 	defer span.End()
-	return u.next.CancelOrder(ctx, id)
+	return u.next.CancelOrder(ctx, id, reason)
+}
+
+func (u *tracingOrderUsecase) PayOrder(ctx context.Context, id int64, paymentToken string) (*domain.Order, error) {
+	ctx, span := u.tracer.Start(ctx, "PayOrder")
+	defer span.End()
+	return u.next.PayOrder(ctx, id, paymentToken)
+}
+
+func (u *tracingOrderUsecase) HandlePaymentEvent(ctx context.Context, event *domain.PaymentEvent) (*domain.Order, error) {
+	ctx, span := u.tracer.Start(ctx, "HandlePaymentEvent")
+	defer span.End()
+	return u.next.HandlePaymentEvent(ctx, event)
+}
+
+func (u *tracingOrderUsecase) AmendOrder(ctx context.Context, id int64, items []OrderItemInput) (*domain.Order, error) {
+	ctx, span := u.tracer.Start(ctx, "AmendOrder")
+	defer span.End()
+	return u.next.AmendOrder(ctx, id, items)
+}
+
+func (u *tracingOrderUsecase) CompleteOrder(ctx context.Context, id int64) (*domain.Order, error) {
+	ctx, span := u.tracer.Start(ctx, "CompleteOrder")
+	defer span.End()
+	return u.next.CompleteOrder(ctx, id)
+}
+
+func (u *tracingOrderUsecase) ShipOrder(ctx context.Context, id int64, carrier, trackingNumber string, shippedAt time.Time) (*domain.Shipment, error) {
+	ctx, span := u.tracer.Start(ctx, "ShipOrder")
+	defer span.End()
+	return u.next.ShipOrder(ctx, id, carrier, trackingNumber, shippedAt)
+}
+
+func (u *tracingOrderUsecase) DeliverShipment(ctx context.Context, orderID, shipmentID int64, deliveredAt time.Time) (*domain.Order, error) {
+	ctx, span := u.tracer.Start(ctx, "DeliverShipment")
+	defer span.End()
+	return u.next.DeliverShipment(ctx, orderID, shipmentID, deliveredAt)
+}
+
+func (u *tracingOrderUsecase) GetShipments(ctx context.Context, id int64) ([]*domain.Shipment, error) {
+	ctx, span := u.tracer.Start(ctx, "GetShipments")
+	defer span.End()
+	return u.next.GetShipments(ctx, id)
+}
+
+func (u *tracingOrderUsecase) GetOrderHistory(ctx context.Context, id int64) ([]*domain.StatusChange, error) {
+	ctx, span := u.tracer.Start(ctx, "GetOrderHistory")
+	defer span.End()
+	return u.next.GetOrderHistory(ctx, id)
+}
+
+func (u *tracingOrderUsecase) ExpireOrders(ctx context.Context, ttl time.Duration, limit int) ([]int64, error) {
+	ctx, span := u.tracer.Start(ctx, "ExpireOrders")
+	defer span.End()
+	return u.next.ExpireOrders(ctx, ttl, limit)
+}
+
+func (u *tracingOrderUsecase) AllocateBackorders(ctx context.Context, limit int) ([]int64, error) {
+	ctx, span := u.tracer.Start(ctx, "AllocateBackorders")
+	defer span.End()
+	return u.next.AllocateBackorders(ctx, limit)
+}
+
+func (u *tracingOrderUsecase) ReleasePreorders(ctx context.Context, limit int) ([]int64, error) {
+	ctx, span := u.tracer.Start(ctx, "ReleasePreorders")
+	defer span.End()
+	return u.next.ReleasePreorders(ctx, limit)
+}
+
+func (u *tracingOrderUsecase) RecoverSagas(ctx context.Context, staleAfter time.Duration, limit int) ([]int64, error) {
+	ctx, span := u.tracer.Start(ctx, "RecoverSagas")
+	defer span.End()
+	return u.next.RecoverSagas(ctx, staleAfter, limit)
+}
+
+func (u *tracingOrderUsecase) RefundOrder(ctx context.Context, id int64, amount valueobject.Money, reason string) (*domain.Refund, error) {
+	ctx, span := u.tracer.Start(ctx, "RefundOrder")
+	defer span.End()
+	return u.next.RefundOrder(ctx, id, amount, reason)
+}
+
+func (u *tracingOrderUsecase) GetRefunds(ctx context.Context, id int64) ([]*domain.Refund, error) {
+	ctx, span := u.tracer.Start(ctx, "GetRefunds")
+	defer span.End()
+	return u.next.GetRefunds(ctx, id)
+}
+
+type tracingReturnUsecase struct {
+	next   ReturnUsecase
+	tracer trace.Tracer
+}
+
+func NewTracingReturnUsecase(next ReturnUsecase) ReturnUsecase {
+	return &tracingReturnUsecase{
+		next:   next,
+		tracer: otel.Tracer("return-usecase"),
+	}
+}
+
+func (u *tracingReturnUsecase) RequestReturn(ctx context.Context, orderID int64, items []domain.ReturnItem, reason string) (*domain.Return, error) {
+	ctx, span := u.tracer.Start(ctx, "RequestReturn")
+	defer span.End()
+	return u.next.RequestReturn(ctx, orderID, items, reason)
+}
+
+func (u *tracingReturnUsecase) ApproveReturn(ctx context.Context, orderID, returnID int64) (*domain.Return, error) {
+	ctx, span := u.tracer.Start(ctx, "ApproveReturn")
+	defer span.End()
+	return u.next.ApproveReturn(ctx, orderID, returnID)
+}
+
+func (u *tracingReturnUsecase) RejectReturn(ctx context.Context, orderID, returnID int64, note string) (*domain.Return, error) {
+	ctx, span := u.tracer.Start(ctx, "RejectReturn")
+	defer span.End()
+	return u.next.RejectReturn(ctx, orderID, returnID, note)
+}
+
+func (u *tracingReturnUsecase) ReceiveReturn(ctx context.Context, orderID, returnID int64) (*domain.Return, error) {
+	ctx, span := u.tracer.Start(ctx, "ReceiveReturn")
+	defer span.End()
+	return u.next.ReceiveReturn(ctx, orderID, returnID)
+}
+
+func (u *tracingReturnUsecase) GetReturns(ctx context.Context, orderID int64) ([]*domain.Return, error) {
+	ctx, span := u.tracer.Start(ctx, "GetReturns")
+	defer span.End()
+	return u.next.GetReturns(ctx, orderID)
+}
+
+type tracingCouponUsecase struct {
+	next   CouponUsecase
+	tracer trace.Tracer
+}
+
+func NewTracingCouponUsecase(next CouponUsecase) CouponUsecase {
+	return &tracingCouponUsecase{
+		next:   next,
+		tracer: otel.Tracer("coupon-usecase"),
+	}
+}
+
+func (u *tracingCouponUsecase) CreateCoupon(ctx context.Context, c *domain.Coupon) (*domain.Coupon, error) {
+	ctx, span := u.tracer.Start(ctx, "CreateCoupon")
+	defer span.End()
+	return u.next.CreateCoupon(ctx, c)
+}
+
+func (u *tracingCouponUsecase) GetCoupon(ctx context.Context, code string) (*domain.Coupon, error) {
+	ctx, span := u.tracer.Start(ctx, "GetCoupon")
+	defer span.End()
+	return u.next.GetCoupon(ctx, code)
+}
+
+func (u *tracingCouponUsecase) GetAllCoupons(ctx context.Context) ([]*domain.Coupon, error) {
+	ctx, span := u.tracer.Start(ctx, "GetAllCoupons")
+	defer span.End()
+	return u.next.GetAllCoupons(ctx)
+}
+
+func (u *tracingCouponUsecase) DeactivateCoupon(ctx context.Context, code string) (*domain.Coupon, error) {
+	ctx, span := u.tracer.Start(ctx, "DeactivateCoupon")
+	defer span.End()
+	return u.next.DeactivateCoupon(ctx, code)
+}
+
+type tracingQuoteUsecase struct {
+	next   QuoteUsecase
+	tracer trace.Tracer
+}
+
+func NewTracingQuoteUsecase(next QuoteUsecase) QuoteUsecase {
+	return &tracingQuoteUsecase{
+		next:   next,
+		tracer: otel.Tracer("quote-usecase"),
+	}
+}
+
+func (u *tracingQuoteUsecase) CreateQuote(ctx context.Context, userID int64, items []OrderItemInput, region string) (*domain.Quote, error) {
+	ctx, span := u.tracer.Start(ctx, "CreateQuote")
+	defer span.End()
+	return u.next.CreateQuote(ctx, userID, items, region)
+}
+
+func (u *tracingQuoteUsecase) GetQuote(ctx context.Context, id int64) (*domain.Quote, error) {
+	ctx, span := u.tracer.Start(ctx, "GetQuote")
+	defer span.End()
+	return u.next.GetQuote(ctx, id)
+}
+
+type tracingCartUsecase struct {
+	next   CartUsecase
+	tracer trace.Tracer
+}
+
+func NewTracingCartUsecase(next CartUsecase) CartUsecase {
+	return &tracingCartUsecase{
+		next:   next,
+		tracer: otel.Tracer("cart-usecase"),
+	}
+}
+
+func (u *tracingCartUsecase) CreateCart(ctx context.Context, userID int64, region string) (*domain.Cart, error) {
+	ctx, span := u.tracer.Start(ctx, "CreateCart")
+	defer span.End()
+	return u.next.CreateCart(ctx, userID, region)
+}
+
+func (u *tracingCartUsecase) GetCart(ctx context.Context, id int64) (*domain.Cart, error) {
+	ctx, span := u.tracer.Start(ctx, "GetCart")
+	defer span.End()
+	return u.next.GetCart(ctx, id)
+}
+
+func (u *tracingCartUsecase) AddItem(ctx context.Context, id, productID int64, quantity int) (*domain.Cart, error) {
+	ctx, span := u.tracer.Start(ctx, "AddCartItem")
+	defer span.End()
+	return u.next.AddItem(ctx, id, productID, quantity)
+}
+
+func (u *tracingCartUsecase) UpdateItem(ctx context.Context, id, productID int64, quantity int) (*domain.Cart, error) {
+	ctx, span := u.tracer.Start(ctx, "UpdateCartItem")
+	defer span.End()
+	return u.next.UpdateItem(ctx, id, productID, quantity)
+}
+
+func (u *tracingCartUsecase) RemoveItem(ctx context.Context, id, productID int64) (*domain.Cart, error) {
+	ctx, span := u.tracer.Start(ctx, "RemoveCartItem")
+	defer span.End()
+	return u.next.RemoveItem(ctx, id, productID)
+}
+
+func (u *tracingCartUsecase) Checkout(ctx context.Context, id int64, couponCode string) (*domain.Order, error) {
+	ctx, span := u.tracer.Start(ctx, "CheckoutCart")
+	defer span.End()
+	return u.next.Checkout(ctx, id, couponCode)
+}
+
+type tracingInvoiceUsecase struct {
+	next   InvoiceUsecase
+	tracer trace.Tracer
+}
+
+func NewTracingInvoiceUsecase(next InvoiceUsecase) InvoiceUsecase {
+	return &tracingInvoiceUsecase{
+		next:   next,
+		tracer: otel.Tracer("invoice-usecase"),
+	}
+}
+
+func (u *tracingInvoiceUsecase) GetInvoice(ctx context.Context, orderID int64) (*domain.Invoice, error) {
+	ctx, span := u.tracer.Start(ctx, "GetInvoice")
+	defer span.End()
+	return u.next.GetInvoice(ctx, orderID)
+}
+
+type tracingSubscriptionUsecase struct {
+	next   SubscriptionUsecase
+	tracer trace.Tracer
+}
+
+func NewTracingSubscriptionUsecase(next SubscriptionUsecase) SubscriptionUsecase {
+	return &tracingSubscriptionUsecase{
+		next:   next,
+		tracer: otel.Tracer("subscription-usecase"),
+	}
+}
+
+func (u *tracingSubscriptionUsecase) CreateSubscription(ctx context.Context, in CreateSubscriptionInput) (*domain.Subscription, error) {
+	ctx, span := u.tracer.Start(ctx, "CreateSubscription")
+	defer span.End()
+	return u.next.CreateSubscription(ctx, in)
+}
+
+func (u *tracingSubscriptionUsecase) GetSubscription(ctx context.Context, id int64) (*domain.Subscription, error) {
+	ctx, span := u.tracer.Start(ctx, "GetSubscription")
+	defer span.End()
+	return u.next.GetSubscription(ctx, id)
+}
+
+func (u *tracingSubscriptionUsecase) PauseSubscription(ctx context.Context, id int64) (*domain.Subscription, error) {
+	ctx, span := u.tracer.Start(ctx, "PauseSubscription")
+	defer span.End()
+	return u.next.PauseSubscription(ctx, id)
+}
+
+func (u *tracingSubscriptionUsecase) ResumeSubscription(ctx context.Context, id int64) (*domain.Subscription, error) {
+	ctx, span := u.tracer.Start(ctx, "ResumeSubscription")
+	defer span.End()
+	return u.next.ResumeSubscription(ctx, id)
+}
+
+func (u *tracingSubscriptionUsecase) SkipSubscription(ctx context.Context, id int64) (*domain.Subscription, error) {
+	ctx, span := u.tracer.Start(ctx, "SkipSubscription")
+	defer span.End()
+	return u.next.SkipSubscription(ctx, id)
+}
+
+func (u *tracingSubscriptionUsecase) CancelSubscription(ctx context.Context, id int64) (*domain.Subscription, error) {
+	ctx, span := u.tracer.Start(ctx, "CancelSubscription")
+	defer span.End()
+	return u.next.CancelSubscription(ctx, id)
+}
+
+func (u *tracingSubscriptionUsecase) GetSubscriptionOrders(ctx context.Context, id int64) ([]*domain.SubscriptionOrder, error) {
+	ctx, span := u.tracer.Start(ctx, "GetSubscriptionOrders")
+	defer span.End()
+	return u.next.GetSubscriptionOrders(ctx, id)
+}
+
+func (u *tracingSubscriptionUsecase) RunDueSubscriptions(ctx context.Context, limit int) ([]int64, error) {
+	ctx, span := u.tracer.Start(ctx, "RunDueSubscriptions")
+	defer span.End()
+	return u.next.RunDueSubscriptions(ctx, limit)
+}
+
+type tracingOutboxUsecase struct {
+	next   OutboxUsecase
+	tracer trace.Tracer
+}
+
+func NewTracingOutboxUsecase(next OutboxUsecase) OutboxUsecase {
+	return &tracingOutboxUsecase{
+		next:   next,
+		tracer: otel.Tracer("outbox-usecase"),
+	}
+}
+
+func (u *tracingOutboxUsecase) PublishPending(ctx context.Context, limit int) ([]int64, error) {
+	ctx, span := u.tracer.Start(ctx, "PublishPendingEvents")
+	defer span.End()
+	return u.next.PublishPending(ctx, limit)
+}
+
+func (u *tracingOutboxUsecase) PurgePublished(ctx context.Context, limit int) (int64, error) {
+	ctx, span := u.tracer.Start(ctx, "PurgePublishedEvents")
+	defer span.End()
+	return u.next.PurgePublished(ctx, limit)
+}
+
+type tracingTaskUsecase struct {
+	next   TaskUsecase
+	tracer trace.Tracer
+}
+
+func NewTracingTaskUsecase(next TaskUsecase) TaskUsecase {
+	return &tracingTaskUsecase{
+		next:   next,
+		tracer: otel.Tracer("task-usecase"),
+	}
+}
+
+func (u *tracingTaskUsecase) RunDue(ctx context.Context, limit int) ([]int64, error) {
+	ctx, span := u.tracer.Start(ctx, "RunDueOrderTasks")
+	defer span.End()
+	return u.next.RunDue(ctx, limit)
+}
+
+type tracingIdempotencyUsecase struct {
+	next   IdempotencyUsecase
+	tracer trace.Tracer
+}
+
+func NewTracingIdempotencyUsecase(next IdempotencyUsecase) IdempotencyUsecase {
+	return &tracingIdempotencyUsecase{
+		next:   next,
+		tracer: otel.Tracer("idempotency-usecase"),
+	}
+}
+
+func (u *tracingIdempotencyUsecase) Execute(ctx context.Context, key, requestHash string, fn IdempotentFunc) (*domain.IdempotencyRecord, bool, error) {
+	ctx, span := u.tracer.Start(ctx, "ExecuteIdempotent")
+	defer span.End()
+	return u.next.Execute(ctx, key, requestHash, fn)
+}
+
+func (u *tracingIdempotencyUsecase) PurgeExpired(ctx context.Context, limit int) (int64, error) {
+	ctx, span := u.tracer.Start(ctx, "PurgeExpiredIdempotencyKeys")
+	defer span.End()
+	return u.next.PurgeExpired(ctx, limit)
 }
@@ -1,19 +1,394 @@
 CREATE TABLE IF NOT EXISTS orders (
     id BIGSERIAL PRIMARY KEY,
     user_id BIGINT NOT NULL,
+    tax_region VARCHAR(50) NOT NULL DEFAULT '',
+    subtotal DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    tax_amount DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    shipping_rate JSONB,
+    shipping_amount DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    total_price DECIMAL(10, 2) NOT NULL,
+    refunded_amount DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    order_status VARCHAR(50) NOT NULL,
+    payment_status VARCHAR(50) NOT NULL,
+    payment_reference VARCHAR(255) NOT NULL DEFAULT '',
+    subscription_id BIGINT,
+    saga_id BIGINT,
+    release_at TIMESTAMP WITH TIME ZONE,
+    allocated_at TIMESTAMP WITH TIME ZONE,
+    version INT NOT NULL DEFAULT 0,
+    idempotency_key VARCHAR(64),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS refunded_amount DECIMAL(10, 2) NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS tax_region VARCHAR(50) NOT NULL DEFAULT '';
+-- Orders stored before subtotals were kept get the sum of their lines. Those
+-- older than order_items are backfilled when they are moved into it below.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'orders' AND column_name = 'subtotal') THEN
+        ALTER TABLE orders ADD COLUMN subtotal DECIMAL(10, 2) NOT NULL DEFAULT 0;
+        IF EXISTS (SELECT 1 FROM information_schema.tables WHERE table_name = 'order_items') THEN
+            UPDATE orders o SET subtotal = i.total
+            FROM (SELECT order_id, SUM(line_total) AS total FROM order_items GROUP BY order_id) i
+            WHERE o.id = i.order_id;
+        END IF;
+    END IF;
+END $$;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS tax_amount DECIMAL(10, 2) NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS shipping_rate JSONB;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS shipping_amount DECIMAL(10, 2) NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS payment_reference VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS subscription_id BIGINT;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS saga_id BIGINT;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS release_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS allocated_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS idempotency_key VARCHAR(64);
+
+CREATE INDEX IF NOT EXISTS idx_orders_user_id ON orders(user_id);
+CREATE INDEX IF NOT EXISTS idx_orders_user_id_created_at ON orders(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_orders_status_created_at ON orders(order_status, payment_status, created_at);
+CREATE INDEX IF NOT EXISTS idx_orders_subscription_id ON orders(subscription_id) WHERE subscription_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_orders_saga_id ON orders(saga_id) WHERE saga_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_orders_idempotency_key ON orders(idempotency_key) WHERE idempotency_key IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS order_items (
+    id BIGSERIAL PRIMARY KEY,
+    order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
     product_id BIGINT NOT NULL,
     product_name VARCHAR(255) NOT NULL,
     unit_price DECIMAL(10, 2) NOT NULL,
     quantity INT NOT NULL,
+    line_total DECIMAL(10, 2) NOT NULL,
+    tax_category VARCHAR(50) NOT NULL DEFAULT '',
+    tax_rate NUMERIC(7, 4) NOT NULL DEFAULT 0,
+    weight_grams INT NOT NULL DEFAULT 0,
+    UNIQUE (order_id, product_id)
+);
+
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS tax_category VARCHAR(50) NOT NULL DEFAULT '';
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS tax_rate NUMERIC(7, 4) NOT NULL DEFAULT 0;
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS weight_grams INT NOT NULL DEFAULT 0;
+-- Rates such as 8.875% need four decimal places
+ALTER TABLE order_items ALTER COLUMN tax_rate TYPE NUMERIC(7, 4);
+
+CREATE INDEX IF NOT EXISTS idx_order_items_order_id ON order_items(order_id);
+
+CREATE TABLE IF NOT EXISTS order_status_history (
+    id BIGSERIAL PRIMARY KEY,
+    order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    from_order_status VARCHAR(50) NOT NULL DEFAULT '',
+    from_payment_status VARCHAR(50) NOT NULL DEFAULT '',
+    to_order_status VARCHAR(50) NOT NULL,
+    to_payment_status VARCHAR(50) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    actor VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_status_history_order_id ON order_status_history(order_id);
+
+CREATE TABLE IF NOT EXISTS order_refunds (
+    id BIGSERIAL PRIMARY KEY,
+    order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    amount DECIMAL(10, 2) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_refunds_order_id ON order_refunds(order_id);
+
+CREATE TABLE IF NOT EXISTS order_shipments (
+    id BIGSERIAL PRIMARY KEY,
+    order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    carrier VARCHAR(100) NOT NULL,
+    tracking_number VARCHAR(255) NOT NULL,
+    shipped_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    delivered_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_shipments_order_id ON order_shipments(order_id);
+
+CREATE TABLE IF NOT EXISTS order_returns (
+    id BIGSERIAL PRIMARY KEY,
+    order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    status VARCHAR(50) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    note TEXT NOT NULL DEFAULT '',
+    amount DECIMAL(10, 2) NOT NULL,
+    refund_id BIGINT REFERENCES order_refunds(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_returns_order_id ON order_returns(order_id);
+
+CREATE TABLE IF NOT EXISTS order_return_items (
+    id BIGSERIAL PRIMARY KEY,
+    return_id BIGINT NOT NULL REFERENCES order_returns(id) ON DELETE CASCADE,
+    product_id BIGINT NOT NULL,
+    quantity INT NOT NULL,
+    unit_price DECIMAL(10, 2) NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_return_items_return_id ON order_return_items(return_id);
+
+CREATE TABLE IF NOT EXISTS coupons (
+    id BIGSERIAL PRIMARY KEY,
+    code VARCHAR(64) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    discount_type VARCHAR(50) NOT NULL,
+    percentage DECIMAL(5, 2) NOT NULL DEFAULT 0,
+    amount_off DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    product_id BIGINT NOT NULL DEFAULT 0,
+    buy_qty INT NOT NULL DEFAULT 0,
+    get_qty INT NOT NULL DEFAULT 0,
+    min_spend DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    max_uses INT NOT NULL DEFAULT 0,
+    max_uses_per_user INT NOT NULL DEFAULT 0,
+    uses_count INT NOT NULL DEFAULT 0,
+    valid_from TIMESTAMP WITH TIME ZONE,
+    valid_until TIMESTAMP WITH TIME ZONE,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- terms is a snapshot of the coupon's discount terms at redemption time
+CREATE TABLE IF NOT EXISTS order_discounts (
+    id BIGSERIAL PRIMARY KEY,
+    order_id BIGINT NOT NULL UNIQUE REFERENCES orders(id) ON DELETE CASCADE,
+    coupon_id BIGINT NOT NULL REFERENCES coupons(id),
+    code VARCHAR(64) NOT NULL,
+    terms JSONB NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_discounts_coupon_id ON order_discounts(coupon_id);
+
+-- Tax rates by region and product tax category; '*' matches any value
+CREATE TABLE IF NOT EXISTS tax_rates (
+    region VARCHAR(50) NOT NULL,
+    category VARCHAR(50) NOT NULL,
+    rate NUMERIC(7, 4) NOT NULL,
+    PRIMARY KEY (region, category)
+);
+
+ALTER TABLE tax_rates ALTER COLUMN rate TYPE NUMERIC(7, 4);
+
+-- Price quotes; items holds the priced order lines as quoted
+CREATE TABLE IF NOT EXISTS quotes (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    region VARCHAR(50) NOT NULL DEFAULT '',
+    items JSONB NOT NULL,
+    shipping_rate JSONB,
+    subtotal DECIMAL(10, 2) NOT NULL,
+    tax_amount DECIMAL(10, 2) NOT NULL,
+    shipping_amount DECIMAL(10, 2) NOT NULL,
     total_price DECIMAL(10, 2) NOT NULL,
-    order_status VARCHAR(50) NOT NULL,
-    payment_status VARCHAR(50) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
 
-CREATE INDEX IF NOT EXISTS idx_orders_user_id ON orders(user_id);
+-- Shopping carts; items are priced live when the cart is read
+CREATE TABLE IF NOT EXISTS carts (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    region VARCHAR(50) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'OPEN',
+    order_id BIGINT REFERENCES orders(id),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS cart_items (
+    id BIGSERIAL PRIMARY KEY,
+    cart_id BIGINT NOT NULL REFERENCES carts(id) ON DELETE CASCADE,
+    product_id BIGINT NOT NULL,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    UNIQUE (cart_id, product_id)
+);
+
+-- Shipping rates by zone, a region prefix such as 'US' or 'US-CA'; '*' matches any region
+CREATE TABLE IF NOT EXISTS shipping_rates (
+    zone VARCHAR(50) PRIMARY KEY,
+    base_fee DECIMAL(10, 2) NOT NULL,
+    per_kg_fee DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    free_over DECIMAL(10, 2) NOT NULL DEFAULT 0
+);
+
+-- Payment provider notifications already processed, so redeliveries are ignored
+CREATE TABLE IF NOT EXISTS payment_events (
+    event_id VARCHAR(255) PRIMARY KEY,
+    order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL,
+    received_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Last invoice number issued per year; the row is locked until the invoice
+-- commits, so numbers are never skipped
+CREATE TABLE IF NOT EXISTS invoice_sequences (
+    year INT PRIMARY KEY,
+    last_number BIGINT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS invoices (
+    id BIGSERIAL PRIMARY KEY,
+    number VARCHAR(50) NOT NULL UNIQUE,
+    order_id BIGINT NOT NULL UNIQUE REFERENCES orders(id),
+    user_id BIGINT NOT NULL,
+    region VARCHAR(50) NOT NULL DEFAULT '',
+    lines JSONB NOT NULL,
+    subtotal DECIMAL(10, 2) NOT NULL,
+    discount DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    discount_code VARCHAR(50) NOT NULL DEFAULT '',
+    tax_amount DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    shipping_amount DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    total DECIMAL(10, 2) NOT NULL,
+    payment_reference VARCHAR(255) NOT NULL DEFAULT '',
+    issued_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Issued invoices are immutable
+CREATE OR REPLACE FUNCTION reject_invoice_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'invoices cannot be changed once issued';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS invoices_immutable ON invoices;
+CREATE TRIGGER invoices_immutable BEFORE UPDATE OR DELETE ON invoices
+    FOR EACH ROW EXECUTE FUNCTION reject_invoice_change();
+
+-- Recurring orders; orders.subscription_id links the orders each one placed
+CREATE TABLE IF NOT EXISTS subscriptions (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    product_id BIGINT NOT NULL,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    region VARCHAR(50) NOT NULL DEFAULT '',
+    interval_days INT NOT NULL CHECK (interval_days > 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'ACTIVE',
+    next_run_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    retry_at TIMESTAMP WITH TIME ZONE,
+    failed_attempts INT NOT NULL DEFAULT 0,
+    last_order_id BIGINT REFERENCES orders(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_due ON subscriptions(status, (COALESCE(retry_at, next_run_at)));
+
+-- Placement sagas log the stock reservations taken for an order before it is
+-- stored, so reservations of orders that never made it can be released
+CREATE TABLE IF NOT EXISTS order_sagas (
+    id BIGSERIAL PRIMARY KEY,
+    status VARCHAR(20) NOT NULL,
+    order_id BIGINT REFERENCES orders(id),
+    compensation_status VARCHAR(20) NOT NULL DEFAULT 'NONE',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_sagas_running ON order_sagas(updated_at) WHERE status = 'RUNNING';
+CREATE INDEX IF NOT EXISTS idx_order_sagas_compensation ON order_sagas(next_attempt_at) WHERE compensation_status = 'PENDING';
+
+CREATE TABLE IF NOT EXISTS order_saga_steps (
+    saga_id BIGINT NOT NULL REFERENCES order_sagas(id) ON DELETE CASCADE,
+    seq INT NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    product_id BIGINT NOT NULL,
+    quantity INT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    PRIMARY KEY (saga_id, seq)
+);
+
+-- Transactional outbox: order events are written with the change that raises
+-- them and relayed to the broker in id order
+CREATE TABLE IF NOT EXISTS order_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    order_id BIGINT NOT NULL REFERENCES orders(id),
+    event_type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    published_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_outbox_unpublished ON order_outbox(id) WHERE published_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_order_outbox_published_at ON order_outbox(published_at) WHERE published_at IS NOT NULL;
+
+-- Responses to POST /orders kept by Idempotency-Key for replaying retries
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    key VARCHAR(255) PRIMARY KEY,
+    request_hash VARCHAR(64) NOT NULL,
+    status_code INT NOT NULL,
+    response_body BYTEA NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
+
+-- Side effects owed to other services once an order change is committed,
+-- written in the change's transaction and retried until done
+CREATE TABLE IF NOT EXISTS order_tasks (
+    id BIGSERIAL PRIMARY KEY,
+    order_id BIGINT NOT NULL REFERENCES orders(id),
+    kind VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    done_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_tasks_due ON order_tasks(next_attempt_at) WHERE done_at IS NULL;
+
+-- Move single-product orders created before order_items existed into line
+-- items. They carried no tax, shipping or discount, so their subtotal is
+-- their total.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'orders' AND column_name = 'product_id') THEN
+        INSERT INTO order_items (order_id, product_id, product_name, unit_price, quantity, line_total)
+        SELECT id, product_id, product_name, unit_price, quantity, total_price FROM orders;
+
+        UPDATE orders SET subtotal = total_price;
+
+        ALTER TABLE orders
+            DROP COLUMN product_id,
+            DROP COLUMN product_name,
+            DROP COLUMN unit_price,
+            DROP COLUMN quantity;
+    END IF;
+END $$;
+
+-- Sample orders take fixed IDs so that running the schema again adds none,
+-- and their items are only added along with them. The unpaid one is seeded
+-- cancelled, since no stock is reserved for it in the product service.
+WITH o AS (
+    INSERT INTO orders (id, user_id, subtotal, total_price, order_status, payment_status)
+    VALUES (1, 101, 1999.99, 1999.99, 'COMPLETED', 'PAID')
+    ON CONFLICT (id) DO NOTHING
+    RETURNING id
+)
+INSERT INTO order_items (order_id, product_id, product_name, unit_price, quantity, line_total)
+SELECT id, 1, 'High-Performance Laptop', 1999.99, 1, 1999.99 FROM o;
 
-INSERT INTO orders (user_id, product_id, product_name, unit_price, quantity, total_price, order_status, payment_status) VALUES
-(101, 1, 'High-Performance Laptop', 1999.99, 1, 1999.99, 'COMPLETED', 'PAID'),
-(102, 2, 'Wireless Noise-Canceling Headphones', 299.99, 2, 599.98, 'PENDING', 'PENDING');
+WITH o AS (
+    INSERT INTO orders (id, user_id, subtotal, total_price, order_status, payment_status)
+    VALUES (2, 102, 599.98, 599.98, 'CANCELLED', 'PENDING')
+    ON CONFLICT (id) DO NOTHING
+    RETURNING id
+)
+INSERT INTO order_items (order_id, product_id, product_name, unit_price, quantity, line_total)
+SELECT id, 2, 'Wireless Noise-Canceling Headphones', 299.99, 2, 599.98 FROM o;
 
+SELECT setval(pg_get_serial_sequence('orders', 'id'), GREATEST((SELECT MAX(id) FROM orders), 1));
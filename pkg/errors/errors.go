@@ -11,6 +11,8 @@ var (
 	ErrInternal          = errors.New("internal error")
 	ErrConflict          = errors.New("conflict")
 	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrUnprocessable     = errors.New("unprocessable")
+	ErrUnavailable       = errors.New("service unavailable")
 )
 
 func GetStatusCode(err error) int {
@@ -23,8 +25,11 @@ func GetStatusCode(err error) int {
 	if errors.Is(err, ErrConflict) {
 		return http.StatusConflict
 	}
-	if errors.Is(err, ErrInsufficientStock) {
+	if errors.Is(err, ErrInsufficientStock) || errors.Is(err, ErrUnprocessable) {
 		return http.StatusUnprocessableEntity
 	}
+	if errors.Is(err, ErrUnavailable) {
+		return http.StatusServiceUnavailable
+	}
 	return http.StatusInternalServerError
 }
@@ -41,11 +41,31 @@ func (m Money) Add(other Money) Money {
 	return NewMoney(m.amount + other.amount)
 }
 
+// Subtract subtracts another Money instance
+func (m Money) Subtract(other Money) Money {
+	return NewMoney(m.amount - other.amount)
+}
+
+// GreaterThan returns true if m is strictly more than other
+func (m Money) GreaterThan(other Money) bool {
+	return m.amount > other.amount
+}
+
+// Equal reports whether m and other are the same amount to the cent
+func (m Money) Equal(other Money) bool {
+	return math.Round(m.amount*100) == math.Round(other.amount*100)
+}
+
 // Multiply multiplies Money by a factor (e.g. quantity)
 func (m Money) Multiply(factor int) Money {
 	return NewMoney(m.amount * float64(factor))
 }
 
+// Percentage returns the given share of m, where percent is e.g. 15 for 15%
+func (m Money) Percentage(percent float64) Money {
+	return NewMoney(m.amount * percent / 100)
+}
+
 // MarshalJSON implements json.Marshaler
 func (m Money) MarshalJSON() ([]byte, error) {
 	return json.Marshal(m.amount)
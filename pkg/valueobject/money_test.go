@@ -20,6 +20,27 @@ func TestMoney_Arithmetic(t *testing.T) {
 	if prod.Amount() != 31.50 {
 		t.Errorf("Expected 31.50, got %v", prod.Amount())
 	}
+
+	// Subtraction
+	diff := m1.Subtract(m2)
+	if diff.Amount() != 5.25 {
+		t.Errorf("Expected 5.25, got %v", diff.Amount())
+	}
+
+	// Percentage
+	pct := m1.Percentage(10)
+	if pct.Amount() != 1.05 {
+		t.Errorf("Expected 1.05, got %v", pct.Amount())
+	}
+
+	// Comparison
+	if !m1.GreaterThan(m2) || m2.GreaterThan(m1) || m1.GreaterThan(m1) {
+		t.Errorf("GreaterThan returned unexpected result")
+	}
+	// Sums that drift in float arithmetic still compare equal to the cent
+	if !NewMoney(0.1).Add(NewMoney(0.2)).Equal(NewMoney(0.3)) || m1.Equal(m2) {
+		t.Errorf("Equal returned unexpected result")
+	}
 }
 
 func TestMoney_Rounding(t *testing.T) {
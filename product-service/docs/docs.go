@@ -79,6 +79,67 @@ const docTemplate = `{
                 }
             }
         },
+        "/products/allocate": {
+            "post": {
+                "description": "Reserve every line or none of them, e.g. to fill a backorder once stock arrives",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stock"
+                ],
+                "summary": "Reserve stock for several products at once",
+                "parameters": [
+                    {
+                        "description": "Lines to reserve",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http.StockLinesRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Applies the change at most once per key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
         "/products/confirm": {
             "post": {
                 "description": "Confirm the reservation and permanently deduct stock",
@@ -101,6 +162,177 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/internal_delivery_http.StockRequest"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Applies the change at most once per key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/products/preorders/convert": {
+            "post": {
+                "description": "Once products are released, turn pre-ordered units into stock reservations, for every line or none",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stock"
+                ],
+                "summary": "Convert pre-orders into reservations",
+                "parameters": [
+                    {
+                        "description": "Lines to convert",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http.StockLinesRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Applies the change at most once per key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/products/preorders/release": {
+            "post": {
+                "description": "Give pre-ordered units back to the products' pre-order caps",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stock"
+                ],
+                "summary": "Release pre-ordered units",
+                "parameters": [
+                    {
+                        "description": "Lines to release",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http.StockLinesRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Applies the change at most once per key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/products/preorders/reserve": {
+            "post": {
+                "description": "Take every line from the products' pre-order caps or none of them. Released products cannot be pre-ordered.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stock"
+                ],
+                "summary": "Pre-order unreleased products",
+                "parameters": [
+                    {
+                        "description": "Lines to pre-order",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http.StockLinesRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Applies the change at most once per key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -112,6 +344,24 @@ const docTemplate = `{
                                 "type": "string"
                             }
                         }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
                     }
                 }
             }
@@ -138,6 +388,12 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/internal_delivery_http.StockRequest"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Applies the change at most once per key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -153,6 +409,47 @@ const docTemplate = `{
                 }
             }
         },
+        "/products/reservations/{key}/release": {
+            "post": {
+                "description": "Give back the stock or pre-order cap reserved under the key. If nothing was reserved under it, the key is voided so a late reservation with it fails with 409. Releasing twice is a no-op.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stock"
+                ],
+                "summary": "Release a keyed reservation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Operation key of the reservation",
+                        "name": "key",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
         "/products/reserve": {
             "post": {
                 "description": "Reserve a specific quantity of stock for a given product ID",
@@ -175,6 +472,12 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/internal_delivery_http.StockRequest"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Applies the change at most once per key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -208,6 +511,67 @@ const docTemplate = `{
                 }
             }
         },
+        "/products/restock": {
+            "post": {
+                "description": "Add returned units back to a product's total stock",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stock"
+                ],
+                "summary": "Restock returned units",
+                "parameters": [
+                    {
+                        "description": "Restock request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_delivery_http.StockRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Applies the change at most once per key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
         "/products/{id}": {
             "get": {
                 "description": "Get detailed information about a product by its ID",
@@ -260,6 +624,10 @@ const docTemplate = `{
         "github_com_user_go-microservices_product-service_internal_domain.Product": {
             "type": "object",
             "properties": {
+                "backorderable": {
+                    "description": "Backorderable products accept orders beyond available stock; those\norders wait until the product is restocked",
+                    "type": "boolean"
+                },
                 "created_at": {
                     "type": "string"
                 },
@@ -275,20 +643,58 @@ const docTemplate = `{
                 "name": {
                     "type": "string"
                 },
+                "preorder_cap": {
+                    "type": "integer"
+                },
+                "preordered_qty": {
+                    "type": "integer"
+                },
                 "price": {
                     "$ref": "#/definitions/valueobject.Money"
                 },
+                "release_at": {
+                    "description": "ReleaseAt is when a product that is not on sale yet launches. Until\nthen it can only be pre-ordered, up to PreorderCap units, and\nPreorderedQty counts the units taken from that cap.",
+                    "type": "string"
+                },
                 "reserved_qty": {
                     "type": "integer"
                 },
                 "sku": {
                     "type": "string"
                 },
+                "tax_category": {
+                    "type": "string"
+                },
                 "total_qty": {
                     "type": "integer"
                 },
                 "updated_at": {
                     "type": "string"
+                },
+                "weight_grams": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_user_go-microservices_product-service_internal_domain.StockLine": {
+            "type": "object",
+            "properties": {
+                "product_id": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                }
+            }
+        },
+        "internal_delivery_http.StockLinesRequest": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_user_go-microservices_product-service_internal_domain.StockLine"
+                    }
                 }
             }
         },
@@ -13,6 +13,10 @@ import (
 	"go.uber.org/zap"
 )
 
+// OperationKeyHeader carries the key under which a stock change is applied
+// at most once. Callers that retry a stock change send the same key again.
+const OperationKeyHeader = "Idempotency-Key"
+
 type ProductHandler struct {
 	ProdUsecase usecase.ProductUsecase
 }
@@ -28,6 +32,12 @@ func NewProductHandler(r *mux.Router, us usecase.ProductUsecase) {
 	r.HandleFunc("/products/reserve", handler.ReserveStock).Methods("POST")
 	r.HandleFunc("/products/release", handler.ReleaseStock).Methods("POST")
 	r.HandleFunc("/products/confirm", handler.ConfirmStock).Methods("POST")
+	r.HandleFunc("/products/allocate", handler.AllocateStock).Methods("POST")
+	r.HandleFunc("/products/preorders/reserve", handler.ReservePreorder).Methods("POST")
+	r.HandleFunc("/products/preorders/release", handler.ReleasePreorder).Methods("POST")
+	r.HandleFunc("/products/preorders/convert", handler.ConvertPreorder).Methods("POST")
+	r.HandleFunc("/products/restock", handler.Restock).Methods("POST")
+	r.HandleFunc("/products/reservations/{key}/release", handler.ReleaseReservation).Methods("POST")
 	r.HandleFunc("/health", handler.HealthCheck).Methods("GET")
 }
 
@@ -116,6 +126,7 @@ type StockRequest struct {
 // @Accept  json
 // @Produce  json
 // @Param request body StockRequest true "Stock reservation request"
+// @Param Idempotency-Key header string false "Applies the change at most once per key"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]string
 // @Failure 422 {object} map[string]string
@@ -126,7 +137,7 @@ func (h *ProductHandler) ReserveStock(w http.ResponseWriter, r *http.Request) {
 		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
 		return
 	}
-	err := h.ProdUsecase.ReserveStock(r.Context(), req.ProductID, req.Quantity)
+	err := h.ProdUsecase.ReserveStock(r.Context(), operationKey(r), req.ProductID, req.Quantity)
 	if err != nil {
 		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
 		return
@@ -141,6 +152,7 @@ func (h *ProductHandler) ReserveStock(w http.ResponseWriter, r *http.Request) {
 // @Accept  json
 // @Produce  json
 // @Param request body StockRequest true "Stock release request"
+// @Param Idempotency-Key header string false "Applies the change at most once per key"
 // @Success 200 {object} map[string]string
 // @Router /products/release [post]
 func (h *ProductHandler) ReleaseStock(w http.ResponseWriter, r *http.Request) {
@@ -149,7 +161,7 @@ func (h *ProductHandler) ReleaseStock(w http.ResponseWriter, r *http.Request) {
 		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
 		return
 	}
-	err := h.ProdUsecase.ReleaseStock(r.Context(), req.ProductID, req.Quantity)
+	err := h.ProdUsecase.ReleaseStock(r.Context(), operationKey(r), req.ProductID, req.Quantity)
 	if err != nil {
 		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
 		return
@@ -164,6 +176,7 @@ func (h *ProductHandler) ReleaseStock(w http.ResponseWriter, r *http.Request) {
 // @Accept  json
 // @Produce  json
 // @Param request body StockRequest true "Stock confirmation request"
+// @Param Idempotency-Key header string false "Applies the change at most once per key"
 // @Success 200 {object} map[string]string
 // @Router /products/confirm [post]
 func (h *ProductHandler) ConfirmStock(w http.ResponseWriter, r *http.Request) {
@@ -172,7 +185,7 @@ func (h *ProductHandler) ConfirmStock(w http.ResponseWriter, r *http.Request) {
 		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
 		return
 	}
-	err := h.ProdUsecase.ConfirmStock(r.Context(), req.ProductID, req.Quantity)
+	err := h.ProdUsecase.ConfirmStock(r.Context(), operationKey(r), req.ProductID, req.Quantity)
 	if err != nil {
 		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
 		return
@@ -180,6 +193,162 @@ func (h *ProductHandler) ConfirmStock(w http.ResponseWriter, r *http.Request) {
 	h.respondWithJSON(w, http.StatusOK, map[string]string{"message": "confirmed"})
 }
 
+type StockLinesRequest struct {
+	Items []domain.StockLine `json:"items"`
+}
+
+// AllocateStock godoc
+// @Summary Reserve stock for several products at once
+// @Description Reserve every line or none of them, e.g. to fill a backorder once stock arrives
+// @Tags stock
+// @Accept  json
+// @Produce  json
+// @Param request body StockLinesRequest true "Lines to reserve"
+// @Param Idempotency-Key header string false "Applies the change at most once per key"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /products/allocate [post]
+func (h *ProductHandler) AllocateStock(w http.ResponseWriter, r *http.Request) {
+	var req StockLinesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	err := h.ProdUsecase.AllocateStock(r.Context(), operationKey(r), req.Items)
+	if err != nil {
+		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, map[string]string{"message": "allocated"})
+}
+
+// ReservePreorder godoc
+// @Summary Pre-order unreleased products
+// @Description Take every line from the products' pre-order caps or none of them. Released products cannot be pre-ordered.
+// @Tags stock
+// @Accept  json
+// @Produce  json
+// @Param request body StockLinesRequest true "Lines to pre-order"
+// @Param Idempotency-Key header string false "Applies the change at most once per key"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /products/preorders/reserve [post]
+func (h *ProductHandler) ReservePreorder(w http.ResponseWriter, r *http.Request) {
+	var req StockLinesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	err := h.ProdUsecase.ReservePreorder(r.Context(), operationKey(r), req.Items)
+	if err != nil {
+		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, map[string]string{"message": "pre-ordered"})
+}
+
+// ReleasePreorder godoc
+// @Summary Release pre-ordered units
+// @Description Give pre-ordered units back to the products' pre-order caps
+// @Tags stock
+// @Accept  json
+// @Produce  json
+// @Param request body StockLinesRequest true "Lines to release"
+// @Param Idempotency-Key header string false "Applies the change at most once per key"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /products/preorders/release [post]
+func (h *ProductHandler) ReleasePreorder(w http.ResponseWriter, r *http.Request) {
+	var req StockLinesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	err := h.ProdUsecase.ReleasePreorder(r.Context(), operationKey(r), req.Items)
+	if err != nil {
+		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, map[string]string{"message": "released"})
+}
+
+// ConvertPreorder godoc
+// @Summary Convert pre-orders into reservations
+// @Description Once products are released, turn pre-ordered units into stock reservations, for every line or none
+// @Tags stock
+// @Accept  json
+// @Produce  json
+// @Param request body StockLinesRequest true "Lines to convert"
+// @Param Idempotency-Key header string false "Applies the change at most once per key"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /products/preorders/convert [post]
+func (h *ProductHandler) ConvertPreorder(w http.ResponseWriter, r *http.Request) {
+	var req StockLinesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	err := h.ProdUsecase.ConvertPreorder(r.Context(), operationKey(r), req.Items)
+	if err != nil {
+		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, map[string]string{"message": "converted"})
+}
+
+// Restock godoc
+// @Summary Restock returned units
+// @Description Add returned units back to a product's total stock
+// @Tags stock
+// @Accept  json
+// @Produce  json
+// @Param request body StockRequest true "Restock request"
+// @Param Idempotency-Key header string false "Applies the change at most once per key"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /products/restock [post]
+func (h *ProductHandler) Restock(w http.ResponseWriter, r *http.Request) {
+	var req StockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	err := h.ProdUsecase.Restock(r.Context(), operationKey(r), req.ProductID, req.Quantity)
+	if err != nil {
+		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, map[string]string{"message": "restocked"})
+}
+
+// ReleaseReservation godoc
+// @Summary Release a keyed reservation
+// @Description Give back the stock or pre-order cap reserved under the key. If nothing was reserved under it, the key is voided so a late reservation with it fails with 409. Releasing twice is a no-op.
+// @Tags stock
+// @Produce  json
+// @Param key path string true "Operation key of the reservation"
+// @Success 200 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /products/reservations/{key}/release [post]
+func (h *ProductHandler) ReleaseReservation(w http.ResponseWriter, r *http.Request) {
+	err := h.ProdUsecase.ReleaseReservation(r.Context(), mux.Vars(r)["key"])
+	if err != nil {
+		h.respondWithError(w, pkgerrors.GetStatusCode(err), err.Error())
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, map[string]string{"message": "released"})
+}
+
+func operationKey(r *http.Request) string {
+	return r.Header.Get(OperationKeyHeader)
+}
+
 func (h *ProductHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	h.respondWithJSON(w, http.StatusOK, map[string]string{"status": "UP"})
 }
@@ -47,4 +47,27 @@ func TestProductHandler(t *testing.T) {
 		json.Unmarshal(rr.Body.Bytes(), &res)
 		assert.Equal(t, int64(1), res.ID)
 	})
+	t.Run("ReserveStock_ForwardsOperationKey", func(t *testing.T) {
+		body, _ := json.Marshal(StockRequest{ProductID: 1, Quantity: 2})
+		req, _ := http.NewRequest("POST", "/products/reserve", bytes.NewBuffer(body))
+		req.Header.Set(OperationKeyHeader, "saga-7-1")
+		rr := httptest.NewRecorder()
+
+		mockUC.On("ReserveStock", mock.Anything, "saga-7-1", int64(1), 2).Return(nil).Once()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("ReleaseReservation_Success", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/products/reservations/saga-7-1/release", nil)
+		rr := httptest.NewRecorder()
+
+		mockUC.On("ReleaseReservation", mock.Anything, "saga-7-1").Return(nil).Once()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
 }
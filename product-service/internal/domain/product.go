@@ -7,29 +7,75 @@ import (
 	"github.com/user/go-microservices/pkg/valueobject"
 )
 
+// DefaultTaxCategory is used for products created without a tax category
+const DefaultTaxCategory = "STANDARD"
+
 type Product struct {
 	ID          int64             `json:"id"`
 	SKU         string            `json:"sku"`
 	Name        string            `json:"name"`
 	Description string            `json:"description"`
 	Price       valueobject.Money `json:"price"`
+	TaxCategory string            `json:"tax_category"`
+	WeightGrams int               `json:"weight_grams"`
 	TotalQty    int               `json:"total_qty"`
 	ReservedQty int               `json:"reserved_qty"`
-	IsActive    bool              `json:"is_active"`
-	CreatedAt   time.Time         `json:"created_at"`
-	UpdatedAt   time.Time         `json:"updated_at"`
+	// Backorderable products accept orders beyond available stock; those
+	// orders wait until the product is restocked
+	Backorderable bool `json:"backorderable"`
+	// ReleaseAt is when a product that is not on sale yet launches. Until
+	// then it can only be pre-ordered, up to PreorderCap units, and
+	// PreorderedQty counts the units taken from that cap.
+	ReleaseAt     *time.Time `json:"release_at,omitempty"`
+	PreorderCap   int        `json:"preorder_cap"`
+	PreorderedQty int        `json:"preordered_qty"`
+	IsActive      bool       `json:"is_active"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
 }
 
 func (p *Product) AvailableQty() int {
 	return p.TotalQty - p.ReservedQty
 }
 
+// StockLine is a quantity of one product
+type StockLine struct {
+	ProductID int64 `json:"product_id"`
+	Quantity  int   `json:"quantity"`
+}
+
+// Methods that change stock take an operation key. A non-empty key is
+// applied at most once: repeating it succeeds without changing stock again,
+// which makes retries safe when the caller cannot tell whether a call landed.
+// An empty key is not deduplicated.
+//
 //go:generate mockery --name ProductRepository
 type ProductRepository interface {
 	Create(ctx context.Context, p *Product) error
 	GetByID(ctx context.Context, id int64) (*Product, error)
-	ReserveStock(ctx context.Context, id int64, qty int) error
-	ReleaseStock(ctx context.Context, id int64, qty int) error
-	ConfirmStock(ctx context.Context, id int64, qty int) error
+	ReserveStock(ctx context.Context, key string, id int64, qty int) error
+	ReleaseStock(ctx context.Context, key string, id int64, qty int) error
+	ConfirmStock(ctx context.Context, key string, id int64, qty int) error
+	// AllocateStock reserves every line or none of them. It returns
+	// ErrInsufficientStock if any line cannot be covered.
+	AllocateStock(ctx context.Context, key string, lines []StockLine) error
+	// ReservePreorder takes every line from its product's pre-order cap or
+	// none of them. It returns ErrInsufficientStock if a product is already
+	// released or its remaining cap cannot cover the line.
+	ReservePreorder(ctx context.Context, key string, lines []StockLine) error
+	// ReleasePreorder gives pre-ordered units back to the caps
+	ReleasePreorder(ctx context.Context, key string, lines []StockLine) error
+	// ConvertPreorder turns pre-ordered units of released products into stock
+	// reservations, for every line or none. It returns ErrInsufficientStock
+	// if a product is not released yet or its stock cannot cover the line.
+	ConvertPreorder(ctx context.Context, key string, lines []StockLine) error
+	// Restock puts returned units back into total stock
+	Restock(ctx context.Context, key string, id int64, qty int) error
+	// ReleaseReservation gives back what the reservation made under key
+	// took, whether stock or pre-order cap. A pre-order conversion is
+	// reversed onto the cap it came from. If no reservation was made under
+	// key, the key is voided instead so a reservation still in flight cannot
+	// land later. Releasing twice is a no-op.
+	ReleaseReservation(ctx context.Context, key string) error
 	GetAll(ctx context.Context) ([]*Product, error)
 }
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"sort"
 	"time"
 
 	pkgerrors "github.com/user/go-microservices/pkg/errors"
@@ -21,12 +22,12 @@ func NewPostgresRepository(db *sql.DB) domain.ProductRepository {
 
 func (r *postgresRepository) Create(ctx context.Context, p *domain.Product) error {
 	query := `
-		INSERT INTO products (sku, name, description, price, total_qty, reserved_qty, is_active, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, 0, $6, $7, $8)
+		INSERT INTO products (sku, name, description, price, tax_category, weight_grams, total_qty, reserved_qty, backorderable, release_at, preorder_cap, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, 0, $8, $9, $10, $11, $12, $13)
 		RETURNING id`
 
 	now := time.Now().UTC()
-	err := r.db.QueryRowContext(ctx, query, p.SKU, p.Name, p.Description, p.Price, p.TotalQty, p.IsActive, now, now).Scan(&p.ID)
+	err := r.db.QueryRowContext(ctx, query, p.SKU, p.Name, p.Description, p.Price, p.TaxCategory, p.WeightGrams, p.TotalQty, p.Backorderable, p.ReleaseAt, p.PreorderCap, p.IsActive, now, now).Scan(&p.ID)
 	if err != nil {
 		logger.FromContext(ctx).Error("failed to create product", zap.Error(err))
 		return pkgerrors.ErrInternal
@@ -37,12 +38,12 @@ func (r *postgresRepository) Create(ctx context.Context, p *domain.Product) erro
 }
 
 func (r *postgresRepository) GetByID(ctx context.Context, id int64) (*domain.Product, error) {
-	query := `SELECT id, sku, name, description, price, total_qty, reserved_qty, is_active, created_at, updated_at FROM products WHERE id = $1`
+	query := `SELECT id, sku, name, description, price, tax_category, weight_grams, total_qty, reserved_qty, backorderable, release_at, preorder_cap, preordered_qty, is_active, created_at, updated_at FROM products WHERE id = $1`
 
 	p := &domain.Product{}
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&p.ID, &p.SKU, &p.Name, &p.Description, &p.Price,
-		&p.TotalQty, &p.ReservedQty, &p.IsActive, &p.CreatedAt, &p.UpdatedAt,
+		&p.ID, &p.SKU, &p.Name, &p.Description, &p.Price, &p.TaxCategory, &p.WeightGrams,
+		&p.TotalQty, &p.ReservedQty, &p.Backorderable, &p.ReleaseAt, &p.PreorderCap, &p.PreorderedQty, &p.IsActive, &p.CreatedAt, &p.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -55,68 +56,162 @@ func (r *postgresRepository) GetByID(ctx context.Context, id int64) (*domain.Pro
 	return p, nil
 }
 
-func (r *postgresRepository) ReserveStock(ctx context.Context, id int64, qty int) error {
-	query := `
+// Operations recorded against their keys in stock_operations
+const (
+	opReserveStock    = "reserve stock"
+	opReleaseStock    = "release stock"
+	opConfirmStock    = "confirm stock"
+	opAllocateStock   = "allocate stock"
+	opReservePreorder = "reserve pre-order"
+	opReleasePreorder = "release pre-order"
+	opConvertPreorder = "convert pre-order"
+	opRestock         = "restock"
+)
+
+const (
+	// Products that are not released yet can only be pre-ordered
+	reserveStockQuery = `
 		UPDATE products 
 		SET reserved_qty = reserved_qty + $1, updated_at = NOW()
 		WHERE id = $2 AND (total_qty - reserved_qty) >= $1
+		  AND (release_at IS NULL OR release_at <= NOW())
 	`
-	res, err := r.db.ExecContext(ctx, query, qty, id)
-	if err != nil {
-		logger.FromContext(ctx).Error("failed to reserve stock", zap.Error(err))
-		return pkgerrors.ErrInternal
-	}
+	releaseStockQuery = `
+		UPDATE products 
+		SET reserved_qty = reserved_qty - $1, updated_at = NOW()
+		WHERE id = $2 AND reserved_qty >= $1
+	`
+	// Giving back more than was pre-ordered means the cap has drifted, so
+	// it matches no row rather than clamping
+	releasePreorderQuery = `
+		UPDATE products 
+		SET preordered_qty = preordered_qty - $1, updated_at = NOW()
+		WHERE id = $2 AND preordered_qty >= $1
+	`
+	convertPreorderQuery = `
+		UPDATE products 
+		SET preordered_qty = preordered_qty - $1, reserved_qty = reserved_qty + $1, updated_at = NOW()
+		WHERE id = $2 AND release_at <= NOW() AND (total_qty - reserved_qty) >= $1 AND preordered_qty >= $1
+	`
+	unconvertPreorderQuery = `
+		UPDATE products 
+		SET preordered_qty = preordered_qty + $1, reserved_qty = reserved_qty - $1, updated_at = NOW()
+		WHERE id = $2 AND reserved_qty >= $1
+	`
+)
 
-	rows, err := res.RowsAffected()
-	if err != nil {
-		return pkgerrors.ErrInternal
-	}
-	if rows == 0 {
-		return pkgerrors.ErrInsufficientStock
-	}
-	return nil
+func (r *postgresRepository) ReserveStock(ctx context.Context, key string, id int64, qty int) error {
+	return r.updateLines(ctx, opReserveStock, key, reserveStockQuery,
+		[]domain.StockLine{{ProductID: id, Quantity: qty}}, pkgerrors.ErrInsufficientStock)
 }
 
-func (r *postgresRepository) ReleaseStock(ctx context.Context, id int64, qty int) error {
+func (r *postgresRepository) AllocateStock(ctx context.Context, key string, lines []domain.StockLine) error {
+	return r.updateLines(ctx, opAllocateStock, key, reserveStockQuery, lines, pkgerrors.ErrInsufficientStock)
+}
+
+func (r *postgresRepository) ReservePreorder(ctx context.Context, key string, lines []domain.StockLine) error {
 	query := `
 		UPDATE products 
-		SET reserved_qty = reserved_qty - $1, updated_at = NOW()
-		WHERE id = $2 AND reserved_qty >= $1
+		SET preordered_qty = preordered_qty + $1, updated_at = NOW()
+		WHERE id = $2 AND release_at > NOW() AND preordered_qty + $1 <= preorder_cap
 	`
-	res, err := r.db.ExecContext(ctx, query, qty, id)
-	if err != nil {
-		logger.FromContext(ctx).Error("failed to release stock", zap.Error(err))
-		return pkgerrors.ErrInternal
-	}
-	rows, _ := res.RowsAffected()
-	if rows == 0 {
-		// Should not happen if logic is correct, but safety check
-		return pkgerrors.ErrInternal
-	}
-	return nil
+	return r.updateLines(ctx, opReservePreorder, key, query, lines, pkgerrors.ErrInsufficientStock)
+}
+
+func (r *postgresRepository) ReleasePreorder(ctx context.Context, key string, lines []domain.StockLine) error {
+	// Like releasing stock, releasing more than was pre-ordered is reported
+	// as an internal error
+	return r.updateLines(ctx, opReleasePreorder, key, releasePreorderQuery, lines, pkgerrors.ErrInternal)
+}
+
+func (r *postgresRepository) ConvertPreorder(ctx context.Context, key string, lines []domain.StockLine) error {
+	// A conversion retried after the caller failed to record it is made once
+	// under its key, so the cap is never taken down twice
+	return r.updateLines(ctx, opConvertPreorder, key, convertPreorderQuery, lines, pkgerrors.ErrInsufficientStock)
 }
 
-func (r *postgresRepository) ConfirmStock(ctx context.Context, id int64, qty int) error {
+func (r *postgresRepository) ReleaseStock(ctx context.Context, key string, id int64, qty int) error {
+	// Releasing more than is reserved should not happen if the caller's
+	// logic is correct, so it is reported as an internal error
+	return r.updateLines(ctx, opReleaseStock, key, releaseStockQuery,
+		[]domain.StockLine{{ProductID: id, Quantity: qty}}, pkgerrors.ErrInternal)
+}
+
+func (r *postgresRepository) ConfirmStock(ctx context.Context, key string, id int64, qty int) error {
 	// Confirm means we permanently remove from global stock and reduce reserved
 	query := `
 		UPDATE products 
 		SET total_qty = total_qty - $1, reserved_qty = reserved_qty - $1, updated_at = NOW()
 		WHERE id = $2 AND reserved_qty >= $1
 	`
-	res, err := r.db.ExecContext(ctx, query, qty, id)
+	return r.updateLines(ctx, opConfirmStock, key, query,
+		[]domain.StockLine{{ProductID: id, Quantity: qty}}, pkgerrors.ErrInternal)
+}
+
+func (r *postgresRepository) Restock(ctx context.Context, key string, id int64, qty int) error {
+	query := `UPDATE products SET total_qty = total_qty + $1, updated_at = NOW() WHERE id = $2`
+	return r.updateLines(ctx, opRestock, key, query,
+		[]domain.StockLine{{ProductID: id, Quantity: qty}}, pkgerrors.ErrNotFound)
+}
+
+// updateLines runs query with each line's quantity and product ID in one
+// transaction, recording the operation under key first so that it is applied
+// at most once. If any line matches no row nothing is applied, the key is
+// left free for a retry, and unmatched is returned.
+func (r *postgresRepository) updateLines(ctx context.Context, op, key, query string, lines []domain.StockLine, unmatched error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		logger.FromContext(ctx).Error("failed to confirm stock", zap.Error(err))
+		logger.FromContext(ctx).Error("failed to begin transaction", zap.Error(err))
 		return pkgerrors.ErrInternal
 	}
-	rows, _ := res.RowsAffected()
-	if rows == 0 {
+	defer tx.Rollback()
+
+	if key != "" {
+		applied, err := claimOperation(ctx, tx, key, op, lines)
+		if err != nil {
+			return err
+		}
+		if applied {
+			return nil
+		}
+	}
+	if err := applyLines(ctx, tx, op, query, lines, unmatched); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.FromContext(ctx).Error("failed to commit "+op, zap.Error(err))
 		return pkgerrors.ErrInternal
 	}
 	return nil
 }
 
+// applyLines runs query for each line. Rows are locked in ID order so
+// concurrent batches cannot deadlock.
+func applyLines(ctx context.Context, tx *sql.Tx, op, query string, lines []domain.StockLine, unmatched error) error {
+	sorted := make([]domain.StockLine, len(lines))
+	copy(sorted, lines)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ProductID < sorted[j].ProductID })
+
+	for _, line := range sorted {
+		res, err := tx.ExecContext(ctx, query, line.Quantity, line.ProductID)
+		if err != nil {
+			logger.FromContext(ctx).Error("failed to "+op, zap.Int64("product_id", line.ProductID), zap.Error(err))
+			return pkgerrors.ErrInternal
+		}
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return pkgerrors.ErrInternal
+		}
+		if rows == 0 {
+			return unmatched
+		}
+	}
+	return nil
+}
+
 func (r *postgresRepository) GetAll(ctx context.Context) ([]*domain.Product, error) {
-	query := `SELECT id, sku, name, description, price, total_qty, reserved_qty, is_active, created_at, updated_at FROM products`
+	query := `SELECT id, sku, name, description, price, tax_category, weight_grams, total_qty, reserved_qty, backorderable, release_at, preorder_cap, preordered_qty, is_active, created_at, updated_at FROM products`
 
 	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
@@ -129,8 +224,8 @@ func (r *postgresRepository) GetAll(ctx context.Context) ([]*domain.Product, err
 	for rows.Next() {
 		p := &domain.Product{}
 		err := rows.Scan(
-			&p.ID, &p.SKU, &p.Name, &p.Description, &p.Price,
-			&p.TotalQty, &p.ReservedQty, &p.IsActive, &p.CreatedAt, &p.UpdatedAt,
+			&p.ID, &p.SKU, &p.Name, &p.Description, &p.Price, &p.TaxCategory, &p.WeightGrams,
+			&p.TotalQty, &p.ReservedQty, &p.Backorderable, &p.ReleaseAt, &p.PreorderCap, &p.PreorderedQty, &p.IsActive, &p.CreatedAt, &p.UpdatedAt,
 		)
 		if err != nil {
 			logger.FromContext(ctx).Error("failed to scan product", zap.Error(err))
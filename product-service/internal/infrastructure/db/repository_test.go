@@ -6,6 +6,7 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
 	"github.com/user/go-microservices/pkg/logger"
 	"github.com/user/go-microservices/pkg/valueobject"
 	"github.com/user/go-microservices/product-service/internal/domain"
@@ -29,7 +30,7 @@ func TestProductRepository(t *testing.T) {
 		}
 
 		mock.ExpectQuery("INSERT INTO products").
-			WithArgs(p.SKU, p.Name, sqlmock.AnyArg(), p.Price.Amount(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WithArgs(p.SKU, p.Name, sqlmock.AnyArg(), p.Price.Amount(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
 			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 
 		err := repo.Create(context.Background(), p)
@@ -39,12 +40,223 @@ func TestProductRepository(t *testing.T) {
 	})
 
 	t.Run("ReserveStock_Success", func(t *testing.T) {
+		mock.ExpectBegin()
 		mock.ExpectExec("UPDATE products SET reserved_qty = reserved_qty \\+ \\$1").
 			WithArgs(5, int64(1)).
 			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
 
-		err := repo.ReserveStock(context.Background(), 1, 5)
+		err := repo.ReserveStock(context.Background(), "", 1, 5)
 
 		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ReserveStock_Keyed_RecordsOperation", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO stock_operations").
+			WithArgs("saga-1-1", "reserve stock", []byte(`[{"product_id":1,"quantity":5}]`), "APPLIED").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE products SET reserved_qty = reserved_qty \\+ \\$1").
+			WithArgs(5, int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.ReserveStock(context.Background(), "saga-1-1", 1, 5)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ReserveStock_KeyRepeated_NotAppliedAgain", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO stock_operations").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT operation, state FROM stock_operations").
+			WithArgs("saga-1-1").
+			WillReturnRows(sqlmock.NewRows([]string{"operation", "state"}).AddRow("reserve stock", "APPLIED"))
+		mock.ExpectRollback()
+
+		err := repo.ReserveStock(context.Background(), "saga-1-1", 1, 5)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ReserveStock_KeyVoided_Conflict", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO stock_operations").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT operation, state FROM stock_operations").
+			WithArgs("saga-1-1").
+			WillReturnRows(sqlmock.NewRows([]string{"operation", "state"}).AddRow("", "VOIDED"))
+		mock.ExpectRollback()
+
+		err := repo.ReserveStock(context.Background(), "saga-1-1", 1, 5)
+
+		assert.ErrorIs(t, err, pkgerrors.ErrConflict)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ReleaseReservation_ReversesAppliedReservation", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO stock_operations").
+			WithArgs("saga-1-2", "VOIDED").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT operation, lines, state FROM stock_operations WHERE key = \\$1 FOR UPDATE").
+			WithArgs("saga-1-2").
+			WillReturnRows(sqlmock.NewRows([]string{"operation", "lines", "state"}).
+				AddRow("reserve pre-order", []byte(`[{"product_id":2,"quantity":4}]`), "APPLIED"))
+		mock.ExpectExec("UPDATE products SET preordered_qty = preordered_qty - \\$1, updated_at = NOW\\(\\) WHERE id = \\$2 AND preordered_qty >= \\$1").
+			WithArgs(4, int64(2)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE stock_operations SET state").
+			WithArgs("REVERSED", "saga-1-2").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.ReleaseReservation(context.Background(), "saga-1-2")
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ReleaseReservation_NeverReserved_VoidsKey", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO stock_operations").
+			WithArgs("saga-1-3", "VOIDED").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.ReleaseReservation(context.Background(), "saga-1-3")
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ReleaseReservation_AlreadyReleased_NoOp", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO stock_operations").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT operation, lines, state FROM stock_operations").
+			WithArgs("saga-1-1").
+			WillReturnRows(sqlmock.NewRows([]string{"operation", "lines", "state"}).
+				AddRow("reserve stock", []byte(`[{"product_id":1,"quantity":5}]`), "REVERSED"))
+		mock.ExpectRollback()
+
+		err := repo.ReleaseReservation(context.Background(), "saga-1-1")
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("AllocateStock_AllLines", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE products SET reserved_qty = reserved_qty \\+ \\$1").
+			WithArgs(1, int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE products SET reserved_qty = reserved_qty \\+ \\$1").
+			WithArgs(2, int64(3)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.AllocateStock(context.Background(), "", []domain.StockLine{{ProductID: 3, Quantity: 2}, {ProductID: 1, Quantity: 1}})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("AllocateStock_ShortLineRollsBack", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE products SET reserved_qty = reserved_qty \\+ \\$1").
+			WithArgs(1, int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE products SET reserved_qty = reserved_qty \\+ \\$1").
+			WithArgs(2, int64(3)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := repo.AllocateStock(context.Background(), "", []domain.StockLine{{ProductID: 1, Quantity: 1}, {ProductID: 3, Quantity: 2}})
+
+		assert.ErrorIs(t, err, pkgerrors.ErrInsufficientStock)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ReservePreorder_CapExhaustedRollsBack", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE products SET preordered_qty = preordered_qty \\+ \\$1").
+			WithArgs(4, int64(2)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := repo.ReservePreorder(context.Background(), "", []domain.StockLine{{ProductID: 2, Quantity: 4}})
+
+		assert.ErrorIs(t, err, pkgerrors.ErrInsufficientStock)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ConvertPreorder_MovesToReserved", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE products SET preordered_qty = preordered_qty - \\$1, reserved_qty = reserved_qty \\+ \\$1(.+) AND preordered_qty >= \\$1").
+			WithArgs(4, int64(2)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.ConvertPreorder(context.Background(), "", []domain.StockLine{{ProductID: 2, Quantity: 4}})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ConvertPreorder_Repeated_AppliedOnce", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO stock_operations").
+			WithArgs("preorder-9", "convert pre-order", sqlmock.AnyArg(), "APPLIED").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT operation, state FROM stock_operations").
+			WithArgs("preorder-9").
+			WillReturnRows(sqlmock.NewRows([]string{"operation", "state"}).AddRow("convert pre-order", "APPLIED"))
+		mock.ExpectRollback()
+
+		err := repo.ConvertPreorder(context.Background(), "preorder-9", []domain.StockLine{{ProductID: 2, Quantity: 4}})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ReleaseReservation_ReversesConversionOntoCap", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO stock_operations").
+			WithArgs("preorder-9", "VOIDED").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT operation, lines, state FROM stock_operations WHERE key = \\$1 FOR UPDATE").
+			WithArgs("preorder-9").
+			WillReturnRows(sqlmock.NewRows([]string{"operation", "lines", "state"}).
+				AddRow("convert pre-order", []byte(`[{"product_id":2,"quantity":4}]`), "APPLIED"))
+		mock.ExpectExec("UPDATE products SET preordered_qty = preordered_qty \\+ \\$1, reserved_qty = reserved_qty - \\$1").
+			WithArgs(4, int64(2)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE stock_operations SET state").
+			WithArgs("REVERSED", "preorder-9").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.ReleaseReservation(context.Background(), "preorder-9")
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Restock_NotFound", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE products SET total_qty = total_qty \\+ \\$1").
+			WithArgs(3, int64(99)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := repo.Restock(context.Background(), "", 99, 3)
+
+		assert.ErrorIs(t, err, pkgerrors.ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 }
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
+	"github.com/user/go-microservices/pkg/logger"
+	"github.com/user/go-microservices/product-service/internal/domain"
+	"go.uber.org/zap"
+)
+
+// States of a keyed stock operation
+const (
+	operationApplied  = "APPLIED"
+	operationReversed = "REVERSED"
+	// operationVoided marks a key that was released before any reservation
+	// was made under it
+	operationVoided = "VOIDED"
+)
+
+// reversals holds the query that gives back what each kind of reservation
+// took
+var reversals = map[string]string{
+	opReserveStock:    releaseStockQuery,
+	opAllocateStock:   releaseStockQuery,
+	opReservePreorder: releasePreorderQuery,
+	// A conversion is reversed by putting the units back on the cap
+	opConvertPreorder: unconvertPreorderQuery,
+}
+
+// claimOperation records op under key within tx. It reports whether the key
+// was already used for the same operation, in which case the caller must not
+// apply it again. A key used for another operation, or voided by a release,
+// is a conflict.
+//
+// A concurrent claim of the same key waits on the primary key until the first
+// transaction ends, so only one of them applies the operation.
+func claimOperation(ctx context.Context, tx *sql.Tx, key, op string, lines []domain.StockLine) (bool, error) {
+	body, err := json.Marshal(lines)
+	if err != nil {
+		return false, pkgerrors.ErrInternal
+	}
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO stock_operations (key, operation, lines, state) VALUES ($1, $2, $3, $4) ON CONFLICT (key) DO NOTHING`,
+		key, op, body, operationApplied)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to record stock operation", zap.String("key", key), zap.Error(err))
+		return false, pkgerrors.ErrInternal
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, pkgerrors.ErrInternal
+	}
+	if rows == 1 {
+		return false, nil
+	}
+
+	var existing, state string
+	err = tx.QueryRowContext(ctx, `SELECT operation, state FROM stock_operations WHERE key = $1`, key).Scan(&existing, &state)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to get stock operation", zap.String("key", key), zap.Error(err))
+		return false, pkgerrors.ErrInternal
+	}
+	if existing != op || state == operationVoided {
+		return false, pkgerrors.ErrConflict
+	}
+	return true, nil
+}
+
+func (r *postgresRepository) ReleaseReservation(ctx context.Context, key string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to begin transaction", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	defer tx.Rollback()
+
+	// Void the key if nothing was reserved under it. A reservation still in
+	// flight holds the key until it commits or rolls back, so this waits for
+	// its outcome.
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO stock_operations (key, operation, lines, state) VALUES ($1, '', '[]', $2) ON CONFLICT (key) DO NOTHING`,
+		key, operationVoided)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to void stock operation", zap.String("key", key), zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return pkgerrors.ErrInternal
+	}
+
+	if rows == 0 {
+		var op, state string
+		var body []byte
+		err := tx.QueryRowContext(ctx,
+			`SELECT operation, lines, state FROM stock_operations WHERE key = $1 FOR UPDATE`, key).Scan(&op, &body, &state)
+		if err != nil {
+			logger.FromContext(ctx).Error("failed to get stock operation", zap.String("key", key), zap.Error(err))
+			return pkgerrors.ErrInternal
+		}
+		if state != operationApplied {
+			return nil
+		}
+		query, ok := reversals[op]
+		if !ok {
+			return pkgerrors.ErrConflict
+		}
+		var lines []domain.StockLine
+		if err := json.Unmarshal(body, &lines); err != nil {
+			logger.FromContext(ctx).Error("failed to decode stock operation", zap.String("key", key), zap.Error(err))
+			return pkgerrors.ErrInternal
+		}
+		if err := applyLines(ctx, tx, "release reservation", query, lines, pkgerrors.ErrInternal); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE stock_operations SET state = $1 WHERE key = $2`, operationReversed, key); err != nil {
+			logger.FromContext(ctx).Error("failed to mark stock operation reversed", zap.String("key", key), zap.Error(err))
+			return pkgerrors.ErrInternal
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.FromContext(ctx).Error("failed to commit release reservation", zap.Error(err))
+		return pkgerrors.ErrInternal
+	}
+	return nil
+}
@@ -55,14 +55,14 @@ func (c *productTestContext) aProductExistsWithSKUNamePriceAndStock(sku, name st
 
 func (c *productTestContext) iReserveUnitsOfStockForThisProduct(qty int) error {
 	if c.mockProduct.AvailableQty() >= qty {
-		c.repo.On("ReserveStock", mock.Anything, c.mockProduct.ID, qty).Return(nil).Once()
+		c.repo.On("ReserveStock", mock.Anything, "", c.mockProduct.ID, qty).Return(nil).Once()
 		// Update internal mock state for validation
 		c.mockProduct.ReservedQty += qty
 	} else {
-		c.repo.On("ReserveStock", mock.Anything, c.mockProduct.ID, qty).Return(fmt.Errorf("insufficient stock")).Once()
+		c.repo.On("ReserveStock", mock.Anything, "", c.mockProduct.ID, qty).Return(fmt.Errorf("insufficient stock")).Once()
 	}
 
-	c.lastError = c.uc.ReserveStock(context.Background(), c.mockProduct.ID, qty)
+	c.lastError = c.uc.ReserveStock(context.Background(), "", c.mockProduct.ID, qty)
 	return nil
 }
 
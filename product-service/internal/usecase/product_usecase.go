@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
 	"github.com/user/go-microservices/product-service/internal/domain"
 )
 
@@ -11,9 +12,24 @@ import (
 type ProductUsecase interface {
 	CreateProduct(ctx context.Context, p *domain.Product) error
 	GetProduct(ctx context.Context, id int64) (*domain.Product, error)
-	ReserveStock(ctx context.Context, id int64, qty int) error
-	ReleaseStock(ctx context.Context, id int64, qty int) error
-	ConfirmStock(ctx context.Context, id int64, qty int) error
+	// Stock changes take an operation key; a call repeated with the same
+	// non-empty key is applied once
+	ReserveStock(ctx context.Context, key string, id int64, qty int) error
+	ReleaseStock(ctx context.Context, key string, id int64, qty int) error
+	ConfirmStock(ctx context.Context, key string, id int64, qty int) error
+	// AllocateStock reserves stock for all lines at once or for none
+	AllocateStock(ctx context.Context, key string, lines []domain.StockLine) error
+	// ReservePreorder takes all lines from the pre-order caps of unreleased
+	// products, or none
+	ReservePreorder(ctx context.Context, key string, lines []domain.StockLine) error
+	ReleasePreorder(ctx context.Context, key string, lines []domain.StockLine) error
+	// ConvertPreorder turns pre-ordered units into stock reservations once
+	// the products are released, for all lines or none
+	ConvertPreorder(ctx context.Context, key string, lines []domain.StockLine) error
+	Restock(ctx context.Context, key string, id int64, qty int) error
+	// ReleaseReservation gives back the reservation made under key, or
+	// voids the key if none was made
+	ReleaseReservation(ctx context.Context, key string) error
 	GetAllProducts(ctx context.Context) ([]*domain.Product, error)
 }
 
@@ -32,6 +48,17 @@ func NewProductUsecase(repo domain.ProductRepository, timeout time.Duration) Pro
 func (u *productUsecase) CreateProduct(ctx context.Context, p *domain.Product) error {
 	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
 	defer cancel()
+
+	if p.WeightGrams < 0 || p.PreorderCap < 0 {
+		return pkgerrors.ErrInvalidInput
+	}
+	// A pre-order cap only makes sense for a product with a launch date
+	if p.PreorderCap > 0 && p.ReleaseAt == nil {
+		return pkgerrors.ErrInvalidInput
+	}
+	if p.TaxCategory == "" {
+		p.TaxCategory = domain.DefaultTaxCategory
+	}
 	return u.repo.Create(ctx, p)
 }
 
@@ -41,22 +68,92 @@ func (u *productUsecase) GetProduct(ctx context.Context, id int64) (*domain.Prod
 	return u.repo.GetByID(ctx, id)
 }
 
-func (u *productUsecase) ReserveStock(ctx context.Context, id int64, qty int) error {
+func (u *productUsecase) ReserveStock(ctx context.Context, key string, id int64, qty int) error {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+	return u.repo.ReserveStock(ctx, key, id, qty)
+}
+
+func (u *productUsecase) ReleaseStock(ctx context.Context, key string, id int64, qty int) error {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+	return u.repo.ReleaseStock(ctx, key, id, qty)
+}
+
+func (u *productUsecase) ConfirmStock(ctx context.Context, key string, id int64, qty int) error {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+	return u.repo.ConfirmStock(ctx, key, id, qty)
+}
+
+func (u *productUsecase) AllocateStock(ctx context.Context, key string, lines []domain.StockLine) error {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+	if err := validateLines(lines); err != nil {
+		return err
+	}
+	return u.repo.AllocateStock(ctx, key, lines)
+}
+
+func (u *productUsecase) ReservePreorder(ctx context.Context, key string, lines []domain.StockLine) error {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+	if err := validateLines(lines); err != nil {
+		return err
+	}
+	return u.repo.ReservePreorder(ctx, key, lines)
+}
+
+func (u *productUsecase) ReleasePreorder(ctx context.Context, key string, lines []domain.StockLine) error {
 	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
 	defer cancel()
-	return u.repo.ReserveStock(ctx, id, qty)
+	if err := validateLines(lines); err != nil {
+		return err
+	}
+	return u.repo.ReleasePreorder(ctx, key, lines)
 }
 
-func (u *productUsecase) ReleaseStock(ctx context.Context, id int64, qty int) error {
+func (u *productUsecase) ConvertPreorder(ctx context.Context, key string, lines []domain.StockLine) error {
 	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
 	defer cancel()
-	return u.repo.ReleaseStock(ctx, id, qty)
+	if err := validateLines(lines); err != nil {
+		return err
+	}
+	return u.repo.ConvertPreorder(ctx, key, lines)
 }
 
-func (u *productUsecase) ConfirmStock(ctx context.Context, id int64, qty int) error {
+func (u *productUsecase) ReleaseReservation(ctx context.Context, key string) error {
 	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
 	defer cancel()
-	return u.repo.ConfirmStock(ctx, id, qty)
+	if key == "" {
+		return pkgerrors.ErrInvalidInput
+	}
+	return u.repo.ReleaseReservation(ctx, key)
+}
+
+// validateLines rejects empty batches, non-positive quantities and a product
+// listed twice
+func validateLines(lines []domain.StockLine) error {
+	if len(lines) == 0 {
+		return pkgerrors.ErrInvalidInput
+	}
+	seen := make(map[int64]bool, len(lines))
+	for _, line := range lines {
+		if line.Quantity <= 0 || seen[line.ProductID] {
+			return pkgerrors.ErrInvalidInput
+		}
+		seen[line.ProductID] = true
+	}
+	return nil
+}
+
+func (u *productUsecase) Restock(ctx context.Context, key string, id int64, qty int) error {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+	if qty <= 0 {
+		return pkgerrors.ErrInvalidInput
+	}
+	return u.repo.Restock(ctx, key, id, qty)
 }
 
 func (u *productUsecase) GetAllProducts(ctx context.Context) ([]*domain.Product, error) {
@@ -7,6 +7,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	pkgerrors "github.com/user/go-microservices/pkg/errors"
 	"github.com/user/go-microservices/pkg/logger"
 	"github.com/user/go-microservices/pkg/valueobject"
 	"github.com/user/go-microservices/product-service/internal/domain"
@@ -26,6 +27,17 @@ func TestProductUsecase(t *testing.T) {
 		mockRepo.On("Create", mock.Anything, p).Return(nil).Once()
 		err := uc.CreateProduct(ctx, p)
 		assert.NoError(t, err)
+		assert.Equal(t, domain.DefaultTaxCategory, p.TaxCategory)
+	})
+
+	t.Run("CreateProduct_NegativeWeight", func(t *testing.T) {
+		err := uc.CreateProduct(ctx, &domain.Product{SKU: "SKU2", Name: "N2", WeightGrams: -1})
+		assert.ErrorIs(t, err, pkgerrors.ErrInvalidInput)
+	})
+
+	t.Run("CreateProduct_PreorderCapWithoutReleaseDate", func(t *testing.T) {
+		err := uc.CreateProduct(ctx, &domain.Product{SKU: "SKU3", Name: "N3", PreorderCap: 10})
+		assert.ErrorIs(t, err, pkgerrors.ErrInvalidInput)
 	})
 
 	t.Run("GetProduct", func(t *testing.T) {
@@ -37,8 +49,54 @@ func TestProductUsecase(t *testing.T) {
 	})
 
 	t.Run("ReserveStock", func(t *testing.T) {
-		mockRepo.On("ReserveStock", mock.Anything, int64(1), 5).Return(nil).Once()
-		err := uc.ReserveStock(ctx, 1, 5)
+		mockRepo.On("ReserveStock", mock.Anything, "", int64(1), 5).Return(nil).Once()
+		err := uc.ReserveStock(ctx, "", 1, 5)
+		assert.NoError(t, err)
+	})
+
+	t.Run("AllocateStock", func(t *testing.T) {
+		lines := []domain.StockLine{{ProductID: 1, Quantity: 2}, {ProductID: 2, Quantity: 1}}
+		mockRepo.On("AllocateStock", mock.Anything, "", lines).Return(nil).Once()
+		err := uc.AllocateStock(ctx, "", lines)
 		assert.NoError(t, err)
 	})
+
+	t.Run("AllocateStock_DuplicateProduct", func(t *testing.T) {
+		err := uc.AllocateStock(ctx, "", []domain.StockLine{{ProductID: 1, Quantity: 2}, {ProductID: 1, Quantity: 1}})
+		assert.ErrorIs(t, err, pkgerrors.ErrInvalidInput)
+	})
+
+	t.Run("ReservePreorder", func(t *testing.T) {
+		lines := []domain.StockLine{{ProductID: 4, Quantity: 1}}
+		mockRepo.On("ReservePreorder", mock.Anything, "", lines).Return(pkgerrors.ErrInsufficientStock).Once()
+		err := uc.ReservePreorder(ctx, "", lines)
+		assert.ErrorIs(t, err, pkgerrors.ErrInsufficientStock)
+	})
+
+	t.Run("ConvertPreorder_InvalidQuantity", func(t *testing.T) {
+		err := uc.ConvertPreorder(ctx, "", []domain.StockLine{{ProductID: 4, Quantity: 0}})
+		assert.ErrorIs(t, err, pkgerrors.ErrInvalidInput)
+	})
+
+	t.Run("Restock", func(t *testing.T) {
+		mockRepo.On("Restock", mock.Anything, "", int64(1), 2).Return(nil).Once()
+		err := uc.Restock(ctx, "", 1, 2)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Restock_InvalidQuantity", func(t *testing.T) {
+		err := uc.Restock(ctx, "", 1, 0)
+		assert.ErrorIs(t, err, pkgerrors.ErrInvalidInput)
+	})
+
+	t.Run("ReleaseReservation", func(t *testing.T) {
+		mockRepo.On("ReleaseReservation", mock.Anything, "saga-1-1").Return(nil).Once()
+		err := uc.ReleaseReservation(ctx, "saga-1-1")
+		assert.NoError(t, err)
+	})
+
+	t.Run("ReleaseReservation_NoKey", func(t *testing.T) {
+		err := uc.ReleaseReservation(ctx, "")
+		assert.ErrorIs(t, err, pkgerrors.ErrInvalidInput)
+	})
 }
@@ -32,22 +32,58 @@ func (u *tracingProductUsecase) GetProduct(ctx context.Context, id int64) (*doma
 	return u.next.GetProduct(ctx, id)
 }
 
-func (u *tracingProductUsecase) ReserveStock(ctx context.Context, id int64, qty int) error {
+func (u *tracingProductUsecase) ReserveStock(ctx context.Context, key string, id int64, qty int) error {
 	ctx, span := u.tracer.Start(ctx, "ReserveStock")
 	defer span.End()
-	return u.next.ReserveStock(ctx, id, qty)
+	return u.next.ReserveStock(ctx, key, id, qty)
 }
 
-func (u *tracingProductUsecase) ReleaseStock(ctx context.Context, id int64, qty int) error {
+func (u *tracingProductUsecase) ReleaseStock(ctx context.Context, key string, id int64, qty int) error {
 	ctx, span := u.tracer.Start(ctx, "ReleaseStock")
 	defer span.End()
-	return u.next.ReleaseStock(ctx, id, qty)
+	return u.next.ReleaseStock(ctx, key, id, qty)
 }
 
-func (u *tracingProductUsecase) ConfirmStock(ctx context.Context, id int64, qty int) error {
+func (u *tracingProductUsecase) ConfirmStock(ctx context.Context, key string, id int64, qty int) error {
 	ctx, span := u.tracer.Start(ctx, "ConfirmStock")
 	defer span.End()
-	return u.next.ConfirmStock(ctx, id, qty)
+	return u.next.ConfirmStock(ctx, key, id, qty)
+}
+
+func (u *tracingProductUsecase) AllocateStock(ctx context.Context, key string, lines []domain.StockLine) error {
+	ctx, span := u.tracer.Start(ctx, "AllocateStock")
+	defer span.End()
+	return u.next.AllocateStock(ctx, key, lines)
+}
+
+func (u *tracingProductUsecase) ReservePreorder(ctx context.Context, key string, lines []domain.StockLine) error {
+	ctx, span := u.tracer.Start(ctx, "ReservePreorder")
+	defer span.End()
+	return u.next.ReservePreorder(ctx, key, lines)
+}
+
+func (u *tracingProductUsecase) ReleasePreorder(ctx context.Context, key string, lines []domain.StockLine) error {
+	ctx, span := u.tracer.Start(ctx, "ReleasePreorder")
+	defer span.End()
+	return u.next.ReleasePreorder(ctx, key, lines)
+}
+
+func (u *tracingProductUsecase) ConvertPreorder(ctx context.Context, key string, lines []domain.StockLine) error {
+	ctx, span := u.tracer.Start(ctx, "ConvertPreorder")
+	defer span.End()
+	return u.next.ConvertPreorder(ctx, key, lines)
+}
+
+func (u *tracingProductUsecase) Restock(ctx context.Context, key string, id int64, qty int) error {
+	ctx, span := u.tracer.Start(ctx, "Restock")
+	defer span.End()
+	return u.next.Restock(ctx, key, id, qty)
+}
+
+func (u *tracingProductUsecase) ReleaseReservation(ctx context.Context, key string) error {
+	ctx, span := u.tracer.Start(ctx, "ReleaseReservation")
+	defer span.End()
+	return u.next.ReleaseReservation(ctx, key)
 }
 
 func (u *tracingProductUsecase) GetAllProducts(ctx context.Context) ([]*domain.Product, error) {
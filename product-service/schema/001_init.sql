@@ -4,17 +4,41 @@ CREATE TABLE IF NOT EXISTS products (
     name VARCHAR(255) NOT NULL,
     description TEXT,
     price DECIMAL(10, 2) NOT NULL,
+    tax_category VARCHAR(50) NOT NULL DEFAULT 'STANDARD',
+    weight_grams INT NOT NULL DEFAULT 0,
     total_qty INT NOT NULL DEFAULT 0,
     reserved_qty INT NOT NULL DEFAULT 0,
+    backorderable BOOLEAN NOT NULL DEFAULT false,
+    release_at TIMESTAMP WITH TIME ZONE,
+    preorder_cap INT NOT NULL DEFAULT 0,
+    preordered_qty INT NOT NULL DEFAULT 0 CHECK (preordered_qty >= 0),
     is_active BOOLEAN DEFAULT true,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
 
+ALTER TABLE products ADD COLUMN IF NOT EXISTS tax_category VARCHAR(50) NOT NULL DEFAULT 'STANDARD';
+ALTER TABLE products ADD COLUMN IF NOT EXISTS weight_grams INT NOT NULL DEFAULT 0;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS backorderable BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS release_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS preorder_cap INT NOT NULL DEFAULT 0;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS preordered_qty INT NOT NULL DEFAULT 0;
+
 CREATE INDEX IF NOT EXISTS idx_products_sku ON products(sku);
 
-INSERT INTO products (sku, name, description, price, total_qty, reserved_qty) VALUES
-('PROD-001', 'High-Performance Laptop', 'A powerful laptop for developers.', 1999.99, 10000, 0),
-('PROD-002', 'Wireless Noise-Canceling Headphones', 'Immersive sound experience.', 299.99, 10000, 0),
-('PROD-003', 'Smartphone X', 'Latest generation smartphone with advanced camera.', 999.99, 10000, 0);
+-- Stock changes made under an operation key, so that a retried call is
+-- applied once; lines holds what the operation changed, for its release
+CREATE TABLE IF NOT EXISTS stock_operations (
+    key VARCHAR(255) PRIMARY KEY,
+    operation VARCHAR(50) NOT NULL,
+    lines JSONB NOT NULL,
+    state VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO products (sku, name, description, price, weight_grams, total_qty, reserved_qty) VALUES
+('PROD-001', 'High-Performance Laptop', 'A powerful laptop for developers.', 1999.99, 2200, 10000, 0),
+('PROD-002', 'Wireless Noise-Canceling Headphones', 'Immersive sound experience.', 299.99, 350, 10000, 0),
+('PROD-003', 'Smartphone X', 'Latest generation smartphone with advanced camera.', 999.99, 200, 10000, 0)
+ON CONFLICT (sku) DO NOTHING;
 
@@ -68,8 +68,7 @@ export default function () {
       let product = products[Math.floor(Math.random() * products.length)];
       let orderPayload = JSON.stringify({
         user_id: Math.floor(Math.random() * 1000) + 1,
-        product_id: product.id,
-        quantity: 1,
+        items: [{ product_id: product.id, quantity: 1 }],
       });
 
       let orderRes = http.post(`${BASE_URL_ORDER}/orders`, orderPayload, { headers: { 'Content-Type': 'application/json' } });
@@ -25,8 +25,7 @@ export default function () {
         
         const orderData = {
             user_id: Math.floor(Math.random() * 1000),
-            product_id: product.id,
-            quantity: 1
+            items: [{ product_id: product.id, quantity: 1 }]
         };
         Api.order.create(orderData);
     }